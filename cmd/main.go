@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -141,11 +142,18 @@ func main() {
 		AllowOriginFunc:  func(origin string) bool { return isAllowedOrigin(origin, allowedOrigins) },
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With", "X-Integration-Token"},
+		ExposedHeaders:   []string{"X-Total-Count"},
 		AllowCredentials: true,
 	})
 
 	config.ConnectDB()
 	config.EnsureEmailCodesTable()
+	go handlers.StartNotificacaoOutboxWorker(context.Background())
+	go handlers.StartPedidoExpiracaoWorker(context.Background())
+	go handlers.StartNaoComparecimentoWorker(context.Background())
+	go handlers.StartListaEsperaWorker(context.Background())
+	go handlers.StartCreditoExpiracaoWorker(context.Background())
+	go handlers.StartRecorrenciaWorker(context.Background())
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -176,7 +184,27 @@ func main() {
 	r.HandleFunc("/horarios-disponiveis", handlers.GetHorariosDisponiveisCampo).Methods("GET")
 	r.HandleFunc("/horarios-disponiveis/{campo_id}", handlers.GetHorariosDisponiveisCampo).Methods("GET")
 	r.HandleFunc("/horarios-disponiveis/id-campo/{id_campo}", handlers.GetHorariosDisponiveisCampo).Methods("GET")
+	r.HandleFunc("/disponibilidade", handlers.GetDisponibilidadePeriodo).Methods("GET")
+	r.HandleFunc("/busca/horarios", handlers.BuscarHorariosLivres).Methods("GET")
+	r.HandleFunc("/participantes/{token}", handlers.GetParticipantePagamento).Methods("GET")
 	r.HandleFunc("/integracao/agendamentos", handlers.CriarPedidoAgendamentoJogador).Methods("POST")
+	r.HandleFunc("/integracao/agendamentos/{id}/cancelar", handlers.CancelarAgendamentoJogador).Methods("PUT")
+	r.HandleFunc("/integracao/lista-espera", handlers.EntrarListaEsperaJogador).Methods("POST")
+	r.HandleFunc("/integracao/lista-espera/{id}", handlers.SairListaEsperaJogador).Methods("DELETE")
+	r.HandleFunc("/integracao/remarcacoes/{id}/aceitar", handlers.AceitarRemarcacaoJogador).Methods("PUT")
+	r.HandleFunc("/integracao/remarcacoes/{id}/rejeitar", handlers.RejeitarRemarcacaoJogador).Methods("PUT")
+	r.HandleFunc("/integracao/participantes/{token}/pagamentos", handlers.PagarParticipanteJogador).Methods("POST")
+	r.HandleFunc("/integracao/webhooks", handlers.GetWebhooksIntegracao).Methods("GET")
+	r.HandleFunc("/integracao/webhooks", handlers.CriarWebhookIntegracao).Methods("POST")
+	r.HandleFunc("/integracao/webhooks/{id}", handlers.DeleteWebhookIntegracao).Methods("DELETE")
+
+	// The feed keeps its connection open, so it must not hold the per-user
+	// request slot; the handler limits open feeds per owner instead. It is
+	// also exempt from any WriteTimeout set on the server: the handler clears
+	// its own write deadline.
+	feedRouter := r.PathPrefix("/agendamentos/feed").Subrouter()
+	feedRouter.Use(middleware.AuthMiddleware)
+	feedRouter.HandleFunc("", handlers.StreamAgendamentosFeed).Methods("GET")
 
 	authRouter := r.PathPrefix("").Subrouter()
 	authRouter.Use(middleware.AuthMiddleware)
@@ -188,6 +216,14 @@ func main() {
 	authRouter.HandleFunc("/excluir-arena", handlers.DeleteArena).Methods("DELETE")
 	authRouter.HandleFunc("/editar-arena", handlers.UpdateArena).Methods("PUT")
 	authRouter.HandleFunc("/listararenas", handlers.GetArenas).Methods("GET")
+	authRouter.HandleFunc("/arenas/{id}/politicas", handlers.GetArenaPoliticas).Methods("GET")
+	authRouter.HandleFunc("/arenas/{id}/politicas", handlers.AtualizarArenaPoliticas).Methods("PUT")
+	authRouter.HandleFunc("/arenas/{id}/creditos", handlers.GetCarteirasCredito).Methods("GET")
+	authRouter.HandleFunc("/arenas/{id}/creditos/pacotes", handlers.VenderPacoteCredito).Methods("POST")
+	authRouter.HandleFunc("/arenas/{id}/creditos/{id_carteira}", handlers.GetExtratoCredito).Methods("GET")
+	authRouter.HandleFunc("/arenas/{id}/cupons", handlers.GetCuponsArena).Methods("GET")
+	authRouter.HandleFunc("/arenas/{id}/cupons", handlers.CriarCupomArena).Methods("POST")
+	authRouter.HandleFunc("/arenas/{id}/cupons/{id_cupom}", handlers.EditarCupomArena).Methods("PUT")
 	authRouter.HandleFunc("/cadastrar-campo", handlers.CadastrodeCampo).Methods("POST")
 	authRouter.HandleFunc("/listar-campos", handlers.GetCampos).Methods("GET")
 	authRouter.HandleFunc("/editar-campo", handlers.UpdateCampo).Methods("PUT")
@@ -195,7 +231,18 @@ func main() {
 	authRouter.HandleFunc("/manutencao", handlers.AtualizarManutencaoCampo).Methods("PUT")
 	authRouter.HandleFunc("/manutencao/{id}", handlers.AtualizarManutencaoCampo).Methods("PUT")
 	authRouter.HandleFunc("/excluir-campo/{id}", handlers.DeleteCampo).Methods("DELETE")
+	authRouter.HandleFunc("/campos/{id}/hora-extra", handlers.GetHoraExtraCampo).Methods("GET")
+	authRouter.HandleFunc("/campos/{id}/hora-extra", handlers.AtualizarHoraExtraCampo).Methods("PUT")
+	authRouter.HandleFunc("/campos/{id}/regras-preco", handlers.GetRegrasPrecoCampo).Methods("GET")
+	authRouter.HandleFunc("/campos/{id}/regras-preco", handlers.CriarRegraPrecoCampo).Methods("POST")
+	authRouter.HandleFunc("/campos/{id}/regras-preco/{id_regra}", handlers.EditarRegraPrecoCampo).Methods("PUT")
+	authRouter.HandleFunc("/campos/{id}/regras-preco/{id_regra}", handlers.DeleteRegraPrecoCampo).Methods("DELETE")
+	authRouter.HandleFunc("/bloqueios", handlers.GetBloqueiosAgenda).Methods("GET")
+	authRouter.HandleFunc("/bloqueios", handlers.CriarBloqueioAgenda).Methods("POST")
+	authRouter.HandleFunc("/bloqueios/{id}/conflitos", handlers.GetConflitosBloqueioAgenda).Methods("GET")
+	authRouter.HandleFunc("/bloqueios/{id}", handlers.DeleteBloqueioAgenda).Methods("DELETE")
 	authRouter.HandleFunc("/cadastrar-agendamento", handlers.AgendarCampo).Methods("POST")
+	authRouter.HandleFunc("/lista-espera", handlers.GetListaEspera).Methods("GET")
 	authRouter.HandleFunc("/agendamentos", handlers.GetAgendamentos).Methods("GET")
 	authRouter.HandleFunc("/pedidos", handlers.GetPedidos).Methods("GET")
 	authRouter.HandleFunc("/pedidos/{id}/aceitar", handlers.AceitarPedido).Methods("PUT")
@@ -204,10 +251,33 @@ func main() {
 	authRouter.HandleFunc("/editar-agendamento", handlers.EditarAgendamento).Methods("PUT")
 	authRouter.HandleFunc("/agendamentos/{id}/iniciar-cronometro", handlers.IniciarCronometroAgendamento).Methods("POST")
 	authRouter.HandleFunc("/agendamentos/{id}/encerrar-cronometro", handlers.EncerrarCronometroAgendamento).Methods("POST")
+	authRouter.HandleFunc("/agendamentos/{id}/pausar-cronometro", handlers.PausarCronometroAgendamento).Methods("POST")
+	authRouter.HandleFunc("/agendamentos/{id}/retomar-cronometro", handlers.RetomarCronometroAgendamento).Methods("POST")
+	authRouter.HandleFunc("/agendamentos/{id}/cronometro", handlers.GetCronometroAgendamento).Methods("GET")
 	authRouter.HandleFunc("/agendamentos/{id}/pagamentos", handlers.GetPagamentosAgendamento).Methods("GET")
 	authRouter.HandleFunc("/agendamentos/{id}/pagamentos/parcial", handlers.RegistrarPagamentoParcialAgendamento).Methods("POST")
 	authRouter.HandleFunc("/agendamentos/{id}/pagamentos/total", handlers.RegistrarPagamentoTotalAgendamento).Methods("POST")
+	authRouter.HandleFunc("/agendamentos/{id}/participantes", handlers.GetParticipantesAgendamento).Methods("GET")
+	authRouter.HandleFunc("/agendamentos/{id}/participantes", handlers.AdicionarParticipantesAgendamento).Methods("POST")
+	authRouter.HandleFunc("/agendamentos/{id}/participantes/{id_participante}", handlers.RemoverParticipanteAgendamento).Methods("DELETE")
 	authRouter.HandleFunc("/agendamentos/{id}/concluir", handlers.ConcluirAgendamento).Methods("POST")
+	authRouter.HandleFunc("/agendamentos/{id}/remarcacao", handlers.GetRemarcacaoAgendamento).Methods("GET")
+	authRouter.HandleFunc("/agendamentos/{id}/remarcacao", handlers.ProporRemarcacaoAgendamento).Methods("POST")
+	authRouter.HandleFunc("/agendamentos/{id}/remarcacao", handlers.CancelarRemarcacaoAgendamento).Methods("DELETE")
+	authRouter.HandleFunc("/agendamentos/{id}/historico", handlers.GetHistoricoAgendamento).Methods("GET")
+	authRouter.HandleFunc("/agendamentos/{id}/acoes", handlers.GetAcoesAgendamento).Methods("GET")
+	authRouter.HandleFunc("/recorrencias", handlers.CriarRecorrenciaAgendamento).Methods("POST")
+	authRouter.HandleFunc("/recorrencias", handlers.GetRecorrenciasAgendamento).Methods("GET")
+	authRouter.HandleFunc("/recorrencias/{id}", handlers.GetRecorrenciaAgendamento).Methods("GET")
+	authRouter.HandleFunc("/recorrencias/{id}", handlers.EditarRecorrenciaAgendamento).Methods("PUT")
+	authRouter.HandleFunc("/recorrencias/{id}/cancelar", handlers.CancelarRecorrenciaAgendamento).Methods("PUT")
+	authRouter.HandleFunc("/recorrencias/{id}/materializar", handlers.MaterializarRecorrenciaAgendamento).Methods("POST")
+	authRouter.HandleFunc("/recorrencias/{id}/ocorrencias/{id_agendamento}/pular", handlers.PularOcorrenciaRecorrencia).Methods("PUT")
+	authRouter.HandleFunc("/webhooks", handlers.GetWebhooks).Methods("GET")
+	authRouter.HandleFunc("/webhooks", handlers.CriarWebhook).Methods("POST")
+	authRouter.HandleFunc("/webhooks/{id}", handlers.DeleteWebhook).Methods("DELETE")
+	authRouter.HandleFunc("/notificacoes/entregas", handlers.GetEntregasNotificacao).Methods("GET")
+	authRouter.HandleFunc("/notificacoes/entregas/{id}/reenviar", handlers.ReenviarEntregaNotificacao).Methods("POST")
 	authRouter.HandleFunc("/dashboard", handlers.GetDashboard).Methods("GET")
 
 	log.Printf("Server running at http://localhost:%s", port)
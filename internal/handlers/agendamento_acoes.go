@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+type agendamentoAcaoResponse struct {
+	Acao          models.AgendamentoAcao     `json:"acao"`
+	Disponivel    bool                       `json:"disponivel"`
+	StatusDestino []models.AgendamentoStatus `json:"status_destino"`
+	Motivo        string                     `json:"motivo,omitempty"`
+}
+
+type agendamentoAcoesResponse struct {
+	IDAgendamento  int                        `json:"id_agendamento"`
+	Status         models.AgendamentoStatus   `json:"status"`
+	ProximosStatus []models.AgendamentoStatus `json:"proximos_status"`
+	Acoes          []agendamentoAcaoResponse  `json:"acoes"`
+}
+
+type agendamentoTransicaoErrorResponse struct {
+	Erro            string                     `json:"erro"`
+	StatusAtual     models.AgendamentoStatus   `json:"status_atual"`
+	Acao            models.AgendamentoAcao     `json:"acao,omitempty"`
+	StatusDestino   models.AgendamentoStatus   `json:"status_destino,omitempty"`
+	ProximosStatus  []models.AgendamentoStatus `json:"proximos_status"`
+	AcoesPermitidas []models.AgendamentoAcao   `json:"acoes_permitidas"`
+}
+
+func writeAgendamentoTransicaoError(w http.ResponseWriter, err *models.AgendamentoTransicaoError) {
+	writeJSON(w, http.StatusConflict, agendamentoTransicaoErrorResponse{
+		Erro:            "O estado atual do agendamento nao permite esta operacao",
+		StatusAtual:     err.StatusAtual,
+		Acao:            err.Acao,
+		StatusDestino:   err.StatusDestino,
+		ProximosStatus:  err.ProximosStatus,
+		AcoesPermitidas: err.AcoesPermitidas,
+	})
+}
+
+func (service agendamentoService) GetAcoes(ctx context.Context, ownerUserID int, agendamentoID int) (models.Agendamento, error) {
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Agendamento{}, errAgendamentoNaoEncontrado
+		}
+		return models.Agendamento{}, err
+	}
+
+	agendamento, _, err = service.refreshFinancialState(ctx, agendamento)
+	return agendamento, err
+}
+
+// GetAcoesAgendamento tells the frontend which buttons to enable: every
+// action the transition table allows, flagged unavailable when the
+// agendamento's data still blocks it.
+func GetAcoesAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	agendamento, err := service.GetAcoes(r.Context(), userID, agendamentoID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, agendamentoAcoesResponse{
+		IDAgendamento:  agendamento.ID,
+		Status:         agendamento.Status,
+		ProximosStatus: models.AgendamentoProximosStatus(agendamento.Status),
+		Acoes:          agendamentoAcoesDisponiveis(agendamento),
+	})
+}
+
+func agendamentoAcoesDisponiveis(agendamento models.Agendamento) []agendamentoAcaoResponse {
+	acoes := make([]agendamentoAcaoResponse, 0)
+	for _, acao := range models.AgendamentoAcoesPermitidas(agendamento.Status) {
+		motivo := agendamentoAcaoBloqueio(agendamento, acao)
+		acoes = append(acoes, agendamentoAcaoResponse{
+			Acao:          acao,
+			Disponivel:    motivo == "",
+			StatusDestino: models.AgendamentoDestinosDaAcao(agendamento.Status, acao),
+			Motivo:        motivo,
+		})
+	}
+
+	return acoes
+}
+
+// agendamentoAcaoBloqueio mirrors the data checks the service methods make
+// after the transition table has allowed the action.
+func agendamentoAcaoBloqueio(agendamento models.Agendamento, acao models.AgendamentoAcao) string {
+	switch acao {
+	case models.AgendamentoAcaoRemarcar:
+		if agendamento.OrigemAgendamento == models.AgendamentoOrigemManual {
+			return "Agendamentos manuais devem ser editados diretamente"
+		}
+	case models.AgendamentoAcaoMarcarNaoCompareceu:
+		if agendamento.InicioCronometro != nil {
+			return "O cronometro deste agendamento ja foi iniciado"
+		}
+		if agendamento.Horario.After(agendamentoNow()) {
+			return "O horario do agendamento ainda nao comecou"
+		}
+	case models.AgendamentoAcaoEncerrarCronometro:
+		if agendamento.InicioCronometro == nil {
+			return "O cronometro ainda nao foi iniciado"
+		}
+	case models.AgendamentoAcaoPausarCronometro:
+		if agendamento.InicioCronometro == nil || agendamento.FimCronometro != nil {
+			return "O cronometro nao esta rodando"
+		}
+		if agendamento.CronometroPausadoEm != nil {
+			return "O cronometro ja esta pausado"
+		}
+	case models.AgendamentoAcaoRetomarCronometro:
+		if agendamento.CronometroPausadoEm == nil {
+			return "O cronometro nao esta pausado"
+		}
+	case models.AgendamentoAcaoRegistrarPagamento:
+		if agendamento.ValorRestante <= 0 {
+			return "O agendamento nao possui saldo pendente"
+		}
+	case models.AgendamentoAcaoConcluir:
+		if agendamento.FimCronometro == nil {
+			return "O cronometro precisa ser encerrado antes da conclusao"
+		}
+		if agendamento.ValorRestante > 0 {
+			return "O agendamento ainda possui saldo pendente"
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestAgendamentoAcoesDisponiveisFlagsDataBlockers(t *testing.T) {
+	fim := time.Now()
+	acoes := agendamentoAcoesDisponiveis(models.Agendamento{
+		Status:        models.AgendamentoStatusAguardandoPagamento,
+		FimCronometro: &fim,
+		ValorRestante: 40,
+	})
+
+	byAcao := make(map[models.AgendamentoAcao]agendamentoAcaoResponse, len(acoes))
+	for _, acao := range acoes {
+		byAcao[acao.Acao] = acao
+	}
+
+	if pagamento, ok := byAcao[models.AgendamentoAcaoRegistrarPagamento]; !ok || !pagamento.Disponivel {
+		t.Fatalf("expected payment to be available, got %+v", pagamento)
+	}
+	if concluir, ok := byAcao[models.AgendamentoAcaoConcluir]; !ok || concluir.Disponivel || concluir.Motivo == "" {
+		t.Fatalf("expected concluir to be listed but blocked by the balance, got %+v", concluir)
+	}
+	if _, ok := byAcao[models.AgendamentoAcaoAceitar]; ok {
+		t.Fatalf("expected aceitar not to be listed outside pedido")
+	}
+}
+
+func TestAgendamentoAcaoBloqueioForCronometroPause(t *testing.T) {
+	inicio := time.Now().Unix()
+	pausadoEm := time.Now()
+	rodando := models.Agendamento{Status: models.AgendamentoStatusEmAndamento, InicioCronometro: &inicio}
+	pausado := rodando
+	pausado.CronometroPausadoEm = &pausadoEm
+
+	if motivo := agendamentoAcaoBloqueio(rodando, models.AgendamentoAcaoPausarCronometro); motivo != "" {
+		t.Fatalf("expected a running cronometro to be pausable, got %q", motivo)
+	}
+	if motivo := agendamentoAcaoBloqueio(rodando, models.AgendamentoAcaoRetomarCronometro); motivo == "" {
+		t.Fatalf("expected retomar to be blocked while running")
+	}
+	if motivo := agendamentoAcaoBloqueio(pausado, models.AgendamentoAcaoPausarCronometro); motivo == "" {
+		t.Fatalf("expected pausar to be blocked while paused")
+	}
+	if motivo := agendamentoAcaoBloqueio(pausado, models.AgendamentoAcaoRetomarCronometro); motivo != "" {
+		t.Fatalf("expected a paused cronometro to be resumable, got %q", motivo)
+	}
+}
+
+func TestAgendamentoAcoesDisponiveisEmptyForTerminalStatus(t *testing.T) {
+	if acoes := agendamentoAcoesDisponiveis(models.Agendamento{Status: models.AgendamentoStatusCancelado}); len(acoes) != 0 {
+		t.Fatalf("expected no actions for cancelado, got %+v", acoes)
+	}
+}
+
+func TestWriteAgendamentoServiceErrorReturnsAllowedStatusesOnInvalidTransition(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writeAgendamentoServiceError(recorder, models.ValidarTransicaoAgendamento(
+		models.AgendamentoStatusConcluido,
+		models.AgendamentoAcaoAceitar,
+		models.AgendamentoStatusPedido,
+	))
+
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", recorder.Code)
+	}
+	if body := recorder.Body.String(); !strings.Contains(body, `"status_atual":"concluido"`) || !strings.Contains(body, `"proximos_status":[]`) {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"math"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+// aplicarPoliticaCancelamento settles the money of an agendamento being
+// cancelled: the refund becomes negative payment entries, the part paid with
+// credit or hour pacotes going back to the carteira, and what the arena keeps
+// goes to valor_retido, so nothing stays open. valor_total keeps the price.
+// Refused pedidos and system cancellations never pay a fee. It runs inside
+// transitionStatus's transaction.
+func (service agendamentoService) aplicarPoliticaCancelamento(ctx context.Context, agendamento models.Agendamento, statusAnterior models.AgendamentoStatus) (models.Agendamento, *models.AgendamentoCancelamento, error) {
+	totalPago, err := service.repository.sumPayments(ctx, agendamento.ID)
+	if err != nil {
+		return models.Agendamento{}, nil, err
+	}
+
+	politica := newArenaPoliticaPadrao(agendamento.IDArena)
+	if statusAnterior != models.AgendamentoStatusPedido && agendamentoAtorFromContext(ctx).Tipo != models.AgendamentoAtorSistema {
+		politica, err = loadArenaPolitica(ctx, service.repository.database(), agendamento.IDArena)
+		if err != nil {
+			return models.Agendamento{}, nil, err
+		}
+	}
+
+	consumos, err := service.repository.listCreditoConsumosAgendamento(ctx, agendamento.ID)
+	if err != nil {
+		return models.Agendamento{}, nil, err
+	}
+	pagoCredito := 0.0
+	minutosConsumidos := 0
+	for _, consumo := range consumos {
+		pagoCredito += consumo.Valor
+		minutosConsumidos += consumo.Minutos
+	}
+	pagoHoras := 0.0
+	if minutosConsumidos > 0 {
+		pagoHoras, err = service.repository.sumPagamentosHorasAgendamento(ctx, agendamento.ID)
+		if err != nil {
+			return models.Agendamento{}, nil, err
+		}
+	}
+
+	cancelamento := politica.CalcularCancelamento(agendamento.ValorTotal, totalPago, agendamento.Horario, agendamentoNow())
+	reembolsoCredito := models.DividirReembolsoCredito(cancelamento.Reembolso, totalPago, pagoCredito)
+	reembolsoHoras := math.Min(models.DividirReembolsoCredito(cancelamento.Reembolso, totalPago, pagoHoras), models.ArredondarCentavos(cancelamento.Reembolso-reembolsoCredito))
+	cancelamento.ReembolsoMinutos = models.DividirReembolsoHoras(reembolsoHoras, pagoHoras, minutosConsumidos)
+	if cancelamento.ReembolsoMinutos == 0 {
+		reembolsoHoras = 0
+	}
+	cancelamento.ReembolsoCredito = models.ArredondarCentavos(reembolsoCredito + reembolsoHoras)
+	reembolsoDinheiro := models.ArredondarCentavos(cancelamento.Reembolso - cancelamento.ReembolsoCredito)
+
+	// Credit and hours go back to the carteira as they were paid; only the
+	// rest is money the arena pays back.
+	if reembolsoCredito > 0 {
+		pagamento, err := service.registrarReembolso(ctx, agendamento, statusAnterior, cancelamento, reembolsoCredito, models.AgendamentoFormaPagamentoCredito)
+		if err != nil {
+			return models.Agendamento{}, nil, err
+		}
+		if err := service.estornarCredito(ctx, agendamento, consumos, reembolsoCredito, pagamento.ID); err != nil {
+			return models.Agendamento{}, nil, err
+		}
+	}
+	if reembolsoHoras > 0 {
+		pagamento, err := service.registrarReembolso(ctx, agendamento, statusAnterior, cancelamento, reembolsoHoras, models.AgendamentoFormaPagamentoCredito)
+		if err != nil {
+			return models.Agendamento{}, nil, err
+		}
+		if err := service.estornarHoras(ctx, agendamento, consumos, cancelamento.ReembolsoMinutos, pagamento.ID); err != nil {
+			return models.Agendamento{}, nil, err
+		}
+	}
+	if reembolsoDinheiro > 0 {
+		if _, err := service.registrarReembolso(ctx, agendamento, statusAnterior, cancelamento, reembolsoDinheiro, models.AgendamentoFormaPagamentoReembolso); err != nil {
+			return models.Agendamento{}, nil, err
+		}
+	}
+
+	if err := service.repository.updateFinancialState(ctx, agendamento.ID, agendamentoFinancialUpdate{
+		ValorRetido:       &cancelamento.Retido,
+		ValorRestante:     0,
+		Pago:              true,
+		StatusDePagamento: true,
+	}); err != nil {
+		return models.Agendamento{}, nil, err
+	}
+
+	agendamento.ValorRetido = &cancelamento.Retido
+	agendamento.ValorRestante = 0
+	agendamento.Pago = true
+	agendamento.StatusDePagamento = true
+	return agendamento, &cancelamento, nil
+}
+
+// registrarReembolso writes one refund as a negative payment, so the payments
+// of a cancelled agendamento add up to what the arena kept.
+func (service agendamentoService) registrarReembolso(ctx context.Context, agendamento models.Agendamento, statusAnterior models.AgendamentoStatus, cancelamento models.AgendamentoCancelamento, valor float64, formaPagamento string) (models.AgendamentoPagamento, error) {
+	pagamento, err := service.repository.insertPayment(ctx, agendamento.ID, models.RegistrarPagamentoInput{
+		ValorPago:      -valor,
+		FormaPagamento: formaPagamento,
+	})
+	if err != nil {
+		return models.AgendamentoPagamento{}, err
+	}
+
+	err = service.recordStatusEvento(ctx, agendamento.ID, agendamentoStatusEventoInput{
+		Tipo:           models.AgendamentoStatusEventoPagamento,
+		StatusAnterior: &statusAnterior,
+		StatusNovo:     statusAnterior,
+		Detalhes: map[string]any{
+			"id_pagamento":    pagamento.ID,
+			"forma_pagamento": formaPagamento,
+			"reembolso":       valor,
+			"multa":           cancelamento.Multa,
+			"retido":          cancelamento.Retido,
+		},
+	})
+	return pagamento, err
+}
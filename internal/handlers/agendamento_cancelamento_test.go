@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+// TestCancelarAgendamentoKeepsValorTotal needs a disposable Postgres
+// database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestCancelarAgendamentoKeepsValorTotal(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	statements := []string{
+		fmt.Sprintf(`INSERT INTO %s.arena_politicas (id_arena, cancelamento_gratuito_horas, cancelamento_multa_percentual) VALUES (1, 24, 50)`, schema),
+		fmt.Sprintf(`INSERT INTO %s.agendamentos (id_campo, horario, jogadores, status, valor_bruto, valor_desconto, valor_total, valor_restante, pago, status_de_pagamento)
+			VALUES (1, NOW() + INTERVAL '2 hours', 10, 'agendado', 120, 20, 100, 0, TRUE, TRUE)`, schema),
+		fmt.Sprintf(`INSERT INTO %s.pagamentos_por_agendamento (id_agendamento, valor_pago, forma_pagamento, data_pagamento) VALUES (1, 100, 'pix', NOW())`, schema),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("failed to prepare agendamento: %v", err)
+		}
+	}
+
+	ownerCtx := context.WithValue(ctx, middleware.UserIDKey, 1)
+	result, err := newAgendamentoService().UpdateStatus(ownerCtx, 1, 1, models.AgendamentoStatusCancelado)
+	if err != nil {
+		t.Fatalf("expected the cancel to succeed, got %v", err)
+	}
+	if result.Cancelamento == nil || result.Cancelamento.Retido != 50 || result.Cancelamento.Reembolso != 50 {
+		t.Fatalf("expected 50 kept and 50 refunded, got %+v", result.Cancelamento)
+	}
+
+	agendamento, err := newAgendamentoRepository().getByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to reload agendamento: %v", err)
+	}
+	if agendamento.ValorTotal != 100 || agendamento.ValorBruto-agendamento.ValorDesconto != agendamento.ValorTotal {
+		t.Fatalf("expected valor_total to stay 100 = bruto - desconto, got total %.2f bruto %.2f desconto %.2f", agendamento.ValorTotal, agendamento.ValorBruto, agendamento.ValorDesconto)
+	}
+	if agendamento.ValorRetido == nil || *agendamento.ValorRetido != 50 {
+		t.Fatalf("expected valor_retido 50, got %v", agendamento.ValorRetido)
+	}
+	if agendamento.ValorRestante != 0 {
+		t.Fatalf("expected nothing left to pay, got %.2f", agendamento.ValorRestante)
+	}
+}
+
+// TestCancelarAgendamentoPagoComCreditoDevolveCredito needs a disposable
+// Postgres database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestCancelarAgendamentoPagoComCreditoDevolveCredito(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	statements := []string{
+		fmt.Sprintf(`INSERT INTO %s.arena_politicas (id_arena, cancelamento_gratuito_horas, cancelamento_multa_percentual) VALUES (1, 24, 50)`, schema),
+		fmt.Sprintf(`INSERT INTO %s.agendamentos (id_campo, horario, jogadores, status, valor_bruto, valor_total, valor_restante)
+			VALUES (1, NOW() + INTERVAL '2 hours', 10, 'agendado', 120, 120, 120)`, schema),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("failed to prepare agendamento: %v", err)
+		}
+	}
+
+	service := newAgendamentoService()
+	carteira, _, err := service.VenderPacoteCredito(ctx, 1, models.VenderPacoteCreditoInput{
+		NomeCliente: "Cliente Teste",
+		Descricao:   "Pacote",
+		Credito:     200,
+		Preco:       200,
+	})
+	if err != nil {
+		t.Fatalf("failed to sell pacote: %v", err)
+	}
+
+	ownerCtx := context.WithValue(ctx, middleware.UserIDKey, 1)
+	if _, err := service.RegistrarPagamentoParcial(ownerCtx, 1, 1, models.RegistrarPagamentoInput{
+		ValorPago:      120,
+		FormaPagamento: models.AgendamentoFormaPagamentoCredito,
+		IDCarteira:     &carteira.ID,
+	}); err != nil {
+		t.Fatalf("failed to pay with credit: %v", err)
+	}
+
+	result, err := service.UpdateStatus(ownerCtx, 1, 1, models.AgendamentoStatusCancelado)
+	if err != nil {
+		t.Fatalf("expected the cancel to succeed, got %v", err)
+	}
+	if result.Cancelamento == nil || result.Cancelamento.Reembolso != 60 || result.Cancelamento.ReembolsoCredito != 60 {
+		t.Fatalf("expected 60 refunded as credit, got %+v", result.Cancelamento)
+	}
+
+	atual, pacotes, movimentos, err := service.GetExtratoCredito(ctx, 1, carteira.ID)
+	if err != nil {
+		t.Fatalf("failed to load extrato: %v", err)
+	}
+	if atual.Saldo != 140 || len(pacotes) != 1 || pacotes[0].Saldo != 140 {
+		t.Fatalf("expected 140 back on the pacote, got carteira %.2f pacotes %+v", atual.Saldo, pacotes)
+	}
+	if movimentos[0].Tipo != models.CreditoMovimentoEstorno || movimentos[0].Valor != 60 {
+		t.Fatalf("expected an estorno of 60 as the last movimento, got %+v", movimentos[0])
+	}
+
+	var reembolsosEmDinheiro int
+	var totalPago float64
+	err = db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FILTER (WHERE forma_pagamento = 'reembolso'), COALESCE(SUM(valor_pago), 0)
+		FROM %s.pagamentos_por_agendamento
+		WHERE id_agendamento = 1
+	`, schema)).Scan(&reembolsosEmDinheiro, &totalPago)
+	if err != nil {
+		t.Fatalf("failed to load payments: %v", err)
+	}
+	if reembolsosEmDinheiro != 0 || totalPago != 60 {
+		t.Fatalf("expected no cash refund and 60 kept, got %d cash refunds and %.2f paid", reembolsosEmDinheiro, totalPago)
+	}
+}
+
+// TestCancelarAgendamentoPagoComHorasDevolveMinutos needs a disposable
+// Postgres database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestCancelarAgendamentoPagoComHorasDevolveMinutos(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	statements := []string{
+		fmt.Sprintf(`INSERT INTO %s.arena_politicas (id_arena, cancelamento_gratuito_horas, cancelamento_multa_percentual) VALUES (1, 24, 50)`, schema),
+		fmt.Sprintf(`INSERT INTO %s.agendamentos (id_campo, horario, duracao_minutos, jogadores, status, valor_bruto, valor_total, valor_restante)
+			VALUES (1, NOW() + INTERVAL '2 hours', 90, 10, 'agendado', 180, 180, 180)`, schema),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("failed to prepare agendamento: %v", err)
+		}
+	}
+
+	service := newAgendamentoService()
+	carteira, _, err := service.VenderPacoteCredito(ctx, 1, models.VenderPacoteCreditoInput{
+		NomeCliente: "Cliente Teste",
+		Descricao:   "Pacote de horas",
+		Minutos:     600,
+		Preco:       900,
+	})
+	if err != nil {
+		t.Fatalf("failed to sell pacote: %v", err)
+	}
+
+	ownerCtx := context.WithValue(ctx, middleware.UserIDKey, 1)
+	pagamento, err := service.RegistrarPagamentoParcial(ownerCtx, 1, 1, models.RegistrarPagamentoInput{
+		IDCarteira: &carteira.ID,
+		UsarHoras:  true,
+	})
+	if err != nil {
+		t.Fatalf("failed to pay with hours: %v", err)
+	}
+	if pagamento.Pagamento.ValorPago != 180 || pagamento.Agendamento.ValorRestante != 0 {
+		t.Fatalf("expected the hours to settle the booking, got %+v", pagamento.Pagamento)
+	}
+
+	atual, _, _, err := service.GetExtratoCredito(ctx, 1, carteira.ID)
+	if err != nil {
+		t.Fatalf("failed to load extrato: %v", err)
+	}
+	if atual.SaldoMinutos != 510 || atual.Saldo != 0 {
+		t.Fatalf("expected 90 minutes debited and no money credit, got %+v", atual)
+	}
+
+	result, err := service.UpdateStatus(ownerCtx, 1, 1, models.AgendamentoStatusCancelado)
+	if err != nil {
+		t.Fatalf("expected the cancel to succeed, got %v", err)
+	}
+	if result.Cancelamento == nil || result.Cancelamento.ReembolsoCredito != 90 || result.Cancelamento.ReembolsoMinutos != 45 {
+		t.Fatalf("expected 45 minutes back, got %+v", result.Cancelamento)
+	}
+
+	atual, pacotes, movimentos, err := service.GetExtratoCredito(ctx, 1, carteira.ID)
+	if err != nil {
+		t.Fatalf("failed to load extrato: %v", err)
+	}
+	if atual.SaldoMinutos != 555 || len(pacotes) != 1 || pacotes[0].SaldoMinutos != 555 {
+		t.Fatalf("expected 555 minutes left, got carteira %+v pacotes %+v", atual, pacotes)
+	}
+	if movimentos[0].Tipo != models.CreditoMovimentoEstorno || movimentos[0].Minutos != 45 || movimentos[0].Valor != 0 {
+		t.Fatalf("expected an estorno of 45 minutes as the last movimento, got %+v", movimentos[0])
+	}
+}
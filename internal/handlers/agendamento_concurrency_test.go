@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestMapScheduleWriteErrorTranslatesExclusionViolation(t *testing.T) {
+	err := fmt.Errorf("insert agendamento: %w", &pgconn.PgError{Code: pgExclusionViolation})
+	if got := mapScheduleWriteError(err); !errors.Is(got, errAgendamentoHorarioIndisponivel) {
+		t.Fatalf("expected horario indisponivel, got %v", got)
+	}
+
+	other := errors.New("boom")
+	if got := mapScheduleWriteError(other); got != other {
+		t.Fatalf("expected unrelated errors to pass through, got %v", got)
+	}
+}
+
+// TestCreateAgendamentoConcurrentRequestsBookOnce needs a disposable Postgres
+// database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestCreateAgendamentoConcurrentRequestsBookOnce(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	const ownerUserID = 1
+	service := newAgendamentoService()
+	inicio := time.Date(2026, time.May, 4, 20, 0, 0, 0, agendamentoLocation())
+
+	const requests = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		criados   int
+		conflitos int
+		outros    []error
+	)
+	for index := 0; index < requests; index++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+
+			// Starts vary by up to 30 minutes, so every 90-minute window overlaps the others.
+			_, err := service.CreateManual(ctx, ownerUserID, models.CreateAgendamentoInput{
+				IDCampo:        1,
+				Horario:        inicio.Add(time.Duration(index%3) * 15 * time.Minute),
+				DuracaoMinutos: 90,
+				Jogadores:      10,
+				Pagamento:      "pix",
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				criados++
+			case errors.Is(err, errAgendamentoHorarioIndisponivel):
+				conflitos++
+			default:
+				outros = append(outros, err)
+			}
+		}(index)
+	}
+	wg.Wait()
+
+	if len(outros) > 0 {
+		t.Fatalf("unexpected errors: %v", outros)
+	}
+	if criados != 1 || conflitos != requests-1 {
+		t.Fatalf("expected 1 booking and %d conflicts, got %d bookings and %d conflicts", requests-1, criados, conflitos)
+	}
+}
+
+// TestEncerrarCronometroConcurrentRequestsEndOnce needs a disposable Postgres
+// database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestEncerrarCronometroConcurrentRequestsEndOnce(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s.agendamentos (id_campo, horario, jogadores, status, inicio_cronometro, valor_bruto, valor_total, valor_restante)
+		VALUES (1, NOW() - INTERVAL '1 hour', 10, 'em_andamento', EXTRACT(EPOCH FROM NOW() - INTERVAL '1 hour')::BIGINT, 120, 120, 120)
+	`, schema)); err != nil {
+		t.Fatalf("failed to prepare agendamento: %v", err)
+	}
+
+	const ownerUserID = 1
+	service := newAgendamentoService()
+
+	const requests = 4
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		encerrados int
+		recusados  int
+		outros     []error
+	)
+	for index := 0; index < requests; index++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := service.EncerrarCronometro(ctx, ownerUserID, 1)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				encerrados++
+			case errors.Is(err, models.ErrAgendamentoTransicaoInvalida):
+				recusados++
+			default:
+				outros = append(outros, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(outros) > 0 {
+		t.Fatalf("unexpected errors: %v", outros)
+	}
+	if encerrados != 1 || recusados != requests-1 {
+		t.Fatalf("expected 1 end and %d refusals, got %d ends and %d refusals", requests-1, encerrados, recusados)
+	}
+
+	var eventos int
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM %s.agendamento_status_eventos WHERE id_agendamento = 1 AND tipo = $1
+	`, schema), string(models.AgendamentoStatusEventoCronometroEncerrado)).Scan(&eventos); err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if eventos != 1 {
+		t.Fatalf("expected one cronometro_encerrado event, got %d", eventos)
+	}
+}
+
+// TestRegistrarPagamentoConcurrentHoursDebitOnce needs a disposable Postgres
+// database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestRegistrarPagamentoConcurrentHoursDebitOnce(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s.agendamentos (id_campo, horario, duracao_minutos, jogadores, status, valor_bruto, valor_total, valor_restante)
+		VALUES (1, NOW() + INTERVAL '1 day', 60, 10, 'agendado', 120, 120, 120)
+	`, schema)); err != nil {
+		t.Fatalf("failed to prepare agendamento: %v", err)
+	}
+
+	const ownerUserID = 1
+	service := newAgendamentoService()
+	carteira, _, err := service.VenderPacoteCredito(ctx, 1, models.VenderPacoteCreditoInput{
+		NomeCliente: "Cliente Teste",
+		Descricao:   "Pacote de horas",
+		Minutos:     600,
+		Preco:       900,
+	})
+	if err != nil {
+		t.Fatalf("failed to sell pacote: %v", err)
+	}
+
+	const requests = 4
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		pagos     int
+		recusados int
+		outros    []error
+	)
+	for index := 0; index < requests; index++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := service.RegistrarPagamentoParcial(ctx, ownerUserID, 1, models.RegistrarPagamentoInput{
+				IDCarteira: &carteira.ID,
+				UsarHoras:  true,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				pagos++
+			case errors.Is(err, errAgendamentoSemSaldoPendente),
+				errors.Is(err, errCreditoHorasReservaInteira),
+				errors.Is(err, models.ErrAgendamentoTransicaoInvalida):
+				recusados++
+			default:
+				outros = append(outros, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(outros) > 0 {
+		t.Fatalf("unexpected errors: %v", outros)
+	}
+	if pagos != 1 || recusados != requests-1 {
+		t.Fatalf("expected 1 payment and %d refusals, got %d payments and %d refusals", requests-1, pagos, recusados)
+	}
+
+	atual, _, _, err := service.GetExtratoCredito(ctx, 1, carteira.ID)
+	if err != nil {
+		t.Fatalf("failed to load extrato: %v", err)
+	}
+	if atual.SaldoMinutos != 540 {
+		t.Fatalf("expected 60 minutes debited once, got %d left", atual.SaldoMinutos)
+	}
+}
+
+// setupAgendamentoTestSchema creates the tables that predate the migrations
+// directory and then applies every migration in order, with the arena and
+// public schemas pointed at the test schema.
+func setupAgendamentoTestSchema(t *testing.T, db *sql.DB, schema string) {
+	t.Helper()
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS btree_gist`,
+		fmt.Sprintf(`CREATE SCHEMA %s`, schema),
+		fmt.Sprintf(`CREATE TABLE %s.arenas (id SERIAL PRIMARY KEY, id_usuario INTEGER NOT NULL, nome VARCHAR(255) NOT NULL)`, schema),
+		fmt.Sprintf(`CREATE TABLE %s.campo (
+			id_campo SERIAL PRIMARY KEY,
+			id_arena INTEGER NOT NULL REFERENCES %s.arenas (id),
+			nome_campo VARCHAR(255) NOT NULL,
+			max_jogadores INTEGER,
+			valor_hora NUMERIC(10, 2),
+			ativo BOOLEAN DEFAULT TRUE
+		)`, schema, schema),
+		fmt.Sprintf(`CREATE TABLE %s.agendamentos (
+			id_agendamento SERIAL PRIMARY KEY,
+			id_usuario INTEGER,
+			id_campo INTEGER NOT NULL REFERENCES %s.campo (id_campo),
+			horario TIMESTAMP NOT NULL,
+			jogadores INTEGER NOT NULL,
+			pagamento VARCHAR(100),
+			pago BOOLEAN DEFAULT FALSE,
+			criado_em TIMESTAMP,
+			status VARCHAR(50) NOT NULL,
+			status_de_pagamento BOOLEAN DEFAULT FALSE,
+			inicio_cronometro BIGINT,
+			fim_cronometro TIMESTAMP,
+			time1 VARCHAR(255),
+			time2 VARCHAR(255),
+			modo_de_jogo VARCHAR(100)
+		)`, schema, schema),
+		fmt.Sprintf(`CREATE TABLE %s.pagamentos_por_agendamento (
+			id SERIAL PRIMARY KEY,
+			id_agendamento INTEGER NOT NULL,
+			id_usuario INTEGER,
+			valor_pago NUMERIC(10, 2) NOT NULL,
+			forma_pagamento VARCHAR(100),
+			data_pagamento TIMESTAMP NOT NULL
+		)`, schema),
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("failed to prepare test schema: %v", err)
+		}
+	}
+
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.sql"))
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	sort.Strings(migrations)
+
+	replacer := strings.NewReplacer(
+		"arena.", schema+".",
+		"public.", schema+".",
+		"'arena'", "'"+schema+"'",
+	)
+	for _, migration := range migrations {
+		content, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", migration, err)
+		}
+		// Without arguments pgx sends the file as one simple query, so the
+		// migration's own BEGIN/COMMIT and DO blocks run as written.
+		if _, err := db.Exec(replacer.Replace(string(content))); err != nil {
+			t.Fatalf("failed to apply %s: %v", filepath.Base(migration), err)
+		}
+	}
+
+	seed := []string{
+		fmt.Sprintf(`INSERT INTO %s.arenas (id_usuario, nome) VALUES (1, 'Arena Teste')`, schema),
+		fmt.Sprintf(`INSERT INTO %s.campo (id_arena, nome_campo, max_jogadores, valor_hora) VALUES (1, 'Campo 1', 14, 120)`, schema),
+	}
+	for _, statement := range seed {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("failed to seed test schema: %v", err)
+		}
+	}
+}
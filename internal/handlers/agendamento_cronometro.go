@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+var (
+	errAgendamentoCronometroPausado    = errors.New("cronometro ja pausado")
+	errAgendamentoCronometroNaoPausado = errors.New("cronometro nao pausado")
+)
+
+type agendamentoCronometroPausaResponse struct {
+	ID     int    `json:"id"`
+	Inicio string `json:"inicio"`
+	Fim    string `json:"fim,omitempty"`
+}
+
+type agendamentoCronometroResponse struct {
+	IDAgendamento     int                                  `json:"id_agendamento"`
+	Estado            string                               `json:"estado"`
+	Inicio            string                               `json:"inicio,omitempty"`
+	Fim               string                               `json:"fim,omitempty"`
+	PausadoEm         string                               `json:"pausado_em,omitempty"`
+	DecorridoSegundos int64                                `json:"decorrido_segundos"`
+	PausadoSegundos   int64                                `json:"pausado_segundos"`
+	ReservadoSegundos int64                                `json:"reservado_segundos"`
+	Pausas            []agendamentoCronometroPausaResponse `json:"pausas"`
+	Agora             string                               `json:"agora"`
+}
+
+func (repository agendamentoRepository) listCronometroPausas(ctx context.Context, agendamentoID int) ([]models.AgendamentoCronometroPausa, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, id_agendamento, inicio, fim
+		FROM %s
+		WHERE id_agendamento = $1
+		ORDER BY inicio ASC, id ASC
+	`, agendamentoCronometroPausasTableName()), agendamentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pausas := make([]models.AgendamentoCronometroPausa, 0)
+	for rows.Next() {
+		var (
+			pausa models.AgendamentoCronometroPausa
+			fim   sql.NullTime
+		)
+		if err := rows.Scan(&pausa.ID, &pausa.IDAgendamento, &pausa.Inicio, &fim); err != nil {
+			return nil, err
+		}
+		if fim.Valid {
+			pausa.Fim = &fim.Time
+		}
+		pausas = append(pausas, pausa)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pausas, nil
+}
+
+func (repository agendamentoRepository) insertCronometroPausa(ctx context.Context, agendamentoID int, inicio time.Time) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id_agendamento, inicio) VALUES ($1, $2)
+	`, agendamentoCronometroPausasTableName()), agendamentoID, inicio)
+	return err
+}
+
+// closeCronometroPausa ends the open pause, if any, at fim.
+func (repository agendamentoRepository) closeCronometroPausa(ctx context.Context, agendamentoID int, fim time.Time) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s SET fim = $2 WHERE id_agendamento = $1 AND fim IS NULL
+	`, agendamentoCronometroPausasTableName()), agendamentoID, fim)
+	return err
+}
+
+func (repository agendamentoRepository) deleteCronometroPausas(ctx context.Context, agendamentoID int) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		DELETE FROM %s WHERE id_agendamento = $1
+	`, agendamentoCronometroPausasTableName()), agendamentoID)
+	return err
+}
+
+// lockCronometro re-reads the agendamento under a row lock so two tablets
+// pausing or resuming at once cannot both win.
+func (service agendamentoService) lockCronometro(ctx context.Context, agendamentoID int, acao models.AgendamentoAcao) (models.Agendamento, error) {
+	if _, err := service.repository.lockAgendamentoStatus(ctx, agendamentoID); err != nil {
+		return models.Agendamento{}, err
+	}
+
+	agendamento, err := service.repository.getByID(ctx, agendamentoID)
+	if err != nil {
+		return models.Agendamento{}, err
+	}
+	if err := models.ValidarAcaoAgendamento(agendamento.Status, acao); err != nil {
+		return models.Agendamento{}, err
+	}
+	if agendamento.InicioCronometro == nil || agendamento.FimCronometro != nil {
+		return models.Agendamento{}, errAgendamentoCronometroNaoIniciado
+	}
+
+	return agendamento, nil
+}
+
+func (service agendamentoService) PausarCronometro(ctx context.Context, ownerUserID int, agendamentoID int) (models.Agendamento, error) {
+	if _, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Agendamento{}, errAgendamentoNaoEncontrado
+		}
+		return models.Agendamento{}, err
+	}
+
+	var agendamento models.Agendamento
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		var err error
+		agendamento, err = service.lockCronometro(ctx, agendamentoID, models.AgendamentoAcaoPausarCronometro)
+		if err != nil {
+			return err
+		}
+		if agendamento.CronometroPausadoEm != nil {
+			return errAgendamentoCronometroPausado
+		}
+
+		inicio := agendamentoNow()
+		if err := service.repository.insertCronometroPausa(ctx, agendamentoID, inicio); err != nil {
+			return err
+		}
+		agendamento.CronometroPausadoEm = &inicio
+
+		return service.recordStatusEvento(ctx, agendamentoID, agendamentoStatusEventoInput{
+			Tipo:           models.AgendamentoStatusEventoCronometroPausado,
+			StatusAnterior: &agendamento.Status,
+			StatusNovo:     agendamento.Status,
+			Detalhes:       map[string]string{"pausado_em": formatAgendamentoDateTime(inicio)},
+		})
+	})
+	if err != nil {
+		return models.Agendamento{}, err
+	}
+
+	return agendamento, nil
+}
+
+func (service agendamentoService) RetomarCronometro(ctx context.Context, ownerUserID int, agendamentoID int) (models.Agendamento, error) {
+	if _, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Agendamento{}, errAgendamentoNaoEncontrado
+		}
+		return models.Agendamento{}, err
+	}
+
+	var agendamento models.Agendamento
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		var err error
+		agendamento, err = service.lockCronometro(ctx, agendamentoID, models.AgendamentoAcaoRetomarCronometro)
+		if err != nil {
+			return err
+		}
+		if agendamento.CronometroPausadoEm == nil {
+			return errAgendamentoCronometroNaoPausado
+		}
+
+		fim := agendamentoNow()
+		if err := service.repository.closeCronometroPausa(ctx, agendamentoID, fim); err != nil {
+			return err
+		}
+		pausa := fim.Sub(*agendamento.CronometroPausadoEm)
+		agendamento.CronometroPausasSegundos += int64(pausa / time.Second)
+		agendamento.CronometroPausadoEm = nil
+
+		return service.recordStatusEvento(ctx, agendamentoID, agendamentoStatusEventoInput{
+			Tipo:           models.AgendamentoStatusEventoCronometroRetomado,
+			StatusAnterior: &agendamento.Status,
+			StatusNovo:     agendamento.Status,
+			Detalhes: map[string]any{
+				"retomado_em":      formatAgendamentoDateTime(fim),
+				"pausado_segundos": int64(pausa / time.Second),
+			},
+		})
+	})
+	if err != nil {
+		return models.Agendamento{}, err
+	}
+
+	return agendamento, nil
+}
+
+// GetCronometro computes the clock on the server so a reloaded tablet shows
+// the same time as before.
+func (service agendamentoService) GetCronometro(ctx context.Context, ownerUserID int, agendamentoID int) (models.AgendamentoCronometro, error) {
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AgendamentoCronometro{}, errAgendamentoNaoEncontrado
+		}
+		return models.AgendamentoCronometro{}, err
+	}
+
+	cronometro := agendamento.Cronometro(agendamentoNow())
+	if agendamento.InicioCronometro == nil {
+		return cronometro, nil
+	}
+
+	cronometro.Pausas, err = service.repository.listCronometroPausas(ctx, agendamentoID)
+	if err != nil {
+		return models.AgendamentoCronometro{}, err
+	}
+
+	return cronometro, nil
+}
+
+func PausarCronometroAgendamento(w http.ResponseWriter, r *http.Request) {
+	handleCronometroPausa(w, r, true)
+}
+
+func RetomarCronometroAgendamento(w http.ResponseWriter, r *http.Request) {
+	handleCronometroPausa(w, r, false)
+}
+
+func handleCronometroPausa(w http.ResponseWriter, r *http.Request, pausar bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	var agendamento models.Agendamento
+	message := "Cronometro retomado com sucesso"
+	if pausar {
+		agendamento, err = service.PausarCronometro(r.Context(), userID, agendamentoID)
+		message = "Cronometro pausado com sucesso"
+	} else {
+		agendamento, err = service.RetomarCronometro(r.Context(), userID, agendamentoID)
+	}
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message":     message,
+		"agendamento": newAgendamentoResponse(agendamento),
+		"cronometro":  newAgendamentoCronometroResponse(agendamento.Cronometro(agendamentoNow())),
+	})
+}
+
+func GetCronometroAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	cronometro, err := service.GetCronometro(r.Context(), userID, agendamentoID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAgendamentoCronometroResponse(cronometro))
+}
+
+func newAgendamentoCronometroResponse(cronometro models.AgendamentoCronometro) agendamentoCronometroResponse {
+	response := agendamentoCronometroResponse{
+		IDAgendamento:     cronometro.IDAgendamento,
+		Estado:            string(cronometro.Estado),
+		DecorridoSegundos: cronometro.DecorridoSegundos,
+		PausadoSegundos:   cronometro.PausadoSegundos,
+		ReservadoSegundos: cronometro.ReservadoSegundos,
+		Pausas:            make([]agendamentoCronometroPausaResponse, 0, len(cronometro.Pausas)),
+		Agora:             formatAgendamentoDateTime(cronometro.Agora),
+	}
+	if cronometro.Inicio != nil {
+		response.Inicio = formatAgendamentoDateTime(*cronometro.Inicio)
+	}
+	if cronometro.Fim != nil {
+		response.Fim = formatAgendamentoDateTime(*cronometro.Fim)
+	}
+	if cronometro.PausadoEm != nil {
+		response.PausadoEm = formatAgendamentoDateTime(*cronometro.PausadoEm)
+	}
+	for _, pausa := range cronometro.Pausas {
+		item := agendamentoCronometroPausaResponse{ID: pausa.ID, Inicio: formatAgendamentoDateTime(pausa.Inicio)}
+		if pausa.Fim != nil {
+			item.Fim = formatAgendamentoDateTime(*pausa.Fim)
+		}
+		response.Pausas = append(response.Pausas, item)
+	}
+
+	return response
+}
@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+const (
+	agendamentoFeedHeartbeatPadrao = 25 * time.Second
+	agendamentoFeedLote            = 100
+	agendamentoFeedEsperaRetidos   = time.Second
+	agendamentoFeedConexoesPadrao  = 5
+)
+
+var errAgendamentoFeedLimite = errors.New("limite de conexoes do feed atingido")
+
+const (
+	agendamentoFeedPedidoCriado         = "pedido_criado"
+	agendamentoFeedCanceladoPeloJogador = "cancelado_pelo_jogador"
+	agendamentoFeedPagamentoRegistrado  = "pagamento_registrado"
+	agendamentoFeedCronometroIniciado   = "cronometro_iniciado"
+	agendamentoFeedCronometroEncerrado  = "cronometro_encerrado"
+)
+
+// agendamentoFeedHub wakes the open feeds of an owner when one of their
+// agendamentos changes. The event log stays the source of truth: a wake-up
+// only tells the feed to read what it has not sent yet.
+type agendamentoFeedHub struct {
+	mu        sync.Mutex
+	inscritos map[int]map[chan struct{}]struct{}
+	// maxPorOwner caps the open feeds of one owner; zero reads
+	// AGENDAMENTO_FEED_MAX_CONEXOES.
+	maxPorOwner int
+}
+
+var agendamentoFeed = newAgendamentoFeedHub()
+
+func newAgendamentoFeedHub() *agendamentoFeedHub {
+	return &agendamentoFeedHub{inscritos: make(map[int]map[chan struct{}]struct{})}
+}
+
+// subscribe refuses a feed past the owner's limit: each one holds a
+// connection open and reads the event log on every wake-up and heartbeat.
+func (hub *agendamentoFeedHub) subscribe(ownerUserID int) (<-chan struct{}, func(), error) {
+	limite := hub.maxPorOwner
+	if limite <= 0 {
+		limite = envPositiveInt("AGENDAMENTO_FEED_MAX_CONEXOES", agendamentoFeedConexoesPadrao)
+	}
+	canal := make(chan struct{}, 1)
+
+	hub.mu.Lock()
+	if len(hub.inscritos[ownerUserID]) >= limite {
+		hub.mu.Unlock()
+		return nil, nil, errAgendamentoFeedLimite
+	}
+	if hub.inscritos[ownerUserID] == nil {
+		hub.inscritos[ownerUserID] = make(map[chan struct{}]struct{})
+	}
+	hub.inscritos[ownerUserID][canal] = struct{}{}
+	hub.mu.Unlock()
+
+	return canal, func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		delete(hub.inscritos[ownerUserID], canal)
+		if len(hub.inscritos[ownerUserID]) == 0 {
+			delete(hub.inscritos, ownerUserID)
+		}
+	}, nil
+}
+
+func (hub *agendamentoFeedHub) hasInscritos() bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return len(hub.inscritos) > 0
+}
+
+// publish never blocks: a feed that is still sending keeps a single pending
+// wake-up and picks every new event up in its next read.
+func (hub *agendamentoFeedHub) publish(ownerUserID int) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for canal := range hub.inscritos[ownerUserID] {
+		select {
+		case canal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// publicarFeed wakes the owner's feeds once the event is committed. The owner
+// lookup is skipped while nobody is connected.
+func (service agendamentoService) publicarFeed(ctx context.Context, agendamentoID int) error {
+	if !agendamentoFeed.hasInscritos() {
+		return nil
+	}
+
+	ownerUserID, err := service.repository.getOwnerUserID(ctx, agendamentoID)
+	if err != nil {
+		return err
+	}
+
+	service.repository.afterCommit(func() {
+		agendamentoFeed.publish(ownerUserID)
+	})
+	return nil
+}
+
+func (repository agendamentoRepository) getOwnerUserID(ctx context.Context, agendamentoID int) (int, error) {
+	var ownerUserID int
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT ar.id_usuario
+		FROM %s a
+		JOIN %s c ON a.id_campo = c.id_campo
+		JOIN %s ar ON c.id_arena = ar.id
+		WHERE a.id_agendamento = $1
+	`, agendamentosTableName(), campoTableName(), arenasTableName()), agendamentoID).Scan(&ownerUserID)
+	return ownerUserID, err
+}
+
+// agendamentoFeedCursor marks the last event a feed has sent. Events are
+// ordered by the transaction that wrote them and then by id, because ids are
+// taken at insert time and a transaction holding a lower id may commit after
+// one holding a higher id.
+type agendamentoFeedCursor struct {
+	TxID int64
+	ID   int64
+}
+
+// agendamentoFeedLinha is an event read for the feed together with its
+// position and the oldest transaction still running when it was read.
+type agendamentoFeedLinha struct {
+	Evento models.AgendamentoStatusEvento
+	Cursor agendamentoFeedCursor
+	XMin   int64
+}
+
+type agendamentoFeedScanner struct {
+	scanner agendamentoScanner
+	extras  []any
+}
+
+func (scanner agendamentoFeedScanner) Scan(dest ...any) error {
+	return scanner.scanner.Scan(append(dest, scanner.extras...)...)
+}
+
+// feedCursorInicial starts a new feed at the oldest transaction still
+// running. Events committed next to a long transaction may be sent once more
+// on connect; none committed after it are lost.
+func (repository agendamentoRepository) feedCursorInicial(ctx context.Context) (agendamentoFeedCursor, error) {
+	var xmin int64
+	err := repository.database().QueryRowContext(ctx, `
+		SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+	`).Scan(&xmin)
+	return agendamentoFeedCursor{TxID: xmin - 1, ID: math.MaxInt64}, err
+}
+
+// feedCursorDoEvento resumes after the event a client last received. If that
+// event is gone with its agendamento, the newest transaction at or below its
+// id stands in for it.
+func (repository agendamentoRepository) feedCursorDoEvento(ctx context.Context, eventoID int64) (agendamentoFeedCursor, error) {
+	var txid int64
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COALESCE(
+			(SELECT txid::text::bigint FROM %[1]s WHERE id = $1),
+			(SELECT MAX(txid::text::bigint) FROM %[1]s WHERE id <= $1),
+			0
+		)
+	`, agendamentoStatusEventosTableName()), eventoID).Scan(&txid)
+	return agendamentoFeedCursor{TxID: txid, ID: eventoID}, err
+}
+
+// listStatusEventosForOwner returns the owner's events after the cursor in
+// feed order, each with the snapshot xmin of the read.
+func (repository agendamentoRepository) listStatusEventosForOwner(ctx context.Context, ownerUserID int, cursor agendamentoFeedCursor, limite int) ([]agendamentoFeedLinha, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT e.id, e.id_agendamento, e.tipo, e.status_anterior, e.status_novo, e.ator_tipo, e.id_ator, e.origem, e.detalhes, e.criado_em,
+			e.txid::text::bigint, pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+		FROM %s e
+		JOIN %s a ON e.id_agendamento = a.id_agendamento
+		JOIN %s c ON a.id_campo = c.id_campo
+		JOIN %s ar ON c.id_arena = ar.id
+		WHERE ar.id_usuario = $1
+		  AND (e.txid > $2::text::xid8 OR (e.txid = $2::text::xid8 AND e.id > $3))
+		ORDER BY e.txid ASC, e.id ASC
+		LIMIT $4
+	`, agendamentoStatusEventosTableName(), agendamentosTableName(), campoTableName(), arenasTableName()),
+		ownerUserID, strconv.FormatInt(cursor.TxID, 10), cursor.ID, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	linhas := make([]agendamentoFeedLinha, 0)
+	for rows.Next() {
+		var linha agendamentoFeedLinha
+		evento, scanErr := scanAgendamentoStatusEvento(agendamentoFeedScanner{
+			scanner: rows,
+			extras:  []any{&linha.Cursor.TxID, &linha.XMin},
+		})
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		linha.Evento = evento
+		linha.Cursor.ID = evento.ID
+		linhas = append(linhas, linha)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return linhas, nil
+}
+
+// agendamentoFeedLiberados keeps the rows whose transaction is older than
+// every transaction still running. Anything after the first row that is not
+// is held back, since an older transaction may still commit an event that
+// belongs before it.
+func agendamentoFeedLiberados(linhas []agendamentoFeedLinha) ([]agendamentoFeedLinha, bool) {
+	for index, linha := range linhas {
+		if linha.Cursor.TxID >= linha.XMin {
+			return linhas[:index], true
+		}
+	}
+
+	return linhas, false
+}
+
+// agendamentoFeedEvento names the log entries the owner feed streams; the
+// rest of the history stays out of it.
+func agendamentoFeedEvento(evento models.AgendamentoStatusEvento) (string, bool) {
+	switch evento.Tipo {
+	case models.AgendamentoStatusEventoCriacao:
+		if evento.StatusNovo == models.AgendamentoStatusPedido {
+			return agendamentoFeedPedidoCriado, true
+		}
+	case models.AgendamentoStatusEventoStatus:
+		if evento.StatusNovo == models.AgendamentoStatusCancelado && evento.AtorTipo == models.AgendamentoAtorIntegracao {
+			return agendamentoFeedCanceladoPeloJogador, true
+		}
+	case models.AgendamentoStatusEventoPagamento:
+		return agendamentoFeedPagamentoRegistrado, true
+	case models.AgendamentoStatusEventoCronometroIniciado:
+		return agendamentoFeedCronometroIniciado, true
+	case models.AgendamentoStatusEventoCronometroEncerrado:
+		return agendamentoFeedCronometroEncerrado, true
+	}
+
+	return "", false
+}
+
+// resolveAgendamentoFeedLastEventID reads where a reconnecting client stopped.
+// Browsers send the Last-Event-ID header; the query parameter covers clients
+// that cannot set it.
+func resolveAgendamentoFeedLastEventID(r *http.Request) (int64, bool, error) {
+	value := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if value == "" {
+		value = strings.TrimSpace(r.URL.Query().Get("last_event_id"))
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, errors.New("last_event_id invalido")
+	}
+
+	return id, true, nil
+}
+
+func writeAgendamentoFeedEvento(w http.ResponseWriter, nome string, evento models.AgendamentoStatusEvento) error {
+	body, err := json.Marshal(newAgendamentoStatusEventoResponse(evento))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", evento.ID, nome, body)
+	return err
+}
+
+// StreamAgendamentosFeed streams the owner's pedidos and game events as
+// Server-Sent Events. It is registered outside the one-request-per-user lock,
+// since the connection stays open, and the hub caps how many feeds one owner
+// keeps open instead.
+func StreamAgendamentosFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming nao suportado", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID, resume, err := resolveAgendamentoFeedLastEventID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Subscribing before the first read means nothing committed in between is
+	// missed.
+	wake, unsubscribe, err := agendamentoFeed.subscribe(userID)
+	if err != nil {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "Limite de conexoes do feed atingido", http.StatusTooManyRequests)
+		return
+	}
+	defer unsubscribe()
+
+	ctx := r.Context()
+	repository := newAgendamentoRepository()
+	var cursor agendamentoFeedCursor
+	if resume {
+		cursor, err = repository.feedCursorDoEvento(ctx, lastEventID)
+	} else {
+		cursor, err = repository.feedCursorInicial(ctx)
+	}
+	if err != nil {
+		http.Error(w, "Erro ao abrir feed de agendamentos", http.StatusInternalServerError)
+		log.Printf("Erro ao abrir feed de agendamentos do usuario %d: %v", userID, err)
+		return
+	}
+
+	// The stream outlives any server-level WriteTimeout by design, so its
+	// write deadline is cleared; idle connections are still detected by the
+	// heartbeat write failing.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Erro ao remover prazo de escrita do feed do usuario %d: %v", userID, err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(agendamentoFeedHeartbeatPadrao)
+	defer heartbeat.Stop()
+
+	for {
+		pendente := false
+		for {
+			linhas, err := repository.listStatusEventosForOwner(ctx, userID, cursor, agendamentoFeedLote)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Erro ao ler feed de agendamentos do usuario %d: %v", userID, err)
+				}
+				return
+			}
+
+			liberados, retidos := agendamentoFeedLiberados(linhas)
+			for _, linha := range liberados {
+				cursor = linha.Cursor
+				nome, ok := agendamentoFeedEvento(linha.Evento)
+				if !ok {
+					continue
+				}
+				if err := writeAgendamentoFeedEvento(w, nome, linha.Evento); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+
+			if retidos || len(linhas) < agendamentoFeedLote {
+				pendente = retidos
+				break
+			}
+		}
+
+		// Held-back events are released when the older transaction ends,
+		// which may belong to another owner and never wake this feed.
+		var espera <-chan time.Time
+		if pendente {
+			espera = time.After(agendamentoFeedEsperaRetidos)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-espera:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestAgendamentoFeedHubWakesOnlyTheOwner(t *testing.T) {
+	hub := newAgendamentoFeedHub()
+	owner, unsubscribeOwner, err := hub.subscribe(1)
+	if err != nil {
+		t.Fatalf("expected the owner to subscribe, got %v", err)
+	}
+	other, unsubscribeOther, err := hub.subscribe(2)
+	if err != nil {
+		t.Fatalf("expected the other owner to subscribe, got %v", err)
+	}
+	defer unsubscribeOther()
+
+	hub.publish(1)
+	hub.publish(1)
+
+	select {
+	case <-owner:
+	default:
+		t.Fatalf("expected the owner feed to be woken")
+	}
+	select {
+	case <-owner:
+		t.Fatalf("expected repeated publishes to coalesce into one wake-up")
+	default:
+	}
+	select {
+	case <-other:
+		t.Fatalf("expected other owners not to be woken")
+	default:
+	}
+
+	unsubscribeOwner()
+	unsubscribeOther()
+	if hub.hasInscritos() {
+		t.Fatalf("expected no subscribers after unsubscribing")
+	}
+}
+
+func TestAgendamentoFeedHubLimitsFeedsPerOwner(t *testing.T) {
+	hub := newAgendamentoFeedHub()
+	hub.maxPorOwner = 2
+
+	_, primeiro, err := hub.subscribe(1)
+	if err != nil {
+		t.Fatalf("expected the first feed to open, got %v", err)
+	}
+	if _, _, err := hub.subscribe(1); err != nil {
+		t.Fatalf("expected the second feed to open, got %v", err)
+	}
+	if _, _, err := hub.subscribe(1); !errors.Is(err, errAgendamentoFeedLimite) {
+		t.Fatalf("expected the third feed to be refused, got %v", err)
+	}
+	if _, _, err := hub.subscribe(2); err != nil {
+		t.Fatalf("expected other owners to keep their own limit, got %v", err)
+	}
+
+	primeiro()
+	if _, _, err := hub.subscribe(1); err != nil {
+		t.Fatalf("expected a closed feed to free its slot, got %v", err)
+	}
+}
+
+func TestStreamAgendamentosFeedRefusesOwnersOverTheLimit(t *testing.T) {
+	previous := agendamentoFeed
+	agendamentoFeed = newAgendamentoFeedHub()
+	agendamentoFeed.maxPorOwner = 1
+	defer func() { agendamentoFeed = previous }()
+
+	_, unsubscribe, err := agendamentoFeed.subscribe(1)
+	if err != nil {
+		t.Fatalf("expected the first feed to open, got %v", err)
+	}
+	defer unsubscribe()
+
+	request := httptest.NewRequest(http.MethodGet, "/agendamentos/feed", nil)
+	request = request.WithContext(context.WithValue(request.Context(), middleware.UserIDKey, 1))
+	recorder := httptest.NewRecorder()
+	StreamAgendamentosFeed(recorder, request)
+
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d", recorder.Code)
+	}
+}
+
+func TestAgendamentoFeedEventoNamesStreamedEvents(t *testing.T) {
+	testCases := []struct {
+		name   string
+		evento models.AgendamentoStatusEvento
+		want   string
+	}{
+		{
+			name:   "pedido from integration",
+			evento: models.AgendamentoStatusEvento{Tipo: models.AgendamentoStatusEventoCriacao, StatusNovo: models.AgendamentoStatusPedido},
+			want:   agendamentoFeedPedidoCriado,
+		},
+		{
+			name:   "manual booking",
+			evento: models.AgendamentoStatusEvento{Tipo: models.AgendamentoStatusEventoCriacao, StatusNovo: models.AgendamentoStatusAgendado},
+		},
+		{
+			name:   "cancelled by jogador",
+			evento: models.AgendamentoStatusEvento{Tipo: models.AgendamentoStatusEventoStatus, StatusNovo: models.AgendamentoStatusCancelado, AtorTipo: models.AgendamentoAtorIntegracao},
+			want:   agendamentoFeedCanceladoPeloJogador,
+		},
+		{
+			name:   "cancelled by owner",
+			evento: models.AgendamentoStatusEvento{Tipo: models.AgendamentoStatusEventoStatus, StatusNovo: models.AgendamentoStatusCancelado, AtorTipo: models.AgendamentoAtorUsuario},
+		},
+		{
+			name:   "payment",
+			evento: models.AgendamentoStatusEvento{Tipo: models.AgendamentoStatusEventoPagamento},
+			want:   agendamentoFeedPagamentoRegistrado,
+		},
+		{
+			name:   "cronometro closed",
+			evento: models.AgendamentoStatusEvento{Tipo: models.AgendamentoStatusEventoCronometroEncerrado},
+			want:   agendamentoFeedCronometroEncerrado,
+		},
+		{
+			name:   "cronometro paused",
+			evento: models.AgendamentoStatusEvento{Tipo: models.AgendamentoStatusEventoCronometroPausado},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			nome, ok := agendamentoFeedEvento(testCase.evento)
+			if ok != (testCase.want != "") || nome != testCase.want {
+				t.Fatalf("expected %q, got %q (ok=%v)", testCase.want, nome, ok)
+			}
+		})
+	}
+}
+
+func TestResolveAgendamentoFeedLastEventID(t *testing.T) {
+	request := httptest.NewRequest("GET", "/agendamentos/feed?last_event_id=7", nil)
+	request.Header.Set("Last-Event-ID", "42")
+	if id, resume, err := resolveAgendamentoFeedLastEventID(request); err != nil || !resume || id != 42 {
+		t.Fatalf("expected the header to win with 42, got %d resume=%v err=%v", id, resume, err)
+	}
+
+	request = httptest.NewRequest("GET", "/agendamentos/feed", nil)
+	if _, resume, err := resolveAgendamentoFeedLastEventID(request); err != nil || resume {
+		t.Fatalf("expected a fresh feed without Last-Event-ID, got resume=%v err=%v", resume, err)
+	}
+
+	request = httptest.NewRequest("GET", "/agendamentos/feed?last_event_id=abc", nil)
+	if _, _, err := resolveAgendamentoFeedLastEventID(request); err == nil {
+		t.Fatalf("expected an invalid id to be rejected")
+	}
+}
+
+func TestAgendamentoFeedLiberadosHoldsBackRowsFromRunningTransactions(t *testing.T) {
+	linhas := []agendamentoFeedLinha{
+		{Cursor: agendamentoFeedCursor{TxID: 10, ID: 5}, XMin: 12},
+		{Cursor: agendamentoFeedCursor{TxID: 11, ID: 3}, XMin: 12},
+		{Cursor: agendamentoFeedCursor{TxID: 12, ID: 4}, XMin: 12},
+		{Cursor: agendamentoFeedCursor{TxID: 13, ID: 9}, XMin: 12},
+	}
+
+	liberados, retidos := agendamentoFeedLiberados(linhas)
+	if !retidos || len(liberados) != 2 || liberados[1].Cursor.ID != 3 {
+		t.Fatalf("expected the first two rows released and the rest held, got %+v retidos=%v", liberados, retidos)
+	}
+
+	liberados, retidos = agendamentoFeedLiberados(linhas[:2])
+	if retidos || len(liberados) != 2 {
+		t.Fatalf("expected every finished row released, got %+v retidos=%v", liberados, retidos)
+	}
+}
+
+// TestAgendamentoFeedWaitsForEventsCommittedOutOfOrder needs a disposable
+// Postgres database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestAgendamentoFeedWaitsForEventsCommittedOutOfOrder(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	var agendamentoID int
+	err = db.QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s.agendamentos (id_campo, horario, jogadores, status)
+		VALUES (1, '2026-05-04 20:00:00', 10, 'pedido')
+		RETURNING id_agendamento
+	`, schema)).Scan(&agendamentoID)
+	if err != nil {
+		t.Fatalf("failed to insert agendamento: %v", err)
+	}
+
+	repository := newAgendamentoRepository()
+	cursor, err := repository.feedCursorInicial(ctx)
+	if err != nil {
+		t.Fatalf("failed to open cursor: %v", err)
+	}
+
+	insertEvento := func(tx *sql.Tx) int64 {
+		t.Helper()
+		var id int64
+		err := tx.QueryRowContext(ctx, fmt.Sprintf(`
+			INSERT INTO %s.agendamento_status_eventos (id_agendamento, tipo, status_novo, ator_tipo, origem)
+			VALUES ($1, 'pagamento', 'pedido', 'usuario', 'test')
+			RETURNING id
+		`, schema), agendamentoID).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to insert evento: %v", err)
+		}
+		return id
+	}
+
+	primeira, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer primeira.Rollback()
+	segunda, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer segunda.Rollback()
+
+	primeiroID := insertEvento(primeira)
+	segundoID := insertEvento(segunda)
+	if err := segunda.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	linhas, err := repository.listStatusEventosForOwner(ctx, 1, cursor, agendamentoFeedLote)
+	if err != nil {
+		t.Fatalf("failed to read feed: %v", err)
+	}
+	if liberados, retidos := agendamentoFeedLiberados(linhas); len(liberados) != 0 || !retidos {
+		t.Fatalf("expected the later commit to wait for the open transaction, got %d released retidos=%v", len(liberados), retidos)
+	}
+
+	if err := primeira.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	linhas, err = repository.listStatusEventosForOwner(ctx, 1, cursor, agendamentoFeedLote)
+	if err != nil {
+		t.Fatalf("failed to read feed: %v", err)
+	}
+	liberados, retidos := agendamentoFeedLiberados(linhas)
+	if retidos || len(liberados) != 2 {
+		t.Fatalf("expected both events once every transaction ended, got %d retidos=%v", len(liberados), retidos)
+	}
+	if liberados[0].Evento.ID != primeiroID || liberados[1].Evento.ID != segundoID {
+		t.Fatalf("expected events %d and %d, got %d and %d", primeiroID, segundoID, liberados[0].Evento.ID, liberados[1].Evento.ID)
+	}
+
+	linhas, err = repository.listStatusEventosForOwner(ctx, 1, liberados[1].Cursor, agendamentoFeedLote)
+	if err != nil {
+		t.Fatalf("failed to read feed: %v", err)
+	}
+	if len(linhas) != 0 {
+		t.Fatalf("expected nothing after the last event, got %d", len(linhas))
+	}
+}
+
+// TestCancelarAgendamentoJogadorEmitsFeedEvent needs a disposable Postgres
+// database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestCancelarAgendamentoJogadorEmitsFeedEvent(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+	t.Setenv("JOGADOR_INTEGRATION_TOKEN", "token-teste")
+	t.Setenv("JOGADOR_STATUS_CALLBACK_URL", "")
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	var agendamentoID int
+	err = db.QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s.agendamentos (id_campo, horario, jogadores, status, origem_agendamento, valor_total, valor_restante, valor_bruto, nome_solicitante)
+		VALUES (1, $1, 10, 'agendado', 'jogador', 120, 120, 120, 'Jogador Teste')
+		RETURNING id_agendamento
+	`, schema), agendamentoNow().Add(72*time.Hour).Truncate(time.Hour)).Scan(&agendamentoID)
+	if err != nil {
+		t.Fatalf("failed to insert agendamento: %v", err)
+	}
+
+	request := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/integracao/agendamentos/%d/cancelar", agendamentoID), nil)
+	request.Header.Set("X-Integration-Token", "token-teste")
+	request = mux.SetURLVars(request, map[string]string{"id": fmt.Sprint(agendamentoID)})
+	recorder := httptest.NewRecorder()
+	CancelarAgendamentoJogador(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	feedCtx, cancel := context.WithTimeout(context.WithValue(ctx, middleware.UserIDKey, 1), 2*time.Second)
+	defer cancel()
+	feedRequest := httptest.NewRequest(http.MethodGet, "/agendamentos/feed", nil).WithContext(feedCtx)
+	feedRequest.Header.Set("Last-Event-ID", "0")
+	feedRecorder := httptest.NewRecorder()
+	StreamAgendamentosFeed(feedRecorder, feedRequest)
+
+	body := feedRecorder.Body.String()
+	if !strings.Contains(body, "event: "+agendamentoFeedCanceladoPeloJogador+"\n") {
+		t.Fatalf("expected a %s event in the feed, got %q", agendamentoFeedCanceladoPeloJogador, body)
+	}
+}
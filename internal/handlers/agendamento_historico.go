@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+const (
+	agendamentoOrigemEventoPainel      = "painel"
+	agendamentoOrigemEventoRecorrencia = "recorrencia"
+	agendamentoOrigemEventoSistema     = "sistema"
+	agendamentoOrigemEventoListaEspera = "lista_espera"
+)
+
+// agendamentoAtor is who caused a state change. It travels in the context so
+// the service methods shared by handlers, integrations and jobs do not need an
+// extra parameter.
+type agendamentoAtor struct {
+	Tipo      models.AgendamentoAtorTipo
+	IDUsuario *int
+	Origem    string
+	// Motivo explains a system change, e.g. an expired pedido; it is stored in
+	// the event details.
+	Motivo string
+}
+
+type agendamentoAtorContextKey struct{}
+
+func newAgendamentoAtorSistema(motivo string) agendamentoAtor {
+	return agendamentoAtor{Tipo: models.AgendamentoAtorSistema, Origem: agendamentoOrigemEventoSistema, Motivo: motivo}
+}
+
+func withAgendamentoAtor(ctx context.Context, ator agendamentoAtor) context.Context {
+	return context.WithValue(ctx, agendamentoAtorContextKey{}, ator)
+}
+
+func hasAgendamentoAtor(ctx context.Context) bool {
+	_, ok := ctx.Value(agendamentoAtorContextKey{}).(agendamentoAtor)
+	return ok
+}
+
+// agendamentoAtorFromContext falls back to the authenticated owner, and to the
+// system when nobody is logged in.
+func agendamentoAtorFromContext(ctx context.Context) agendamentoAtor {
+	if ator, ok := ctx.Value(agendamentoAtorContextKey{}).(agendamentoAtor); ok {
+		return ator
+	}
+	if userID, ok := ctx.Value(middleware.UserIDKey).(int); ok && userID > 0 {
+		return agendamentoAtor{Tipo: models.AgendamentoAtorUsuario, IDUsuario: &userID, Origem: agendamentoOrigemEventoPainel}
+	}
+
+	return agendamentoAtor{Tipo: models.AgendamentoAtorSistema, Origem: agendamentoOrigemEventoSistema}
+}
+
+type agendamentoStatusEventoInput struct {
+	Tipo           models.AgendamentoStatusEventoTipo
+	StatusAnterior *models.AgendamentoStatus
+	StatusNovo     models.AgendamentoStatus
+	Detalhes       any
+}
+
+type agendamentoStatusEventoResponse struct {
+	ID             int64                              `json:"id"`
+	Tipo           models.AgendamentoStatusEventoTipo `json:"tipo"`
+	StatusAnterior *models.AgendamentoStatus          `json:"status_anterior,omitempty"`
+	StatusNovo     models.AgendamentoStatus           `json:"status_novo"`
+	AtorTipo       models.AgendamentoAtorTipo         `json:"ator_tipo"`
+	IDAtor         *int                               `json:"id_ator,omitempty"`
+	Origem         string                             `json:"origem"`
+	Detalhes       json.RawMessage                    `json:"detalhes,omitempty"`
+	CriadoEm       string                             `json:"criado_em"`
+}
+
+func (repository agendamentoRepository) insertStatusEvento(ctx context.Context, agendamentoID int, ator agendamentoAtor, input agendamentoStatusEventoInput) error {
+	var detalhes any
+	if input.Detalhes != nil {
+		body, err := json.Marshal(input.Detalhes)
+		if err != nil {
+			return err
+		}
+		detalhes = string(body)
+	}
+
+	var statusAnterior any
+	if input.StatusAnterior != nil {
+		statusAnterior = string(*input.StatusAnterior)
+	}
+
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id_agendamento, tipo, status_anterior, status_novo, ator_tipo, id_ator, origem, detalhes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb)
+	`, agendamentoStatusEventosTableName()),
+		agendamentoID,
+		string(input.Tipo),
+		statusAnterior,
+		string(input.StatusNovo),
+		string(ator.Tipo),
+		nullableIntValue(ator.IDUsuario),
+		ator.Origem,
+		detalhes,
+	)
+	return err
+}
+
+func (repository agendamentoRepository) listStatusEventos(ctx context.Context, agendamentoID int) ([]models.AgendamentoStatusEvento, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, id_agendamento, tipo, status_anterior, status_novo, ator_tipo, id_ator, origem, detalhes, criado_em
+		FROM %s
+		WHERE id_agendamento = $1
+		ORDER BY criado_em ASC, id ASC
+	`, agendamentoStatusEventosTableName()), agendamentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	eventos := make([]models.AgendamentoStatusEvento, 0)
+	for rows.Next() {
+		evento, scanErr := scanAgendamentoStatusEvento(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		eventos = append(eventos, evento)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return eventos, nil
+}
+
+func scanAgendamentoStatusEvento(scanner agendamentoScanner) (models.AgendamentoStatusEvento, error) {
+	var (
+		evento         models.AgendamentoStatusEvento
+		tipo           string
+		statusAnterior sql.NullString
+		statusNovo     string
+		atorTipo       string
+		idAtor         sql.NullInt64
+		detalhes       []byte
+	)
+
+	err := scanner.Scan(
+		&evento.ID,
+		&evento.IDAgendamento,
+		&tipo,
+		&statusAnterior,
+		&statusNovo,
+		&atorTipo,
+		&idAtor,
+		&evento.Origem,
+		&detalhes,
+		&evento.CriadoEm,
+	)
+	if err != nil {
+		return models.AgendamentoStatusEvento{}, err
+	}
+
+	evento.Tipo = models.AgendamentoStatusEventoTipo(tipo)
+	evento.StatusNovo = models.AgendamentoStatus(statusNovo)
+	evento.AtorTipo = models.AgendamentoAtorTipo(atorTipo)
+	if statusAnterior.Valid {
+		value := models.AgendamentoStatus(statusAnterior.String)
+		evento.StatusAnterior = &value
+	}
+	if idAtor.Valid {
+		value := int(idAtor.Int64)
+		evento.IDAtor = &value
+	}
+	if len(detalhes) > 0 {
+		evento.Detalhes = json.RawMessage(detalhes)
+	}
+
+	return evento, nil
+}
+
+// recordStatusEvento appends to the history. Callers run it inside the same
+// transaction as the change it describes.
+func (service agendamentoService) recordStatusEvento(ctx context.Context, agendamentoID int, input agendamentoStatusEventoInput) error {
+	ator := agendamentoAtorFromContext(ctx)
+	if input.Detalhes == nil && ator.Motivo != "" {
+		input.Detalhes = map[string]string{"motivo": ator.Motivo}
+	}
+
+	if err := service.repository.insertStatusEvento(ctx, agendamentoID, ator, input); err != nil {
+		return err
+	}
+
+	return service.publicarFeed(ctx, agendamentoID)
+}
+
+func (service agendamentoService) GetHistorico(ctx context.Context, ownerUserID int, agendamentoID int) ([]models.AgendamentoStatusEvento, error) {
+	if _, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errAgendamentoNaoEncontrado
+		}
+		return nil, err
+	}
+
+	return service.repository.listStatusEventos(ctx, agendamentoID)
+}
+
+func GetHistoricoAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	eventos, err := service.GetHistorico(r.Context(), userID, agendamentoID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	response := make([]agendamentoStatusEventoResponse, 0, len(eventos))
+	for _, evento := range eventos {
+		response = append(response, newAgendamentoStatusEventoResponse(evento))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func newAgendamentoStatusEventoResponse(evento models.AgendamentoStatusEvento) agendamentoStatusEventoResponse {
+	return agendamentoStatusEventoResponse{
+		ID:             evento.ID,
+		Tipo:           evento.Tipo,
+		StatusAnterior: evento.StatusAnterior,
+		StatusNovo:     evento.StatusNovo,
+		AtorTipo:       evento.AtorTipo,
+		IDAtor:         evento.IDAtor,
+		Origem:         evento.Origem,
+		Detalhes:       evento.Detalhes,
+		CriadoEm:       formatAgendamentoDateTime(evento.CriadoEm),
+	}
+}
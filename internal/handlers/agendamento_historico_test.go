@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+type historicoScannerStub struct {
+	values []any
+}
+
+func (stub historicoScannerStub) Scan(dest ...any) error {
+	for index, target := range dest {
+		switch value := target.(type) {
+		case *int64:
+			*value = stub.values[index].(int64)
+		case *int:
+			*value = stub.values[index].(int)
+		case *string:
+			*value = stub.values[index].(string)
+		case *sql.NullString:
+			*value = stub.values[index].(sql.NullString)
+		case *sql.NullInt64:
+			*value = stub.values[index].(sql.NullInt64)
+		case *[]byte:
+			*value = stub.values[index].([]byte)
+		case *time.Time:
+			*value = stub.values[index].(time.Time)
+		}
+	}
+	return nil
+}
+
+func TestAgendamentoAtorFromContextFallsBackToOwnerThenSistema(t *testing.T) {
+	ator := agendamentoAtorFromContext(context.Background())
+	if ator.Tipo != models.AgendamentoAtorSistema || ator.IDUsuario != nil || ator.Origem != agendamentoOrigemEventoSistema {
+		t.Fatalf("expected sistema actor without context, got %+v", ator)
+	}
+
+	ctx := context.WithValue(context.Background(), middleware.UserIDKey, 7)
+	ator = agendamentoAtorFromContext(ctx)
+	if ator.Tipo != models.AgendamentoAtorUsuario || ator.IDUsuario == nil || *ator.IDUsuario != 7 {
+		t.Fatalf("expected owner actor 7, got %+v", ator)
+	}
+	if ator.Origem != agendamentoOrigemEventoPainel {
+		t.Fatalf("expected painel origin, got %q", ator.Origem)
+	}
+}
+
+func TestAgendamentoAtorFromContextPrefersExplicitActor(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.UserIDKey, 7)
+	ctx = withRecorrenciaAtor(ctx, 7)
+
+	ator := agendamentoAtorFromContext(ctx)
+	if ator.Origem != agendamentoOrigemEventoRecorrencia {
+		t.Fatalf("expected recorrencia origin, got %q", ator.Origem)
+	}
+	if !hasAgendamentoAtor(ctx) {
+		t.Fatalf("expected explicit actor to be detected")
+	}
+	if hasAgendamentoAtor(context.Background()) {
+		t.Fatalf("expected no explicit actor on a bare context")
+	}
+}
+
+func TestScanAgendamentoStatusEventoHandlesCreationEvent(t *testing.T) {
+	criadoEm := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	evento, err := scanAgendamentoStatusEvento(historicoScannerStub{values: []any{
+		int64(1), 10, "criacao", sql.NullString{}, "pedido", "integracao", sql.NullInt64{}, "jogador", []byte(nil), criadoEm,
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if evento.StatusAnterior != nil || evento.IDAtor != nil || evento.Detalhes != nil {
+		t.Fatalf("expected empty optional fields, got %+v", evento)
+	}
+	if evento.Tipo != models.AgendamentoStatusEventoCriacao || evento.StatusNovo != models.AgendamentoStatusPedido {
+		t.Fatalf("expected criacao -> pedido, got %s -> %s", evento.Tipo, evento.StatusNovo)
+	}
+}
+
+func TestScanAgendamentoStatusEventoReadsActorAndDetails(t *testing.T) {
+	evento, err := scanAgendamentoStatusEvento(historicoScannerStub{values: []any{
+		int64(2), 10, "pagamento",
+		sql.NullString{String: "aguardando_pagamento", Valid: true}, "agendado",
+		"usuario", sql.NullInt64{Int64: 3, Valid: true}, "painel",
+		[]byte(`{"valor_pago":50}`), time.Now(),
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if evento.StatusAnterior == nil || *evento.StatusAnterior != models.AgendamentoStatusAguardandoPagamento {
+		t.Fatalf("expected previous status aguardando_pagamento, got %v", evento.StatusAnterior)
+	}
+	if evento.IDAtor == nil || *evento.IDAtor != 3 {
+		t.Fatalf("expected actor 3, got %v", evento.IDAtor)
+	}
+	if string(evento.Detalhes) != `{"valor_pago":50}` {
+		t.Fatalf("expected details to be kept, got %s", evento.Detalhes)
+	}
+}
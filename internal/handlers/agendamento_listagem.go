@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+const (
+	agendamentoListPorPaginaPadrao = 20
+	agendamentoListPorPaginaMax    = 100
+)
+
+// agendamentoListOrdens maps the accepted ?ordenar= values to ORDER BY
+// clauses. The default keeps the historical "pedidos first" listing.
+var agendamentoListOrdens = map[string]string{
+	"status": `
+			CASE a.status
+				WHEN 'pedido' THEN 0
+				WHEN 'agendado' THEN 1
+				WHEN 'em_andamento' THEN 2
+				WHEN 'aguardando_pagamento' THEN 3
+				WHEN 'concluido' THEN 4
+				WHEN 'nao_compareceu' THEN 5
+				WHEN 'cancelado' THEN 6
+				ELSE 7
+			END,
+			a.horario DESC`,
+	"horario":    "a.horario ASC",
+	"-horario":   "a.horario DESC",
+	"criado_em":  "COALESCE(a.criado_em, a.horario) ASC",
+	"-criado_em": "COALESCE(a.criado_em, a.horario) DESC",
+}
+
+type agendamentoListFiltro struct {
+	Status     *models.AgendamentoStatus
+	CampoID    *int
+	ArenaID    *int
+	DataInicio *time.Time
+	DataFim    *time.Time
+	Origem     *models.AgendamentoOrigem
+	Pago       *bool
+	Ordem      string
+	Pagina     int
+	PorPagina  int
+}
+
+func (filtro agendamentoListFiltro) paginado() bool {
+	return filtro.PorPagina > 0
+}
+
+type agendamentoListPage struct {
+	Agendamentos []models.Agendamento
+	Total        int
+}
+
+type agendamentoListEnvelope struct {
+	Itens         []agendamentoResponse `json:"itens"`
+	Total         int                   `json:"total"`
+	Pagina        int                   `json:"pagina"`
+	PorPagina     int                   `json:"por_pagina"`
+	TotalPaginas  int                   `json:"total_paginas"`
+	ProximaPagina *int                  `json:"proxima_pagina"`
+}
+
+// buildAgendamentoListWhere turns the filter into SQL conditions appended to
+// the owner check; args already holds the owner id as $1.
+func buildAgendamentoListWhere(filtro agendamentoListFiltro, args []any) ([]string, []any) {
+	where := []string{"ar.id_usuario = $1"}
+	add := func(condition string, value any) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filtro.Status != nil {
+		add("a.status = $%d", string(*filtro.Status))
+	}
+	if filtro.CampoID != nil {
+		add("a.id_campo = $%d", *filtro.CampoID)
+	}
+	if filtro.ArenaID != nil {
+		add("c.id_arena = $%d", *filtro.ArenaID)
+	}
+	if filtro.DataInicio != nil {
+		add("a.horario >= $%d", *filtro.DataInicio)
+	}
+	if filtro.DataFim != nil {
+		add("a.horario < $%d", *filtro.DataFim)
+	}
+	if filtro.Origem != nil {
+		add("COALESCE(a.origem_agendamento, 'manual') = $%d", string(*filtro.Origem))
+	}
+	if filtro.Pago != nil {
+		add("COALESCE(a.status_de_pagamento, FALSE) = $%d", *filtro.Pago)
+	}
+
+	return where, args
+}
+
+func agendamentoListOrderBy(ordem string) string {
+	if orderBy, ok := agendamentoListOrdens[ordem]; ok {
+		return orderBy + ", a.id_agendamento DESC"
+	}
+
+	return agendamentoListOrdens["status"] + ", a.id_agendamento DESC"
+}
+
+// parseAgendamentoListFiltro reads the listing query string. Pagination is
+// only enabled when pagina or por_pagina is sent, so old clients keep getting
+// the full list.
+func parseAgendamentoListFiltro(r *http.Request) (agendamentoListFiltro, error) {
+	query := r.URL.Query()
+	location := agendamentoLocation()
+	filtro := agendamentoListFiltro{}
+
+	if raw := strings.TrimSpace(query.Get("status")); raw != "" {
+		status, ok := models.NormalizeAgendamentoStatus(raw)
+		if !ok {
+			return agendamentoListFiltro{}, errors.New("status invalido")
+		}
+		filtro.Status = &status
+	}
+
+	if raw := firstNonEmptyCampoValue(query.Get("campo_id"), query.Get("id_campo")); raw != "" {
+		campoID := parsePositiveIntParam(raw)
+		if campoID == 0 {
+			return agendamentoListFiltro{}, errors.New("campo_id invalido")
+		}
+		filtro.CampoID = &campoID
+	}
+
+	if raw := strings.TrimSpace(query.Get("id_arena")); raw != "" {
+		arenaID := parsePositiveIntParam(raw)
+		if arenaID == 0 {
+			return agendamentoListFiltro{}, errors.New("id_arena invalido")
+		}
+		filtro.ArenaID = &arenaID
+	}
+
+	// Dates are operational days: the madrugada before agendamentoHoraVirada
+	// still belongs to the previous day.
+	if raw := strings.TrimSpace(query.Get("data_inicio")); raw != "" {
+		data, err := time.ParseInLocation("2006-01-02", raw, location)
+		if err != nil {
+			return agendamentoListFiltro{}, errors.New("data_inicio invalida. Use o formato YYYY-MM-DD")
+		}
+		inicio := data.Add(agendamentoHoraVirada * time.Hour)
+		filtro.DataInicio = &inicio
+	}
+	if raw := strings.TrimSpace(query.Get("data_fim")); raw != "" {
+		data, err := time.ParseInLocation("2006-01-02", raw, location)
+		if err != nil {
+			return agendamentoListFiltro{}, errors.New("data_fim invalida. Use o formato YYYY-MM-DD")
+		}
+		fim := data.AddDate(0, 0, 1).Add(agendamentoHoraVirada * time.Hour)
+		filtro.DataFim = &fim
+	}
+	if filtro.DataInicio != nil && filtro.DataFim != nil && !filtro.DataFim.After(*filtro.DataInicio) {
+		return agendamentoListFiltro{}, errors.New("data_fim deve ser igual ou posterior a data_inicio")
+	}
+
+	if raw := firstNonEmptyCampoValue(query.Get("origem_agendamento"), query.Get("origem")); raw != "" {
+		origem, ok := models.NormalizeAgendamentoOrigem(raw)
+		if !ok {
+			return agendamentoListFiltro{}, errors.New("origem_agendamento invalida")
+		}
+		filtro.Origem = &origem
+	}
+
+	if raw := strings.TrimSpace(query.Get("pago")); raw != "" {
+		pago, err := strconv.ParseBool(raw)
+		if err != nil {
+			return agendamentoListFiltro{}, errors.New("pago invalido")
+		}
+		filtro.Pago = &pago
+	}
+
+	if raw := strings.TrimSpace(query.Get("ordenar")); raw != "" {
+		if _, ok := agendamentoListOrdens[raw]; !ok {
+			return agendamentoListFiltro{}, errors.New("ordenar invalido. Use status, horario, -horario, criado_em ou -criado_em")
+		}
+		filtro.Ordem = raw
+	}
+
+	rawPagina := strings.TrimSpace(query.Get("pagina"))
+	rawPorPagina := strings.TrimSpace(query.Get("por_pagina"))
+	if rawPagina == "" && rawPorPagina == "" {
+		return filtro, nil
+	}
+
+	filtro.Pagina = 1
+	filtro.PorPagina = agendamentoListPorPaginaPadrao
+	if rawPagina != "" {
+		filtro.Pagina = parsePositiveIntParam(rawPagina)
+		if filtro.Pagina == 0 {
+			return agendamentoListFiltro{}, errors.New("pagina invalida")
+		}
+	}
+	if rawPorPagina != "" {
+		filtro.PorPagina = parsePositiveIntParam(rawPorPagina)
+		if filtro.PorPagina == 0 || filtro.PorPagina > agendamentoListPorPaginaMax {
+			return agendamentoListFiltro{}, fmt.Errorf("por_pagina deve estar entre 1 e %d", agendamentoListPorPaginaMax)
+		}
+	}
+
+	return filtro, nil
+}
+
+// writeAgendamentoList always reports the total in X-Total-Count; the body is
+// wrapped in an envelope only for paginated requests.
+func writeAgendamentoList(w http.ResponseWriter, filtro agendamentoListFiltro, page agendamentoListPage) {
+	itens := make([]agendamentoResponse, 0, len(page.Agendamentos))
+	for _, agendamento := range page.Agendamentos {
+		itens = append(itens, newAgendamentoResponse(agendamento))
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if !filtro.paginado() {
+		writeJSON(w, http.StatusOK, itens)
+		return
+	}
+
+	envelope := agendamentoListEnvelope{
+		Itens:        itens,
+		Total:        page.Total,
+		Pagina:       filtro.Pagina,
+		PorPagina:    filtro.PorPagina,
+		TotalPaginas: (page.Total + filtro.PorPagina - 1) / filtro.PorPagina,
+	}
+	if filtro.Pagina < envelope.TotalPaginas {
+		proxima := filtro.Pagina + 1
+		envelope.ProximaPagina = &proxima
+	}
+
+	writeJSON(w, http.StatusOK, envelope)
+}
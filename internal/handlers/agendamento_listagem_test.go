@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestParseAgendamentoListFiltro(t *testing.T) {
+	request := httptest.NewRequest("GET", "/agendamentos?status=agendado&campo_id=3&data_inicio=2026-10-01&data_fim=2026-10-31&pago=true&ordenar=-horario&pagina=2", nil)
+
+	filtro, err := parseAgendamentoListFiltro(request)
+	if err != nil {
+		t.Fatalf("expected valid filter, got %v", err)
+	}
+	if filtro.Status == nil || *filtro.Status != models.AgendamentoStatusAgendado {
+		t.Fatalf("expected status agendado, got %v", filtro.Status)
+	}
+	if filtro.CampoID == nil || *filtro.CampoID != 3 {
+		t.Fatalf("expected campo 3, got %v", filtro.CampoID)
+	}
+	if filtro.DataInicio.Hour() != agendamentoHoraVirada || filtro.DataFim.Day() != 1 {
+		t.Fatalf("expected operational day bounds, got %v - %v", filtro.DataInicio, filtro.DataFim)
+	}
+	if filtro.Pago == nil || !*filtro.Pago {
+		t.Fatalf("expected pago filter, got %v", filtro.Pago)
+	}
+	if filtro.Pagina != 2 || filtro.PorPagina != agendamentoListPorPaginaPadrao || !filtro.paginado() {
+		t.Fatalf("expected page 2 with default size, got %d/%d", filtro.Pagina, filtro.PorPagina)
+	}
+}
+
+func TestParseAgendamentoListFiltroWithoutPaginationKeepsFullList(t *testing.T) {
+	filtro, err := parseAgendamentoListFiltro(httptest.NewRequest("GET", "/agendamentos", nil))
+	if err != nil {
+		t.Fatalf("expected empty filter to be valid, got %v", err)
+	}
+	if filtro.paginado() {
+		t.Fatal("expected listing without pagina/por_pagina to stay unpaginated")
+	}
+}
+
+func TestParseAgendamentoListFiltroRejectsInvalidValues(t *testing.T) {
+	for _, rawQuery := range []string{
+		"status=desconhecido",
+		"ordenar=nome",
+		"por_pagina=500",
+		"pago=talvez",
+		"data_inicio=2026-10-10&data_fim=2026-10-01",
+	} {
+		if _, err := parseAgendamentoListFiltro(httptest.NewRequest("GET", "/agendamentos?"+rawQuery, nil)); err == nil {
+			t.Fatalf("expected error for %q", rawQuery)
+		}
+	}
+}
+
+func TestBuildAgendamentoListWhereNumbersPlaceholders(t *testing.T) {
+	arenaID := 7
+	pago := false
+	where, args := buildAgendamentoListWhere(agendamentoListFiltro{ArenaID: &arenaID, Pago: &pago}, []any{42})
+
+	joined := strings.Join(where, " AND ")
+	if !strings.Contains(joined, "c.id_arena = $2") || !strings.Contains(joined, "status_de_pagamento, FALSE) = $3") {
+		t.Fatalf("unexpected where clause: %s", joined)
+	}
+	if len(args) != 3 || args[0] != 42 || args[1] != 7 || args[2] != false {
+		t.Fatalf("unexpected args: %v", args)
+	}
+}
+
+func TestWriteAgendamentoListSetsTotalHeaderAndEnvelope(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writeAgendamentoList(recorder, agendamentoListFiltro{Pagina: 1, PorPagina: 1}, agendamentoListPage{
+		Agendamentos: []models.Agendamento{{ID: 1}},
+		Total:        3,
+	})
+
+	if got := recorder.Header().Get("X-Total-Count"); got != "3" {
+		t.Fatalf("expected X-Total-Count 3, got %q", got)
+	}
+	body := recorder.Body.String()
+	if !strings.Contains(body, `"total_paginas":3`) || !strings.Contains(body, `"proxima_pagina":2`) {
+		t.Fatalf("unexpected envelope: %s", body)
+	}
+}
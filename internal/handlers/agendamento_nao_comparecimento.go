@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+const (
+	naoComparecimentoIntervaloPadrao = time.Minute
+	naoComparecimentoLote            = 50
+	naoComparecimentoMotivo          = "nao_comparecimento"
+	conclusaoAutomaticaMotivo        = "conclusao_automatica"
+)
+
+// listAgendamentosVencidos returns agendado bookings whose start plus the
+// arena tolerance has passed and that either never started the cronometro or
+// already closed it. Arenas without a tolerance are skipped.
+func (repository agendamentoRepository) listAgendamentosVencidos(ctx context.Context, agora time.Time, limite int) ([]models.Agendamento, error) {
+	rows, err := repository.database().QueryContext(ctx, agendamentoBaseSelectQuery()+fmt.Sprintf(`
+		JOIN %s p ON p.id_arena = ar.id
+		WHERE a.status = $1
+		  AND p.tolerancia_nao_comparecimento_minutos IS NOT NULL
+		  AND a.horario + p.tolerancia_nao_comparecimento_minutos * INTERVAL '1 minute' <= $2
+		  AND (a.inicio_cronometro IS NULL OR a.fim_cronometro IS NOT NULL)
+		ORDER BY a.horario ASC, a.id_agendamento ASC
+		LIMIT $3
+	`, arenaPoliticasTableName()), string(models.AgendamentoStatusAgendado), agora, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agendamentos := make([]models.Agendamento, 0)
+	for rows.Next() {
+		agendamento, scanErr := scanAgendamento(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		agendamentos = append(agendamentos, agendamento)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return agendamentos, nil
+}
+
+// desfechoAgendamentoVencido decides what the job does with a listed
+// agendamento: a fully paid one is concluded once its slot is over, an unpaid
+// one that never started becomes a no-show. Anything else waits.
+func desfechoAgendamentoVencido(agendamento models.Agendamento, tolerancia time.Duration, agora time.Time) (models.AgendamentoAcao, bool) {
+	if agendamento.ValorRestante <= 0 {
+		if agendamento.HorarioFim().Add(tolerancia).After(agora) {
+			return "", false
+		}
+		return models.AgendamentoAcaoConcluir, true
+	}
+
+	if agendamento.InicioCronometro != nil || agendamento.Horario.Add(tolerancia).After(agora) {
+		return "", false
+	}
+
+	return models.AgendamentoAcaoMarcarNaoCompareceu, true
+}
+
+func (service agendamentoService) MarcarNaoComparecimento(ctx context.Context, ownerUserID int, agendamentoID int) (agendamentoMutationResult, error) {
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return agendamentoMutationResult{}, errAgendamentoNaoEncontrado
+		}
+		return agendamentoMutationResult{}, err
+	}
+
+	if err := models.ValidarTransicaoAgendamento(agendamento.Status, models.AgendamentoAcaoMarcarNaoCompareceu, models.AgendamentoStatusNaoCompareceu); err != nil {
+		return agendamentoMutationResult{}, err
+	}
+	if agendamento.InicioCronometro != nil {
+		return agendamentoMutationResult{}, errAgendamentoCronometroIniciado
+	}
+	if agendamento.Horario.After(agendamentoNow()) {
+		return agendamentoMutationResult{}, errAgendamentoAindaNaoComecou
+	}
+
+	politica, err := loadArenaPolitica(ctx, service.repository.database(), agendamento.IDArena)
+	if err != nil {
+		return agendamentoMutationResult{}, err
+	}
+
+	return service.registrarNaoComparecimento(ctx, agendamento, politica.NaoComparecimentoMantemCobranca)
+}
+
+// registrarNaoComparecimento moves the agendamento to nao_compareceu. When
+// the arena does not keep the charge, the open balance is waived: valor_total
+// keeps the price and valor_retido records what was already paid, as a
+// cancellation does.
+func (service agendamentoService) registrarNaoComparecimento(ctx context.Context, agendamento models.Agendamento, mantemCobranca bool) (agendamentoMutationResult, error) {
+	var result agendamentoMutationResult
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		atualizado, totalPago, err := service.refreshFinancialState(ctx, agendamento)
+		if err != nil {
+			return err
+		}
+
+		if !mantemCobranca && atualizado.ValorRestante > 0 {
+			if err := service.repository.updateFinancialState(ctx, atualizado.ID, agendamentoFinancialUpdate{
+				ValorRetido:       &totalPago,
+				ValorRestante:     0,
+				Pago:              true,
+				StatusDePagamento: true,
+			}); err != nil {
+				return err
+			}
+			atualizado.ValorRetido = &totalPago
+			atualizado.ValorRestante = 0
+			atualizado.Pago = true
+			atualizado.StatusDePagamento = true
+		}
+
+		result, err = service.transitionStatus(ctx, atualizado, models.AgendamentoAcaoMarcarNaoCompareceu, models.AgendamentoStatusNaoCompareceu)
+		return err
+	})
+	if err != nil {
+		return agendamentoMutationResult{}, err
+	}
+
+	return result, nil
+}
+
+// ProcessarNaoComparecimentos closes the agendamentos left agendado after
+// their slot, as the system. It returns how many were changed.
+func (service agendamentoService) ProcessarNaoComparecimentos(ctx context.Context, agora time.Time) (int, error) {
+	agendamentos, err := service.repository.listAgendamentosVencidos(ctx, agora, naoComparecimentoLote)
+	if err != nil {
+		return 0, err
+	}
+
+	politicas := make(map[int]models.ArenaPolitica)
+	processados := 0
+	for _, agendamento := range agendamentos {
+		politica, ok := politicas[agendamento.IDArena]
+		if !ok {
+			politica, err = loadArenaPolitica(ctx, service.repository.database(), agendamento.IDArena)
+			if err != nil {
+				log.Printf("Erro ao buscar politicas da arena %d: %v", agendamento.IDArena, err)
+				continue
+			}
+			politicas[agendamento.IDArena] = politica
+		}
+		if politica.ToleranciaNaoComparecimentoMinutos == nil {
+			continue
+		}
+		tolerancia := time.Duration(*politica.ToleranciaNaoComparecimentoMinutos) * time.Minute
+
+		alterado := false
+		err := service.inTransaction(ctx, func(service agendamentoService) error {
+			status, err := service.repository.lockAgendamentoStatus(ctx, agendamento.ID)
+			if err != nil || status != models.AgendamentoStatusAgendado {
+				return err
+			}
+
+			atualizado, _, err := service.refreshFinancialState(ctx, agendamento)
+			if err != nil {
+				return err
+			}
+
+			acao, ok := desfechoAgendamentoVencido(atualizado, tolerancia, agora)
+			if !ok {
+				return nil
+			}
+
+			if acao == models.AgendamentoAcaoConcluir {
+				jobCtx := withAgendamentoAtor(ctx, newAgendamentoAtorSistema(conclusaoAutomaticaMotivo))
+				_, err = service.transitionStatus(jobCtx, atualizado, acao, models.AgendamentoStatusConcluido)
+			} else {
+				jobCtx := withAgendamentoAtor(ctx, newAgendamentoAtorSistema(naoComparecimentoMotivo))
+				_, err = service.registrarNaoComparecimento(jobCtx, atualizado, politica.NaoComparecimentoMantemCobranca)
+			}
+			alterado = err == nil
+			return err
+		})
+		if err != nil {
+			log.Printf("Erro ao encerrar agendamento vencido %d: %v", agendamento.ID, err)
+			continue
+		}
+		if alterado {
+			processados++
+		}
+	}
+
+	return processados, nil
+}
+
+// StartNaoComparecimentoWorker closes past agendamentos until ctx is done. It
+// is meant to run in its own goroutine.
+func StartNaoComparecimentoWorker(ctx context.Context) {
+	service := newAgendamentoService()
+	intervalo := time.Duration(envPositiveInt("NAO_COMPARECIMENTO_INTERVALO_SEGUNDOS", int(naoComparecimentoIntervaloPadrao/time.Second))) * time.Second
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		processados, err := service.ProcessarNaoComparecimentos(ctx, agendamentoNow())
+		if err != nil {
+			log.Printf("Erro ao buscar agendamentos vencidos: %v", err)
+		} else if processados > 0 {
+			log.Printf("%d agendamento(s) vencido(s) encerrado(s) automaticamente", processados)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
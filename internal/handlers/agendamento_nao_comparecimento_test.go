@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestDesfechoAgendamentoVencido(t *testing.T) {
+	horario := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	tolerancia := 15 * time.Minute
+	inicio := horario.Unix()
+
+	testCases := []struct {
+		name        string
+		agendamento models.Agendamento
+		agora       time.Time
+		acao        models.AgendamentoAcao
+		ok          bool
+	}{
+		{
+			name:        "unpaid within tolerance waits",
+			agendamento: models.Agendamento{Horario: horario, DuracaoMinutos: 60, ValorRestante: 100},
+			agora:       horario.Add(10 * time.Minute),
+		},
+		{
+			name:        "unpaid after tolerance is a no-show",
+			agendamento: models.Agendamento{Horario: horario, DuracaoMinutos: 60, ValorRestante: 100},
+			agora:       horario.Add(tolerancia),
+			acao:        models.AgendamentoAcaoMarcarNaoCompareceu,
+			ok:          true,
+		},
+		{
+			name:        "started game is never a no-show",
+			agendamento: models.Agendamento{Horario: horario, DuracaoMinutos: 60, ValorRestante: 100, InicioCronometro: &inicio},
+			agora:       horario.Add(3 * time.Hour),
+		},
+		{
+			name:        "paid game waits for the end of the slot",
+			agendamento: models.Agendamento{Horario: horario, DuracaoMinutos: 60},
+			agora:       horario.Add(time.Hour),
+		},
+		{
+			name:        "paid game is concluded after the slot",
+			agendamento: models.Agendamento{Horario: horario, DuracaoMinutos: 60},
+			agora:       horario.Add(time.Hour + tolerancia),
+			acao:        models.AgendamentoAcaoConcluir,
+			ok:          true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			acao, ok := desfechoAgendamentoVencido(testCase.agendamento, tolerancia, testCase.agora)
+			if ok != testCase.ok || acao != testCase.acao {
+				t.Fatalf("expected %q/%v, got %q/%v", testCase.acao, testCase.ok, acao, ok)
+			}
+		})
+	}
+}
+
+// TestNaoComparecimentoSemCobrancaKeepsValorTotal needs a disposable Postgres
+// database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestNaoComparecimentoSemCobrancaKeepsValorTotal(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	statements := []string{
+		fmt.Sprintf(`INSERT INTO %s.arena_politicas (id_arena, tolerancia_nao_comparecimento_minutos, nao_comparecimento_mantem_cobranca) VALUES (1, 15, FALSE)`, schema),
+		fmt.Sprintf(`INSERT INTO %s.agendamentos (id_campo, horario, jogadores, status, valor_bruto, valor_desconto, valor_total, valor_restante, pago, status_de_pagamento)
+			VALUES (1, NOW() - INTERVAL '2 hours', 10, 'agendado', 120, 20, 100, 60, FALSE, FALSE)`, schema),
+		fmt.Sprintf(`INSERT INTO %s.pagamentos_por_agendamento (id_agendamento, valor_pago, forma_pagamento, data_pagamento) VALUES (1, 40, 'pix', NOW())`, schema),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("failed to prepare agendamento: %v", err)
+		}
+	}
+
+	service := newAgendamentoService()
+	processados, err := service.ProcessarNaoComparecimentos(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("expected the no-show job to succeed, got %v", err)
+	}
+	if processados != 1 {
+		t.Fatalf("expected one agendamento closed, got %d", processados)
+	}
+
+	agendamento, err := newAgendamentoRepository().getByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to reload agendamento: %v", err)
+	}
+	if agendamento.Status != models.AgendamentoStatusNaoCompareceu {
+		t.Fatalf("expected nao_compareceu, got %s", agendamento.Status)
+	}
+	if agendamento.ValorTotal != 100 {
+		t.Fatalf("expected valor_total to stay 100, got %.2f", agendamento.ValorTotal)
+	}
+	if agendamento.ValorRetido == nil || *agendamento.ValorRetido != 40 {
+		t.Fatalf("expected valor_retido 40, got %v", agendamento.ValorRetido)
+	}
+
+	acoes, err := service.GetAcoes(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("failed to load acoes: %v", err)
+	}
+	if acoes.ValorRestante != 0 || !acoes.Pago {
+		t.Fatalf("expected the waived balance to stay closed, got restante %.2f pago %v", acoes.ValorRestante, acoes.Pago)
+	}
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -30,13 +29,19 @@ type jogadorStatusCallbackPayload struct {
 	NomeArena         string  `json:"nome_arena,omitempty"`
 	ValorTotal        float64 `json:"valor_total"`
 	ValorRestante     float64 `json:"valor_restante"`
+	// IDListaEspera is set when the pedido was created from a waitlist entry.
+	IDListaEspera *int `json:"id_lista_espera,omitempty"`
+	// Remarcacao carries the slot proposed by the owner while it is pending
+	// and its outcome once answered.
+	Remarcacao *agendamentoRemarcacaoResponse `json:"remarcacao,omitempty"`
 }
 
+// jogadorNotificationResult tells the caller whether the status change was
+// queued for the jogador backend; delivery happens later in the outbox worker.
 type jogadorNotificationResult struct {
-	Attempted   bool                         `json:"attempted"`
-	Delivered   bool                         `json:"delivered"`
+	Enfileirada bool                         `json:"enfileirada"`
+	IDEntrega   int64                        `json:"id_entrega,omitempty"`
 	CallbackURL string                       `json:"callback_url,omitempty"`
-	Error       string                       `json:"error,omitempty"`
 	Payload     jogadorStatusCallbackPayload `json:"payload"`
 }
 
@@ -48,7 +53,11 @@ func newJogadorStatusNotifier() jogadorStatusNotifier {
 	}
 }
 
-func (notifier jogadorStatusNotifier) NotifyStatusChange(ctx context.Context, agendamento models.Agendamento) jogadorNotificationResult {
+func (notifier jogadorStatusNotifier) enabled() bool {
+	return notifier.callbackURL != ""
+}
+
+func newJogadorStatusCallbackPayload(agendamento models.Agendamento) jogadorStatusCallbackPayload {
 	payload := jogadorStatusCallbackPayload{
 		IDAgendamento:     agendamento.ID,
 		IDCampo:           agendamento.IDCampo,
@@ -61,51 +70,63 @@ func (notifier jogadorStatusNotifier) NotifyStatusChange(ctx context.Context, ag
 		ValorTotal:        agendamento.ValorTotal,
 		ValorRestante:     agendamento.ValorRestante,
 	}
-
-	result := jogadorNotificationResult{
-		CallbackURL: notifier.callbackURL,
-		Payload:     payload,
+	if agendamento.Remarcacao != nil {
+		remarcacao := newAgendamentoRemarcacaoResponse(*agendamento.Remarcacao)
+		payload.Remarcacao = &remarcacao
 	}
 
-	if notifier.callbackURL == "" {
-		return result
-	}
+	return payload
+}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		result.Attempted = true
-		result.Error = fmt.Sprintf("erro ao serializar payload de notificacao: %v", err)
-		return result
+// deliver makes a single POST of an already serialized payload. Any non-2xx
+// answer is reported as an error so the outbox can retry it.
+func (notifier jogadorStatusNotifier) deliver(ctx context.Context, destino string, body []byte) error {
+	headers := http.Header{}
+	if notifier.callbackToken != "" {
+		headers.Set("Authorization", "Bearer "+notifier.callbackToken)
 	}
 
+	return notifier.post(ctx, destino, body, headers)
+}
+
+// deliverWebhook signs the body with the subscription secret instead of the
+// shared bearer token; see signWebhookPayload for the scheme.
+func (notifier jogadorStatusNotifier) deliverWebhook(ctx context.Context, entrega models.NotificacaoEntrega, segredo string) error {
+	timestamp := time.Now().Unix()
+	headers := http.Header{}
+	headers.Set(webhookHeaderTimestamp, fmt.Sprintf("%d", timestamp))
+	headers.Set(webhookHeaderAssinatura, "sha256="+signWebhookPayload(segredo, timestamp, entrega.Payload))
+	headers.Set(webhookHeaderEvento, entrega.Evento)
+	headers.Set(webhookHeaderEntrega, fmt.Sprintf("%d", entrega.ID))
+
+	return notifier.post(ctx, entrega.Destino, entrega.Payload, headers)
+}
+
+func (notifier jogadorStatusNotifier) post(ctx context.Context, destino string, body []byte, headers http.Header) error {
 	callbackCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(callbackCtx, http.MethodPost, notifier.callbackURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(callbackCtx, http.MethodPost, destino, bytes.NewReader(body))
 	if err != nil {
-		result.Attempted = true
-		result.Error = fmt.Sprintf("erro ao criar requisicao de notificacao: %v", err)
-		return result
+		return fmt.Errorf("erro ao criar requisicao de notificacao: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if notifier.callbackToken != "" {
-		req.Header.Set("Authorization", "Bearer "+notifier.callbackToken)
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
 	}
 
 	resp, err := notifier.httpClient.Do(req)
 	if err != nil {
-		result.Attempted = true
-		result.Error = fmt.Sprintf("erro ao enviar notificacao: %v", err)
-		return result
+		return fmt.Errorf("erro ao enviar notificacao: %w", err)
 	}
 	defer resp.Body.Close()
 
-	result.Attempted = true
-	result.Delivered = resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
-	if !result.Delivered {
-		result.Error = fmt.Sprintf("callback respondeu com status %d", resp.StatusCode)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("callback respondeu com status %d", resp.StatusCode)
 	}
 
-	return result
+	return nil
 }
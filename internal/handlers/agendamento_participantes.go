@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+var (
+	errParticipanteInvalido       = errors.New("participante invalido")
+	errParticipanteNaoEncontrado  = errors.New("participante nao encontrado")
+	errParticipanteComPagamentos  = errors.New("participante possui pagamentos")
+	errParticipantesListaInvalida = errors.New("lista de participantes vazia")
+)
+
+type agendamentoParticipanteRequest struct {
+	Nome      string `json:"nome"`
+	IDJogador *int   `json:"id_jogador"`
+}
+
+type agendamentoParticipantesRequest struct {
+	Participantes []agendamentoParticipanteRequest `json:"participantes"`
+}
+
+type agendamentoParticipanteResponse struct {
+	ID            int     `json:"id"`
+	IDAgendamento int     `json:"id_agendamento"`
+	Nome          string  `json:"nome"`
+	IDJogador     *int    `json:"id_jogador,omitempty"`
+	Token         string  `json:"token"`
+	LinkPagamento string  `json:"link_pagamento,omitempty"`
+	Cota          float64 `json:"cota"`
+	TotalPago     float64 `json:"total_pago"`
+	Restante      float64 `json:"restante"`
+	CriadoEm      string  `json:"criado_em"`
+}
+
+// participantePagamentoLinkResponse is what a player sees when opening their
+// payment link; it leaves out the rest of the roster.
+type participantePagamentoLinkResponse struct {
+	Participante  agendamentoParticipanteResponse `json:"participante"`
+	IDAgendamento int                             `json:"id_agendamento"`
+	NomeCampo     string                          `json:"nome_campo,omitempty"`
+	NomeArena     string                          `json:"nome_arena,omitempty"`
+	Horario       string                          `json:"horario"`
+	HorarioFim    string                          `json:"horario_fim"`
+	Status        string                          `json:"status"`
+}
+
+const agendamentoParticipanteColumns = `id, id_agendamento, nome, id_jogador, token, criado_em`
+
+func scanAgendamentoParticipante(scanner agendamentoScanner) (models.AgendamentoParticipante, error) {
+	var (
+		participante models.AgendamentoParticipante
+		idJogador    sql.NullInt64
+	)
+
+	err := scanner.Scan(
+		&participante.ID,
+		&participante.IDAgendamento,
+		&participante.Nome,
+		&idJogador,
+		&participante.Token,
+		&participante.CriadoEm,
+	)
+	if err != nil {
+		return models.AgendamentoParticipante{}, err
+	}
+
+	participante.IDJogador = nullIntPointer(idJogador)
+	return participante, nil
+}
+
+func (repository agendamentoRepository) listParticipantes(ctx context.Context, agendamentoID int) ([]models.AgendamentoParticipante, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s WHERE id_agendamento = $1 ORDER BY id ASC
+	`, agendamentoParticipanteColumns, agendamentoParticipantesTableName()), agendamentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participantes := make([]models.AgendamentoParticipante, 0)
+	for rows.Next() {
+		participante, scanErr := scanAgendamentoParticipante(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		participantes = append(participantes, participante)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return participantes, nil
+}
+
+func (repository agendamentoRepository) insertParticipante(ctx context.Context, agendamentoID int, input models.AdicionarParticipanteInput, token string) (models.AgendamentoParticipante, error) {
+	return scanAgendamentoParticipante(repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id_agendamento, nome, id_jogador, token)
+		VALUES ($1, $2, $3, $4)
+		RETURNING %s
+	`, agendamentoParticipantesTableName(), agendamentoParticipanteColumns),
+		agendamentoID,
+		input.Nome,
+		nullableIntValue(input.IDJogador),
+		token,
+	))
+}
+
+func (repository agendamentoRepository) getParticipanteByToken(ctx context.Context, token string) (models.AgendamentoParticipante, error) {
+	return scanAgendamentoParticipante(repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s WHERE token = $1
+	`, agendamentoParticipanteColumns, agendamentoParticipantesTableName()), token))
+}
+
+// deleteParticipante removes a participant that has no payments yet; the
+// count of removed rows tells the caller whether it happened.
+func (repository agendamentoRepository) deleteParticipante(ctx context.Context, agendamentoID int, participanteID int) (int64, error) {
+	result, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		DELETE FROM %s pt
+		WHERE pt.id = $1
+		  AND pt.id_agendamento = $2
+		  AND NOT EXISTS (SELECT 1 FROM %s p WHERE p.id_participante = pt.id)
+	`, agendamentoParticipantesTableName(), pagamentosPorAgendamentoTableName()), participanteID, agendamentoID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (repository agendamentoRepository) participanteExists(ctx context.Context, agendamentoID int, participanteID int) (bool, error) {
+	var exists bool
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND id_agendamento = $2)
+	`, agendamentoParticipantesTableName()), participanteID, agendamentoID).Scan(&exists)
+	return exists, err
+}
+
+func (repository agendamentoRepository) sumPaymentsByParticipante(ctx context.Context, agendamentoID int) (map[int]float64, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT id_participante, COALESCE(SUM(valor_pago), 0)
+		FROM %s
+		WHERE id_agendamento = $1 AND id_participante IS NOT NULL
+		GROUP BY id_participante
+	`, pagamentosPorAgendamentoTableName()), agendamentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totais := make(map[int]float64)
+	for rows.Next() {
+		var (
+			participanteID int
+			total          float64
+		)
+		if err := rows.Scan(&participanteID, &total); err != nil {
+			return nil, err
+		}
+		totais[participanteID] = total
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return totais, nil
+}
+
+func generateParticipanteToken() (string, error) {
+	buffer := make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buffer), nil
+}
+
+// participantePagamentoLink builds the link sent to each player from
+// PARTICIPANTE_PAGAMENTO_URL. Without it only the token is returned.
+func participantePagamentoLink(token string) string {
+	base := strings.TrimRight(strings.TrimSpace(os.Getenv("PARTICIPANTE_PAGAMENTO_URL")), "/")
+	if base == "" || token == "" {
+		return ""
+	}
+
+	return base + "/" + token
+}
+
+// saldosParticipantes splits the current valor_total over the roster.
+func (service agendamentoService) saldosParticipantes(ctx context.Context, agendamento models.Agendamento) ([]models.AgendamentoParticipanteSaldo, error) {
+	participantes, err := service.repository.listParticipantes(ctx, agendamento.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(participantes) == 0 {
+		return []models.AgendamentoParticipanteSaldo{}, nil
+	}
+
+	pagos, err := service.repository.sumPaymentsByParticipante(ctx, agendamento.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.CalcularSaldosParticipantes(agendamento.ValorTotal, participantes, pagos), nil
+}
+
+func (service agendamentoService) saldoParticipante(ctx context.Context, agendamento models.Agendamento, participanteID int) (models.AgendamentoParticipanteSaldo, error) {
+	saldos, err := service.saldosParticipantes(ctx, agendamento)
+	if err != nil {
+		return models.AgendamentoParticipanteSaldo{}, err
+	}
+
+	for _, saldo := range saldos {
+		if saldo.Participante.ID == participanteID {
+			return saldo, nil
+		}
+	}
+
+	return models.AgendamentoParticipanteSaldo{}, errParticipanteNaoEncontrado
+}
+
+func (service agendamentoService) ListParticipantes(ctx context.Context, ownerUserID int, agendamentoID int) ([]models.AgendamentoParticipanteSaldo, error) {
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errAgendamentoNaoEncontrado
+		}
+		return nil, err
+	}
+
+	return service.saldosParticipantes(ctx, agendamento)
+}
+
+// AdicionarParticipantes appends players to the roster and returns the whole
+// roster with the shares recomputed.
+func (service agendamentoService) AdicionarParticipantes(ctx context.Context, ownerUserID int, agendamentoID int, inputs []models.AdicionarParticipanteInput) ([]models.AgendamentoParticipanteSaldo, error) {
+	if len(inputs) == 0 {
+		return nil, errParticipantesListaInvalida
+	}
+	for index := range inputs {
+		inputs[index].Nome = strings.TrimSpace(inputs[index].Nome)
+		if inputs[index].Nome == "" || (inputs[index].IDJogador != nil && *inputs[index].IDJogador <= 0) {
+			return nil, errParticipanteInvalido
+		}
+	}
+
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errAgendamentoNaoEncontrado
+		}
+		return nil, err
+	}
+
+	var saldos []models.AgendamentoParticipanteSaldo
+	err = service.inTransaction(ctx, func(service agendamentoService) error {
+		for _, input := range inputs {
+			token, err := generateParticipanteToken()
+			if err != nil {
+				return err
+			}
+			if _, err := service.repository.insertParticipante(ctx, agendamentoID, input, token); err != nil {
+				return err
+			}
+		}
+
+		saldos, err = service.saldosParticipantes(ctx, agendamento)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return saldos, nil
+}
+
+func (service agendamentoService) RemoverParticipante(ctx context.Context, ownerUserID int, agendamentoID int, participanteID int) error {
+	if _, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errAgendamentoNaoEncontrado
+		}
+		return err
+	}
+
+	removidos, err := service.repository.deleteParticipante(ctx, agendamentoID, participanteID)
+	if err != nil {
+		return err
+	}
+	if removidos > 0 {
+		return nil
+	}
+
+	exists, err := service.repository.participanteExists(ctx, agendamentoID, participanteID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errParticipanteComPagamentos
+	}
+
+	return errParticipanteNaoEncontrado
+}
+
+func (service agendamentoService) GetParticipantePorToken(ctx context.Context, token string) (models.Agendamento, models.AgendamentoParticipanteSaldo, error) {
+	participante, err := service.repository.getParticipanteByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Agendamento{}, models.AgendamentoParticipanteSaldo{}, errParticipanteNaoEncontrado
+		}
+		return models.Agendamento{}, models.AgendamentoParticipanteSaldo{}, err
+	}
+
+	agendamento, err := service.repository.getByID(ctx, participante.IDAgendamento)
+	if err != nil {
+		return models.Agendamento{}, models.AgendamentoParticipanteSaldo{}, err
+	}
+
+	saldo, err := service.saldoParticipante(ctx, agendamento, participante.ID)
+	if err != nil {
+		return models.Agendamento{}, models.AgendamentoParticipanteSaldo{}, err
+	}
+
+	return agendamento, saldo, nil
+}
+
+// PagarParticipante records a payment made through a participant's link. A
+// zero valor pays the participant's whole remaining share.
+func (service agendamentoService) PagarParticipante(ctx context.Context, token string, input models.RegistrarPagamentoInput) (agendamentoPagamentoMutationResult, error) {
+	agendamento, saldo, err := service.GetParticipantePorToken(ctx, token)
+	if err != nil {
+		return agendamentoPagamentoMutationResult{}, err
+	}
+
+	if !hasAgendamentoAtor(ctx) {
+		ctx = withAgendamentoAtor(ctx, agendamentoAtor{
+			Tipo:   models.AgendamentoAtorIntegracao,
+			Origem: string(agendamento.OrigemAgendamento),
+		})
+	}
+
+	participanteID := saldo.Participante.ID
+	input.IDParticipante = &participanteID
+	input.IDUsuario = saldo.Participante.IDJogador
+	if input.ValorPago == 0 {
+		input.ValorPago = saldo.Restante
+	}
+
+	return service.registrarPagamento(ctx, agendamento, input)
+}
+
+func GetParticipantesAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	saldos, err := service.ListParticipantes(r.Context(), userID, agendamentoID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAgendamentoParticipantesResponse(saldos))
+}
+
+func AdicionarParticipantesAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var request agendamentoParticipantesRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Erro ao decodificar JSON", http.StatusBadRequest)
+		return
+	}
+
+	inputs := make([]models.AdicionarParticipanteInput, 0, len(request.Participantes))
+	for _, participante := range request.Participantes {
+		inputs = append(inputs, models.AdicionarParticipanteInput{
+			Nome:      participante.Nome,
+			IDJogador: participante.IDJogador,
+		})
+	}
+
+	service := newAgendamentoService()
+	saldos, err := service.AdicionarParticipantes(r.Context(), userID, agendamentoID, inputs)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"message":       "Participantes adicionados com sucesso",
+		"participantes": newAgendamentoParticipantesResponse(saldos),
+	})
+}
+
+func RemoverParticipanteAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	participanteID, err := strconv.Atoi(strings.TrimSpace(mux.Vars(r)["id_participante"]))
+	if err != nil || participanteID <= 0 {
+		http.Error(w, "ID do participante invalido", http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	if err := service.RemoverParticipante(r.Context(), userID, agendamentoID, participanteID); err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Participante removido com sucesso"})
+}
+
+// GetParticipantePagamento serves a participant's payment link. The token in
+// the path is the only credential, so nothing about other players is shown.
+func GetParticipantePagamento(w http.ResponseWriter, r *http.Request) {
+	service := newAgendamentoService()
+	agendamento, saldo, err := service.GetParticipantePorToken(r.Context(), strings.TrimSpace(mux.Vars(r)["token"]))
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, participantePagamentoLinkResponse{
+		Participante:  newAgendamentoParticipanteResponse(saldo),
+		IDAgendamento: agendamento.ID,
+		NomeCampo:     agendamento.NomeCampo,
+		NomeArena:     agendamento.NomeArena,
+		Horario:       formatAgendamentoDateTime(agendamento.Horario),
+		HorarioFim:    formatAgendamentoDateTime(agendamento.HorarioFim()),
+		Status:        string(agendamento.Status),
+	})
+}
+
+func PagarParticipanteJogador(w http.ResponseWriter, r *http.Request) {
+	if err := validateJogadorIntegrationRequest(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var request agendamentoPagamentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Erro ao decodificar JSON", http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	result, err := service.PagarParticipante(r.Context(), strings.TrimSpace(mux.Vars(r)["token"]), models.RegistrarPagamentoInput{
+		ValorPago:      request.ValorPago,
+		FormaPagamento: strings.TrimSpace(request.FormaPagamento),
+	})
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"message":     "Pagamento registrado com sucesso",
+		"agendamento": newAgendamentoResponse(result.Agendamento),
+		"pagamento":   newAgendamentoPagamentoResponse(result.Pagamento),
+		"total_pago":  result.TotalPago,
+	})
+}
+
+func newAgendamentoParticipanteResponse(saldo models.AgendamentoParticipanteSaldo) agendamentoParticipanteResponse {
+	return agendamentoParticipanteResponse{
+		ID:            saldo.Participante.ID,
+		IDAgendamento: saldo.Participante.IDAgendamento,
+		Nome:          saldo.Participante.Nome,
+		IDJogador:     saldo.Participante.IDJogador,
+		Token:         saldo.Participante.Token,
+		LinkPagamento: participantePagamentoLink(saldo.Participante.Token),
+		Cota:          saldo.Cota,
+		TotalPago:     saldo.TotalPago,
+		Restante:      saldo.Restante,
+		CriadoEm:      formatAgendamentoDateTime(saldo.Participante.CriadoEm),
+	}
+}
+
+func newAgendamentoParticipantesResponse(saldos []models.AgendamentoParticipanteSaldo) []agendamentoParticipanteResponse {
+	response := make([]agendamentoParticipanteResponse, 0, len(saldos))
+	for _, saldo := range saldos {
+		response = append(response, newAgendamentoParticipanteResponse(saldo))
+	}
+
+	return response
+}
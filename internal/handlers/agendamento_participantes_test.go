@@ -0,0 +1,30 @@
+package handlers
+
+import "testing"
+
+func TestParticipantePagamentoLink(t *testing.T) {
+	t.Setenv("PARTICIPANTE_PAGAMENTO_URL", "")
+	if link := participantePagamentoLink("abc"); link != "" {
+		t.Fatalf("expected no link without a base url, got %q", link)
+	}
+
+	t.Setenv("PARTICIPANTE_PAGAMENTO_URL", "https://marca.ai/pagar/")
+	if link := participantePagamentoLink("abc"); link != "https://marca.ai/pagar/abc" {
+		t.Fatalf("expected link with token, got %q", link)
+	}
+}
+
+func TestGenerateParticipanteTokenIsUnique(t *testing.T) {
+	primeiro, err := generateParticipanteToken()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	segundo, err := generateParticipanteToken()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(primeiro) != 32 || primeiro == segundo {
+		t.Fatalf("expected two distinct 32 char tokens, got %q and %q", primeiro, segundo)
+	}
+}
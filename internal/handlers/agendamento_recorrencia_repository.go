@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func (repository agendamentoRepository) createRecorrencia(ctx context.Context, ownerUserID int, input models.CreateAgendamentoRecorrenciaInput) (models.AgendamentoRecorrencia, error) {
+	createdAt := agendamentoNow()
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			id_usuario,
+			id_campo,
+			frequencia,
+			data_inicio,
+			data_fim,
+			horario,
+			jogadores,
+			pagamento,
+			nome_solicitante,
+			time1,
+			time2,
+			modo_de_jogo,
+			status,
+			criado_em,
+			duracao_minutos
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), $13, $14, $15)
+		RETURNING id, criado_em
+	`, agendamentoRecorrenciasTableName())
+
+	recorrencia := models.AgendamentoRecorrencia{
+		IDUsuario:       ownerUserID,
+		IDCampo:         input.IDCampo,
+		Frequencia:      input.Frequencia,
+		DataInicio:      input.DataInicio,
+		DataFim:         input.DataFim,
+		Horario:         input.Horario,
+		DuracaoMinutos:  input.DuracaoMinutos,
+		Jogadores:       input.Jogadores,
+		Pagamento:       input.Pagamento,
+		NomeSolicitante: input.NomeSolicitante,
+		Time1:           input.Time1,
+		Time2:           input.Time2,
+		ModoDeJogo:      input.ModoDeJogo,
+		Status:          models.AgendamentoRecorrenciaAtiva,
+	}
+
+	err := repository.database().QueryRowContext(
+		ctx,
+		query,
+		ownerUserID,
+		input.IDCampo,
+		string(input.Frequencia),
+		input.DataInicio,
+		nullableTimeValue(input.DataFim),
+		input.Horario,
+		input.Jogadores,
+		input.Pagamento,
+		input.NomeSolicitante,
+		input.Time1,
+		input.Time2,
+		input.ModoDeJogo,
+		string(models.AgendamentoRecorrenciaAtiva),
+		createdAt,
+		input.DuracaoMinutos,
+	).Scan(&recorrencia.ID, &recorrencia.CriadoEm)
+	if err != nil {
+		return models.AgendamentoRecorrencia{}, err
+	}
+
+	return recorrencia, nil
+}
+
+func (repository agendamentoRepository) updateRecorrencia(ctx context.Context, recorrenciaID int, input models.CreateAgendamentoRecorrenciaInput) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET
+			id_campo = $1,
+			frequencia = $2,
+			data_inicio = $3,
+			data_fim = $4,
+			horario = $5,
+			jogadores = $6,
+			pagamento = $7,
+			nome_solicitante = NULLIF($8, ''),
+			time1 = NULLIF($9, ''),
+			time2 = NULLIF($10, ''),
+			modo_de_jogo = NULLIF($11, ''),
+			duracao_minutos = $12
+		WHERE id = $13
+	`, agendamentoRecorrenciasTableName()),
+		input.IDCampo,
+		string(input.Frequencia),
+		input.DataInicio,
+		nullableTimeValue(input.DataFim),
+		input.Horario,
+		input.Jogadores,
+		input.Pagamento,
+		input.NomeSolicitante,
+		input.Time1,
+		input.Time2,
+		input.ModoDeJogo,
+		input.DuracaoMinutos,
+		recorrenciaID,
+	)
+	return err
+}
+
+func (repository agendamentoRepository) updateRecorrenciaStatus(ctx context.Context, recorrenciaID int, status models.AgendamentoRecorrenciaStatus) error {
+	_, err := repository.database().ExecContext(
+		ctx,
+		fmt.Sprintf(`UPDATE %s SET status = $1 WHERE id = $2`, agendamentoRecorrenciasTableName()),
+		string(status),
+		recorrenciaID,
+	)
+	return err
+}
+
+func (repository agendamentoRepository) getRecorrenciaForOwner(ctx context.Context, recorrenciaID int, ownerUserID int) (models.AgendamentoRecorrencia, error) {
+	query := fmt.Sprintf(`
+		%s
+		WHERE r.id = $1
+		  AND ar.id_usuario = $2
+	`, agendamentoRecorrenciaBaseSelectQuery())
+
+	return scanAgendamentoRecorrencia(
+		repository.database().QueryRowContext(ctx, query, recorrenciaID, ownerUserID),
+	)
+}
+
+func (repository agendamentoRepository) getRecorrencia(ctx context.Context, recorrenciaID int) (models.AgendamentoRecorrencia, error) {
+	query := fmt.Sprintf(`
+		%s
+		WHERE r.id = $1
+	`, agendamentoRecorrenciaBaseSelectQuery())
+
+	return scanAgendamentoRecorrencia(repository.database().QueryRowContext(ctx, query, recorrenciaID))
+}
+
+// lockRecorrencia serializes edits and the rolling materialization of one
+// recorrencia. It must run inside a transaction.
+func (repository agendamentoRepository) lockRecorrencia(ctx context.Context, recorrenciaID int) error {
+	var id int
+	return repository.database().QueryRowContext(
+		ctx,
+		fmt.Sprintf(`SELECT id FROM %s WHERE id = $1 FOR UPDATE`, agendamentoRecorrenciasTableName()),
+		recorrenciaID,
+	).Scan(&id)
+}
+
+// listRecorrenciasAtivas returns the active recorrencias that have not ended
+// before desde.
+func (repository agendamentoRepository) listRecorrenciasAtivas(ctx context.Context, desde time.Time) ([]models.AgendamentoRecorrencia, error) {
+	query := fmt.Sprintf(`
+		%s
+		WHERE r.status = $1
+		  AND (r.data_fim IS NULL OR r.data_fim >= $2)
+		ORDER BY r.id ASC
+	`, agendamentoRecorrenciaBaseSelectQuery())
+
+	rows, err := repository.database().QueryContext(ctx, query, string(models.AgendamentoRecorrenciaAtiva), desde)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recorrencias := make([]models.AgendamentoRecorrencia, 0)
+	for rows.Next() {
+		recorrencia, scanErr := scanAgendamentoRecorrencia(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		recorrencias = append(recorrencias, recorrencia)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recorrencias, nil
+}
+
+func (repository agendamentoRepository) listRecorrenciasByOwner(ctx context.Context, ownerUserID int) ([]models.AgendamentoRecorrencia, error) {
+	query := fmt.Sprintf(`
+		%s
+		WHERE ar.id_usuario = $1
+		ORDER BY
+			CASE r.status WHEN 'ativa' THEN 0 ELSE 1 END,
+			r.data_inicio DESC,
+			r.id DESC
+	`, agendamentoRecorrenciaBaseSelectQuery())
+
+	rows, err := repository.database().QueryContext(ctx, query, ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recorrencias := make([]models.AgendamentoRecorrencia, 0)
+	for rows.Next() {
+		recorrencia, scanErr := scanAgendamentoRecorrencia(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		recorrencias = append(recorrencias, recorrencia)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recorrencias, nil
+}
+
+func (repository agendamentoRepository) listOcorrenciasRecorrencia(ctx context.Context, recorrenciaID int) ([]models.Agendamento, error) {
+	query := fmt.Sprintf(`
+		%s
+		WHERE a.id_recorrencia = $1
+		ORDER BY a.horario ASC
+	`, agendamentoBaseSelectQuery())
+
+	rows, err := repository.database().QueryContext(ctx, query, recorrenciaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agendamentos := make([]models.Agendamento, 0)
+	for rows.Next() {
+		agendamento, scanErr := scanAgendamento(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		agendamentos = append(agendamentos, agendamento)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return agendamentos, nil
+}
+
+func agendamentoRecorrenciaBaseSelectQuery() string {
+	return fmt.Sprintf(`
+		SELECT
+			r.id,
+			r.id_usuario,
+			r.id_campo,
+			c.id_arena,
+			c.nome_campo,
+			ar.nome AS nome_arena,
+			r.frequencia,
+			r.data_inicio,
+			r.data_fim,
+			r.horario,
+			r.jogadores,
+			COALESCE(r.pagamento, ''),
+			COALESCE(r.nome_solicitante, ''),
+			COALESCE(r.time1, ''),
+			COALESCE(r.time2, ''),
+			COALESCE(r.modo_de_jogo, ''),
+			r.status,
+			r.criado_em,
+			COALESCE(r.duracao_minutos, %d)
+		FROM %s r
+		JOIN %s c ON r.id_campo = c.id_campo
+		JOIN %s ar ON c.id_arena = ar.id
+	`, models.AgendamentoDuracaoPadraoMinutos, agendamentoRecorrenciasTableName(), campoTableName(), arenasTableName())
+}
+
+func scanAgendamentoRecorrencia(scanner agendamentoScanner) (models.AgendamentoRecorrencia, error) {
+	var (
+		recorrencia   models.AgendamentoRecorrencia
+		idUsuario     sql.NullInt64
+		frequenciaRaw string
+		statusRaw     string
+		dataFim       sql.NullTime
+		criadoEm      sql.NullTime
+	)
+
+	err := scanner.Scan(
+		&recorrencia.ID,
+		&idUsuario,
+		&recorrencia.IDCampo,
+		&recorrencia.IDArena,
+		&recorrencia.NomeCampo,
+		&recorrencia.NomeArena,
+		&frequenciaRaw,
+		&recorrencia.DataInicio,
+		&dataFim,
+		&recorrencia.Horario,
+		&recorrencia.Jogadores,
+		&recorrencia.Pagamento,
+		&recorrencia.NomeSolicitante,
+		&recorrencia.Time1,
+		&recorrencia.Time2,
+		&recorrencia.ModoDeJogo,
+		&statusRaw,
+		&criadoEm,
+		&recorrencia.DuracaoMinutos,
+	)
+	if err != nil {
+		return models.AgendamentoRecorrencia{}, err
+	}
+
+	if idUsuario.Valid {
+		recorrencia.IDUsuario = int(idUsuario.Int64)
+	}
+	recorrencia.DataInicio = agendamentoDate(recorrencia.DataInicio)
+	if dataFim.Valid {
+		value := agendamentoDate(dataFim.Time)
+		recorrencia.DataFim = &value
+	}
+	if criadoEm.Valid {
+		recorrencia.CriadoEm = criadoEm.Time
+	}
+	if frequencia, ok := models.NormalizeAgendamentoRecorrenciaFrequencia(frequenciaRaw); ok {
+		recorrencia.Frequencia = frequencia
+	}
+	recorrencia.Status = models.AgendamentoRecorrenciaStatus(statusRaw)
+
+	return recorrencia, nil
+}
+
+func nullableTimeValue(value *time.Time) any {
+	if value == nil {
+		return nil
+	}
+
+	return *value
+}
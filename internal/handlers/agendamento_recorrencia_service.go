@@ -0,0 +1,572 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+const (
+	recorrenciaHorizontePadrao = 12 * 7 * 24 * time.Hour
+	recorrenciaDuracaoMaxima   = 366 * 24 * time.Hour
+	recorrenciaIntervaloPadrao = time.Hour
+)
+
+var (
+	errRecorrenciaNaoEncontrada      = errors.New("recorrencia nao encontrada")
+	errRecorrenciaInvalida           = errors.New("recorrencia invalida")
+	errRecorrenciaCancelada          = errors.New("recorrencia cancelada")
+	errRecorrenciaOcorrenciaInvalida = errors.New("ocorrencia nao pertence a recorrencia")
+	errRecorrenciaOcorrenciaIniciada = errors.New("ocorrencia ja iniciada")
+)
+
+type recorrenciaConflito struct {
+	Horario time.Time `json:"horario"`
+	Motivo  string    `json:"motivo"`
+}
+
+type agendamentoRecorrenciaResult struct {
+	Recorrencia models.AgendamentoRecorrencia `json:"recorrencia"`
+	Criados     []models.Agendamento          `json:"criados"`
+	Atualizados []models.Agendamento          `json:"atualizados"`
+	Cancelados  []models.Agendamento          `json:"cancelados"`
+	Conflitos   []recorrenciaConflito         `json:"conflitos"`
+}
+
+type agendamentoRecorrenciaDetalhe struct {
+	Recorrencia models.AgendamentoRecorrencia `json:"recorrencia"`
+	Ocorrencias []models.Agendamento          `json:"ocorrencias"`
+}
+
+func (service agendamentoService) CreateRecorrencia(ctx context.Context, ownerUserID int, input models.CreateAgendamentoRecorrenciaInput) (agendamentoRecorrenciaResult, error) {
+	if err := validateRecorrenciaInput(input); err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	campo, err := service.loadCampoForOwner(ctx, input.IDCampo, ownerUserID)
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	var result agendamentoRecorrenciaResult
+	err = service.inTransaction(ctx, func(service agendamentoService) error {
+		recorrencia, err := service.repository.createRecorrencia(ctx, ownerUserID, input)
+		if err != nil {
+			return err
+		}
+		recorrencia.IDArena = campo.IDArena
+		recorrencia.NomeCampo = campo.NomeCampo
+		recorrencia.NomeArena = campo.NomeArena
+
+		result = newAgendamentoRecorrenciaResult(recorrencia)
+		return service.materializeRecorrencia(ctx, ownerUserID, recorrencia, nil, &result)
+	})
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	return result, nil
+}
+
+func (service agendamentoService) ListRecorrencias(ctx context.Context, ownerUserID int) ([]models.AgendamentoRecorrencia, error) {
+	return service.repository.listRecorrenciasByOwner(ctx, ownerUserID)
+}
+
+func (service agendamentoService) GetRecorrencia(ctx context.Context, ownerUserID int, recorrenciaID int) (agendamentoRecorrenciaDetalhe, error) {
+	recorrencia, err := service.getRecorrenciaForOwner(ctx, ownerUserID, recorrenciaID)
+	if err != nil {
+		return agendamentoRecorrenciaDetalhe{}, err
+	}
+
+	ocorrencias, err := service.repository.listOcorrenciasRecorrencia(ctx, recorrencia.ID)
+	if err != nil {
+		return agendamentoRecorrenciaDetalhe{}, err
+	}
+
+	return agendamentoRecorrenciaDetalhe{
+		Recorrencia: recorrencia,
+		Ocorrencias: ocorrencias,
+	}, nil
+}
+
+func (service agendamentoService) MaterializarRecorrencia(ctx context.Context, ownerUserID int, recorrenciaID int) (agendamentoRecorrenciaResult, error) {
+	recorrencia, err := service.getRecorrenciaForOwner(ctx, ownerUserID, recorrenciaID)
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+	if recorrencia.Status == models.AgendamentoRecorrenciaCancelada {
+		return agendamentoRecorrenciaResult{}, errRecorrenciaCancelada
+	}
+
+	ocorrencias, err := service.repository.listOcorrenciasRecorrencia(ctx, recorrencia.ID)
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	result := newAgendamentoRecorrenciaResult(recorrencia)
+	if err := service.materializeRecorrencia(ctx, ownerUserID, recorrencia, ocorrencias, &result); err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	return result, nil
+}
+
+func (service agendamentoService) UpdateRecorrencia(ctx context.Context, ownerUserID int, recorrenciaID int, input models.CreateAgendamentoRecorrenciaInput) (agendamentoRecorrenciaResult, error) {
+	ctx = withRecorrenciaAtor(ctx, ownerUserID)
+
+	if err := validateRecorrenciaInput(input); err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	recorrencia, err := service.getRecorrenciaForOwner(ctx, ownerUserID, recorrenciaID)
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+	if recorrencia.Status == models.AgendamentoRecorrenciaCancelada {
+		return agendamentoRecorrenciaResult{}, errRecorrenciaCancelada
+	}
+
+	campo, err := service.loadCampoForOwner(ctx, input.IDCampo, ownerUserID)
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	var result agendamentoRecorrenciaResult
+	err = service.inTransaction(ctx, func(service agendamentoService) error {
+		if err := service.repository.lockRecorrencia(ctx, recorrencia.ID); err != nil {
+			return err
+		}
+		atual, err := service.repository.getRecorrencia(ctx, recorrencia.ID)
+		if err != nil {
+			return err
+		}
+		if atual.Status == models.AgendamentoRecorrenciaCancelada {
+			return errRecorrenciaCancelada
+		}
+		if err := service.repository.updateRecorrencia(ctx, recorrencia.ID, input); err != nil {
+			return err
+		}
+
+		recorrencia.IDCampo = input.IDCampo
+		recorrencia.IDArena = campo.IDArena
+		recorrencia.NomeCampo = campo.NomeCampo
+		recorrencia.NomeArena = campo.NomeArena
+		recorrencia.Frequencia = input.Frequencia
+		recorrencia.DataInicio = input.DataInicio
+		recorrencia.DataFim = input.DataFim
+		recorrencia.Horario = input.Horario
+		recorrencia.Jogadores = input.Jogadores
+		recorrencia.Pagamento = input.Pagamento
+		recorrencia.NomeSolicitante = input.NomeSolicitante
+		recorrencia.Time1 = input.Time1
+		recorrencia.Time2 = input.Time2
+		recorrencia.ModoDeJogo = input.ModoDeJogo
+
+		ocorrencias, err := service.repository.listOcorrenciasRecorrencia(ctx, recorrencia.ID)
+		if err != nil {
+			return err
+		}
+
+		result = newAgendamentoRecorrenciaResult(recorrencia)
+		now := agendamentoNow()
+		mantidas := make([]models.Agendamento, 0, len(ocorrencias))
+
+		for _, ocorrencia := range ocorrencias {
+			if !isOcorrenciaFuturaEditavel(ocorrencia, now) {
+				mantidas = append(mantidas, ocorrencia)
+				continue
+			}
+
+			dataOperacional := agendamentoDataOperacional(ocorrencia.Horario)
+			if !recorrenciaIncluiData(recorrencia, dataOperacional) {
+				cancelada, err := service.transitionStatus(ctx, ocorrencia, models.AgendamentoAcaoCancelar, models.AgendamentoStatusCancelado)
+				if err != nil {
+					return err
+				}
+				result.Cancelados = append(result.Cancelados, cancelada.Agendamento)
+				continue
+			}
+
+			novoHorario, err := recorrenciaHorarioNaData(dataOperacional, recorrencia.Horario)
+			if err != nil {
+				return errRecorrenciaInvalida
+			}
+
+			atualizado, err := service.Edit(ctx, ownerUserID, ocorrencia.ID, models.CreateAgendamentoInput{
+				IDCampo:         recorrencia.IDCampo,
+				Horario:         novoHorario,
+				DuracaoMinutos:  recorrencia.DuracaoMinutos,
+				Jogadores:       recorrencia.Jogadores,
+				Pagamento:       recorrencia.Pagamento,
+				NomeSolicitante: recorrencia.NomeSolicitante,
+				Time1:           recorrencia.Time1,
+				Time2:           recorrencia.Time2,
+				ModoDeJogo:      recorrencia.ModoDeJogo,
+			})
+			if err != nil {
+				if motivo, ok := recorrenciaConflitoMotivo(err); ok {
+					result.Conflitos = append(result.Conflitos, recorrenciaConflito{Horario: novoHorario, Motivo: motivo})
+					mantidas = append(mantidas, ocorrencia)
+					continue
+				}
+				return err
+			}
+
+			result.Atualizados = append(result.Atualizados, atualizado)
+			mantidas = append(mantidas, atualizado)
+		}
+
+		return service.materializeRecorrencia(ctx, ownerUserID, recorrencia, mantidas, &result)
+	})
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	return result, nil
+}
+
+func (service agendamentoService) CancelRecorrencia(ctx context.Context, ownerUserID int, recorrenciaID int) (agendamentoRecorrenciaResult, error) {
+	ctx = withRecorrenciaAtor(ctx, ownerUserID)
+
+	recorrencia, err := service.getRecorrenciaForOwner(ctx, ownerUserID, recorrenciaID)
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	if recorrencia.Status != models.AgendamentoRecorrenciaCancelada {
+		if err := service.repository.updateRecorrenciaStatus(ctx, recorrencia.ID, models.AgendamentoRecorrenciaCancelada); err != nil {
+			return agendamentoRecorrenciaResult{}, err
+		}
+		recorrencia.Status = models.AgendamentoRecorrenciaCancelada
+	}
+
+	ocorrencias, err := service.repository.listOcorrenciasRecorrencia(ctx, recorrencia.ID)
+	if err != nil {
+		return agendamentoRecorrenciaResult{}, err
+	}
+
+	result := newAgendamentoRecorrenciaResult(recorrencia)
+	now := agendamentoNow()
+	for _, ocorrencia := range ocorrencias {
+		if !isOcorrenciaFuturaEditavel(ocorrencia, now) {
+			continue
+		}
+
+		cancelada, err := service.transitionStatus(ctx, ocorrencia, models.AgendamentoAcaoCancelar, models.AgendamentoStatusCancelado)
+		if err != nil {
+			return agendamentoRecorrenciaResult{}, err
+		}
+		result.Cancelados = append(result.Cancelados, cancelada.Agendamento)
+	}
+
+	return result, nil
+}
+
+func (service agendamentoService) PularOcorrenciaRecorrencia(ctx context.Context, ownerUserID int, recorrenciaID int, agendamentoID int) (agendamentoMutationResult, error) {
+	ctx = withRecorrenciaAtor(ctx, ownerUserID)
+
+	recorrencia, err := service.getRecorrenciaForOwner(ctx, ownerUserID, recorrenciaID)
+	if err != nil {
+		return agendamentoMutationResult{}, err
+	}
+
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return agendamentoMutationResult{}, errAgendamentoNaoEncontrado
+		}
+		return agendamentoMutationResult{}, err
+	}
+
+	if agendamento.IDRecorrencia == nil || *agendamento.IDRecorrencia != recorrencia.ID {
+		return agendamentoMutationResult{}, errRecorrenciaOcorrenciaInvalida
+	}
+	if !isOcorrenciaFuturaEditavel(agendamento, agendamentoNow()) {
+		return agendamentoMutationResult{}, errRecorrenciaOcorrenciaIniciada
+	}
+
+	return service.transitionStatus(ctx, agendamento, models.AgendamentoAcaoCancelar, models.AgendamentoStatusCancelado)
+}
+
+func (service agendamentoService) materializeRecorrencia(
+	ctx context.Context,
+	ownerUserID int,
+	recorrencia models.AgendamentoRecorrencia,
+	existentes []models.Agendamento,
+	result *agendamentoRecorrenciaResult,
+) error {
+	ctx = withRecorrenciaAtor(ctx, ownerUserID)
+
+	datasExistentes := make(map[string]struct{}, len(existentes))
+	for _, existente := range existentes {
+		datasExistentes[agendamentoDataOperacional(existente.Horario).Format("2006-01-02")] = struct{}{}
+	}
+
+	now := agendamentoNow()
+	horarios, err := buildRecorrenciaOcorrencias(recorrencia, now, now.Add(recorrenciaHorizontePadrao))
+	if err != nil {
+		return errRecorrenciaInvalida
+	}
+
+	recorrenciaID := recorrencia.ID
+	for _, horario := range horarios {
+		if _, exists := datasExistentes[agendamentoDataOperacional(horario).Format("2006-01-02")]; exists {
+			continue
+		}
+
+		agendamento, err := service.CreateManual(ctx, ownerUserID, models.CreateAgendamentoInput{
+			IDCampo:         recorrencia.IDCampo,
+			Horario:         horario,
+			DuracaoMinutos:  recorrencia.DuracaoMinutos,
+			Jogadores:       recorrencia.Jogadores,
+			Pagamento:       recorrencia.Pagamento,
+			NomeSolicitante: recorrencia.NomeSolicitante,
+			Time1:           recorrencia.Time1,
+			Time2:           recorrencia.Time2,
+			ModoDeJogo:      recorrencia.ModoDeJogo,
+			IDRecorrencia:   &recorrenciaID,
+		})
+		if err != nil {
+			if motivo, ok := recorrenciaConflitoMotivo(err); ok {
+				result.Conflitos = append(result.Conflitos, recorrenciaConflito{Horario: horario, Motivo: motivo})
+				continue
+			}
+			return err
+		}
+
+		result.Criados = append(result.Criados, agendamento)
+	}
+
+	return nil
+}
+
+// MaterializarRecorrenciasAtivas books every active recorrencia up to
+// recorrenciaHorizontePadrao ahead, so open-ended series keep rolling forward.
+// It returns how many occurrences were created.
+func (service agendamentoService) MaterializarRecorrenciasAtivas(ctx context.Context, agora time.Time) (int, error) {
+	recorrencias, err := service.repository.listRecorrenciasAtivas(ctx, agendamentoDate(agora))
+	if err != nil {
+		return 0, err
+	}
+
+	criados := 0
+	for _, recorrencia := range recorrencias {
+		result := newAgendamentoRecorrenciaResult(recorrencia)
+		err := service.inTransaction(ctx, func(service agendamentoService) error {
+			if err := service.repository.lockRecorrencia(ctx, recorrencia.ID); err != nil {
+				return err
+			}
+
+			// An edit may have committed since the list was read.
+			atual, err := service.repository.getRecorrencia(ctx, recorrencia.ID)
+			if err != nil || atual.Status != models.AgendamentoRecorrenciaAtiva {
+				return err
+			}
+
+			ocorrencias, err := service.repository.listOcorrenciasRecorrencia(ctx, atual.ID)
+			if err != nil {
+				return err
+			}
+
+			return service.materializeRecorrencia(ctx, atual.IDUsuario, atual, ocorrencias, &result)
+		})
+		if err != nil {
+			log.Printf("Erro ao materializar recorrencia %d: %v", recorrencia.ID, err)
+			continue
+		}
+
+		criados += len(result.Criados)
+		if len(result.Conflitos) > 0 {
+			log.Printf("Recorrencia %d: %d ocorrencia(s) em conflito nao criada(s)", recorrencia.ID, len(result.Conflitos))
+		}
+	}
+
+	return criados, nil
+}
+
+// StartRecorrenciaWorker extends active recorrencias until ctx is done. It is
+// meant to run in its own goroutine.
+func StartRecorrenciaWorker(ctx context.Context) {
+	service := newAgendamentoService()
+	intervalo := time.Duration(envPositiveInt("RECORRENCIA_INTERVALO_SEGUNDOS", int(recorrenciaIntervaloPadrao/time.Second))) * time.Second
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		criados, err := service.MaterializarRecorrenciasAtivas(ctx, agendamentoNow())
+		if err != nil {
+			log.Printf("Erro ao buscar recorrencias ativas: %v", err)
+		} else if criados > 0 {
+			log.Printf("%d ocorrencia(s) de recorrencia criada(s)", criados)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// withRecorrenciaAtor tags history events caused by recurrence maintenance so
+// they can be told apart from one-off edits in the panel.
+func withRecorrenciaAtor(ctx context.Context, ownerUserID int) context.Context {
+	return withAgendamentoAtor(ctx, agendamentoAtor{
+		Tipo:      models.AgendamentoAtorUsuario,
+		IDUsuario: &ownerUserID,
+		Origem:    agendamentoOrigemEventoRecorrencia,
+	})
+}
+
+func (service agendamentoService) getRecorrenciaForOwner(ctx context.Context, ownerUserID int, recorrenciaID int) (models.AgendamentoRecorrencia, error) {
+	recorrencia, err := service.repository.getRecorrenciaForOwner(ctx, recorrenciaID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AgendamentoRecorrencia{}, errRecorrenciaNaoEncontrada
+		}
+		return models.AgendamentoRecorrencia{}, err
+	}
+
+	return recorrencia, nil
+}
+
+func (service agendamentoService) loadCampoForOwner(ctx context.Context, campoID int, ownerUserID int) (campoAgendamentoSnapshot, error) {
+	campo, err := service.repository.loadCampoSnapshot(ctx, campoID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return campoAgendamentoSnapshot{}, errAgendamentoCampoNaoEncontrado
+		}
+		return campoAgendamentoSnapshot{}, err
+	}
+	if campo.OwnerUserID != ownerUserID {
+		return campoAgendamentoSnapshot{}, errAgendamentoCampoSemPermissao
+	}
+
+	return campo, nil
+}
+
+func newAgendamentoRecorrenciaResult(recorrencia models.AgendamentoRecorrencia) agendamentoRecorrenciaResult {
+	return agendamentoRecorrenciaResult{
+		Recorrencia: recorrencia,
+		Criados:     []models.Agendamento{},
+		Atualizados: []models.Agendamento{},
+		Cancelados:  []models.Agendamento{},
+		Conflitos:   []recorrenciaConflito{},
+	}
+}
+
+func validateRecorrenciaInput(input models.CreateAgendamentoRecorrenciaInput) error {
+	if input.IDCampo <= 0 {
+		return errAgendamentoCampoNaoEncontrado
+	}
+	if input.Jogadores <= 0 {
+		return errAgendamentoJogadoresInvalidos
+	}
+	if !isAgendamentoDuracaoValida(input.DuracaoMinutos) {
+		return errAgendamentoDuracaoInvalida
+	}
+	if input.Frequencia.IntervaloDias() <= 0 || input.DataInicio.IsZero() {
+		return errRecorrenciaInvalida
+	}
+	if _, err := recorrenciaHorarioNaData(input.DataInicio, input.Horario); err != nil {
+		return errRecorrenciaInvalida
+	}
+	if input.DataFim != nil {
+		if input.DataFim.Before(input.DataInicio) || input.DataFim.Sub(input.DataInicio) > recorrenciaDuracaoMaxima {
+			return errRecorrenciaInvalida
+		}
+	}
+
+	return nil
+}
+
+func isOcorrenciaFuturaEditavel(agendamento models.Agendamento, now time.Time) bool {
+	if !agendamento.Horario.After(now) || agendamento.InicioCronometro != nil {
+		return false
+	}
+
+	switch agendamento.Status {
+	case models.AgendamentoStatusAgendado, models.AgendamentoStatusPedido:
+		return true
+	default:
+		return false
+	}
+}
+
+func recorrenciaConflitoMotivo(err error) (string, bool) {
+	switch {
+	case errors.Is(err, errAgendamentoHorarioIndisponivel):
+		return "horario_indisponivel", true
+	case errors.Is(err, errAgendamentoCampoIndisponivel):
+		return "campo_indisponivel", true
+	case errors.Is(err, errAgendamentoForaDoHorario):
+		return "fora_do_horario", true
+	case errors.Is(err, errAgendamentoHorarioBloqueado):
+		return "horario_bloqueado", true
+	default:
+		return "", false
+	}
+}
+
+func buildRecorrenciaOcorrencias(recorrencia models.AgendamentoRecorrencia, desde time.Time, horizonte time.Time) ([]time.Time, error) {
+	intervalo := recorrencia.Frequencia.IntervaloDias()
+	limite := horizonte
+	if recorrencia.DataFim != nil {
+		fim, err := recorrenciaHorarioNaData(*recorrencia.DataFim, recorrencia.Horario)
+		if err != nil {
+			return nil, err
+		}
+		if fim.Before(limite) {
+			limite = fim
+		}
+	}
+
+	ocorrencias := make([]time.Time, 0)
+	for data := agendamentoDate(recorrencia.DataInicio); ; data = data.AddDate(0, 0, intervalo) {
+		horario, err := recorrenciaHorarioNaData(data, recorrencia.Horario)
+		if err != nil {
+			return nil, err
+		}
+		if horario.After(limite) {
+			break
+		}
+		if !horario.After(desde) {
+			continue
+		}
+		ocorrencias = append(ocorrencias, horario)
+	}
+
+	return ocorrencias, nil
+}
+
+func recorrenciaIncluiData(recorrencia models.AgendamentoRecorrencia, data time.Time) bool {
+	data = agendamentoDate(data)
+	inicio := agendamentoDate(recorrencia.DataInicio)
+	if data.Before(inicio) {
+		return false
+	}
+	if recorrencia.DataFim != nil && data.After(agendamentoDate(*recorrencia.DataFim)) {
+		return false
+	}
+
+	dias := int(data.Sub(inicio).Round(24*time.Hour) / (24 * time.Hour))
+	return dias%recorrencia.Frequencia.IntervaloDias() == 0
+}
+
+func recorrenciaHorarioNaData(data time.Time, horario string) (time.Time, error) {
+	normalized, err := normalizeCampoHorario(horario)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	slots := generateBookingSlots(agendamentoDate(data), agendamentoLocation(), []string{normalized})
+	if len(slots) == 0 {
+		return time.Time{}, errRecorrenciaInvalida
+	}
+
+	return slots[0], nil
+}
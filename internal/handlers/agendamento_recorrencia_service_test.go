@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestBuildRecorrenciaOcorrenciasRespectsFrequencyAndEndDate(t *testing.T) {
+	loc := agendamentoLocation()
+	dataFim := time.Date(2026, 5, 4, 0, 0, 0, 0, loc)
+	recorrencia := models.AgendamentoRecorrencia{
+		Frequencia: models.AgendamentoRecorrenciaQuinzenal,
+		DataInicio: time.Date(2026, 4, 6, 0, 0, 0, 0, loc),
+		DataFim:    &dataFim,
+		Horario:    "20:00",
+	}
+
+	desde := time.Date(2026, 4, 1, 0, 0, 0, 0, loc)
+	horarios, err := buildRecorrenciaOcorrencias(recorrencia, desde, desde.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []time.Time{
+		time.Date(2026, 4, 6, 20, 0, 0, 0, loc),
+		time.Date(2026, 4, 20, 20, 0, 0, 0, loc),
+		time.Date(2026, 5, 4, 20, 0, 0, 0, loc),
+	}
+	if len(horarios) != len(expected) {
+		t.Fatalf("expected %d occurrences, got %d", len(expected), len(horarios))
+	}
+	for index := range expected {
+		if !horarios[index].Equal(expected[index]) {
+			t.Fatalf("expected occurrence %d at %v, got %v", index, expected[index], horarios[index])
+		}
+	}
+}
+
+func TestBuildRecorrenciaOcorrenciasSkipsPastAndStopsAtHorizon(t *testing.T) {
+	loc := agendamentoLocation()
+	recorrencia := models.AgendamentoRecorrencia{
+		Frequencia: models.AgendamentoRecorrenciaSemanal,
+		DataInicio: time.Date(2026, 4, 6, 0, 0, 0, 0, loc),
+		Horario:    "01:00",
+	}
+
+	desde := time.Date(2026, 4, 10, 12, 0, 0, 0, loc)
+	horarios, err := buildRecorrenciaOcorrencias(recorrencia, desde, desde.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(horarios) != 2 {
+		t.Fatalf("expected 2 occurrences inside the horizon, got %d", len(horarios))
+	}
+	if !horarios[0].Equal(time.Date(2026, 4, 14, 1, 0, 0, 0, loc)) {
+		t.Fatalf("expected late-night slot on the following day, got %v", horarios[0])
+	}
+}
+
+func TestRecorrenciaIncluiData(t *testing.T) {
+	loc := agendamentoLocation()
+	recorrencia := models.AgendamentoRecorrencia{
+		Frequencia: models.AgendamentoRecorrenciaQuinzenal,
+		DataInicio: time.Date(2026, 4, 6, 0, 0, 0, 0, loc),
+		Horario:    "20:00",
+	}
+
+	if !recorrenciaIncluiData(recorrencia, time.Date(2026, 4, 20, 0, 0, 0, 0, loc)) {
+		t.Fatal("expected date two weeks after start to be included")
+	}
+	if recorrenciaIncluiData(recorrencia, time.Date(2026, 4, 13, 0, 0, 0, 0, loc)) {
+		t.Fatal("expected off-week date to be excluded")
+	}
+	if recorrenciaIncluiData(recorrencia, time.Date(2026, 3, 23, 0, 0, 0, 0, loc)) {
+		t.Fatal("expected date before start to be excluded")
+	}
+}
+
+func TestRecorrenciaDataOperacionalUsesPreviousDayForLateNightSlots(t *testing.T) {
+	loc := agendamentoLocation()
+	data := agendamentoDataOperacional(time.Date(2026, 4, 14, 1, 0, 0, 0, loc))
+	if !data.Equal(time.Date(2026, 4, 13, 0, 0, 0, 0, loc)) {
+		t.Fatalf("expected operational date 2026-04-13, got %v", data)
+	}
+}
+
+// TestUpdateRecorrenciaKeepsTeamsAndGameMode needs a disposable Postgres
+// database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestUpdateRecorrenciaKeepsTeamsAndGameMode(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	const ownerUserID = 1
+	service := newAgendamentoService()
+	input := models.CreateAgendamentoRecorrenciaInput{
+		IDCampo:        1,
+		Frequencia:     models.AgendamentoRecorrenciaSemanal,
+		DataInicio:     agendamentoDataOperacional(agendamentoNow().AddDate(0, 0, 7)),
+		Horario:        "20:00",
+		DuracaoMinutos: 60,
+		Jogadores:      10,
+		Pagamento:      "pix",
+		Time1:          "Azul",
+		Time2:          "Branco",
+		ModoDeJogo:     "society",
+	}
+	criada, err := service.CreateRecorrencia(ctx, ownerUserID, input)
+	if err != nil {
+		t.Fatalf("failed to create recorrencia: %v", err)
+	}
+	if len(criada.Criados) == 0 {
+		t.Fatalf("expected occurrences to be created, got %+v", criada)
+	}
+
+	input.Time2 = "Vermelho"
+	input.ModoDeJogo = "futsal"
+	if _, err := service.UpdateRecorrencia(ctx, ownerUserID, criada.Recorrencia.ID, input); err != nil {
+		t.Fatalf("failed to update recorrencia: %v", err)
+	}
+
+	detalhe, err := service.GetRecorrencia(ctx, ownerUserID, criada.Recorrencia.ID)
+	if err != nil {
+		t.Fatalf("failed to reload recorrencia: %v", err)
+	}
+	for _, ocorrencia := range detalhe.Ocorrencias {
+		if ocorrencia.Time1 != "Azul" || ocorrencia.Time2 != "Vermelho" || ocorrencia.ModoDeJogo != "futsal" {
+			t.Fatalf("expected the edit to reach every occurrence, got %+v", ocorrencia)
+		}
+	}
+}
+
+// TestMaterializarRecorrenciasAtivasExtendsOpenSeries needs a disposable
+// Postgres database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestMaterializarRecorrenciasAtivasExtendsOpenSeries(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	dataInicio := agendamentoDataOperacional(agendamentoNow().AddDate(0, 0, 1))
+	statements := []string{
+		fmt.Sprintf(`INSERT INTO %s.agendamento_recorrencias (id_usuario, id_campo, frequencia, data_inicio, horario, jogadores, pagamento, status, criado_em, duracao_minutos)
+			VALUES (1, 1, 'semanal', $1, '20:00', 10, 'pix', 'ativa', NOW(), 60)`, schema),
+		fmt.Sprintf(`INSERT INTO %s.agendamento_recorrencias (id_usuario, id_campo, frequencia, data_inicio, horario, jogadores, pagamento, status, criado_em, duracao_minutos)
+			VALUES (1, 1, 'semanal', $1, '18:00', 10, 'pix', 'cancelada', NOW(), 60)`, schema),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement, dataInicio); err != nil {
+			t.Fatalf("failed to prepare recorrencias: %v", err)
+		}
+	}
+
+	service := newAgendamentoService()
+	criados, err := service.MaterializarRecorrenciasAtivas(ctx, agendamentoNow())
+	if err != nil {
+		t.Fatalf("expected the materialization to succeed, got %v", err)
+	}
+	if criados == 0 {
+		t.Fatal("expected the active recorrencia to be booked ahead")
+	}
+
+	var cancelada int
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s.agendamentos WHERE id_recorrencia = 2`, schema)).Scan(&cancelada); err != nil {
+		t.Fatalf("failed to count occurrences: %v", err)
+	}
+	if cancelada != 0 {
+		t.Fatalf("expected the cancelled recorrencia to stay empty, got %d occurrences", cancelada)
+	}
+
+	criados, err = service.MaterializarRecorrenciasAtivas(ctx, agendamentoNow())
+	if err != nil {
+		t.Fatalf("expected the second run to succeed, got %v", err)
+	}
+	if criados != 0 {
+		t.Fatalf("expected the second run to create nothing, got %d", criados)
+	}
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+type agendamentoRecorrenciaRequest struct {
+	CampoID         agendamentoInt `json:"campo_id"`
+	IDCampo         agendamentoInt `json:"id_campo"`
+	Frequencia      string         `json:"frequencia"`
+	DataInicio      string         `json:"data_inicio"`
+	DataFim         string         `json:"data_fim"`
+	Horario         string         `json:"horario"`
+	DuracaoMinutos  agendamentoInt `json:"duracao_minutos"`
+	Jogadores       agendamentoInt `json:"jogadores"`
+	Pagamento       string         `json:"pagamento"`
+	NomeSolicitante string         `json:"nome_solicitante"`
+	Time1           string         `json:"time1"`
+	Time2           string         `json:"time2"`
+	ModoDeJogo      string         `json:"modo_de_jogo"`
+}
+
+type agendamentoRecorrenciaResponse struct {
+	ID              int    `json:"id"`
+	IDCampo         int    `json:"id_campo"`
+	IDArena         int    `json:"id_arena,omitempty"`
+	NomeCampo       string `json:"nome_campo,omitempty"`
+	NomeArena       string `json:"nome_arena,omitempty"`
+	Frequencia      string `json:"frequencia"`
+	DataInicio      string `json:"data_inicio"`
+	DataFim         string `json:"data_fim,omitempty"`
+	Horario         string `json:"horario"`
+	DuracaoMinutos  int    `json:"duracao_minutos"`
+	Jogadores       int    `json:"jogadores"`
+	Pagamento       string `json:"pagamento"`
+	NomeSolicitante string `json:"nome_solicitante,omitempty"`
+	Time1           string `json:"time1,omitempty"`
+	Time2           string `json:"time2,omitempty"`
+	ModoDeJogo      string `json:"modo_de_jogo,omitempty"`
+	Status          string `json:"status"`
+	CriadoEm        string `json:"criado_em,omitempty"`
+}
+
+type recorrenciaConflitoResponse struct {
+	Horario string `json:"horario"`
+	Motivo  string `json:"motivo"`
+}
+
+func CriarRecorrenciaAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	input, err := parseAgendamentoRecorrenciaRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	result, err := service.CreateRecorrencia(r.Context(), userID, input)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	response := newAgendamentoRecorrenciaResultResponse(result)
+	response["message"] = "Recorrencia criada com sucesso"
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func GetRecorrenciasAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	service := newAgendamentoService()
+	recorrencias, err := service.ListRecorrencias(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Erro ao buscar recorrencias", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]agendamentoRecorrenciaResponse, 0, len(recorrencias))
+	for _, recorrencia := range recorrencias {
+		response = append(response, newAgendamentoRecorrenciaResponse(recorrencia))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func GetRecorrenciaAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	recorrenciaID, err := resolveRecorrenciaID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	detalhe, err := service.GetRecorrencia(r.Context(), userID, recorrenciaID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"recorrencia": newAgendamentoRecorrenciaResponse(detalhe.Recorrencia),
+		"ocorrencias": newAgendamentoResponses(detalhe.Ocorrencias),
+	})
+}
+
+func EditarRecorrenciaAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	recorrenciaID, err := resolveRecorrenciaID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	input, err := parseAgendamentoRecorrenciaRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	result, err := service.UpdateRecorrencia(r.Context(), userID, recorrenciaID, input)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	response := newAgendamentoRecorrenciaResultResponse(result)
+	response["message"] = "Recorrencia atualizada com sucesso"
+	writeJSON(w, http.StatusOK, response)
+}
+
+func CancelarRecorrenciaAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	recorrenciaID, err := resolveRecorrenciaID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	result, err := service.CancelRecorrencia(r.Context(), userID, recorrenciaID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	response := newAgendamentoRecorrenciaResultResponse(result)
+	response["message"] = "Recorrencia cancelada com sucesso"
+	writeJSON(w, http.StatusOK, response)
+}
+
+func MaterializarRecorrenciaAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	recorrenciaID, err := resolveRecorrenciaID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	result, err := service.MaterializarRecorrencia(r.Context(), userID, recorrenciaID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	response := newAgendamentoRecorrenciaResultResponse(result)
+	response["message"] = "Ocorrencias geradas com sucesso"
+	writeJSON(w, http.StatusOK, response)
+}
+
+func PularOcorrenciaRecorrencia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	recorrenciaID, err := resolveRecorrenciaID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	agendamentoID, err := strconv.Atoi(strings.TrimSpace(mux.Vars(r)["id_agendamento"]))
+	if err != nil || agendamentoID <= 0 {
+		http.Error(w, "ID do agendamento invalido", http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	result, err := service.PularOcorrenciaRecorrencia(r.Context(), userID, recorrenciaID, agendamentoID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message":     "Ocorrencia cancelada com sucesso",
+		"agendamento": newAgendamentoResponse(result.Agendamento),
+		"notificacao": result.Notificacao,
+	})
+}
+
+func parseAgendamentoRecorrenciaRequest(r *http.Request) (models.CreateAgendamentoRecorrenciaInput, error) {
+	var request agendamentoRecorrenciaRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return models.CreateAgendamentoRecorrenciaInput{}, errors.New("Erro ao decodificar JSON")
+	}
+
+	campoID := request.CampoID
+	if campoID <= 0 {
+		campoID = request.IDCampo
+	}
+	if campoID <= 0 {
+		return models.CreateAgendamentoRecorrenciaInput{}, errors.New("Campo e obrigatorio")
+	}
+
+	frequenciaRaw := strings.TrimSpace(request.Frequencia)
+	if frequenciaRaw == "" {
+		frequenciaRaw = string(models.AgendamentoRecorrenciaSemanal)
+	}
+	frequencia, ok := models.NormalizeAgendamentoRecorrenciaFrequencia(frequenciaRaw)
+	if !ok {
+		return models.CreateAgendamentoRecorrenciaInput{}, errors.New("Frequencia invalida")
+	}
+
+	dataInicio, err := parseRecorrenciaData(request.DataInicio)
+	if err != nil {
+		return models.CreateAgendamentoRecorrenciaInput{}, errors.New("Data de inicio invalida. Use o formato YYYY-MM-DD")
+	}
+
+	var dataFim *time.Time
+	if strings.TrimSpace(request.DataFim) != "" {
+		parsed, err := parseRecorrenciaData(request.DataFim)
+		if err != nil {
+			return models.CreateAgendamentoRecorrenciaInput{}, errors.New("Data de fim invalida. Use o formato YYYY-MM-DD")
+		}
+		dataFim = &parsed
+	}
+
+	horario, err := normalizeCampoHorario(request.Horario)
+	if err != nil {
+		return models.CreateAgendamentoRecorrenciaInput{}, errors.New("Formato de horario invalido")
+	}
+
+	return models.CreateAgendamentoRecorrenciaInput{
+		IDCampo:         int(campoID),
+		Frequencia:      frequencia,
+		DataInicio:      dataInicio,
+		DataFim:         dataFim,
+		Horario:         horario,
+		DuracaoMinutos:  models.NormalizeAgendamentoDuracao(int(request.DuracaoMinutos)),
+		Jogadores:       int(request.Jogadores),
+		Pagamento:       strings.TrimSpace(request.Pagamento),
+		NomeSolicitante: strings.TrimSpace(request.NomeSolicitante),
+		Time1:           strings.TrimSpace(request.Time1),
+		Time2:           strings.TrimSpace(request.Time2),
+		ModoDeJogo:      strings.TrimSpace(request.ModoDeJogo),
+	}, nil
+}
+
+func parseRecorrenciaData(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, errors.New("data obrigatoria")
+	}
+
+	return time.ParseInLocation("2006-01-02", raw, agendamentoLocation())
+}
+
+func resolveRecorrenciaID(r *http.Request) (int, error) {
+	rawID := strings.TrimSpace(mux.Vars(r)["id"])
+	if rawID == "" {
+		return 0, errors.New("ID da recorrencia e obrigatorio")
+	}
+
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id <= 0 {
+		return 0, errors.New("ID da recorrencia invalido")
+	}
+
+	return id, nil
+}
+
+func newAgendamentoRecorrenciaResponse(recorrencia models.AgendamentoRecorrencia) agendamentoRecorrenciaResponse {
+	response := agendamentoRecorrenciaResponse{
+		ID:              recorrencia.ID,
+		IDCampo:         recorrencia.IDCampo,
+		IDArena:         recorrencia.IDArena,
+		NomeCampo:       recorrencia.NomeCampo,
+		NomeArena:       recorrencia.NomeArena,
+		Frequencia:      string(recorrencia.Frequencia),
+		DataInicio:      recorrencia.DataInicio.Format("2006-01-02"),
+		Horario:         recorrencia.Horario,
+		DuracaoMinutos:  recorrencia.DuracaoMinutos,
+		Jogadores:       recorrencia.Jogadores,
+		Pagamento:       recorrencia.Pagamento,
+		NomeSolicitante: recorrencia.NomeSolicitante,
+		Time1:           recorrencia.Time1,
+		Time2:           recorrencia.Time2,
+		ModoDeJogo:      recorrencia.ModoDeJogo,
+		Status:          string(recorrencia.Status),
+		CriadoEm:        formatAgendamentoDateTime(recorrencia.CriadoEm),
+	}
+
+	if recorrencia.DataFim != nil {
+		response.DataFim = recorrencia.DataFim.Format("2006-01-02")
+	}
+
+	return response
+}
+
+func newAgendamentoRecorrenciaResultResponse(result agendamentoRecorrenciaResult) map[string]any {
+	conflitos := make([]recorrenciaConflitoResponse, 0, len(result.Conflitos))
+	for _, conflito := range result.Conflitos {
+		conflitos = append(conflitos, recorrenciaConflitoResponse{
+			Horario: formatAgendamentoDateTime(conflito.Horario),
+			Motivo:  conflito.Motivo,
+		})
+	}
+
+	return map[string]any{
+		"recorrencia": newAgendamentoRecorrenciaResponse(result.Recorrencia),
+		"criados":     newAgendamentoResponses(result.Criados),
+		"atualizados": newAgendamentoResponses(result.Atualizados),
+		"cancelados":  newAgendamentoResponses(result.Cancelados),
+		"conflitos":   conflitos,
+	}
+}
+
+func newAgendamentoResponses(agendamentos []models.Agendamento) []agendamentoResponse {
+	response := make([]agendamentoResponse, 0, len(agendamentos))
+	for _, agendamento := range agendamentos {
+		response = append(response, newAgendamentoResponse(agendamento))
+	}
+
+	return response
+}
@@ -0,0 +1,616 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+var (
+	errRemarcacaoAgendamentoManual = errors.New("agendamento manual deve ser editado diretamente")
+	errRemarcacaoNecessaria        = errors.New("agendamento de jogador exige remarcacao")
+	errRemarcacaoSemAlteracao      = errors.New("remarcacao sem alteracao de horario")
+	errRemarcacaoHorarioPassado    = errors.New("horario da remarcacao ja passou")
+	errRemarcacaoPendente          = errors.New("agendamento ja possui remarcacao pendente")
+	errRemarcacaoNaoEncontrada     = errors.New("remarcacao nao encontrada")
+	errRemarcacaoNaoPendente       = errors.New("remarcacao nao esta pendente")
+)
+
+type agendamentoRemarcacaoRequest struct {
+	IDCampo        agendamentoInt `json:"id_campo"`
+	Horario        string         `json:"horario"`
+	DuracaoMinutos agendamentoInt `json:"duracao_minutos"`
+}
+
+type agendamentoRemarcacaoResponse struct {
+	ID                     int     `json:"id"`
+	IDAgendamento          int     `json:"id_agendamento"`
+	IDCampoAnterior        int     `json:"id_campo_anterior"`
+	HorarioAnterior        string  `json:"horario_anterior"`
+	DuracaoMinutosAnterior int     `json:"duracao_minutos_anterior"`
+	IDCampo                int     `json:"id_campo"`
+	Horario                string  `json:"horario"`
+	DuracaoMinutos         int     `json:"duracao_minutos"`
+	ValorTotal             float64 `json:"valor_total"`
+	Status                 string  `json:"status"`
+	CriadoEm               string  `json:"criado_em"`
+	RespondidaEm           string  `json:"respondida_em,omitempty"`
+}
+
+type remarcacaoInput struct {
+	IDCampo        int
+	Horario        time.Time
+	DuracaoMinutos int
+}
+
+const agendamentoRemarcacaoColumns = `
+	id, id_agendamento, id_campo_anterior, horario_anterior, duracao_minutos_anterior,
+	id_campo, horario, duracao_minutos, valor_total, status, criado_em, respondida_em`
+
+// remarcacoesPendentesQuery selects the slots held by open proposals of
+// agendamentos that can still move. campos is the placeholder list of the
+// caller's query, which binds the window as $1 and $2.
+func remarcacoesPendentesQuery(campos string) string {
+	return fmt.Sprintf(`
+		SELECT r.id_campo, r.horario, r.duracao_minutos
+		FROM %s r
+		JOIN %s ag ON ag.id_agendamento = r.id_agendamento
+		WHERE r.id_campo IN (%s)
+		  AND r.status = '%s'
+		  AND ag.status IN ('%s', '%s')
+		  AND r.horario < $2
+		  AND r.horario + r.duracao_minutos * INTERVAL '1 minute' > $1
+	`, agendamentoRemarcacoesTableName(), agendamentosTableName(), campos,
+		models.AgendamentoRemarcacaoPendente, models.AgendamentoStatusPedido, models.AgendamentoStatusAgendado)
+}
+
+func (repository agendamentoRepository) hasRemarcacaoConflict(ctx context.Context, campoID int, inicio time.Time, fim time.Time, excludeAgendamentoID *int) (bool, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM (%s) reservas", remarcacoesPendentesQuery("$3"))
+	args := []any{inicio, fim, campoID}
+	if excludeAgendamentoID != nil {
+		query = fmt.Sprintf("SELECT COUNT(*) FROM (%s AND r.id_agendamento != $4) reservas", remarcacoesPendentesQuery("$3"))
+		args = append(args, *excludeAgendamentoID)
+	}
+
+	var count int
+	if err := repository.database().QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func scanAgendamentoRemarcacao(scanner agendamentoScanner) (models.AgendamentoRemarcacao, error) {
+	var (
+		remarcacao   models.AgendamentoRemarcacao
+		status       string
+		respondidaEm sql.NullTime
+	)
+
+	err := scanner.Scan(
+		&remarcacao.ID,
+		&remarcacao.IDAgendamento,
+		&remarcacao.IDCampoAnterior,
+		&remarcacao.HorarioAnterior,
+		&remarcacao.DuracaoMinutosAnterior,
+		&remarcacao.IDCampo,
+		&remarcacao.Horario,
+		&remarcacao.DuracaoMinutos,
+		&remarcacao.ValorTotal,
+		&status,
+		&remarcacao.CriadoEm,
+		&respondidaEm,
+	)
+	if err != nil {
+		return models.AgendamentoRemarcacao{}, err
+	}
+
+	remarcacao.Status = models.AgendamentoRemarcacaoStatus(status)
+	if respondidaEm.Valid {
+		remarcacao.RespondidaEm = &respondidaEm.Time
+	}
+
+	return remarcacao, nil
+}
+
+func (repository agendamentoRepository) insertRemarcacao(ctx context.Context, agendamento models.Agendamento, input remarcacaoInput, valorTotal float64) (models.AgendamentoRemarcacao, error) {
+	return scanAgendamentoRemarcacao(repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (
+			id_agendamento,
+			id_campo_anterior,
+			horario_anterior,
+			duracao_minutos_anterior,
+			id_campo,
+			horario,
+			duracao_minutos,
+			valor_total
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING %s
+	`, agendamentoRemarcacoesTableName(), agendamentoRemarcacaoColumns),
+		agendamento.ID,
+		agendamento.IDCampo,
+		agendamento.Horario,
+		models.NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos),
+		input.IDCampo,
+		input.Horario,
+		input.DuracaoMinutos,
+		valorTotal,
+	))
+}
+
+// getRemarcacaoPendente locks the open proposal of an agendamento.
+func (repository agendamentoRepository) getRemarcacaoPendente(ctx context.Context, agendamentoID int) (models.AgendamentoRemarcacao, error) {
+	return scanAgendamentoRemarcacao(repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s WHERE id_agendamento = $1 AND status = $2 FOR UPDATE
+	`, agendamentoRemarcacaoColumns, agendamentoRemarcacoesTableName()), agendamentoID, string(models.AgendamentoRemarcacaoPendente)))
+}
+
+func (repository agendamentoRepository) getRemarcacaoForUpdate(ctx context.Context, remarcacaoID int) (models.AgendamentoRemarcacao, error) {
+	return scanAgendamentoRemarcacao(repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s WHERE id = $1 FOR UPDATE
+	`, agendamentoRemarcacaoColumns, agendamentoRemarcacoesTableName()), remarcacaoID))
+}
+
+func (repository agendamentoRepository) getUltimaRemarcacao(ctx context.Context, agendamentoID int) (models.AgendamentoRemarcacao, error) {
+	return scanAgendamentoRemarcacao(repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s WHERE id_agendamento = $1 ORDER BY criado_em DESC, id DESC LIMIT 1
+	`, agendamentoRemarcacaoColumns, agendamentoRemarcacoesTableName()), agendamentoID))
+}
+
+func (repository agendamentoRepository) updateRemarcacaoStatus(ctx context.Context, remarcacaoID int, status models.AgendamentoRemarcacaoStatus) (time.Time, error) {
+	var respondidaEm time.Time
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		UPDATE %s SET status = $2, respondida_em = NOW() WHERE id = $1 RETURNING respondida_em
+	`, agendamentoRemarcacoesTableName()), remarcacaoID, string(status)).Scan(&respondidaEm)
+	return respondidaEm, err
+}
+
+func (repository agendamentoRepository) getByID(ctx context.Context, agendamentoID int) (models.Agendamento, error) {
+	return scanAgendamento(repository.database().QueryRowContext(ctx, agendamentoBaseSelectQuery()+`
+		WHERE a.id_agendamento = $1
+	`, agendamentoID))
+}
+
+// agendamentoEditMudaHorario reports whether an edit moves the booking. Bookings
+// made by a jogador only move through a remarcacao they accept.
+func agendamentoEditMudaHorario(agendamento models.Agendamento, input models.CreateAgendamentoInput) bool {
+	return input.IDCampo != agendamento.IDCampo ||
+		!input.Horario.Equal(agendamento.Horario) ||
+		input.DuracaoMinutos != models.NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos)
+}
+
+// agendamentoRemarcacaoDestino fills what the owner left out with the current
+// slot and refuses a proposal that changes nothing.
+func agendamentoRemarcacaoDestino(agendamento models.Agendamento, input remarcacaoInput) (remarcacaoInput, error) {
+	if input.IDCampo <= 0 {
+		input.IDCampo = agendamento.IDCampo
+	}
+	if input.DuracaoMinutos <= 0 {
+		input.DuracaoMinutos = agendamento.DuracaoMinutos
+	}
+	input.DuracaoMinutos = models.NormalizeAgendamentoDuracao(input.DuracaoMinutos)
+
+	if input.IDCampo == agendamento.IDCampo &&
+		input.Horario.Equal(agendamento.Horario) &&
+		input.DuracaoMinutos == models.NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos) {
+		return remarcacaoInput{}, errRemarcacaoSemAlteracao
+	}
+
+	return input, nil
+}
+
+// ProporRemarcacao holds the new slot and asks the jogador. The agendamento
+// keeps its current slot until the proposal is accepted.
+func (service agendamentoService) ProporRemarcacao(ctx context.Context, ownerUserID int, agendamentoID int, input remarcacaoInput) (models.Agendamento, error) {
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Agendamento{}, errAgendamentoNaoEncontrado
+		}
+		return models.Agendamento{}, err
+	}
+
+	if err := models.ValidarAcaoAgendamento(agendamento.Status, models.AgendamentoAcaoRemarcar); err != nil {
+		return models.Agendamento{}, err
+	}
+	if agendamento.OrigemAgendamento == models.AgendamentoOrigemManual {
+		return models.Agendamento{}, errRemarcacaoAgendamentoManual
+	}
+	if input, err = agendamentoRemarcacaoDestino(agendamento, input); err != nil {
+		return models.Agendamento{}, err
+	}
+	if !input.Horario.After(agendamentoNow()) {
+		return models.Agendamento{}, errRemarcacaoHorarioPassado
+	}
+
+	err = service.inTransaction(ctx, func(service agendamentoService) error {
+		if _, err := service.repository.lockAgendamentoStatus(ctx, agendamentoID); err != nil {
+			return err
+		}
+		if _, err := service.repository.getRemarcacaoPendente(ctx, agendamentoID); err == nil {
+			return errRemarcacaoPendente
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		campo, err := service.validateCampoAndSchedule(ctx, models.CreateAgendamentoInput{
+			IDCampo:        input.IDCampo,
+			Horario:        input.Horario,
+			Jogadores:      agendamento.Jogadores,
+			DuracaoMinutos: input.DuracaoMinutos,
+		}, ownerUserID, &agendamentoID)
+		if err != nil {
+			return err
+		}
+
+		valorBruto := calcularValorAgendamento(campo.ValorHora, campo.RegrasPreco, input.Horario, input.DuracaoMinutos)
+		valorDesconto, err := service.descontoCupom(ctx, agendamento, valorBruto)
+		if err != nil {
+			return err
+		}
+		valorTotal := models.ArredondarCentavos(valorBruto - valorDesconto)
+		remarcacao, err := service.repository.insertRemarcacao(ctx, agendamento, input, valorTotal)
+		if err != nil {
+			return err
+		}
+		agendamento.Remarcacao = &remarcacao
+
+		return service.publicarRemarcacao(ctx, agendamento, models.WebhookEventoRemarcacaoProposta, true)
+	})
+	if err != nil {
+		return models.Agendamento{}, err
+	}
+
+	return agendamento, nil
+}
+
+func (service agendamentoService) CancelarRemarcacao(ctx context.Context, ownerUserID int, agendamentoID int) (models.Agendamento, error) {
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Agendamento{}, errAgendamentoNaoEncontrado
+		}
+		return models.Agendamento{}, err
+	}
+
+	err = service.inTransaction(ctx, func(service agendamentoService) error {
+		remarcacao, err := service.repository.getRemarcacaoPendente(ctx, agendamentoID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errRemarcacaoNaoEncontrada
+			}
+			return err
+		}
+
+		return service.encerrarRemarcacao(ctx, &agendamento, remarcacao, models.AgendamentoRemarcacaoCancelada, "", true)
+	})
+	if err != nil {
+		return models.Agendamento{}, err
+	}
+
+	return agendamento, nil
+}
+
+func (service agendamentoService) GetRemarcacao(ctx context.Context, ownerUserID int, agendamentoID int) (models.AgendamentoRemarcacao, error) {
+	if _, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AgendamentoRemarcacao{}, errAgendamentoNaoEncontrado
+		}
+		return models.AgendamentoRemarcacao{}, err
+	}
+
+	remarcacao, err := service.repository.getUltimaRemarcacao(ctx, agendamentoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AgendamentoRemarcacao{}, errRemarcacaoNaoEncontrada
+	}
+	return remarcacao, err
+}
+
+// ResponderRemarcacao is the jogador's answer. Accepting moves the
+// agendamento to the held slot, repriced, and releases the original one;
+// rejecting only releases the held slot.
+func (service agendamentoService) ResponderRemarcacao(ctx context.Context, remarcacaoID int, aceitar bool) (models.Agendamento, error) {
+	var agendamento models.Agendamento
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		remarcacao, err := service.repository.getRemarcacaoForUpdate(ctx, remarcacaoID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errRemarcacaoNaoEncontrada
+			}
+			return err
+		}
+		if remarcacao.Status != models.AgendamentoRemarcacaoPendente {
+			return errRemarcacaoNaoPendente
+		}
+
+		if _, err := service.repository.lockAgendamentoStatus(ctx, remarcacao.IDAgendamento); err != nil {
+			return err
+		}
+		agendamento, err = service.repository.getByID(ctx, remarcacao.IDAgendamento)
+		if err != nil {
+			return err
+		}
+		if !hasAgendamentoAtor(ctx) {
+			ctx = withAgendamentoAtor(ctx, agendamentoAtor{
+				Tipo:   models.AgendamentoAtorIntegracao,
+				Origem: string(agendamento.OrigemAgendamento),
+			})
+		}
+
+		if !aceitar {
+			return service.encerrarRemarcacao(ctx, &agendamento, remarcacao, models.AgendamentoRemarcacaoRejeitada, models.WebhookEventoRemarcacaoRejeitada, false)
+		}
+
+		if err := models.ValidarAcaoAgendamento(agendamento.Status, models.AgendamentoAcaoRemarcar); err != nil {
+			return err
+		}
+		campo, err := service.validateCampoAndSchedule(ctx, models.CreateAgendamentoInput{
+			IDCampo:        remarcacao.IDCampo,
+			Horario:        remarcacao.Horario,
+			Jogadores:      agendamento.Jogadores,
+			DuracaoMinutos: remarcacao.DuracaoMinutos,
+		}, 0, &agendamento.ID)
+		if err != nil {
+			return err
+		}
+
+		totalPago, err := service.repository.sumPayments(ctx, agendamento.ID)
+		if err != nil {
+			return err
+		}
+		valorBruto := calcularValorAgendamento(campo.ValorHora, campo.RegrasPreco, remarcacao.Horario, remarcacao.DuracaoMinutos)
+		valorDesconto, err := service.descontoCupom(ctx, agendamento, valorBruto)
+		if err != nil {
+			return err
+		}
+		valorTotal := models.ArredondarCentavos(valorBruto - valorDesconto)
+		valorRestante, pago, statusDePagamento := resolveFinancialState(valorTotal, totalPago, agendamento.Pago, agendamento.StatusDePagamento)
+		if err := service.repository.update(ctx, agendamento.ID, agendamentoUpdateInput{
+			IDCampo:         remarcacao.IDCampo,
+			Horario:         remarcacao.Horario,
+			DuracaoMinutos:  remarcacao.DuracaoMinutos,
+			Jogadores:       agendamento.Jogadores,
+			Pagamento:       agendamento.Pagamento,
+			Pago:            pago,
+			NomeSolicitante: agendamento.NomeSolicitante,
+			ValorBruto:      valorBruto,
+			ValorDesconto:   valorDesconto,
+			ValorTotal:      valorTotal,
+			ValorRestante:   valorRestante,
+		}); err != nil {
+			return err
+		}
+
+		agendamento.IDCampo = remarcacao.IDCampo
+		agendamento.IDArena = campo.IDArena
+		agendamento.NomeCampo = campo.NomeCampo
+		agendamento.NomeArena = campo.NomeArena
+		agendamento.Horario = remarcacao.Horario
+		agendamento.DuracaoMinutos = remarcacao.DuracaoMinutos
+		agendamento.ValorBruto = valorBruto
+		agendamento.ValorDesconto = valorDesconto
+		agendamento.ValorTotal = valorTotal
+		agendamento.ValorRestante = valorRestante
+		agendamento.Pago = pago
+		agendamento.StatusDePagamento = statusDePagamento
+		return service.encerrarRemarcacao(ctx, &agendamento, remarcacao, models.AgendamentoRemarcacaoAceita, models.WebhookEventoRemarcacaoAceita, false)
+	})
+	if err != nil {
+		return models.Agendamento{}, mapScheduleWriteError(err)
+	}
+
+	return agendamento, nil
+}
+
+// encerrarRemarcacao closes a pending proposal and publishes the outcome.
+// An empty evento skips the webhooks.
+func (service agendamentoService) encerrarRemarcacao(
+	ctx context.Context,
+	agendamento *models.Agendamento,
+	remarcacao models.AgendamentoRemarcacao,
+	status models.AgendamentoRemarcacaoStatus,
+	evento models.WebhookEvento,
+	notificarJogador bool,
+) error {
+	respondidaEm, err := service.repository.updateRemarcacaoStatus(ctx, remarcacao.ID, status)
+	if err != nil {
+		return err
+	}
+
+	remarcacao.Status = status
+	remarcacao.RespondidaEm = &respondidaEm
+	agendamento.Remarcacao = &remarcacao
+	return service.publicarRemarcacao(ctx, *agendamento, evento, notificarJogador)
+}
+
+// publicarRemarcacao records the proposal's step in the history and tells the
+// integrations; the jogador callback is only sent for the owner's moves.
+func (service agendamentoService) publicarRemarcacao(ctx context.Context, agendamento models.Agendamento, evento models.WebhookEvento, notificarJogador bool) error {
+	remarcacao := agendamento.Remarcacao
+	if err := service.recordStatusEvento(ctx, agendamento.ID, agendamentoStatusEventoInput{
+		Tipo:           models.AgendamentoStatusEventoRemarcacao,
+		StatusAnterior: &agendamento.Status,
+		StatusNovo:     agendamento.Status,
+		Detalhes: map[string]any{
+			"id_remarcacao":    remarcacao.ID,
+			"status":           remarcacao.Status,
+			"id_campo":         remarcacao.IDCampo,
+			"horario":          formatAgendamentoDateTime(remarcacao.Horario),
+			"duracao_minutos":  remarcacao.DuracaoMinutos,
+			"horario_anterior": formatAgendamentoDateTime(remarcacao.HorarioAnterior),
+		},
+	}); err != nil {
+		return err
+	}
+
+	if evento != "" {
+		if err := service.dispatchWebhookEvento(ctx, evento, agendamento); err != nil {
+			return err
+		}
+	}
+	if !notificarJogador || agendamento.OrigemAgendamento == models.AgendamentoOrigemManual {
+		return nil
+	}
+
+	_, err := service.enqueueJogadorStatusChange(ctx, agendamento)
+	return err
+}
+
+func ProporRemarcacaoAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var request agendamentoRemarcacaoRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Erro ao decodificar JSON", http.StatusBadRequest)
+		return
+	}
+	horario, err := parseAgendamentoHorario(request.Horario)
+	if err != nil {
+		http.Error(w, "Formato de horario invalido", http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	agendamento, err := service.ProporRemarcacao(r.Context(), userID, agendamentoID, remarcacaoInput{
+		IDCampo:        int(request.IDCampo),
+		Horario:        horario,
+		DuracaoMinutos: int(request.DuracaoMinutos),
+	})
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"message":     "Remarcacao enviada ao jogador",
+		"agendamento": newAgendamentoResponse(agendamento),
+		"remarcacao":  newAgendamentoRemarcacaoResponse(*agendamento.Remarcacao),
+	})
+}
+
+func GetRemarcacaoAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	remarcacao, err := service.GetRemarcacao(r.Context(), userID, agendamentoID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAgendamentoRemarcacaoResponse(remarcacao))
+}
+
+func CancelarRemarcacaoAgendamento(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID, err := resolveAgendamentoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	agendamento, err := service.CancelarRemarcacao(r.Context(), userID, agendamentoID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message":    "Remarcacao cancelada",
+		"remarcacao": newAgendamentoRemarcacaoResponse(*agendamento.Remarcacao),
+	})
+}
+
+func AceitarRemarcacaoJogador(w http.ResponseWriter, r *http.Request) {
+	responderRemarcacaoJogador(w, r, true)
+}
+
+func RejeitarRemarcacaoJogador(w http.ResponseWriter, r *http.Request) {
+	responderRemarcacaoJogador(w, r, false)
+}
+
+func responderRemarcacaoJogador(w http.ResponseWriter, r *http.Request, aceitar bool) {
+	if err := validateJogadorIntegrationRequest(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	remarcacaoID := parsePositiveIntParam(mux.Vars(r)["id"])
+	if remarcacaoID == 0 {
+		http.Error(w, "ID da remarcacao invalido", http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	agendamento, err := service.ResponderRemarcacao(r.Context(), remarcacaoID, aceitar)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	message := "Remarcacao rejeitada"
+	if aceitar {
+		message = "Remarcacao aceita"
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message":     message,
+		"agendamento": newAgendamentoResponse(agendamento),
+		"remarcacao":  newAgendamentoRemarcacaoResponse(*agendamento.Remarcacao),
+	})
+}
+
+func newAgendamentoRemarcacaoResponse(remarcacao models.AgendamentoRemarcacao) agendamentoRemarcacaoResponse {
+	response := agendamentoRemarcacaoResponse{
+		ID:                     remarcacao.ID,
+		IDAgendamento:          remarcacao.IDAgendamento,
+		IDCampoAnterior:        remarcacao.IDCampoAnterior,
+		HorarioAnterior:        formatAgendamentoDateTime(remarcacao.HorarioAnterior),
+		DuracaoMinutosAnterior: remarcacao.DuracaoMinutosAnterior,
+		IDCampo:                remarcacao.IDCampo,
+		Horario:                formatAgendamentoDateTime(remarcacao.Horario),
+		DuracaoMinutos:         remarcacao.DuracaoMinutos,
+		ValorTotal:             remarcacao.ValorTotal,
+		Status:                 string(remarcacao.Status),
+		CriadoEm:               formatAgendamentoDateTime(remarcacao.CriadoEm),
+	}
+	if remarcacao.RespondidaEm != nil {
+		response.RespondidaEm = formatAgendamentoDateTime(*remarcacao.RespondidaEm)
+	}
+
+	return response
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestAgendamentoRemarcacaoDestino(t *testing.T) {
+	horario := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	agendamento := models.Agendamento{IDCampo: 3, Horario: horario, DuracaoMinutos: 60}
+
+	destino, err := agendamentoRemarcacaoDestino(agendamento, remarcacaoInput{Horario: horario.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if destino.IDCampo != 3 || destino.DuracaoMinutos != 60 {
+		t.Fatalf("expected current campo and duracao to be kept, got %+v", destino)
+	}
+
+	if _, err := agendamentoRemarcacaoDestino(agendamento, remarcacaoInput{Horario: horario}); !errors.Is(err, errRemarcacaoSemAlteracao) {
+		t.Fatalf("expected errRemarcacaoSemAlteracao, got %v", err)
+	}
+
+	destino, err = agendamentoRemarcacaoDestino(agendamento, remarcacaoInput{IDCampo: 4, Horario: horario})
+	if err != nil || destino.IDCampo != 4 {
+		t.Fatalf("expected a campo change to be accepted, got %+v/%v", destino, err)
+	}
+}
+
+func TestAgendamentoEditMudaHorario(t *testing.T) {
+	horario := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	agendamento := models.Agendamento{IDCampo: 3, Horario: horario, DuracaoMinutos: 60}
+
+	testCases := []struct {
+		name  string
+		input models.CreateAgendamentoInput
+		muda  bool
+	}{
+		{
+			name:  "same slot",
+			input: models.CreateAgendamentoInput{IDCampo: 3, Horario: horario, DuracaoMinutos: 60, Jogadores: 12},
+		},
+		{
+			name:  "other horario",
+			input: models.CreateAgendamentoInput{IDCampo: 3, Horario: horario.Add(30 * time.Minute), DuracaoMinutos: 60},
+			muda:  true,
+		},
+		{
+			name:  "other campo",
+			input: models.CreateAgendamentoInput{IDCampo: 4, Horario: horario, DuracaoMinutos: 60},
+			muda:  true,
+		},
+		{
+			name:  "longer game",
+			input: models.CreateAgendamentoInput{IDCampo: 3, Horario: horario, DuracaoMinutos: 90},
+			muda:  true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if muda := agendamentoEditMudaHorario(agendamento, testCase.input); muda != testCase.muda {
+				t.Fatalf("expected %v, got %v", testCase.muda, muda)
+			}
+		})
+	}
+}
+
+func TestRemarcacoesPendentesQueryOnlyHoldsOpenBookings(t *testing.T) {
+	query := remarcacoesPendentesQuery("$3")
+
+	for _, trecho := range []string{"r.id_campo IN ($3)", "r.status = 'pendente'", "ag.status IN ('pedido', 'agendado')"} {
+		if !strings.Contains(query, trecho) {
+			t.Fatalf("expected query to contain %q, got %s", trecho, query)
+		}
+	}
+}
@@ -11,33 +11,65 @@ import (
 	"github.com/danpi/marca_ai_backend/internal/models"
 )
 
-type agendamentoRepository struct{}
+type agendamentoRepository struct {
+	db agendamentoDB
+	// commitHooks collects work that must only happen once the surrounding
+	// transaction has committed.
+	commitHooks *[]func()
+}
+
+type agendamentoDB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
 
 type campoAgendamentoSnapshot struct {
-	IDCampo           int
-	IDArena           int
-	OwnerUserID       int
-	NomeCampo         string
-	NomeArena         string
-	ValorHora         float64
-	MaxJogadores      int
-	Ativo             bool
-	CampoEmManutencao bool
-	ArenaEmManutencao bool
+	IDCampo             int
+	IDArena             int
+	OwnerUserID         int
+	NomeCampo           string
+	NomeArena           string
+	ValorHora           float64
+	MaxJogadores        int
+	Ativo               bool
+	CampoEmManutencao   bool
+	ArenaEmManutencao   bool
+	HorariosDisponiveis []string
+	HorariosSemana      map[int][]string
+	RegrasPreco         []models.CampoRegraPreco
 }
 
 type agendamentoUpdateInput struct {
 	IDCampo         int
 	Horario         time.Time
+	DuracaoMinutos  int
 	Jogadores       int
 	Pagamento       string
 	Pago            bool
 	NomeSolicitante string
+	Time1           string
+	Time2           string
+	ModoDeJogo      string
+	ValorBruto      float64
+	ValorDesconto   float64
 	ValorTotal      float64
 	ValorRestante   float64
 }
 
+// agendamentoCreateValores is the price of a new booking; IDCupom is set when
+// a coupon took ValorDesconto off ValorBruto.
+type agendamentoCreateValores struct {
+	ValorBruto    float64
+	ValorDesconto float64
+	ValorTotal    float64
+	ValorRestante float64
+	IDCupom       *int
+}
+
 type agendamentoFinancialUpdate struct {
+	ValorTotal        *float64
+	ValorRetido       *float64
 	ValorRestante     float64
 	Pago              bool
 	StatusDePagamento bool
@@ -48,7 +80,59 @@ func newAgendamentoRepository() agendamentoRepository {
 	return agendamentoRepository{}
 }
 
-func (agendamentoRepository) loadCampoSnapshot(ctx context.Context, campoID int) (campoAgendamentoSnapshot, error) {
+func (repository agendamentoRepository) database() agendamentoDB {
+	if repository.db != nil {
+		return repository.db
+	}
+
+	return config.DB
+}
+
+func (repository agendamentoRepository) inTransaction(ctx context.Context, fn func(agendamentoRepository) error) error {
+	if _, ok := repository.db.(*sql.Tx); ok {
+		return fn(repository)
+	}
+
+	tx, err := config.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var hooks []func()
+	if err := fn(agendamentoRepository{db: tx, commitHooks: &hooks}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		hook()
+	}
+
+	return nil
+}
+
+// afterCommit runs fn once the current transaction commits, or right away
+// outside of one.
+func (repository agendamentoRepository) afterCommit(fn func()) {
+	if repository.commitHooks == nil {
+		fn()
+		return
+	}
+
+	*repository.commitHooks = append(*repository.commitHooks, fn)
+}
+
+// lockCampoSchedule serializes booking writes for a campo until the current
+// transaction ends, so the conflict check and the write see the same agenda.
+func (repository agendamentoRepository) lockCampoSchedule(ctx context.Context, campoID int) error {
+	_, err := repository.database().ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('agendamentos'), $1)`, campoID)
+	return err
+}
+
+func (repository agendamentoRepository) loadCampoSnapshot(ctx context.Context, campoID int) (campoAgendamentoSnapshot, error) {
 	optionalColumns, err := loadCampoOptionalColumns(ctx)
 	if err != nil {
 		return campoAgendamentoSnapshot{}, err
@@ -78,6 +162,8 @@ func (agendamentoRepository) loadCampoSnapshot(ctx context.Context, campoID int)
 			%s,
 			c.max_jogadores,
 			%s,
+			%s,
+			%s,
 			%s
 		FROM %s c
 		JOIN %s a ON a.id = c.id_arena
@@ -86,13 +172,16 @@ func (agendamentoRepository) loadCampoSnapshot(ctx context.Context, campoID int)
 		optionalCampoSelectExpression("c", "valor_hora", optionalColumns.ValorHora),
 		optionalCampoSelectExpression("c", "ativo", optionalColumns.Ativo),
 		arenaMaintenanceExpr,
+		optionalCampoSelectExpression("c", "horarios_disponiveis", optionalColumns.HorariosDisponiveis),
+		optionalCampoSelectExpression("c", "horarios_semana", optionalColumns.HorariosSemana),
 		campoTable,
 		arenasTable,
 	)
 
 	var snapshot campoAgendamentoSnapshot
 	var maxJogadores sql.NullInt64
-	err = config.DB.QueryRowContext(ctx, query, campoID).Scan(
+	var horariosRaw, semanaRaw string
+	err = repository.database().QueryRowContext(ctx, query, campoID).Scan(
 		&snapshot.IDCampo,
 		&snapshot.IDArena,
 		&snapshot.OwnerUserID,
@@ -102,6 +191,8 @@ func (agendamentoRepository) loadCampoSnapshot(ctx context.Context, campoID int)
 		&maxJogadores,
 		&snapshot.Ativo,
 		&snapshot.ArenaEmManutencao,
+		&horariosRaw,
+		&semanaRaw,
 	)
 	if err != nil {
 		return campoAgendamentoSnapshot{}, err
@@ -111,33 +202,42 @@ func (agendamentoRepository) loadCampoSnapshot(ctx context.Context, campoID int)
 		snapshot.MaxJogadores = int(maxJogadores.Int64)
 	}
 	snapshot.CampoEmManutencao = !snapshot.Ativo
+	snapshot.HorariosDisponiveis = decodeCampoHorarios(horariosRaw)
+	snapshot.HorariosSemana = decodeCampoHorariosSemana(semanaRaw)
+
+	regras, err := loadCampoRegrasPreco(ctx, repository.database(), []int{campoID})
+	if err != nil {
+		return campoAgendamentoSnapshot{}, err
+	}
+	snapshot.RegrasPreco = regras[campoID]
 	return snapshot, nil
 }
 
-func (agendamentoRepository) hasScheduleConflict(ctx context.Context, campoID int, horario time.Time, excludeID *int) (bool, error) {
+func (repository agendamentoRepository) hasScheduleConflict(ctx context.Context, campoID int, inicio time.Time, fim time.Time, excludeID *int) (bool, error) {
 	query := fmt.Sprintf(`
 		SELECT COUNT(*)
 		FROM %s
 		WHERE id_campo = $1
-		  AND horario = $2
-		  AND status != $3
-	`, agendamentosTableName())
+		  AND horario < $3
+		  AND horario + COALESCE(duracao_minutos, %d) * INTERVAL '1 minute' > $2
+		  AND status != $4
+	`, agendamentosTableName(), models.AgendamentoDuracaoPadraoMinutos)
 
-	args := []any{campoID, horario, string(models.AgendamentoStatusCancelado)}
+	args := []any{campoID, inicio, fim, string(models.AgendamentoStatusCancelado)}
 	if excludeID != nil {
-		query += " AND id_agendamento != $4"
+		query += " AND id_agendamento != $5"
 		args = append(args, *excludeID)
 	}
 
 	var count int
-	if err := config.DB.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+	if err := repository.database().QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
 		return false, err
 	}
 
 	return count > 0, nil
 }
 
-func (agendamentoRepository) create(ctx context.Context, input models.CreateAgendamentoInput, status models.AgendamentoStatus, valorTotal float64, valorRestante float64) (models.Agendamento, error) {
+func (repository agendamentoRepository) create(ctx context.Context, input models.CreateAgendamentoInput, status models.AgendamentoStatus, valores agendamentoCreateValores) (models.Agendamento, error) {
 	createdAt := agendamentoNow()
 	query := fmt.Sprintf(`
 		INSERT INTO %s (
@@ -156,14 +256,19 @@ func (agendamentoRepository) create(ctx context.Context, input models.CreateAgen
 			valor_restante,
 			time1,
 			time2,
-			modo_de_jogo
+			modo_de_jogo,
+			id_recorrencia,
+			duracao_minutos,
+			valor_bruto,
+			valor_desconto,
+			id_cupom
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13, NULLIF($14, ''), NULLIF($15, ''), NULLIF($16, ''))
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13, NULLIF($14, ''), NULLIF($15, ''), NULLIF($16, ''), $17, $18, $19, $20, $21)
 		RETURNING id_agendamento, criado_em
 	`, agendamentosTableName())
 
 	var agendamento models.Agendamento
-	err := config.DB.QueryRowContext(
+	err := repository.database().QueryRowContext(
 		ctx,
 		query,
 		nullableIntValue(input.IDUsuario),
@@ -177,11 +282,16 @@ func (agendamentoRepository) create(ctx context.Context, input models.CreateAgen
 		string(status),
 		input.Pago,
 		string(input.OrigemAgendamento),
-		valorTotal,
-		valorRestante,
+		valores.ValorTotal,
+		valores.ValorRestante,
 		input.Time1,
 		input.Time2,
 		input.ModoDeJogo,
+		nullableIntValue(input.IDRecorrencia),
+		input.DuracaoMinutos,
+		valores.ValorBruto,
+		valores.ValorDesconto,
+		nullableIntValue(valores.IDCupom),
 	).Scan(&agendamento.ID, &agendamento.CriadoEm)
 	if err != nil {
 		return models.Agendamento{}, err
@@ -199,15 +309,20 @@ func (agendamentoRepository) create(ctx context.Context, input models.CreateAgen
 	agendamento.StatusDePagamento = input.Pago
 	agendamento.Status = status
 	agendamento.OrigemAgendamento = input.OrigemAgendamento
-	agendamento.ValorTotal = valorTotal
-	agendamento.ValorRestante = valorRestante
+	agendamento.ValorBruto = valores.ValorBruto
+	agendamento.ValorDesconto = valores.ValorDesconto
+	agendamento.ValorTotal = valores.ValorTotal
+	agendamento.ValorRestante = valores.ValorRestante
+	agendamento.IDCupom = valores.IDCupom
 	agendamento.Time1 = input.Time1
 	agendamento.Time2 = input.Time2
 	agendamento.ModoDeJogo = input.ModoDeJogo
+	agendamento.IDRecorrencia = input.IDRecorrencia
+	agendamento.DuracaoMinutos = input.DuracaoMinutos
 	return agendamento, nil
 }
 
-func (agendamentoRepository) loadJogadorNomeByID(ctx context.Context, jogadorID int) (string, error) {
+func (repository agendamentoRepository) loadJogadorNomeByID(ctx context.Context, jogadorID int) (string, error) {
 	query := fmt.Sprintf(`
 		SELECT COALESCE(nome, ''), COALESCE(sobrenome, '')
 		FROM %s
@@ -216,7 +331,7 @@ func (agendamentoRepository) loadJogadorNomeByID(ctx context.Context, jogadorID
 
 	var nome string
 	var sobrenome string
-	if err := config.DB.QueryRowContext(ctx, query, jogadorID).Scan(&nome, &sobrenome); err != nil {
+	if err := repository.database().QueryRowContext(ctx, query, jogadorID).Scan(&nome, &sobrenome); err != nil {
 		return "", err
 	}
 
@@ -236,34 +351,22 @@ func buildNomeCompleto(nome string, sobrenome string) string {
 	return strings.Join(parts, " ")
 }
 
-func (repository agendamentoRepository) listByOwner(ctx context.Context, ownerUserID int, status *models.AgendamentoStatus) ([]models.Agendamento, error) {
-	where := []string{"ar.id_usuario = $1"}
-	args := []any{ownerUserID}
-
-	if status != nil {
-		where = append(where, fmt.Sprintf("a.status = $%d", len(args)+1))
-		args = append(args, string(*status))
-	}
+func (repository agendamentoRepository) listByOwner(ctx context.Context, ownerUserID int, filtro agendamentoListFiltro) (agendamentoListPage, error) {
+	where, args := buildAgendamentoListWhere(filtro, []any{ownerUserID})
 
 	query := fmt.Sprintf(`
 		%s
 		WHERE %s
-		ORDER BY
-			CASE a.status
-				WHEN 'pedido' THEN 0
-				WHEN 'agendado' THEN 1
-				WHEN 'em_andamento' THEN 2
-				WHEN 'aguardando_pagamento' THEN 3
-				WHEN 'concluido' THEN 4
-				WHEN 'cancelado' THEN 5
-				ELSE 6
-			END,
-			a.horario DESC
-	`, agendamentoBaseSelectQuery(), strings.Join(where, " AND "))
-
-	rows, err := config.DB.QueryContext(ctx, query, args...)
+		ORDER BY %s
+	`, agendamentoBaseSelectQuery(), strings.Join(where, " AND "), agendamentoListOrderBy(filtro.Ordem))
+	if filtro.paginado() {
+		args = append(args, filtro.PorPagina, (filtro.Pagina-1)*filtro.PorPagina)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := repository.database().QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return agendamentoListPage{}, err
 	}
 	defer rows.Close()
 
@@ -271,16 +374,32 @@ func (repository agendamentoRepository) listByOwner(ctx context.Context, ownerUs
 	for rows.Next() {
 		agendamento, scanErr := scanAgendamento(rows)
 		if scanErr != nil {
-			return nil, scanErr
+			return agendamentoListPage{}, scanErr
 		}
 		agendamentos = append(agendamentos, agendamento)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return agendamentoListPage{}, err
+	}
+
+	page := agendamentoListPage{Agendamentos: agendamentos, Total: len(agendamentos)}
+	if !filtro.paginado() {
+		return page, nil
+	}
+
+	countWhere, countArgs := buildAgendamentoListWhere(filtro, []any{ownerUserID})
+	if err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM %s a
+		JOIN %s c ON a.id_campo = c.id_campo
+		JOIN %s ar ON c.id_arena = ar.id
+		WHERE %s
+	`, agendamentosTableName(), campoTableName(), arenasTableName(), strings.Join(countWhere, " AND ")), countArgs...).Scan(&page.Total); err != nil {
+		return agendamentoListPage{}, err
 	}
 
-	return agendamentos, nil
+	return page, nil
 }
 
 func (repository agendamentoRepository) getByIDForOwner(ctx context.Context, agendamentoID int, ownerUserID int) (models.Agendamento, error) {
@@ -291,12 +410,12 @@ func (repository agendamentoRepository) getByIDForOwner(ctx context.Context, age
 	`, agendamentoBaseSelectQuery())
 
 	return scanAgendamento(
-		config.DB.QueryRowContext(ctx, query, agendamentoID, ownerUserID),
+		repository.database().QueryRowContext(ctx, query, agendamentoID, ownerUserID),
 	)
 }
 
-func (agendamentoRepository) updateStatus(ctx context.Context, agendamentoID int, status models.AgendamentoStatus) error {
-	_, err := config.DB.ExecContext(
+func (repository agendamentoRepository) updateStatus(ctx context.Context, agendamentoID int, status models.AgendamentoStatus) error {
+	_, err := repository.database().ExecContext(
 		ctx,
 		fmt.Sprintf(`UPDATE %s SET status = $1 WHERE id_agendamento = $2`, agendamentosTableName()),
 		string(status),
@@ -305,8 +424,8 @@ func (agendamentoRepository) updateStatus(ctx context.Context, agendamentoID int
 	return err
 }
 
-func (agendamentoRepository) update(ctx context.Context, agendamentoID int, input agendamentoUpdateInput) error {
-	_, err := config.DB.ExecContext(ctx, fmt.Sprintf(`
+func (repository agendamentoRepository) update(ctx context.Context, agendamentoID int, input agendamentoUpdateInput) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
 		UPDATE %s
 		SET
 			id_campo = $1,
@@ -317,8 +436,14 @@ func (agendamentoRepository) update(ctx context.Context, agendamentoID int, inpu
 			status_de_pagamento = $6,
 			nome_solicitante = NULLIF($7, ''),
 			valor_total = $8,
-			valor_restante = $9
-		WHERE id_agendamento = $10
+			valor_restante = $9,
+			duracao_minutos = $10,
+			valor_bruto = $11,
+			valor_desconto = $12,
+			time1 = NULLIF($13, ''),
+			time2 = NULLIF($14, ''),
+			modo_de_jogo = NULLIF($15, '')
+		WHERE id_agendamento = $16
 	`, agendamentosTableName()),
 		input.IDCampo,
 		input.Horario,
@@ -329,13 +454,19 @@ func (agendamentoRepository) update(ctx context.Context, agendamentoID int, inpu
 		input.NomeSolicitante,
 		input.ValorTotal,
 		input.ValorRestante,
+		input.DuracaoMinutos,
+		input.ValorBruto,
+		input.ValorDesconto,
+		input.Time1,
+		input.Time2,
+		input.ModoDeJogo,
 		agendamentoID,
 	)
 	return err
 }
 
-func (agendamentoRepository) startCronometro(ctx context.Context, agendamentoID int, inicioUnix int64) error {
-	_, err := config.DB.ExecContext(ctx, fmt.Sprintf(`
+func (repository agendamentoRepository) startCronometro(ctx context.Context, agendamentoID int, inicioUnix int64) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
 		UPDATE %s
 		SET
 			inicio_cronometro = $1,
@@ -346,18 +477,38 @@ func (agendamentoRepository) startCronometro(ctx context.Context, agendamentoID
 	return err
 }
 
-func (agendamentoRepository) finishCronometro(ctx context.Context, agendamentoID int, fim time.Time, status models.AgendamentoStatus) error {
-	_, err := config.DB.ExecContext(ctx, fmt.Sprintf(`
+// finishCronometro only ends a running clock; false means the agendamento
+// was no longer em_andamento.
+func (repository agendamentoRepository) finishCronometro(ctx context.Context, agendamentoID int, fim time.Time, status models.AgendamentoStatus) (bool, error) {
+	result, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
 		UPDATE %s
 		SET
 			fim_cronometro = $1,
 			status = $2
 		WHERE id_agendamento = $3
-	`, agendamentosTableName()), fim, string(status), agendamentoID)
+		  AND status = $4
+	`, agendamentosTableName()), fim, string(status), agendamentoID, string(models.AgendamentoStatusEmAndamento))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (repository agendamentoRepository) updateHoraExtra(ctx context.Context, agendamentoID int, horaExtra models.AgendamentoHoraExtra) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET
+			hora_extra_minutos = $1,
+			hora_extra_blocos = $2,
+			hora_extra_valor = $3
+		WHERE id_agendamento = $4
+	`, agendamentosTableName()), horaExtra.MinutosExcedentes, horaExtra.Blocos, horaExtra.Valor, agendamentoID)
 	return err
 }
 
-func (agendamentoRepository) updateFinancialState(ctx context.Context, agendamentoID int, input agendamentoFinancialUpdate) error {
+func (repository agendamentoRepository) updateFinancialState(ctx context.Context, agendamentoID int, input agendamentoFinancialUpdate) error {
 	query := fmt.Sprintf(`
 		UPDATE %s
 		SET
@@ -368,18 +519,26 @@ func (agendamentoRepository) updateFinancialState(ctx context.Context, agendamen
 
 	args := []any{input.ValorRestante, input.Pago, input.StatusDePagamento}
 	if input.Status != nil {
-		query += ", status = $4"
 		args = append(args, string(*input.Status))
+		query += fmt.Sprintf(", status = $%d", len(args))
+	}
+	if input.ValorTotal != nil {
+		args = append(args, *input.ValorTotal)
+		query += fmt.Sprintf(", valor_total = $%d", len(args))
+	}
+	if input.ValorRetido != nil {
+		args = append(args, *input.ValorRetido)
+		query += fmt.Sprintf(", valor_retido = $%d", len(args))
 	}
 
 	query += fmt.Sprintf(" WHERE id_agendamento = $%d", len(args)+1)
 	args = append(args, agendamentoID)
 
-	_, err := config.DB.ExecContext(ctx, query, args...)
+	_, err := repository.database().ExecContext(ctx, query, args...)
 	return err
 }
 
-func (agendamentoRepository) insertPayment(ctx context.Context, agendamentoID int, input models.RegistrarPagamentoInput) (models.AgendamentoPagamento, error) {
+func (repository agendamentoRepository) insertPayment(ctx context.Context, agendamentoID int, input models.RegistrarPagamentoInput) (models.AgendamentoPagamento, error) {
 	dataPagamento := agendamentoNow()
 	query := fmt.Sprintf(`
 		INSERT INTO %s (
@@ -387,14 +546,15 @@ func (agendamentoRepository) insertPayment(ctx context.Context, agendamentoID in
 			id_usuario,
 			valor_pago,
 			forma_pagamento,
-			data_pagamento
+			data_pagamento,
+			id_participante
 		)
-		VALUES ($1, $2, $3, $4, $5)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, data_pagamento
 	`, pagamentosPorAgendamentoTableName())
 
 	var pagamento models.AgendamentoPagamento
-	err := config.DB.QueryRowContext(
+	err := repository.database().QueryRowContext(
 		ctx,
 		query,
 		agendamentoID,
@@ -402,6 +562,7 @@ func (agendamentoRepository) insertPayment(ctx context.Context, agendamentoID in
 		input.ValorPago,
 		input.FormaPagamento,
 		dataPagamento,
+		nullableIntValue(input.IDParticipante),
 	).Scan(&pagamento.ID, &pagamento.DataPagamento)
 	if err != nil {
 		return models.AgendamentoPagamento{}, err
@@ -409,12 +570,13 @@ func (agendamentoRepository) insertPayment(ctx context.Context, agendamentoID in
 
 	pagamento.IDAgendamento = agendamentoID
 	pagamento.IDUsuario = input.IDUsuario
+	pagamento.IDParticipante = input.IDParticipante
 	pagamento.ValorPago = input.ValorPago
 	pagamento.FormaPagamento = input.FormaPagamento
 	return pagamento, nil
 }
 
-func (agendamentoRepository) listPayments(ctx context.Context, agendamentoID int) ([]models.AgendamentoPagamento, error) {
+func (repository agendamentoRepository) listPayments(ctx context.Context, agendamentoID int) ([]models.AgendamentoPagamento, error) {
 	query := fmt.Sprintf(`
 		SELECT
 			p.id,
@@ -425,14 +587,17 @@ func (agendamentoRepository) listPayments(ctx context.Context, agendamentoID int
 			p.data_pagamento,
 			COALESCE(u.nome, ''),
 			COALESCE(u.sobrenome, ''),
-			COALESCE(u.email, '')
+			COALESCE(u.email, ''),
+			p.id_participante,
+			COALESCE(pt.nome, '')
 		FROM %s p
 		LEFT JOIN %s u ON u.id = p.id_usuario
+		LEFT JOIN %s pt ON pt.id = p.id_participante
 		WHERE p.id_agendamento = $1
 		ORDER BY p.data_pagamento ASC, p.id ASC
-	`, pagamentosPorAgendamentoTableName(), usuarioJogadorTableName())
+	`, pagamentosPorAgendamentoTableName(), usuarioJogadorTableName(), agendamentoParticipantesTableName())
 
-	rows, err := config.DB.QueryContext(ctx, query, agendamentoID)
+	rows, err := repository.database().QueryContext(ctx, query, agendamentoID)
 	if err != nil {
 		return nil, err
 	}
@@ -441,8 +606,9 @@ func (agendamentoRepository) listPayments(ctx context.Context, agendamentoID int
 	pagamentos := make([]models.AgendamentoPagamento, 0)
 	for rows.Next() {
 		var (
-			pagamento models.AgendamentoPagamento
-			idUsuario sql.NullInt64
+			pagamento      models.AgendamentoPagamento
+			idUsuario      sql.NullInt64
+			idParticipante sql.NullInt64
 		)
 
 		if err := rows.Scan(
@@ -455,6 +621,8 @@ func (agendamentoRepository) listPayments(ctx context.Context, agendamentoID int
 			&pagamento.NomeUsuario,
 			&pagamento.SobrenomeUsuario,
 			&pagamento.EmailUsuario,
+			&idParticipante,
+			&pagamento.NomeParticipante,
 		); err != nil {
 			return nil, err
 		}
@@ -463,6 +631,7 @@ func (agendamentoRepository) listPayments(ctx context.Context, agendamentoID int
 			value := int(idUsuario.Int64)
 			pagamento.IDUsuario = &value
 		}
+		pagamento.IDParticipante = nullIntPointer(idParticipante)
 
 		pagamentos = append(pagamentos, pagamento)
 	}
@@ -474,9 +643,9 @@ func (agendamentoRepository) listPayments(ctx context.Context, agendamentoID int
 	return pagamentos, nil
 }
 
-func (agendamentoRepository) sumPayments(ctx context.Context, agendamentoID int) (float64, error) {
+func (repository agendamentoRepository) sumPayments(ctx context.Context, agendamentoID int) (float64, error) {
 	var total sql.NullFloat64
-	err := config.DB.QueryRowContext(
+	err := repository.database().QueryRowContext(
 		ctx,
 		fmt.Sprintf(`SELECT COALESCE(SUM(valor_pago), 0) FROM %s WHERE id_agendamento = $1`, pagamentosPorAgendamentoTableName()),
 		agendamentoID,
@@ -516,11 +685,27 @@ func agendamentoBaseSelectQuery() string {
 			a.fim_cronometro,
 			COALESCE(a.time1, ''),
 			COALESCE(a.time2, ''),
-			COALESCE(a.modo_de_jogo, '')
-		FROM %s a
-		JOIN %s c ON a.id_campo = c.id_campo
-		JOIN %s ar ON c.id_arena = ar.id
-	`, agendamentosTableName(), campoTableName(), arenasTableName())
+			COALESCE(a.modo_de_jogo, ''),
+			a.id_recorrencia,
+			COALESCE(a.duracao_minutos, %[1]d),
+			COALESCE(a.hora_extra_minutos, 0),
+			COALESCE(a.hora_extra_blocos, 0),
+			COALESCE(a.hora_extra_valor, 0),
+			(SELECT cp.inicio FROM %[5]s cp WHERE cp.id_agendamento = a.id_agendamento AND cp.fim IS NULL),
+			COALESCE((
+				SELECT SUM(EXTRACT(EPOCH FROM cp.fim - cp.inicio))
+				FROM %[5]s cp
+				WHERE cp.id_agendamento = a.id_agendamento AND cp.fim IS NOT NULL
+			), 0)::BIGINT,
+			COALESCE(a.valor_bruto, a.valor_total, 0),
+			COALESCE(a.valor_desconto, 0),
+			a.id_cupom,
+			(SELECT cu.codigo FROM %[6]s cu WHERE cu.id = a.id_cupom),
+			a.valor_retido
+		FROM %[2]s a
+		JOIN %[3]s c ON a.id_campo = c.id_campo
+		JOIN %[4]s ar ON c.id_arena = ar.id
+	`, models.AgendamentoDuracaoPadraoMinutos, agendamentosTableName(), campoTableName(), arenasTableName(), agendamentoCronometroPausasTableName(), cuponsTableName())
 }
 
 type agendamentoScanner interface {
@@ -545,6 +730,12 @@ func scanAgendamento(scanner agendamentoScanner) (models.Agendamento, error) {
 		time1             sql.NullString
 		time2             sql.NullString
 		modoDeJogo        sql.NullString
+		idRecorrencia     sql.NullInt64
+		horaExtra         models.AgendamentoHoraExtra
+		pausadoEm         sql.NullTime
+		idCupom           sql.NullInt64
+		codigoCupom       sql.NullString
+		valorRetido       sql.NullFloat64
 	)
 
 	err := scanner.Scan(
@@ -570,6 +761,18 @@ func scanAgendamento(scanner agendamentoScanner) (models.Agendamento, error) {
 		&time1,
 		&time2,
 		&modoDeJogo,
+		&idRecorrencia,
+		&agendamento.DuracaoMinutos,
+		&horaExtra.MinutosExcedentes,
+		&horaExtra.Blocos,
+		&horaExtra.Valor,
+		&pausadoEm,
+		&agendamento.CronometroPausasSegundos,
+		&agendamento.ValorBruto,
+		&agendamento.ValorDesconto,
+		&idCupom,
+		&codigoCupom,
+		&valorRetido,
 	)
 	if err != nil {
 		return models.Agendamento{}, err
@@ -616,6 +819,25 @@ func scanAgendamento(scanner agendamentoScanner) (models.Agendamento, error) {
 	if modoDeJogo.Valid {
 		agendamento.ModoDeJogo = modoDeJogo.String
 	}
+	if idRecorrencia.Valid {
+		value := int(idRecorrencia.Int64)
+		agendamento.IDRecorrencia = &value
+	}
+	if horaExtra.MinutosExcedentes > 0 || horaExtra.Valor > 0 {
+		agendamento.HoraExtra = &horaExtra
+	}
+	if pausadoEm.Valid {
+		value := pausadoEm.Time
+		agendamento.CronometroPausadoEm = &value
+	}
+	agendamento.IDCupom = nullIntPointer(idCupom)
+	if codigoCupom.Valid {
+		agendamento.CodigoCupom = codigoCupom.String
+	}
+	if valorRetido.Valid {
+		value := valorRetido.Float64
+		agendamento.ValorRetido = &value
+	}
 
 	if normalizedStatus, ok := models.NormalizeAgendamentoStatus(statusRaw); ok {
 		agendamento.Status = normalizedStatus
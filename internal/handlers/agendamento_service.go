@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var (
@@ -15,22 +17,36 @@ var (
 	errAgendamentoNaoEncontrado          = errors.New("agendamento nao encontrado")
 	errAgendamentoJogadorNaoEncontrado   = errors.New("jogador nao encontrado")
 	errAgendamentoOrigemInvalida         = errors.New("origem do agendamento invalida")
-	errAgendamentoStatusInvalido         = errors.New("status do agendamento invalido")
 	errAgendamentoHorarioIndisponivel    = errors.New("horario indisponivel")
 	errAgendamentoCampoIndisponivel      = errors.New("campo indisponivel para agendamento")
 	errAgendamentoJogadoresInvalidos     = errors.New("quantidade de jogadores invalida")
 	errAgendamentoPedidoNaoPendente      = errors.New("pedido nao esta pendente")
 	errAgendamentoCronometroNaoIniciado  = errors.New("cronometro nao iniciado")
 	errAgendamentoCronometroNaoEncerrado = errors.New("cronometro nao encerrado")
+	errAgendamentoCronometroIniciado     = errors.New("cronometro ja iniciado")
+	errAgendamentoAindaNaoComecou        = errors.New("agendamento ainda nao comecou")
 	errAgendamentoConclusaoBloqueada     = errors.New("agendamento ainda possui saldo pendente")
 	errAgendamentoPagamentoInvalido      = errors.New("pagamento invalido")
 	errAgendamentoSemSaldoPendente       = errors.New("agendamento nao possui saldo pendente")
-	errAgendamentoEstadoOperacaoInvalido = errors.New("estado atual do agendamento nao permite esta operacao")
+	errAgendamentoDuracaoInvalida        = errors.New("duracao do agendamento invalida")
+	errAgendamentoForaDoHorario          = errors.New("horario fora do funcionamento do campo")
+	errAgendamentoHorarioBloqueado       = errors.New("horario bloqueado na agenda")
+	errAgendamentoNaoExterno             = errors.New("agendamento nao foi criado pela integracao")
+	errAgendamentoCancelamentoJogador    = errors.New("agendamento nao pode mais ser cancelado pelo jogador")
+)
+
+const pgExclusionViolation = "23P01"
+
+const (
+	agendamentoDuracaoMinimaMinutos = 30
+	agendamentoDuracaoMaximaMinutos = 8 * 60
+	agendamentoDuracaoPassoMinutos  = 15
 )
 
 type agendamentoMutationResult struct {
-	Agendamento models.Agendamento        `json:"agendamento"`
-	Notificacao jogadorNotificationResult `json:"notificacao"`
+	Agendamento  models.Agendamento              `json:"agendamento"`
+	Notificacao  jogadorNotificationResult       `json:"notificacao"`
+	Cancelamento *models.AgendamentoCancelamento `json:"cancelamento,omitempty"`
 }
 
 type agendamentoPagamentoMutationResult struct {
@@ -66,6 +82,12 @@ func (service agendamentoService) CreatePedidoExterno(ctx context.Context, input
 	}
 
 	input.IDUsuario = nil
+	if !hasAgendamentoAtor(ctx) {
+		ctx = withAgendamentoAtor(ctx, agendamentoAtor{
+			Tipo:   models.AgendamentoAtorIntegracao,
+			Origem: string(input.OrigemAgendamento),
+		})
+	}
 	input, err := resolveNomeSolicitanteFromJogador(ctx, input, service.repository.loadJogadorNomeByID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -77,13 +99,14 @@ func (service agendamentoService) CreatePedidoExterno(ctx context.Context, input
 	return service.create(ctx, input, 0, models.AgendamentoStatusPedido)
 }
 
-func (service agendamentoService) ListByOwner(ctx context.Context, ownerUserID int) ([]models.Agendamento, error) {
-	return service.repository.listByOwner(ctx, ownerUserID, nil)
+func (service agendamentoService) ListByOwner(ctx context.Context, ownerUserID int, filtro agendamentoListFiltro) (agendamentoListPage, error) {
+	return service.repository.listByOwner(ctx, ownerUserID, filtro)
 }
 
-func (service agendamentoService) ListPedidosByOwner(ctx context.Context, ownerUserID int) ([]models.Agendamento, error) {
+func (service agendamentoService) ListPedidosByOwner(ctx context.Context, ownerUserID int, filtro agendamentoListFiltro) (agendamentoListPage, error) {
 	status := models.AgendamentoStatusPedido
-	return service.repository.listByOwner(ctx, ownerUserID, &status)
+	filtro.Status = &status
+	return service.repository.listByOwner(ctx, ownerUserID, filtro)
 }
 
 func (service agendamentoService) Edit(ctx context.Context, ownerUserID int, agendamentoID int, input models.CreateAgendamentoInput) (models.Agendamento, error) {
@@ -95,48 +118,88 @@ func (service agendamentoService) Edit(ctx context.Context, ownerUserID int, age
 		return models.Agendamento{}, err
 	}
 
-	campo, err := service.validateCampoAndSchedule(ctx, input, ownerUserID, &agendamentoID)
-	if err != nil {
-		return models.Agendamento{}, err
+	if input.DuracaoMinutos <= 0 {
+		input.DuracaoMinutos = agendamentoAtual.DuracaoMinutos
 	}
-
-	totalPago, err := service.repository.sumPayments(ctx, agendamentoID)
-	if err != nil {
-		return models.Agendamento{}, err
+	input.DuracaoMinutos = models.NormalizeAgendamentoDuracao(input.DuracaoMinutos)
+	if agendamentoAtual.OrigemAgendamento != models.AgendamentoOrigemManual && agendamentoEditMudaHorario(agendamentoAtual, input) {
+		return models.Agendamento{}, errRemarcacaoNecessaria
 	}
 
-	valorTotal := campo.ValorHora
-	valorRestante, pago, statusDePagamento := resolveFinancialState(
-		valorTotal,
-		totalPago,
-		agendamentoAtual.Pago || input.Pago,
-		agendamentoAtual.StatusDePagamento || input.Pago,
+	var (
+		campo             campoAgendamentoSnapshot
+		valorBruto        float64
+		valorDesconto     float64
+		valorTotal        float64
+		valorRestante     float64
+		pago              bool
+		statusDePagamento bool
 	)
+	err = service.inTransaction(ctx, func(service agendamentoService) error {
+		var err error
+		campo, err = service.validateCampoAndSchedule(ctx, input, ownerUserID, &agendamentoID)
+		if err != nil {
+			return err
+		}
+
+		totalPago, err := service.repository.sumPayments(ctx, agendamentoID)
+		if err != nil {
+			return err
+		}
 
-	err = service.repository.update(ctx, agendamentoID, agendamentoUpdateInput{
-		IDCampo:         input.IDCampo,
-		Horario:         input.Horario,
-		Jogadores:       input.Jogadores,
-		Pagamento:       input.Pagamento,
-		Pago:            pago,
-		NomeSolicitante: input.NomeSolicitante,
-		ValorTotal:      valorTotal,
-		ValorRestante:   valorRestante,
+		valorBruto = calcularValorAgendamento(campo.ValorHora, campo.RegrasPreco, input.Horario, input.DuracaoMinutos)
+		valorDesconto, err = service.descontoCupom(ctx, agendamentoAtual, valorBruto)
+		if err != nil {
+			return err
+		}
+		valorTotal = models.ArredondarCentavos(valorBruto - valorDesconto)
+		if agendamentoAtual.HoraExtra != nil {
+			valorTotal += agendamentoAtual.HoraExtra.Valor
+		}
+		valorRestante, pago, statusDePagamento = resolveFinancialState(
+			valorTotal,
+			totalPago,
+			agendamentoAtual.Pago || input.Pago,
+			agendamentoAtual.StatusDePagamento || input.Pago,
+		)
+
+		return service.repository.update(ctx, agendamentoID, agendamentoUpdateInput{
+			IDCampo:         input.IDCampo,
+			Horario:         input.Horario,
+			DuracaoMinutos:  input.DuracaoMinutos,
+			Jogadores:       input.Jogadores,
+			Pagamento:       input.Pagamento,
+			Pago:            pago,
+			NomeSolicitante: input.NomeSolicitante,
+			Time1:           input.Time1,
+			Time2:           input.Time2,
+			ModoDeJogo:      input.ModoDeJogo,
+			ValorBruto:      valorBruto,
+			ValorDesconto:   valorDesconto,
+			ValorTotal:      valorTotal,
+			ValorRestante:   valorRestante,
+		})
 	})
 	if err != nil {
-		return models.Agendamento{}, err
+		return models.Agendamento{}, mapScheduleWriteError(err)
 	}
 
 	agendamentoAtual.IDCampo = input.IDCampo
 	agendamentoAtual.IDArena = campo.IDArena
 	agendamentoAtual.Horario = input.Horario
+	agendamentoAtual.DuracaoMinutos = input.DuracaoMinutos
 	agendamentoAtual.Jogadores = input.Jogadores
 	agendamentoAtual.Pagamento = input.Pagamento
 	agendamentoAtual.Pago = pago
 	agendamentoAtual.NomeSolicitante = input.NomeSolicitante
+	agendamentoAtual.Time1 = input.Time1
+	agendamentoAtual.Time2 = input.Time2
+	agendamentoAtual.ModoDeJogo = input.ModoDeJogo
 	agendamentoAtual.StatusDePagamento = statusDePagamento
 	agendamentoAtual.NomeCampo = campo.NomeCampo
 	agendamentoAtual.NomeArena = campo.NomeArena
+	agendamentoAtual.ValorBruto = valorBruto
+	agendamentoAtual.ValorDesconto = valorDesconto
 	agendamentoAtual.ValorTotal = valorTotal
 	agendamentoAtual.ValorRestante = valorRestante
 
@@ -144,16 +207,28 @@ func (service agendamentoService) Edit(ctx context.Context, ownerUserID int, age
 }
 
 func (service agendamentoService) UpdateStatus(ctx context.Context, ownerUserID int, agendamentoID int, status models.AgendamentoStatus) (agendamentoMutationResult, error) {
-	if status == models.AgendamentoStatusConcluido {
+	switch status {
+	case models.AgendamentoStatusConcluido:
 		return service.Concluir(ctx, ownerUserID, agendamentoID)
+	case models.AgendamentoStatusNaoCompareceu:
+		return service.MarcarNaoComparecimento(ctx, ownerUserID, agendamentoID)
 	}
 
-	switch status {
-	case models.AgendamentoStatusAgendado, models.AgendamentoStatusCancelado, models.AgendamentoStatusPedido:
-	default:
-		return agendamentoMutationResult{}, errAgendamentoStatusInvalido
+	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return agendamentoMutationResult{}, errAgendamentoNaoEncontrado
+		}
+		return agendamentoMutationResult{}, err
 	}
 
+	// Statuses with no owner action (pedido, em_andamento...) are refused by
+	// the transition table with the list of what is allowed.
+	acao, _ := models.AgendamentoAcaoParaStatus(status)
+	return service.transitionStatus(ctx, agendamento, acao, status)
+}
+
+func (service agendamentoService) AcceptPedido(ctx context.Context, ownerUserID int, agendamentoID int) (agendamentoMutationResult, error) {
 	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -162,10 +237,10 @@ func (service agendamentoService) UpdateStatus(ctx context.Context, ownerUserID
 		return agendamentoMutationResult{}, err
 	}
 
-	return service.transitionStatus(ctx, agendamento, status)
+	return service.transitionStatus(ctx, agendamento, models.AgendamentoAcaoAceitar, models.AgendamentoStatusAgendado)
 }
 
-func (service agendamentoService) AcceptPedido(ctx context.Context, ownerUserID int, agendamentoID int) (agendamentoMutationResult, error) {
+func (service agendamentoService) CancelPedido(ctx context.Context, ownerUserID int, agendamentoID int) (agendamentoMutationResult, error) {
 	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -178,11 +253,14 @@ func (service agendamentoService) AcceptPedido(ctx context.Context, ownerUserID
 		return agendamentoMutationResult{}, errAgendamentoPedidoNaoPendente
 	}
 
-	return service.transitionStatus(ctx, agendamento, models.AgendamentoStatusAgendado)
+	return service.transitionStatus(ctx, agendamento, models.AgendamentoAcaoCancelar, models.AgendamentoStatusCancelado)
 }
 
-func (service agendamentoService) CancelPedido(ctx context.Context, ownerUserID int, agendamentoID int) (agendamentoMutationResult, error) {
-	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
+// CancelarPeloJogador cancels a pedido or booking that came in through the
+// integration, before the game starts. The arena's cancellation policy applies
+// as it does for the owner.
+func (service agendamentoService) CancelarPeloJogador(ctx context.Context, agendamentoID int) (agendamentoMutationResult, error) {
+	agendamento, err := service.repository.getByID(ctx, agendamentoID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return agendamentoMutationResult{}, errAgendamentoNaoEncontrado
@@ -190,11 +268,26 @@ func (service agendamentoService) CancelPedido(ctx context.Context, ownerUserID
 		return agendamentoMutationResult{}, err
 	}
 
-	if agendamento.Status != models.AgendamentoStatusPedido {
-		return agendamentoMutationResult{}, errAgendamentoPedidoNaoPendente
+	switch agendamento.OrigemAgendamento {
+	case models.AgendamentoOrigemJogador, models.AgendamentoOrigemTimeXTime:
+	default:
+		return agendamentoMutationResult{}, errAgendamentoNaoExterno
 	}
 
-	return service.transitionStatus(ctx, agendamento, models.AgendamentoStatusCancelado)
+	switch agendamento.Status {
+	case models.AgendamentoStatusPedido, models.AgendamentoStatusAgendado, models.AgendamentoStatusCancelado:
+	default:
+		return agendamentoMutationResult{}, errAgendamentoCancelamentoJogador
+	}
+
+	if !hasAgendamentoAtor(ctx) {
+		ctx = withAgendamentoAtor(ctx, agendamentoAtor{
+			Tipo:   models.AgendamentoAtorIntegracao,
+			Origem: string(agendamento.OrigemAgendamento),
+		})
+	}
+
+	return service.transitionStatus(ctx, agendamento, models.AgendamentoAcaoCancelar, models.AgendamentoStatusCancelado)
 }
 
 func (service agendamentoService) IniciarCronometro(ctx context.Context, ownerUserID int, agendamentoID int) (models.Agendamento, error) {
@@ -206,50 +299,131 @@ func (service agendamentoService) IniciarCronometro(ctx context.Context, ownerUs
 		return models.Agendamento{}, err
 	}
 
-	if !canManageExecutionState(agendamento.Status) {
-		return models.Agendamento{}, errAgendamentoEstadoOperacaoInvalido
+	if err := models.ValidarTransicaoAgendamento(agendamento.Status, models.AgendamentoAcaoIniciarCronometro, models.AgendamentoStatusEmAndamento); err != nil {
+		return models.Agendamento{}, err
 	}
 
+	statusAnterior := agendamento.Status
 	inicioUnix := agendamentoNow().Unix()
-	if err := service.repository.startCronometro(ctx, agendamentoID, inicioUnix); err != nil {
+	err = service.inTransaction(ctx, func(service agendamentoService) error {
+		if err := service.repository.startCronometro(ctx, agendamentoID, inicioUnix); err != nil {
+			return err
+		}
+		if err := service.repository.deleteCronometroPausas(ctx, agendamentoID); err != nil {
+			return err
+		}
+		return service.recordStatusEvento(ctx, agendamentoID, agendamentoStatusEventoInput{
+			Tipo:           models.AgendamentoStatusEventoCronometroIniciado,
+			StatusAnterior: &statusAnterior,
+			StatusNovo:     models.AgendamentoStatusEmAndamento,
+			Detalhes:       map[string]int64{"inicio_cronometro": inicioUnix},
+		})
+	})
+	if err != nil {
 		return models.Agendamento{}, err
 	}
 
 	agendamento.Status = models.AgendamentoStatusEmAndamento
 	agendamento.InicioCronometro = &inicioUnix
 	agendamento.FimCronometro = nil
+	agendamento.CronometroPausadoEm = nil
+	agendamento.CronometroPausasSegundos = 0
 	return agendamento, nil
 }
 
+// EncerrarCronometro stops the clock under the row lock, so hora extra and the
+// financial state come from the row being ended and two tablets ending the
+// same game cannot both write them.
 func (service agendamentoService) EncerrarCronometro(ctx context.Context, ownerUserID int, agendamentoID int) (models.Agendamento, error) {
-	agendamento, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID)
-	if err != nil {
+	if _, err := service.repository.getByIDForOwner(ctx, agendamentoID, ownerUserID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.Agendamento{}, errAgendamentoNaoEncontrado
 		}
 		return models.Agendamento{}, err
 	}
 
-	if !canManageExecutionState(agendamento.Status) {
-		return models.Agendamento{}, errAgendamentoEstadoOperacaoInvalido
-	}
-	if agendamento.InicioCronometro == nil {
-		return models.Agendamento{}, errAgendamentoCronometroNaoIniciado
-	}
+	var (
+		agendamento models.Agendamento
+		fim         time.Time
+	)
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		var err error
+		agendamento, err = service.lockCronometro(ctx, agendamentoID, models.AgendamentoAcaoEncerrarCronometro)
+		if err != nil {
+			return err
+		}
 
-	agendamento, _, err = service.refreshFinancialState(ctx, agendamento)
-	if err != nil {
-		return models.Agendamento{}, err
-	}
+		var totalPago float64
+		agendamento, totalPago, err = service.refreshFinancialState(ctx, agendamento)
+		if err != nil {
+			return err
+		}
+
+		regraHoraExtra, err := loadCampoHoraExtra(ctx, service.repository.database(), agendamento.IDCampo)
+		if err != nil {
+			return err
+		}
+		fim = agendamentoNow()
+		agendamento.FimCronometro = &fim
+		valorTotalReservado := agendamento.ValorTotal
+		horaExtraAnterior := agendamento.HoraExtra
+		agendamento = calcularHoraExtraAgendamento(agendamento, regraHoraExtra, totalPago)
+
+		statusAnterior := agendamento.Status
+		nextStatus := statusAfterCronometroEncerrado(agendamento.ValorRestante)
+		if err := models.ValidarTransicaoAgendamento(statusAnterior, models.AgendamentoAcaoEncerrarCronometro, nextStatus); err != nil {
+			return err
+		}
 
-	nextStatus := statusAfterCronometroEncerrado(agendamento.ValorRestante)
-	fim := agendamentoNow()
-	if err := service.repository.finishCronometro(ctx, agendamentoID, fim, nextStatus); err != nil {
+		encerrado, err := service.repository.finishCronometro(ctx, agendamentoID, fim, nextStatus)
+		if err != nil {
+			return err
+		}
+		if !encerrado {
+			return errAgendamentoCronometroNaoIniciado
+		}
+		if err := service.repository.closeCronometroPausa(ctx, agendamentoID, fim); err != nil {
+			return err
+		}
+
+		detalhes := map[string]any{"fim_cronometro": formatAgendamentoDateTime(fim)}
+		if agendamento.HoraExtra != nil || horaExtraAnterior != nil {
+			var horaExtra models.AgendamentoHoraExtra
+			if agendamento.HoraExtra != nil {
+				horaExtra = *agendamento.HoraExtra
+				detalhes["hora_extra"] = agendamento.HoraExtra
+			}
+			if err := service.repository.updateHoraExtra(ctx, agendamentoID, horaExtra); err != nil {
+				return err
+			}
+		}
+		if agendamento.ValorTotal != valorTotalReservado {
+			if err := service.repository.updateFinancialState(ctx, agendamentoID, agendamentoFinancialUpdate{
+				ValorTotal:        &agendamento.ValorTotal,
+				ValorRestante:     agendamento.ValorRestante,
+				Pago:              agendamento.Pago,
+				StatusDePagamento: agendamento.StatusDePagamento,
+			}); err != nil {
+				return err
+			}
+		}
+
+		agendamento.Status = nextStatus
+		if agendamento.CronometroPausadoEm != nil {
+			agendamento.CronometroPausasSegundos += int64(fim.Sub(*agendamento.CronometroPausadoEm) / time.Second)
+			agendamento.CronometroPausadoEm = nil
+		}
+		return service.recordStatusEvento(ctx, agendamentoID, agendamentoStatusEventoInput{
+			Tipo:           models.AgendamentoStatusEventoCronometroEncerrado,
+			StatusAnterior: &statusAnterior,
+			StatusNovo:     nextStatus,
+			Detalhes:       detalhes,
+		})
+	})
+	if err != nil {
 		return models.Agendamento{}, err
 	}
 
-	agendamento.Status = nextStatus
-	agendamento.FimCronometro = &fim
 	return agendamento, nil
 }
 
@@ -272,15 +446,21 @@ func (service agendamentoService) GetPagamentosResumo(ctx context.Context, owner
 		return models.AgendamentoPagamentosResumo{}, err
 	}
 
+	participantes, err := service.saldosParticipantes(ctx, agendamento)
+	if err != nil {
+		return models.AgendamentoPagamentosResumo{}, err
+	}
+
 	return models.AgendamentoPagamentosResumo{
-		Agendamento: agendamento,
-		Pagamentos:  pagamentos,
-		TotalPago:   totalPago,
+		Agendamento:   agendamento,
+		Pagamentos:    pagamentos,
+		TotalPago:     totalPago,
+		Participantes: participantes,
 	}, nil
 }
 
 func (service agendamentoService) RegistrarPagamentoParcial(ctx context.Context, ownerUserID int, agendamentoID int, input models.RegistrarPagamentoInput) (agendamentoPagamentoMutationResult, error) {
-	if input.ValorPago <= 0 {
+	if input.ValorPago <= 0 && !input.UsarHoras {
 		return agendamentoPagamentoMutationResult{}, errAgendamentoPagamentoInvalido
 	}
 
@@ -292,54 +472,155 @@ func (service agendamentoService) RegistrarPagamentoParcial(ctx context.Context,
 		return agendamentoPagamentoMutationResult{}, err
 	}
 
-	if !canRegisterPayment(agendamento.Status) {
-		return agendamentoPagamentoMutationResult{}, errAgendamentoEstadoOperacaoInvalido
-	}
-
-	agendamento, totalPagoAtual, err := service.refreshFinancialState(ctx, agendamento)
-	if err != nil {
-		return agendamentoPagamentoMutationResult{}, err
-	}
+	return service.registrarPagamento(ctx, agendamento, input)
+}
 
-	if agendamento.ValorRestante <= 0 {
-		return agendamentoPagamentoMutationResult{}, errAgendamentoSemSaldoPendente
-	}
-	if input.ValorPago > agendamento.ValorRestante {
+// registrarPagamento records a payment against an already authorized
+// agendamento. A payment tied to a participant cannot exceed their share. A
+// payment with hour pacotes settles the whole booking and debits its duration.
+// The checks run under the agendamento's row lock, so concurrent payments
+// cannot overpay it or spend the same hours twice.
+func (service agendamentoService) registrarPagamento(ctx context.Context, agendamento models.Agendamento, input models.RegistrarPagamentoInput) (agendamentoPagamentoMutationResult, error) {
+	if input.ValorPago <= 0 && !input.UsarHoras {
 		return agendamentoPagamentoMutationResult{}, errAgendamentoPagamentoInvalido
 	}
 
-	input.FormaPagamento = sanitizePagamento(input.FormaPagamento)
-	if input.FormaPagamento == "" {
-		input.FormaPagamento = sanitizePagamento(agendamento.Pagamento)
-	}
-	pagamento, err := service.repository.insertPayment(ctx, agendamentoID, input)
-	if err != nil {
-		return agendamentoPagamentoMutationResult{}, err
-	}
-
-	totalPago := totalPagoAtual + input.ValorPago
-	valorRestante, pago, statusDePagamento := resolveFinancialState(
-		agendamento.ValorTotal,
-		totalPago,
-		agendamento.Pago,
-		agendamento.StatusDePagamento,
+	agendamentoID := agendamento.ID
+	var (
+		pagamento models.AgendamentoPagamento
+		totalPago float64
 	)
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		if _, err := service.repository.lockAgendamentoStatus(ctx, agendamentoID); err != nil {
+			return err
+		}
+		atual, err := service.repository.getByID(ctx, agendamentoID)
+		if err != nil {
+			return err
+		}
+		if err := models.ValidarAcaoAgendamento(atual.Status, models.AgendamentoAcaoRegistrarPagamento); err != nil {
+			return err
+		}
 
-	statusUpdate := statusAfterPayment(agendamento, valorRestante)
-	if err := service.repository.updateFinancialState(ctx, agendamentoID, agendamentoFinancialUpdate{
-		ValorRestante:     valorRestante,
-		Pago:              pago,
-		StatusDePagamento: statusDePagamento,
-		Status:            statusUpdate,
-	}); err != nil {
-		return agendamentoPagamentoMutationResult{}, err
-	}
+		atual, totalPagoAtual, err := service.refreshFinancialState(ctx, atual)
+		if err != nil {
+			return err
+		}
+		agendamento = atual
 
-	agendamento.ValorRestante = valorRestante
-	agendamento.Pago = pago
-	agendamento.StatusDePagamento = statusDePagamento
-	if statusUpdate != nil {
-		agendamento.Status = *statusUpdate
+		if agendamento.ValorRestante <= 0 {
+			return errAgendamentoSemSaldoPendente
+		}
+		minutosHoras := 0
+		if input.UsarHoras {
+			if input.IDCarteira == nil {
+				return errCreditoCarteiraObrigatoria
+			}
+			if input.IDParticipante != nil || totalPagoAtual != 0 {
+				return errCreditoHorasReservaInteira
+			}
+			input.ValorPago = agendamento.ValorRestante
+			input.FormaPagamento = models.AgendamentoFormaPagamentoCredito
+			minutosHoras = models.NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos)
+		}
+		if input.ValorPago > agendamento.ValorRestante {
+			return errAgendamentoPagamentoInvalido
+		}
+		if input.IDParticipante != nil {
+			saldo, err := service.saldoParticipante(ctx, agendamento, *input.IDParticipante)
+			if err != nil {
+				return err
+			}
+			if saldo.Restante <= 0 {
+				return errAgendamentoSemSaldoPendente
+			}
+			if input.ValorPago > saldo.Restante {
+				return errAgendamentoPagamentoInvalido
+			}
+		}
+
+		input.FormaPagamento = sanitizePagamento(input.FormaPagamento)
+		if input.FormaPagamento == "" {
+			input.FormaPagamento = sanitizePagamento(agendamento.Pagamento)
+		}
+		pagoComCredito := strings.EqualFold(input.FormaPagamento, models.AgendamentoFormaPagamentoCredito)
+		if pagoComCredito {
+			if input.IDCarteira == nil {
+				return errCreditoCarteiraObrigatoria
+			}
+			input.FormaPagamento = models.AgendamentoFormaPagamentoCredito
+		}
+		totalPago = totalPagoAtual + input.ValorPago
+		valorRestante, pago, statusDePagamento := resolveFinancialState(
+			agendamento.ValorTotal,
+			totalPago,
+			agendamento.Pago,
+			agendamento.StatusDePagamento,
+		)
+		statusUpdate := statusAfterPayment(agendamento, valorRestante)
+		statusAnterior := agendamento.Status
+		if statusUpdate != nil {
+			if err := models.ValidarTransicaoAgendamento(statusAnterior, models.AgendamentoAcaoRegistrarPagamento, *statusUpdate); err != nil {
+				return err
+			}
+		}
+
+		pagamento, err = service.repository.insertPayment(ctx, agendamentoID, input)
+		if err != nil {
+			return err
+		}
+		if input.UsarHoras {
+			if err := service.debitarHoras(ctx, agendamento, *input.IDCarteira, minutosHoras, pagamento.ID); err != nil {
+				return err
+			}
+		} else if pagoComCredito {
+			if err := service.debitarCredito(ctx, agendamento, *input.IDCarteira, input.ValorPago, pagamento.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := service.repository.updateFinancialState(ctx, agendamentoID, agendamentoFinancialUpdate{
+			ValorRestante:     valorRestante,
+			Pago:              pago,
+			StatusDePagamento: statusDePagamento,
+			Status:            statusUpdate,
+		}); err != nil {
+			return err
+		}
+
+		agendamento.ValorRestante = valorRestante
+		agendamento.Pago = pago
+		agendamento.StatusDePagamento = statusDePagamento
+		if statusUpdate != nil {
+			agendamento.Status = *statusUpdate
+		}
+		detalhes := map[string]any{
+			"id_pagamento":    pagamento.ID,
+			"valor_pago":      input.ValorPago,
+			"forma_pagamento": input.FormaPagamento,
+			"valor_restante":  valorRestante,
+		}
+		if pagoComCredito {
+			detalhes["id_carteira"] = *input.IDCarteira
+		}
+		if input.UsarHoras {
+			detalhes["minutos"] = minutosHoras
+		}
+		if err := service.recordStatusEvento(ctx, agendamentoID, agendamentoStatusEventoInput{
+			Tipo:           models.AgendamentoStatusEventoPagamento,
+			StatusAnterior: &statusAnterior,
+			StatusNovo:     agendamento.Status,
+			Detalhes:       detalhes,
+		}); err != nil {
+			return err
+		}
+		return service.dispatchWebhookEvento(ctx, models.WebhookEventoPagamentoRegistrado, agendamento)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return agendamentoPagamentoMutationResult{}, errAgendamentoNaoEncontrado
+		}
+		return agendamentoPagamentoMutationResult{}, err
 	}
 
 	return agendamentoPagamentoMutationResult{
@@ -358,8 +639,8 @@ func (service agendamentoService) RegistrarPagamentoTotal(ctx context.Context, o
 		return agendamentoPagamentoMutationResult{}, err
 	}
 
-	if !canRegisterPayment(agendamento.Status) {
-		return agendamentoPagamentoMutationResult{}, errAgendamentoEstadoOperacaoInvalido
+	if err := models.ValidarAcaoAgendamento(agendamento.Status, models.AgendamentoAcaoRegistrarPagamento); err != nil {
+		return agendamentoPagamentoMutationResult{}, err
 	}
 
 	agendamento, _, err = service.refreshFinancialState(ctx, agendamento)
@@ -372,6 +653,16 @@ func (service agendamentoService) RegistrarPagamentoTotal(ctx context.Context, o
 	}
 
 	input.ValorPago = agendamento.ValorRestante
+	if input.IDParticipante != nil {
+		saldo, err := service.saldoParticipante(ctx, agendamento, *input.IDParticipante)
+		if err != nil {
+			return agendamentoPagamentoMutationResult{}, err
+		}
+		if saldo.Restante <= 0 {
+			return agendamentoPagamentoMutationResult{}, errAgendamentoSemSaldoPendente
+		}
+		input.ValorPago = saldo.Restante
+	}
 	return service.RegistrarPagamentoParcial(ctx, ownerUserID, agendamentoID, input)
 }
 
@@ -384,6 +675,9 @@ func (service agendamentoService) Concluir(ctx context.Context, ownerUserID int,
 		return agendamentoMutationResult{}, err
 	}
 
+	if err := models.ValidarTransicaoAgendamento(agendamento.Status, models.AgendamentoAcaoConcluir, models.AgendamentoStatusConcluido); err != nil {
+		return agendamentoMutationResult{}, err
+	}
 	if agendamento.FimCronometro == nil {
 		return agendamentoMutationResult{}, errAgendamentoCronometroNaoEncerrado
 	}
@@ -397,21 +691,66 @@ func (service agendamentoService) Concluir(ctx context.Context, ownerUserID int,
 		return agendamentoMutationResult{}, errAgendamentoConclusaoBloqueada
 	}
 
-	return service.transitionStatus(ctx, agendamento, models.AgendamentoStatusConcluido)
+	return service.transitionStatus(ctx, agendamento, models.AgendamentoAcaoConcluir, models.AgendamentoStatusConcluido)
 }
 
 func (service agendamentoService) create(ctx context.Context, input models.CreateAgendamentoInput, ownerUserID int, status models.AgendamentoStatus) (models.Agendamento, error) {
-	campo, err := service.validateCampoAndSchedule(ctx, input, ownerUserID, nil)
-	if err != nil {
-		return models.Agendamento{}, err
-	}
+	input.DuracaoMinutos = models.NormalizeAgendamentoDuracao(input.DuracaoMinutos)
 
-	valorTotal := campo.ValorHora
-	valorRestante, pago, statusDePagamento := resolveFinancialState(valorTotal, 0, input.Pago, input.Pago)
+	var (
+		campo             campoAgendamentoSnapshot
+		agendamento       models.Agendamento
+		pago              bool
+		statusDePagamento bool
+	)
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		var err error
+		campo, err = service.validateCampoAndSchedule(ctx, input, ownerUserID, nil)
+		if err != nil {
+			return err
+		}
+
+		valores := agendamentoCreateValores{
+			ValorBruto: calcularValorAgendamento(campo.ValorHora, campo.RegrasPreco, input.Horario, input.DuracaoMinutos),
+		}
+		var cupom models.Cupom
+		if input.CodigoCupom != "" {
+			cupom, valores.ValorDesconto, err = service.resolveCupom(ctx, campo, input, valores.ValorBruto)
+			if err != nil {
+				return err
+			}
+			valores.IDCupom = &cupom.ID
+		}
+		valores.ValorTotal = models.ArredondarCentavos(valores.ValorBruto - valores.ValorDesconto)
+		valores.ValorRestante, pago, statusDePagamento = resolveFinancialState(valores.ValorTotal, 0, input.Pago, input.Pago)
+
+		agendamento, err = service.repository.create(ctx, input, status, valores)
+		if err != nil {
+			return err
+		}
+		if valores.IDCupom != nil {
+			agendamento.CodigoCupom = cupom.Codigo
+			if err := service.repository.insertCupomUso(ctx, cupom.ID, agendamento, input.IDUsuarioJogador); err != nil {
+				return err
+			}
+		}
+		if err := service.recordStatusEvento(ctx, agendamento.ID, agendamentoStatusEventoInput{
+			Tipo:       models.AgendamentoStatusEventoCriacao,
+			StatusNovo: status,
+		}); err != nil {
+			return err
+		}
+		if status != models.AgendamentoStatusPedido {
+			return nil
+		}
 
-	agendamento, err := service.repository.create(ctx, input, status, valorTotal, valorRestante)
+		agendamento.IDArena = campo.IDArena
+		agendamento.NomeCampo = campo.NomeCampo
+		agendamento.NomeArena = campo.NomeArena
+		return service.dispatchWebhookEvento(ctx, models.WebhookEventoPedidoCriado, agendamento)
+	})
 	if err != nil {
-		return models.Agendamento{}, err
+		return models.Agendamento{}, mapScheduleWriteError(err)
 	}
 
 	agendamento.IDArena = campo.IDArena
@@ -449,6 +788,9 @@ func (service agendamentoService) validateCampoAndSchedule(ctx context.Context,
 	if input.Jogadores <= 0 {
 		return campoAgendamentoSnapshot{}, errAgendamentoJogadoresInvalidos
 	}
+	if !isAgendamentoDuracaoValida(input.DuracaoMinutos) {
+		return campoAgendamentoSnapshot{}, errAgendamentoDuracaoInvalida
+	}
 
 	campo, err := service.repository.loadCampoSnapshot(ctx, input.IDCampo)
 	if err != nil {
@@ -467,36 +809,103 @@ func (service agendamentoService) validateCampoAndSchedule(ctx context.Context,
 	if campo.MaxJogadores > 0 && input.Jogadores > campo.MaxJogadores {
 		return campoAgendamentoSnapshot{}, errAgendamentoJogadoresInvalidos
 	}
+	// Only campos with a weekly schedule are held to it; legacy agendas keep
+	// accepting manual bookings outside the listed horarios.
+	if len(campo.HorariosSemana) > 0 && !campoFuncionamentoPermite(campo.HorariosDisponiveis, campo.HorariosSemana, input.Horario, input.DuracaoMinutos) {
+		return campoAgendamentoSnapshot{}, errAgendamentoForaDoHorario
+	}
+
+	if err := service.repository.lockCampoSchedule(ctx, input.IDCampo); err != nil {
+		return campoAgendamentoSnapshot{}, err
+	}
 
-	conflict, err := service.repository.hasScheduleConflict(ctx, input.IDCampo, input.Horario, excludeAgendamentoID)
+	fim := input.Horario.Add(time.Duration(input.DuracaoMinutos) * time.Minute)
+	conflict, err := service.repository.hasScheduleConflict(ctx, input.IDCampo, input.Horario, fim, excludeAgendamentoID)
 	if err != nil {
 		return campoAgendamentoSnapshot{}, err
 	}
 	if conflict {
 		return campoAgendamentoSnapshot{}, errAgendamentoHorarioIndisponivel
 	}
+	reservado, err := service.repository.hasRemarcacaoConflict(ctx, input.IDCampo, input.Horario, fim, excludeAgendamentoID)
+	if err != nil {
+		return campoAgendamentoSnapshot{}, err
+	}
+	if reservado {
+		return campoAgendamentoSnapshot{}, errAgendamentoHorarioIndisponivel
+	}
+
+	bloqueado, err := service.repository.hasBloqueioConflict(ctx, input.IDCampo, campo.IDArena, input.Horario, fim)
+	if err != nil {
+		return campoAgendamentoSnapshot{}, err
+	}
+	if bloqueado {
+		return campoAgendamentoSnapshot{}, errAgendamentoHorarioBloqueado
+	}
 
 	return campo, nil
 }
 
-func (service agendamentoService) transitionStatus(ctx context.Context, agendamento models.Agendamento, status models.AgendamentoStatus) (agendamentoMutationResult, error) {
+func (service agendamentoService) inTransaction(ctx context.Context, fn func(agendamentoService) error) error {
+	return service.repository.inTransaction(ctx, func(repository agendamentoRepository) error {
+		txService := service
+		txService.repository = repository
+		return fn(txService)
+	})
+}
+
+func (service agendamentoService) transitionStatus(ctx context.Context, agendamento models.Agendamento, acao models.AgendamentoAcao, status models.AgendamentoStatus) (agendamentoMutationResult, error) {
 	if agendamento.Status == status {
 		return agendamentoMutationResult{Agendamento: agendamento}, nil
 	}
-
-	if err := service.repository.updateStatus(ctx, agendamento.ID, status); err != nil {
+	if err := models.ValidarTransicaoAgendamento(agendamento.Status, acao, status); err != nil {
 		return agendamentoMutationResult{}, err
 	}
 
+	statusAnterior := agendamento.Status
 	agendamento.Status = status
+	agendamento.OrigemStatusEvento = agendamentoAtorFromContext(ctx).Origem
 	notificacao := jogadorNotificationResult{}
-	if shouldNotifyJogador(agendamento) {
-		notificacao = service.notifier.NotifyStatusChange(ctx, agendamento)
+	var cancelamento *models.AgendamentoCancelamento
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		if status == models.AgendamentoStatusCancelado {
+			var err error
+			agendamento, cancelamento, err = service.aplicarPoliticaCancelamento(ctx, agendamento, statusAnterior)
+			if err != nil {
+				return err
+			}
+		}
+		if err := service.repository.updateStatus(ctx, agendamento.ID, status); err != nil {
+			return err
+		}
+		if err := service.recordStatusEvento(ctx, agendamento.ID, agendamentoStatusEventoInput{
+			Tipo:           models.AgendamentoStatusEventoStatus,
+			StatusAnterior: &statusAnterior,
+			StatusNovo:     status,
+		}); err != nil {
+			return err
+		}
+		if evento, ok := webhookEventoParaTransicao(statusAnterior, status); ok {
+			if err := service.dispatchWebhookEvento(ctx, evento, agendamento); err != nil {
+				return err
+			}
+		}
+		if !shouldNotifyJogador(agendamento) {
+			return nil
+		}
+
+		var err error
+		notificacao, err = service.enqueueJogadorStatusChange(ctx, agendamento)
+		return err
+	})
+	if err != nil {
+		return agendamentoMutationResult{}, err
 	}
 
 	return agendamentoMutationResult{
-		Agendamento: agendamento,
-		Notificacao: notificacao,
+		Agendamento:  agendamento,
+		Notificacao:  notificacao,
+		Cancelamento: cancelamento,
 	}, nil
 }
 
@@ -506,14 +915,21 @@ func (service agendamentoService) refreshFinancialState(ctx context.Context, age
 		return models.Agendamento{}, 0, err
 	}
 
+	// Once something was retained, that is all the agendamento still owes;
+	// valor_total keeps the price.
+	valorDevido := agendamento.ValorTotal
+	if agendamento.ValorRetido != nil {
+		valorDevido = *agendamento.ValorRetido
+	}
+
 	valorRestante, pago, statusDePagamento := resolveFinancialState(
-		agendamento.ValorTotal,
+		valorDevido,
 		totalPagoRegistrado,
 		agendamento.Pago,
 		agendamento.StatusDePagamento,
 	)
 
-	totalPago := agendamento.ValorTotal - valorRestante
+	totalPago := valorDevido - valorRestante
 	if totalPago < 0 {
 		totalPago = 0
 	}
@@ -524,24 +940,6 @@ func (service agendamentoService) refreshFinancialState(ctx context.Context, age
 	return agendamento, totalPago, nil
 }
 
-func canManageExecutionState(status models.AgendamentoStatus) bool {
-	switch status {
-	case models.AgendamentoStatusCancelado, models.AgendamentoStatusConcluido:
-		return false
-	default:
-		return true
-	}
-}
-
-func canRegisterPayment(status models.AgendamentoStatus) bool {
-	switch status {
-	case models.AgendamentoStatusCancelado, models.AgendamentoStatusConcluido:
-		return false
-	default:
-		return true
-	}
-}
-
 func statusAfterCronometroEncerrado(valorRestante float64) models.AgendamentoStatus {
 	if valorRestante > 0 {
 		return models.AgendamentoStatusAguardandoPagamento
@@ -582,6 +980,23 @@ func shouldNotifyJogador(agendamento models.Agendamento) bool {
 	}
 }
 
+// mapScheduleWriteError turns the agendamentos exclusion constraint violation
+// into the same error returned by the application-level conflict check.
+func mapScheduleWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgExclusionViolation {
+		return errAgendamentoHorarioIndisponivel
+	}
+
+	return err
+}
+
+func isAgendamentoDuracaoValida(minutos int) bool {
+	return minutos >= agendamentoDuracaoMinimaMinutos &&
+		minutos <= agendamentoDuracaoMaximaMinutos &&
+		minutos%agendamentoDuracaoPassoMinutos == 0
+}
+
 func calcularValorRestante(valorTotal float64, valorPago float64) float64 {
 	valorRestante := valorTotal - valorPago
 	if valorRestante < 0 {
@@ -130,3 +130,28 @@ func TestBuildNomeCompleto(t *testing.T) {
 		t.Fatalf("expected single-name result, got %q", got)
 	}
 }
+
+func TestCalcularValorAgendamentoProratesByDuration(t *testing.T) {
+	inicio := time.Date(2026, time.April, 21, 20, 0, 0, 0, agendamentoLocation())
+	if got := calcularValorAgendamento(120, nil, inicio, 90); got != 180 {
+		t.Fatalf("expected 180 for 90 minutes at 120/h, got %v", got)
+	}
+
+	if got := calcularValorAgendamento(100, nil, inicio, 0); got != 100 {
+		t.Fatalf("expected default one-hour value, got %v", got)
+	}
+}
+
+func TestIsAgendamentoDuracaoValida(t *testing.T) {
+	for _, minutos := range []int{30, 60, 90, 120} {
+		if !isAgendamentoDuracaoValida(minutos) {
+			t.Fatalf("expected %d minutes to be valid", minutos)
+		}
+	}
+
+	for _, minutos := range []int{0, 15, 50, 8*60 + 15} {
+		if isAgendamentoDuracaoValida(minutos) {
+			t.Fatalf("expected %d minutes to be invalid", minutos)
+		}
+	}
+}
@@ -4,6 +4,10 @@ import "time"
 
 const agendamentoTimezone = "America/Sao_Paulo"
 
+// agendamentoHoraVirada is the hour before which a slot still belongs to the
+// previous operating day (e.g. 00:00 closes the night that started at 18:00).
+const agendamentoHoraVirada = 6
+
 func agendamentoLocation() *time.Location {
 	location, err := time.LoadLocation(agendamentoTimezone)
 	if err == nil {
@@ -16,3 +20,19 @@ func agendamentoLocation() *time.Location {
 func agendamentoNow() time.Time {
 	return time.Now().In(agendamentoLocation())
 }
+
+// agendamentoDate keeps the calendar date of value and anchors it at midnight
+// in the agendamento timezone. DATE columns come back from the driver in UTC.
+func agendamentoDate(value time.Time) time.Time {
+	return time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, agendamentoLocation())
+}
+
+func agendamentoDataOperacional(horario time.Time) time.Time {
+	local := horario.In(agendamentoLocation())
+	data := agendamentoDate(local)
+	if local.Hour() < agendamentoHoraVirada {
+		data = data.AddDate(0, 0, -1)
+	}
+
+	return data
+}
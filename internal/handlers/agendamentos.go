@@ -23,6 +23,7 @@ type agendamentoCreateRequest struct {
 	CampoIDCamel      agendamentoInt `json:"campoId"`
 	IDCampoCamel      agendamentoInt `json:"idCampo"`
 	Horario           string         `json:"horario"`
+	DuracaoMinutos    agendamentoInt `json:"duracao_minutos"`
 	Jogadores         agendamentoInt `json:"jogadores"`
 	Pagamento         string         `json:"pagamento"`
 	Pago              bool           `json:"pago"`
@@ -36,6 +37,7 @@ type agendamentoCreateRequest struct {
 	Time1             string         `json:"time1"`
 	Time2             string         `json:"time2"`
 	ModoDeJogo        string         `json:"modo_de_jogo"`
+	Cupom             string         `json:"cupom"`
 }
 
 type agendamentoStatusRequest struct {
@@ -44,34 +46,49 @@ type agendamentoStatusRequest struct {
 
 type agendamentoPagamentoRequest struct {
 	IDUsuario      *int    `json:"id_usuario"`
+	IDParticipante *int    `json:"id_participante"`
+	IDCarteira     *int    `json:"id_carteira"`
+	UsarHoras      bool    `json:"usar_horas"`
 	ValorPago      float64 `json:"valor_pago"`
 	FormaPagamento string  `json:"forma_pagamento"`
 }
 
 type agendamentoResponse struct {
-	ID                int     `json:"id"`
-	IDUsuario         int     `json:"id_usuario,omitempty"`
-	IDCampo           int     `json:"id_campo"`
-	CampoID           int     `json:"campo_id"`
-	IDArena           int     `json:"id_arena,omitempty"`
-	NomeSolicitante   string  `json:"nome_solicitante,omitempty"`
-	Horario           string  `json:"horario"`
-	Jogadores         int     `json:"jogadores"`
-	Pagamento         string  `json:"pagamento"`
-	Pago              bool    `json:"pago"`
-	Status            string  `json:"status"`
-	CriadoEm          string  `json:"criado_em,omitempty"`
-	NomeCampo         string  `json:"nome_campo,omitempty"`
-	NomeArena         string  `json:"nome_arena,omitempty"`
-	OrigemAgendamento string  `json:"origem_agendamento"`
-	ValorTotal        float64 `json:"valor_total"`
-	ValorRestante     float64 `json:"valor_restante"`
-	StatusDePagamento bool    `json:"status_de_pagamento"`
-	InicioCronometro  *int64  `json:"inicio_cronometro,omitempty"`
-	FimCronometro     string  `json:"fim_cronometro,omitempty"`
-	Time1             string  `json:"time1,omitempty"`
-	Time2             string  `json:"time2,omitempty"`
-	ModoDeJogo        string  `json:"modo_de_jogo,omitempty"`
+	ID                int      `json:"id"`
+	IDUsuario         int      `json:"id_usuario,omitempty"`
+	IDCampo           int      `json:"id_campo"`
+	CampoID           int      `json:"campo_id"`
+	IDArena           int      `json:"id_arena,omitempty"`
+	NomeSolicitante   string   `json:"nome_solicitante,omitempty"`
+	Horario           string   `json:"horario"`
+	HorarioFim        string   `json:"horario_fim"`
+	DuracaoMinutos    int      `json:"duracao_minutos"`
+	Jogadores         int      `json:"jogadores"`
+	Pagamento         string   `json:"pagamento"`
+	Pago              bool     `json:"pago"`
+	Status            string   `json:"status"`
+	CriadoEm          string   `json:"criado_em,omitempty"`
+	NomeCampo         string   `json:"nome_campo,omitempty"`
+	NomeArena         string   `json:"nome_arena,omitempty"`
+	OrigemAgendamento string   `json:"origem_agendamento"`
+	ValorBruto        float64  `json:"valor_bruto"`
+	ValorDesconto     float64  `json:"valor_desconto"`
+	ValorTotal        float64  `json:"valor_total"`
+	ValorRestante     float64  `json:"valor_restante"`
+	ValorRetido       *float64 `json:"valor_retido,omitempty"`
+	StatusDePagamento bool     `json:"status_de_pagamento"`
+	InicioCronometro  *int64   `json:"inicio_cronometro,omitempty"`
+	FimCronometro     string   `json:"fim_cronometro,omitempty"`
+	Time1             string   `json:"time1,omitempty"`
+	Time2             string   `json:"time2,omitempty"`
+	ModoDeJogo        string   `json:"modo_de_jogo,omitempty"`
+	IDRecorrencia     *int     `json:"id_recorrencia,omitempty"`
+	IDCupom           *int     `json:"id_cupom,omitempty"`
+	CodigoCupom       string   `json:"codigo_cupom,omitempty"`
+	// MinutosJogados is only set once the cronometro is closed, so it can be
+	// compared with DuracaoMinutos.
+	MinutosJogados *int                         `json:"minutos_jogados,omitempty"`
+	HoraExtra      *models.AgendamentoHoraExtra `json:"hora_extra,omitempty"`
 }
 
 type agendamentoPagamentoResponse struct {
@@ -84,6 +101,8 @@ type agendamentoPagamentoResponse struct {
 	NomeUsuario      string  `json:"nome_usuario,omitempty"`
 	SobrenomeUsuario string  `json:"sobrenome_usuario,omitempty"`
 	EmailUsuario     string  `json:"email_usuario,omitempty"`
+	IDParticipante   *int    `json:"id_participante,omitempty"`
+	NomeParticipante string  `json:"nome_participante,omitempty"`
 }
 
 type agendamentoInt int
@@ -123,9 +142,10 @@ func (value *agendamentoInt) UnmarshalJSON(data []byte) error {
 }
 
 type agendamentoPagamentosResumoResponse struct {
-	Agendamento agendamentoResponse            `json:"agendamento"`
-	Pagamentos  []agendamentoPagamentoResponse `json:"pagamentos"`
-	TotalPago   float64                        `json:"total_pago"`
+	Agendamento   agendamentoResponse               `json:"agendamento"`
+	Pagamentos    []agendamentoPagamentoResponse    `json:"pagamentos"`
+	TotalPago     float64                           `json:"total_pago"`
+	Participantes []agendamentoParticipanteResponse `json:"participantes"`
 }
 
 func formatAgendamentoDateTime(value time.Time) string {
@@ -187,10 +207,49 @@ func CriarPedidoAgendamentoJogador(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]any{
+	response := map[string]any{
 		"message":     "Pedido de agendamento recebido com sucesso",
 		"agendamento": newAgendamentoResponse(agendamento),
-	})
+	}
+	// The jogador is told how long the arena has to answer; a policy lookup
+	// failure does not undo the pedido.
+	if prazo, ok, err := service.PrazoAceitePedido(r.Context(), agendamento); err != nil {
+		log.Printf("Erro ao buscar politicas da arena %d: %v", agendamento.IDArena, err)
+	} else if ok {
+		response["prazo_aceite"] = formatAgendamentoDateTime(prazo)
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func CancelarAgendamentoJogador(w http.ResponseWriter, r *http.Request) {
+	if err := validateJogadorIntegrationRequest(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	agendamentoID := parsePositiveIntParam(mux.Vars(r)["id"])
+	if agendamentoID == 0 {
+		http.Error(w, "ID do agendamento invalido", http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	result, err := service.CancelarPeloJogador(r.Context(), agendamentoID)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	response := map[string]any{
+		"message":     "Agendamento cancelado pelo jogador",
+		"agendamento": newAgendamentoResponse(result.Agendamento),
+	}
+	if result.Cancelamento != nil {
+		response["cancelamento"] = result.Cancelamento
+	}
+
+	writeJSON(w, http.StatusOK, response)
 }
 
 func logJogadorIntegrationRequest(r *http.Request) {
@@ -226,19 +285,20 @@ func GetAgendamentos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	service := newAgendamentoService()
-	agendamentos, err := service.ListByOwner(r.Context(), userID)
+	filtro, err := parseAgendamentoListFiltro(r)
 	if err != nil {
-		http.Error(w, "Erro ao buscar agendamentos", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	response := make([]agendamentoResponse, 0, len(agendamentos))
-	for _, agendamento := range agendamentos {
-		response = append(response, newAgendamentoResponse(agendamento))
+	service := newAgendamentoService()
+	page, err := service.ListByOwner(r.Context(), userID, filtro)
+	if err != nil {
+		http.Error(w, "Erro ao buscar agendamentos", http.StatusInternalServerError)
+		return
 	}
 
-	writeJSON(w, http.StatusOK, response)
+	writeAgendamentoList(w, filtro, page)
 }
 
 func GetPedidos(w http.ResponseWriter, r *http.Request) {
@@ -248,19 +308,20 @@ func GetPedidos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	service := newAgendamentoService()
-	pedidos, err := service.ListPedidosByOwner(r.Context(), userID)
+	filtro, err := parseAgendamentoListFiltro(r)
 	if err != nil {
-		http.Error(w, "Erro ao buscar pedidos", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	response := make([]agendamentoResponse, 0, len(pedidos))
-	for _, pedido := range pedidos {
-		response = append(response, newAgendamentoResponse(pedido))
+	service := newAgendamentoService()
+	page, err := service.ListPedidosByOwner(r.Context(), userID, filtro)
+	if err != nil {
+		http.Error(w, "Erro ao buscar pedidos", http.StatusInternalServerError)
+		return
 	}
 
-	writeJSON(w, http.StatusOK, response)
+	writeAgendamentoList(w, filtro, page)
 }
 
 func AceitarPedido(w http.ResponseWriter, r *http.Request) {
@@ -310,11 +371,16 @@ func CancelarPedido(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
+	response := map[string]any{
 		"message":     "Pedido cancelado com sucesso",
 		"agendamento": newAgendamentoResponse(result.Agendamento),
 		"notificacao": result.Notificacao,
-	})
+	}
+	if result.Cancelamento != nil {
+		response["cancelamento"] = result.Cancelamento
+	}
+
+	writeJSON(w, http.StatusOK, response)
 }
 
 func AtualizarStatusAgendamento(w http.ResponseWriter, r *http.Request) {
@@ -349,11 +415,16 @@ func AtualizarStatusAgendamento(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
+	response := map[string]any{
 		"message":     "Status atualizado com sucesso",
 		"agendamento": newAgendamentoResponse(result.Agendamento),
 		"notificacao": result.Notificacao,
-	})
+	}
+	if result.Cancelamento != nil {
+		response["cancelamento"] = result.Cancelamento
+	}
+
+	writeJSON(w, http.StatusOK, response)
 }
 
 func EditarAgendamento(w http.ResponseWriter, r *http.Request) {
@@ -569,6 +640,11 @@ func parseAgendamentoCreateRequest(r *http.Request) (models.CreateAgendamentoInp
 		request.Jogadores = agendamentoInt(jogadores)
 	}
 
+	if request.DuracaoMinutos <= 0 {
+		duracaoMinutos, _ := strconv.Atoi(strings.TrimSpace(r.URL.Query().Get("duracao_minutos")))
+		request.DuracaoMinutos = agendamentoInt(duracaoMinutos)
+	}
+
 	if request.NomeSolicitante == "" {
 		request.NomeSolicitante = strings.TrimSpace(r.URL.Query().Get("nome_solicitante"))
 	}
@@ -608,6 +684,10 @@ func parseAgendamentoCreateRequest(r *http.Request) (models.CreateAgendamentoInp
 		request.ModoDeJogo = strings.TrimSpace(r.URL.Query().Get("modo_de_jogo"))
 	}
 
+	if request.Cupom == "" {
+		request.Cupom = strings.TrimSpace(r.URL.Query().Get("cupom"))
+	}
+
 	campoID := request.CampoID
 	if campoID <= 0 {
 		campoID = request.IDCampo
@@ -649,6 +729,7 @@ func parseAgendamentoCreateRequest(r *http.Request) (models.CreateAgendamentoInp
 	return models.CreateAgendamentoInput{
 		IDCampo:           int(campoID),
 		Horario:           horario,
+		DuracaoMinutos:    int(request.DuracaoMinutos),
 		Jogadores:         int(request.Jogadores),
 		Pagamento:         strings.TrimSpace(request.Pagamento),
 		Pago:              request.Pago,
@@ -658,6 +739,7 @@ func parseAgendamentoCreateRequest(r *http.Request) (models.CreateAgendamentoInp
 		Time1:             strings.TrimSpace(request.Time1),
 		Time2:             strings.TrimSpace(request.Time2),
 		ModoDeJogo:        strings.TrimSpace(request.ModoDeJogo),
+		CodigoCupom:       models.NormalizeCupomCodigo(request.Cupom),
 	}, nil
 }
 
@@ -668,6 +750,7 @@ func agendamentoCreateRequestFromQuery(r *http.Request) agendamentoCreateRequest
 	campoIDCamel, _ := strconv.Atoi(strings.TrimSpace(query.Get("campoId")))
 	idCampoCamel, _ := strconv.Atoi(strings.TrimSpace(query.Get("idCampo")))
 	jogadores, _ := strconv.Atoi(strings.TrimSpace(query.Get("jogadores")))
+	duracaoMinutos, _ := strconv.Atoi(strings.TrimSpace(query.Get("duracao_minutos")))
 	pago, _ := strconv.ParseBool(strings.TrimSpace(query.Get("pago")))
 
 	return agendamentoCreateRequest{
@@ -676,6 +759,7 @@ func agendamentoCreateRequestFromQuery(r *http.Request) agendamentoCreateRequest
 		CampoIDCamel:      agendamentoInt(campoIDCamel),
 		IDCampoCamel:      agendamentoInt(idCampoCamel),
 		Horario:           strings.TrimSpace(query.Get("horario")),
+		DuracaoMinutos:    agendamentoInt(duracaoMinutos),
 		Jogadores:         agendamentoInt(jogadores),
 		Pagamento:         strings.TrimSpace(query.Get("pagamento")),
 		Pago:              pago,
@@ -689,6 +773,7 @@ func agendamentoCreateRequestFromQuery(r *http.Request) agendamentoCreateRequest
 		Time1:             strings.TrimSpace(query.Get("time1")),
 		Time2:             strings.TrimSpace(query.Get("time2")),
 		ModoDeJogo:        strings.TrimSpace(query.Get("modo_de_jogo")),
+		Cupom:             strings.TrimSpace(query.Get("cupom")),
 	}
 }
 
@@ -700,6 +785,7 @@ func hasAgendamentoCreateQueryParams(r *http.Request) bool {
 		"campoId",
 		"idCampo",
 		"horario",
+		"duracao_minutos",
 		"jogadores",
 		"nome_solicitante",
 		"pagamento",
@@ -713,6 +799,7 @@ func hasAgendamentoCreateQueryParams(r *http.Request) bool {
 		"time1",
 		"time2",
 		"modo_de_jogo",
+		"cupom",
 	}
 
 	for _, key := range keys {
@@ -762,6 +849,9 @@ func parsePagamentoAgendamentoRequest(r *http.Request) (models.RegistrarPagament
 
 	return models.RegistrarPagamentoInput{
 		IDUsuario:      request.IDUsuario,
+		IDParticipante: request.IDParticipante,
+		IDCarteira:     request.IDCarteira,
+		UsarHoras:      request.UsarHoras,
 		ValorPago:      request.ValorPago,
 		FormaPagamento: strings.TrimSpace(request.FormaPagamento),
 	}, nil
@@ -850,6 +940,8 @@ func newAgendamentoResponse(agendamento models.Agendamento) agendamentoResponse
 		IDArena:           agendamento.IDArena,
 		NomeSolicitante:   agendamento.NomeSolicitante,
 		Horario:           formatAgendamentoDateTime(agendamento.Horario),
+		HorarioFim:        formatAgendamentoDateTime(agendamento.HorarioFim()),
+		DuracaoMinutos:    models.NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos),
 		Jogadores:         agendamento.Jogadores,
 		Pagamento:         agendamento.Pagamento,
 		Pago:              agendamento.Pago,
@@ -857,15 +949,26 @@ func newAgendamentoResponse(agendamento models.Agendamento) agendamentoResponse
 		NomeCampo:         agendamento.NomeCampo,
 		NomeArena:         agendamento.NomeArena,
 		OrigemAgendamento: string(agendamento.OrigemAgendamento),
+		ValorBruto:        agendamento.ValorBruto,
+		ValorDesconto:     agendamento.ValorDesconto,
 		ValorTotal:        agendamento.ValorTotal,
 		ValorRestante:     agendamento.ValorRestante,
+		ValorRetido:       agendamento.ValorRetido,
 		StatusDePagamento: agendamento.StatusDePagamento,
 		InicioCronometro:  agendamento.InicioCronometro,
 		Time1:             agendamento.Time1,
 		Time2:             agendamento.Time2,
 		ModoDeJogo:        agendamento.ModoDeJogo,
+		IDRecorrencia:     agendamento.IDRecorrencia,
+		IDCupom:           agendamento.IDCupom,
+		CodigoCupom:       agendamento.CodigoCupom,
+		HoraExtra:         agendamento.HoraExtra,
 	}
 
+	if jogado, ok := agendamento.TempoJogado(); ok {
+		minutos := int(jogado / time.Minute)
+		response.MinutosJogados = &minutos
+	}
 	if !agendamento.CriadoEm.IsZero() {
 		response.CriadoEm = formatAgendamentoDateTime(agendamento.CriadoEm)
 	}
@@ -887,6 +990,8 @@ func newAgendamentoPagamentoResponse(pagamento models.AgendamentoPagamento) agen
 		NomeUsuario:      pagamento.NomeUsuario,
 		SobrenomeUsuario: pagamento.SobrenomeUsuario,
 		EmailUsuario:     pagamento.EmailUsuario,
+		IDParticipante:   pagamento.IDParticipante,
+		NomeParticipante: pagamento.NomeParticipante,
 	}
 }
 
@@ -897,13 +1002,20 @@ func newAgendamentoPagamentosResumoResponse(resumo models.AgendamentoPagamentosR
 	}
 
 	return agendamentoPagamentosResumoResponse{
-		Agendamento: newAgendamentoResponse(resumo.Agendamento),
-		Pagamentos:  pagamentos,
-		TotalPago:   resumo.TotalPago,
+		Agendamento:   newAgendamentoResponse(resumo.Agendamento),
+		Pagamentos:    pagamentos,
+		TotalPago:     resumo.TotalPago,
+		Participantes: newAgendamentoParticipantesResponse(resumo.Participantes),
 	}
 }
 
 func writeAgendamentoServiceError(w http.ResponseWriter, err error) {
+	var transicaoErr *models.AgendamentoTransicaoError
+	if errors.As(err, &transicaoErr) {
+		writeAgendamentoTransicaoError(w, transicaoErr)
+		return
+	}
+
 	switch {
 	case errors.Is(err, errAgendamentoCampoNaoEncontrado):
 		http.Error(w, "Campo nao encontrado", http.StatusNotFound)
@@ -919,24 +1031,98 @@ func writeAgendamentoServiceError(w http.ResponseWriter, err error) {
 		http.Error(w, "O campo selecionado esta indisponivel para agendamento", http.StatusConflict)
 	case errors.Is(err, errAgendamentoJogadoresInvalidos):
 		http.Error(w, "Quantidade de jogadores invalida para o campo selecionado", http.StatusBadRequest)
+	case errors.Is(err, errAgendamentoHorarioBloqueado):
+		http.Error(w, "O horario escolhido esta bloqueado na agenda do campo", http.StatusConflict)
+	case errors.Is(err, errAgendamentoForaDoHorario):
+		http.Error(w, "O horario escolhido esta fora do funcionamento do campo", http.StatusConflict)
+	case errors.Is(err, errAgendamentoDuracaoInvalida):
+		http.Error(w, "Duracao do agendamento invalida. Use multiplos de 15 minutos entre 30 minutos e 8 horas", http.StatusBadRequest)
 	case errors.Is(err, errAgendamentoOrigemInvalida):
 		http.Error(w, "Origem do agendamento invalida", http.StatusBadRequest)
-	case errors.Is(err, errAgendamentoStatusInvalido):
-		http.Error(w, "Status do agendamento invalido", http.StatusBadRequest)
+	case errors.Is(err, errAgendamentoNaoExterno):
+		http.Error(w, "Apenas agendamentos feitos pela integracao podem ser cancelados pelo jogador", http.StatusForbidden)
+	case errors.Is(err, errAgendamentoCancelamentoJogador):
+		http.Error(w, "O agendamento ja comecou ou foi encerrado e nao pode ser cancelado pelo jogador", http.StatusConflict)
 	case errors.Is(err, errAgendamentoPedidoNaoPendente):
 		http.Error(w, "O pedido informado nao esta com status pendente", http.StatusBadRequest)
 	case errors.Is(err, errAgendamentoCronometroNaoIniciado):
 		http.Error(w, "O cronometro ainda nao foi iniciado", http.StatusBadRequest)
 	case errors.Is(err, errAgendamentoCronometroNaoEncerrado):
 		http.Error(w, "O cronometro precisa ser encerrado antes da conclusao", http.StatusBadRequest)
+	case errors.Is(err, errAgendamentoCronometroIniciado):
+		http.Error(w, "O cronometro deste agendamento ja foi iniciado", http.StatusBadRequest)
+	case errors.Is(err, errAgendamentoCronometroPausado):
+		http.Error(w, "O cronometro ja esta pausado", http.StatusBadRequest)
+	case errors.Is(err, errAgendamentoCronometroNaoPausado):
+		http.Error(w, "O cronometro nao esta pausado", http.StatusBadRequest)
+	case errors.Is(err, errAgendamentoAindaNaoComecou):
+		http.Error(w, "O horario do agendamento ainda nao comecou", http.StatusBadRequest)
 	case errors.Is(err, errAgendamentoConclusaoBloqueada):
 		http.Error(w, "O agendamento ainda possui saldo pendente", http.StatusBadRequest)
 	case errors.Is(err, errAgendamentoPagamentoInvalido):
 		http.Error(w, "Pagamento invalido", http.StatusBadRequest)
 	case errors.Is(err, errAgendamentoSemSaldoPendente):
 		http.Error(w, "O agendamento nao possui saldo pendente", http.StatusBadRequest)
-	case errors.Is(err, errAgendamentoEstadoOperacaoInvalido):
-		http.Error(w, "O estado atual do agendamento nao permite esta operacao", http.StatusBadRequest)
+	case errors.Is(err, errListaEsperaHorarioLivre):
+		http.Error(w, "O horario esta disponivel; envie o pedido de agendamento diretamente", http.StatusConflict)
+	case errors.Is(err, errListaEsperaDuplicada):
+		http.Error(w, "O jogador ja esta na lista de espera deste horario", http.StatusConflict)
+	case errors.Is(err, errListaEsperaNaoEncontrada):
+		http.Error(w, "Entrada da lista de espera nao encontrada", http.StatusNotFound)
+	case errors.Is(err, errParticipanteInvalido):
+		http.Error(w, "Informe o nome de cada participante", http.StatusBadRequest)
+	case errors.Is(err, errParticipantesListaInvalida):
+		http.Error(w, "Informe ao menos um participante", http.StatusBadRequest)
+	case errors.Is(err, errParticipanteNaoEncontrado):
+		http.Error(w, "Participante nao encontrado", http.StatusNotFound)
+	case errors.Is(err, errParticipanteComPagamentos):
+		http.Error(w, "O participante ja possui pagamentos registrados", http.StatusConflict)
+	case errors.Is(err, errCreditoCarteiraObrigatoria):
+		http.Error(w, "Informe a carteira de credito para pagar com credito", http.StatusBadRequest)
+	case errors.Is(err, errCreditoCarteiraNaoEncontrada):
+		http.Error(w, "Carteira de credito nao encontrada", http.StatusNotFound)
+	case errors.Is(err, errCreditoSaldoInsuficiente):
+		http.Error(w, "Saldo de credito insuficiente", http.StatusConflict)
+	case errors.Is(err, errCreditoHorasReservaInteira):
+		http.Error(w, "Pacotes de horas pagam a reserva inteira, sem pagamentos anteriores nem por participante", http.StatusConflict)
+	case errors.Is(err, errCupomInvalido):
+		http.Error(w, "Cupom invalido ou inativo", http.StatusBadRequest)
+	case errors.Is(err, errCupomForaDaValidade):
+		http.Error(w, "O cupom esta fora do periodo de validade", http.StatusBadRequest)
+	case errors.Is(err, errCupomNaoAplicavel):
+		http.Error(w, "O cupom nao vale para o campo ou horario escolhido", http.StatusBadRequest)
+	case errors.Is(err, errCupomEsgotado):
+		http.Error(w, "O cupom atingiu o limite de usos", http.StatusConflict)
+	case errors.Is(err, errCupomLimiteCliente):
+		http.Error(w, "O cliente ja usou este cupom o maximo de vezes permitido", http.StatusConflict)
+	case errors.Is(err, errCupomPrimeiraReserva):
+		http.Error(w, "O cupom vale apenas para a primeira reserva na arena", http.StatusConflict)
+	case errors.Is(err, errCupomClienteNaoIdentificado):
+		http.Error(w, "Informe o nome do cliente para usar este cupom", http.StatusBadRequest)
+	case errors.Is(err, errRemarcacaoAgendamentoManual):
+		http.Error(w, "Agendamentos manuais devem ser editados diretamente", http.StatusBadRequest)
+	case errors.Is(err, errRemarcacaoNecessaria):
+		http.Error(w, "Para mudar campo ou horario de um agendamento do jogador, proponha uma remarcacao", http.StatusConflict)
+	case errors.Is(err, errRemarcacaoSemAlteracao):
+		http.Error(w, "A remarcacao precisa alterar campo, horario ou duracao", http.StatusBadRequest)
+	case errors.Is(err, errRemarcacaoHorarioPassado):
+		http.Error(w, "O novo horario da remarcacao ja passou", http.StatusBadRequest)
+	case errors.Is(err, errRemarcacaoPendente):
+		http.Error(w, "O agendamento ja possui uma remarcacao aguardando resposta", http.StatusConflict)
+	case errors.Is(err, errRemarcacaoNaoEncontrada):
+		http.Error(w, "Remarcacao nao encontrada", http.StatusNotFound)
+	case errors.Is(err, errRemarcacaoNaoPendente):
+		http.Error(w, "A remarcacao ja foi respondida ou cancelada", http.StatusConflict)
+	case errors.Is(err, errRecorrenciaNaoEncontrada):
+		http.Error(w, "Recorrencia nao encontrada", http.StatusNotFound)
+	case errors.Is(err, errRecorrenciaInvalida):
+		http.Error(w, "Dados da recorrencia invalidos", http.StatusBadRequest)
+	case errors.Is(err, errRecorrenciaCancelada):
+		http.Error(w, "A recorrencia informada esta cancelada", http.StatusBadRequest)
+	case errors.Is(err, errRecorrenciaOcorrenciaInvalida):
+		http.Error(w, "O agendamento informado nao pertence a recorrencia", http.StatusBadRequest)
+	case errors.Is(err, errRecorrenciaOcorrenciaIniciada):
+		http.Error(w, "A ocorrencia ja foi iniciada e nao pode ser alterada", http.StatusBadRequest)
 	default:
 		http.Error(w, "Erro interno ao processar agendamento", http.StatusInternalServerError)
 	}
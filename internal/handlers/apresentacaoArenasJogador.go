@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/danpi/marca_ai_backend/internal/config"
 	"github.com/danpi/marca_ai_backend/internal/models"
@@ -21,7 +21,13 @@ func GetArenasJogador(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	arenas, err := fetchArenasJogador(r, nil)
+	proximidade, err := parseArenaProximidade(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	arenas, err := fetchArenasJogador(r, nil, proximidade)
 	if err != nil {
 		http.Error(w, "Erro ao buscar arenas", http.StatusInternalServerError)
 		log.Printf("Erro ao buscar arenas: %v", err)
@@ -51,7 +57,7 @@ func GetArenaJogadorPorID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	arenas, err := fetchArenasJogador(r, &arenaID)
+	arenas, err := fetchArenasJogador(r, &arenaID, nil)
 	if err != nil {
 		http.Error(w, "Erro ao buscar arena", http.StatusInternalServerError)
 		log.Printf("Erro ao buscar arena %d: %v", arenaID, err)
@@ -67,7 +73,10 @@ func GetArenaJogadorPorID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(arenas[0])
 }
 
-func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error) {
+// fetchArenasJogador lists arenas with their active campos. When proximidade
+// is set the arenas come back nearest first, and those outside raio_km (or
+// without coordinates, if a radius was given) are left out.
+func fetchArenasJogador(r *http.Request, arenaID *int, proximidade *arenaProximidade) ([]models.Arenas, error) {
 	optionalColumns, err := loadArenaOptionalColumns(r.Context())
 	if err != nil {
 		return nil, err
@@ -78,6 +87,28 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 		return nil, err
 	}
 
+	args := make([]any, 0, 7)
+	filters := make([]string, 0, 6)
+	distanciaExpression := "CAST(NULL AS DOUBLE PRECISION)"
+	if proximidade != nil && optionalColumns.hasCoordenadas() {
+		args = append(args, proximidade.Origem.Latitude, proximidade.Origem.Longitude)
+		distanciaExpression = arenaDistanciaExpression("a", 1, 2)
+
+		if proximidade.RaioKm != nil {
+			minLat, maxLat, minLng, maxLng := proximidade.boundingBox()
+			args = append(args, minLat, maxLat)
+			filters = append(filters, fmt.Sprintf("a.latitude BETWEEN $%d AND $%d", len(args)-1, len(args)))
+			if minLng != nil && maxLng != nil {
+				args = append(args, *minLng, *maxLng)
+				filters = append(filters, fmt.Sprintf("a.longitude BETWEEN $%d AND $%d", len(args)-1, len(args)))
+			}
+			args = append(args, *proximidade.RaioKm)
+			filters = append(filters, fmt.Sprintf("%s <= $%d", distanciaExpression, len(args)))
+		}
+	} else if proximidade != nil && proximidade.RaioKm != nil {
+		return []models.Arenas{}, nil
+	}
+
 	query := fmt.Sprintf(`
 		SELECT
 			a.id,
@@ -90,6 +121,9 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 			%s,
 			%s,
 			%s,
+			%s,
+			%s,
+			%s AS distancia_km,
 			c.id_campo,
 			c.nome_campo,
 			c.max_jogadores,
@@ -98,6 +132,7 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 			c.imagem AS imagem_campo,
 			%s,
 			%s,
+			%s,
 			%s
 		FROM %s a
 		LEFT JOIN %s c ON c.id_arena = a.id
@@ -105,20 +140,26 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 		optionalArenaSelectExpression("a", "observacoes", optionalColumns.Observacoes),
 		optionalArenaSelectExpression("a", "esportes_oferecidos", optionalColumns.EsportesOferecidos),
 		optionalArenaSelectExpression("a", "informacoes_arena", optionalColumns.InformacoesArena),
+		optionalArenaCoordinateExpression("a", "latitude", optionalColumns.hasCoordenadas()),
+		optionalArenaCoordinateExpression("a", "longitude", optionalColumns.hasCoordenadas()),
+		distanciaExpression,
 		optionalCampoSelectExpression("c", "valor_hora", campoOptionalColumns.ValorHora),
 		optionalCampoSelectExpression("c", "ativo", campoOptionalColumns.Ativo),
 		optionalCampoSelectExpression("c", "horarios_disponiveis", campoOptionalColumns.HorariosDisponiveis),
+		optionalCampoSelectExpression("c", "horarios_semana", campoOptionalColumns.HorariosSemana),
 		arenasTableName(),
 		campoTableName(),
 	)
 
-	args := make([]any, 0, 1)
 	if arenaID != nil {
-		query += "\n\t\tWHERE a.id = $1"
 		args = append(args, *arenaID)
+		filters = append(filters, fmt.Sprintf("a.id = $%d", len(args)))
+	}
+	if len(filters) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(filters, "\n\t\t  AND ")
 	}
 
-	query += "\n\t\tORDER BY a.id, c.id_campo;"
+	query += "\n\t\tORDER BY distancia_km ASC NULLS LAST, a.id, c.id_campo;"
 
 	rows, err := config.DB.Query(query, args...)
 	if err != nil {
@@ -127,6 +168,7 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 	defer rows.Close()
 
 	arenasMap := make(map[int]*models.Arenas)
+	orderedIDs := make([]int, 0)
 	arenaHasCampo := make(map[int]bool)
 	arenaHasCampoAtivo := make(map[int]bool)
 
@@ -142,6 +184,9 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 			observacoes  sql.NullString
 			esportes     sql.NullString
 			informacoes  sql.NullString
+			latitude     sql.NullFloat64
+			longitude    sql.NullFloat64
+			distancia    sql.NullFloat64
 			idCampo      sql.NullInt64
 			nomeCampo    sql.NullString
 			maxJogadores sql.NullInt64
@@ -151,6 +196,7 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 			valorHora    sql.NullFloat64
 			ativoCampo   sql.NullBool
 			horariosRaw  sql.NullString
+			semanaRaw    sql.NullString
 		)
 
 		if err := rows.Scan(
@@ -164,6 +210,9 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 			&observacoes,
 			&esportes,
 			&informacoes,
+			&latitude,
+			&longitude,
+			&distancia,
 			&idCampo,
 			&nomeCampo,
 			&maxJogadores,
@@ -173,6 +222,7 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 			&valorHora,
 			&ativoCampo,
 			&horariosRaw,
+			&semanaRaw,
 		); err != nil {
 			return nil, err
 		}
@@ -189,7 +239,11 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 				Observacoes:        observacoes.String,
 				EsportesOferecidos: esportes.String,
 				InformacoesArena:   informacoes.String,
+				Latitude:           nullFloat64Pointer(latitude),
+				Longitude:          nullFloat64Pointer(longitude),
+				DistanciaKm:        nullFloat64Pointer(distancia),
 			}
+			orderedIDs = append(orderedIDs, idArena)
 		}
 
 		if !idCampo.Valid {
@@ -220,6 +274,7 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 			NomeArena:    nomeArena,
 		}
 		applyCampoHorarioAliases(&campo, decodeCampoHorarios(horariosRaw.String))
+		campo.HorariosSemana = decodeCampoHorariosSemana(semanaRaw.String)
 		arenasMap[idArena].Campos = append(arenasMap[idArena].Campos, campo)
 	}
 
@@ -231,12 +286,6 @@ func fetchArenasJogador(r *http.Request, arenaID *int) ([]models.Arenas, error)
 		return []models.Arenas{}, nil
 	}
 
-	orderedIDs := make([]int, 0, len(arenasMap))
-	for id := range arenasMap {
-		orderedIDs = append(orderedIDs, id)
-	}
-	sort.Ints(orderedIDs)
-
 	arenas := make([]models.Arenas, 0, len(orderedIDs))
 	for _, id := range orderedIDs {
 		arena := arenasMap[id]
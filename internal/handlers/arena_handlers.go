@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -51,10 +52,15 @@ func CadastrodeArena(w http.ResponseWriter, r *http.Request) {
 		r.FormValue("informacoesArena"),
 	)
 
+	coordenadas, err := resolveArenaCoordenadas(r, endereco)
+	if err != nil {
+		http.Error(w, "Latitude/longitude invalidas", http.StatusBadRequest)
+		return
+	}
+
 	cnpjInfo := utils.CNPJInfo{}
 	cnpjValidado := false
 	cnpjArmazenado := utils.NormalizeCNPJ(cnpj)
-	var err error
 	optionalColumns, err := loadArenaOptionalColumns(r.Context())
 	if err != nil {
 		http.Error(w, "Erro ao carregar estrutura de arenas", http.StatusInternalServerError)
@@ -99,6 +105,10 @@ func CadastrodeArena(w http.ResponseWriter, r *http.Request) {
 		EsportesOferecidos: esportesOferecidos,
 		InformacoesArena:   informacoesArena,
 	}
+	if coordenadas != nil {
+		newArena.Latitude = &coordenadas.Latitude
+		newArena.Longitude = &coordenadas.Longitude
+	}
 
 	_, err = config.DB.Exec(
 		buildArenaInsertQuery(optionalColumns),
@@ -112,6 +122,7 @@ func CadastrodeArena(w http.ResponseWriter, r *http.Request) {
 			newArena.Observacoes,
 			newArena.EsportesOferecidos,
 			newArena.InformacoesArena,
+			coordenadas,
 			userID,
 			optionalColumns,
 		)...,
@@ -132,6 +143,8 @@ func CadastrodeArena(w http.ResponseWriter, r *http.Request) {
 		"observacoes":         newArena.Observacoes,
 		"esportes_oferecidos": newArena.EsportesOferecidos,
 		"informacoes_arena":   newArena.InformacoesArena,
+		"latitude":            newArena.Latitude,
+		"longitude":           newArena.Longitude,
 		"razao_social":        cnpjInfo.RazaoSocial,
 		"nome_fantasia":       cnpjInfo.NomeFantasia,
 		"situacao_cadastral":  cnpjInfo.DescricaoSituacao,
@@ -169,6 +182,8 @@ func GetArenas(w http.ResponseWriter, r *http.Request) {
 			endereco,
 			%s,
 			%s,
+			%s,
+			%s,
 			%s
 		FROM %s
 		WHERE id_usuario = $1
@@ -176,6 +191,8 @@ func GetArenas(w http.ResponseWriter, r *http.Request) {
 		optionalArenaSelectExpression("", "observacoes", optionalColumns.Observacoes),
 		optionalArenaSelectExpression("", "esportes_oferecidos", optionalColumns.EsportesOferecidos),
 		optionalArenaSelectExpression("", "informacoes_arena", optionalColumns.InformacoesArena),
+		optionalArenaCoordinateExpression("", "latitude", optionalColumns.hasCoordenadas()),
+		optionalArenaCoordinateExpression("", "longitude", optionalColumns.hasCoordenadas()),
 		arenasTableName(),
 	)
 
@@ -190,7 +207,11 @@ func GetArenas(w http.ResponseWriter, r *http.Request) {
 	var arenas []models.Arenas
 
 	for rows.Next() {
-		var arena models.Arenas
+		var (
+			arena     models.Arenas
+			latitude  sql.NullFloat64
+			longitude sql.NullFloat64
+		)
 		err := rows.Scan(
 			&arena.ID,
 			&arena.Nome,
@@ -202,12 +223,16 @@ func GetArenas(w http.ResponseWriter, r *http.Request) {
 			&arena.Observacoes,
 			&arena.EsportesOferecidos,
 			&arena.InformacoesArena,
+			&latitude,
+			&longitude,
 		)
 		if err != nil {
 			http.Error(w, "Erro ao ler dados", http.StatusInternalServerError)
 			log.Printf("Erro ao escanear arenas: %v", err)
 			return
 		}
+		arena.Latitude = nullFloat64Pointer(latitude)
+		arena.Longitude = nullFloat64Pointer(longitude)
 		arenas = append(arenas, arena)
 	}
 
@@ -272,9 +297,18 @@ func UpdateArena(w http.ResponseWriter, r *http.Request) {
 	)
 	fmt.Sscan(r.FormValue("qtdCampos"), &arena.QtdCampos)
 
+	coordenadas, err := resolveArenaCoordenadas(r, arena.Endereco)
+	if err != nil {
+		http.Error(w, "Latitude/longitude invalidas", http.StatusBadRequest)
+		return
+	}
+	if coordenadas != nil {
+		arena.Latitude = &coordenadas.Latitude
+		arena.Longitude = &coordenadas.Longitude
+	}
+
 	var cnpjInfo utils.CNPJInfo
 	cnpjValidado := false
-	var err error
 	optionalColumns, err := loadArenaOptionalColumns(r.Context())
 	if err != nil {
 		http.Error(w, "Erro ao carregar estrutura de arenas", http.StatusInternalServerError)
@@ -317,6 +351,7 @@ func UpdateArena(w http.ResponseWriter, r *http.Request) {
 			arena.Observacoes,
 			arena.EsportesOferecidos,
 			arena.InformacoesArena,
+			coordenadas,
 			userID,
 			optionalColumns,
 		)...,
@@ -336,6 +371,10 @@ func UpdateArena(w http.ResponseWriter, r *http.Request) {
 		"esportes_oferecidos": arena.EsportesOferecidos,
 		"informacoes_arena":   arena.InformacoesArena,
 	}
+	if coordenadas != nil {
+		response["latitude"] = arena.Latitude
+		response["longitude"] = arena.Longitude
+	}
 
 	if strings.TrimSpace(arena.Cnpj) != "" {
 		response["cnpj"] = arena.Cnpj
@@ -349,6 +388,32 @@ func UpdateArena(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// resolveArenaCoordenadas prefers the coordinates typed by the owner and only
+// falls back to the configured geocoder when none were sent. Geocoding
+// failures never block the request; the arena is just saved without a position.
+func resolveArenaCoordenadas(r *http.Request, endereco string) (*utils.Coordenadas, error) {
+	coordenadas, err := utils.ParseCoordenadas(
+		firstNonEmptyArenaValue(r.FormValue("latitude"), r.FormValue("lat")),
+		firstNonEmptyArenaValue(r.FormValue("longitude"), r.FormValue("lng")),
+	)
+	if err != nil || coordenadas != nil {
+		return coordenadas, err
+	}
+	if strings.TrimSpace(endereco) == "" {
+		return nil, nil
+	}
+
+	geocodificadas, err := utils.GeocodeEndereco(r.Context(), endereco)
+	if err != nil {
+		if !errors.Is(err, utils.ErrGeocodingManual) {
+			log.Printf("Erro ao geocodificar endereco da arena: %v", err)
+		}
+		return nil, nil
+	}
+
+	return &geocodificadas, nil
+}
+
 func writeCNPJValidationError(w http.ResponseWriter, err error) {
 	status := http.StatusBadRequest
 	code := "cnpj_invalid"
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// arenaPoliticaMaxMinutos caps every per-arena duration at 30 days.
+const arenaPoliticaMaxMinutos = 30 * 24 * 60
+
+type arenaPoliticaRequest struct {
+	PrazoAceiteMinutos                 *int     `json:"prazo_aceite_minutos"`
+	AntecedenciaAceiteMinutos          *int     `json:"antecedencia_aceite_minutos"`
+	ToleranciaNaoComparecimentoMinutos *int     `json:"tolerancia_nao_comparecimento_minutos"`
+	NaoComparecimentoMantemCobranca    *bool    `json:"nao_comparecimento_mantem_cobranca"`
+	CancelamentoGratuitoHoras          *int     `json:"cancelamento_gratuito_horas"`
+	CancelamentoMultaPercentual        *float64 `json:"cancelamento_multa_percentual"`
+}
+
+type arenaPoliticaResponse struct {
+	IDArena                            int      `json:"id_arena"`
+	PrazoAceiteMinutos                 *int     `json:"prazo_aceite_minutos"`
+	AntecedenciaAceiteMinutos          *int     `json:"antecedencia_aceite_minutos"`
+	ToleranciaNaoComparecimentoMinutos *int     `json:"tolerancia_nao_comparecimento_minutos"`
+	NaoComparecimentoMantemCobranca    bool     `json:"nao_comparecimento_mantem_cobranca"`
+	CancelamentoGratuitoHoras          *int     `json:"cancelamento_gratuito_horas"`
+	CancelamentoMultaPercentual        *float64 `json:"cancelamento_multa_percentual"`
+	AtualizadoEm                       string   `json:"atualizado_em,omitempty"`
+}
+
+func GetArenaPoliticas(w http.ResponseWriter, r *http.Request) {
+	idArena, ok := resolveArenaPoliticaOwner(w, r)
+	if !ok {
+		return
+	}
+
+	politica, err := loadArenaPolitica(r.Context(), config.DB, idArena)
+	if err != nil {
+		http.Error(w, "Erro ao buscar politicas da arena", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar politicas da arena %d: %v", idArena, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newArenaPoliticaResponse(politica))
+}
+
+// AtualizarArenaPoliticas replaces the whole policy; omitted or null fields
+// switch that rule off.
+func AtualizarArenaPoliticas(w http.ResponseWriter, r *http.Request) {
+	idArena, ok := resolveArenaPoliticaOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var request arenaPoliticaRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Erro ao decodificar JSON", http.StatusBadRequest)
+		return
+	}
+
+	politica, err := buildArenaPolitica(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	politica.IDArena = idArena
+
+	var atualizadoEm time.Time
+	err = config.DB.QueryRowContext(r.Context(), fmt.Sprintf(`
+		INSERT INTO %s (
+			id_arena,
+			prazo_aceite_minutos,
+			antecedencia_aceite_minutos,
+			tolerancia_nao_comparecimento_minutos,
+			nao_comparecimento_mantem_cobranca,
+			cancelamento_gratuito_horas,
+			cancelamento_multa_percentual,
+			atualizado_em
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (id_arena) DO UPDATE SET
+			prazo_aceite_minutos = EXCLUDED.prazo_aceite_minutos,
+			antecedencia_aceite_minutos = EXCLUDED.antecedencia_aceite_minutos,
+			tolerancia_nao_comparecimento_minutos = EXCLUDED.tolerancia_nao_comparecimento_minutos,
+			nao_comparecimento_mantem_cobranca = EXCLUDED.nao_comparecimento_mantem_cobranca,
+			cancelamento_gratuito_horas = EXCLUDED.cancelamento_gratuito_horas,
+			cancelamento_multa_percentual = EXCLUDED.cancelamento_multa_percentual,
+			atualizado_em = EXCLUDED.atualizado_em
+		RETURNING atualizado_em
+	`, arenaPoliticasTableName()),
+		idArena,
+		nullableIntValue(politica.PrazoAceiteMinutos),
+		nullableIntValue(politica.AntecedenciaAceiteMinutos),
+		nullableIntValue(politica.ToleranciaNaoComparecimentoMinutos),
+		politica.NaoComparecimentoMantemCobranca,
+		nullableIntValue(politica.CancelamentoGratuitoHoras),
+		nullableFloat64Value(politica.CancelamentoMultaPercentual),
+	).Scan(&atualizadoEm)
+	if err != nil {
+		http.Error(w, "Erro ao salvar politicas da arena", http.StatusInternalServerError)
+		log.Printf("Erro ao salvar politicas da arena %d: %v", idArena, err)
+		return
+	}
+	politica.AtualizadoEm = &atualizadoEm
+
+	writeJSON(w, http.StatusOK, newArenaPoliticaResponse(politica))
+}
+
+func buildArenaPolitica(request arenaPoliticaRequest) (models.ArenaPolitica, error) {
+	politica := newArenaPoliticaPadrao(0)
+	if request.NaoComparecimentoMantemCobranca != nil {
+		politica.NaoComparecimentoMantemCobranca = *request.NaoComparecimentoMantemCobranca
+	}
+
+	var err error
+	if politica.PrazoAceiteMinutos, err = arenaPoliticaMinutos(request.PrazoAceiteMinutos, "prazo_aceite_minutos"); err != nil {
+		return models.ArenaPolitica{}, err
+	}
+	if politica.AntecedenciaAceiteMinutos, err = arenaPoliticaMinutos(request.AntecedenciaAceiteMinutos, "antecedencia_aceite_minutos"); err != nil {
+		return models.ArenaPolitica{}, err
+	}
+	if politica.ToleranciaNaoComparecimentoMinutos, err = arenaPoliticaMinutos(request.ToleranciaNaoComparecimentoMinutos, "tolerancia_nao_comparecimento_minutos"); err != nil {
+		return models.ArenaPolitica{}, err
+	}
+	if politica.CancelamentoGratuitoHoras, err = arenaPoliticaHoras(request.CancelamentoGratuitoHoras, "cancelamento_gratuito_horas"); err != nil {
+		return models.ArenaPolitica{}, err
+	}
+	if politica.CancelamentoMultaPercentual, err = arenaPoliticaPercentual(request.CancelamentoMultaPercentual, "cancelamento_multa_percentual"); err != nil {
+		return models.ArenaPolitica{}, err
+	}
+
+	return politica, nil
+}
+
+// arenaPoliticaMinutos treats 0 like null (rule off) and rejects negatives.
+func arenaPoliticaMinutos(value *int, campo string) (*int, error) {
+	if value == nil || *value == 0 {
+		return nil, nil
+	}
+	if *value < 0 || *value > arenaPoliticaMaxMinutos {
+		return nil, fmt.Errorf("%s deve estar entre 1 e %d minutos", campo, arenaPoliticaMaxMinutos)
+	}
+
+	minutos := *value
+	return &minutos, nil
+}
+
+func arenaPoliticaHoras(value *int, campo string) (*int, error) {
+	maxHoras := arenaPoliticaMaxMinutos / 60
+	if value == nil || *value == 0 {
+		return nil, nil
+	}
+	if *value < 0 || *value > maxHoras {
+		return nil, fmt.Errorf("%s deve estar entre 1 e %d horas", campo, maxHoras)
+	}
+
+	horas := *value
+	return &horas, nil
+}
+
+func arenaPoliticaPercentual(value *float64, campo string) (*float64, error) {
+	if value == nil || *value == 0 {
+		return nil, nil
+	}
+	if *value < 0 || *value > 100 {
+		return nil, fmt.Errorf("%s deve estar entre 0 e 100", campo)
+	}
+
+	percentual := *value
+	return &percentual, nil
+}
+
+// newArenaPoliticaPadrao is the policy of an arena that never saved one:
+// every job is off and a no-show keeps its charge.
+func newArenaPoliticaPadrao(idArena int) models.ArenaPolitica {
+	return models.ArenaPolitica{IDArena: idArena, NaoComparecimentoMantemCobranca: true}
+}
+
+func loadArenaPolitica(ctx context.Context, db agendamentoDB, idArena int) (models.ArenaPolitica, error) {
+	politica := newArenaPoliticaPadrao(idArena)
+
+	var (
+		prazoAceite        sql.NullInt64
+		antecedenciaAceite sql.NullInt64
+		tolerancia         sql.NullInt64
+		gratuitoHoras      sql.NullInt64
+		multaPercentual    sql.NullFloat64
+		atualizadoEm       time.Time
+	)
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT
+			prazo_aceite_minutos,
+			antecedencia_aceite_minutos,
+			tolerancia_nao_comparecimento_minutos,
+			nao_comparecimento_mantem_cobranca,
+			cancelamento_gratuito_horas,
+			cancelamento_multa_percentual,
+			atualizado_em
+		FROM %s
+		WHERE id_arena = $1
+	`, arenaPoliticasTableName()), idArena).Scan(
+		&prazoAceite,
+		&antecedenciaAceite,
+		&tolerancia,
+		&politica.NaoComparecimentoMantemCobranca,
+		&gratuitoHoras,
+		&multaPercentual,
+		&atualizadoEm,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return politica, nil
+	}
+	if err != nil {
+		return models.ArenaPolitica{}, err
+	}
+
+	politica.PrazoAceiteMinutos = nullIntPointer(prazoAceite)
+	politica.AntecedenciaAceiteMinutos = nullIntPointer(antecedenciaAceite)
+	politica.ToleranciaNaoComparecimentoMinutos = nullIntPointer(tolerancia)
+	politica.CancelamentoGratuitoHoras = nullIntPointer(gratuitoHoras)
+	if multaPercentual.Valid {
+		politica.CancelamentoMultaPercentual = &multaPercentual.Float64
+	}
+	politica.AtualizadoEm = &atualizadoEm
+	return politica, nil
+}
+
+func nullIntPointer(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+
+	converted := int(value.Int64)
+	return &converted
+}
+
+func resolveArenaPoliticaOwner(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	idArena := parsePositiveIntParam(mux.Vars(r)["id"])
+	if idArena == 0 {
+		http.Error(w, "ID da arena invalido", http.StatusBadRequest)
+		return 0, false
+	}
+
+	if err := ensureArenaBelongsToUser(idArena, userID); err != nil {
+		writeBloqueioOwnershipError(w, err)
+		return 0, false
+	}
+
+	return idArena, true
+}
+
+func newArenaPoliticaResponse(politica models.ArenaPolitica) arenaPoliticaResponse {
+	response := arenaPoliticaResponse{
+		IDArena:                            politica.IDArena,
+		PrazoAceiteMinutos:                 politica.PrazoAceiteMinutos,
+		AntecedenciaAceiteMinutos:          politica.AntecedenciaAceiteMinutos,
+		ToleranciaNaoComparecimentoMinutos: politica.ToleranciaNaoComparecimentoMinutos,
+		NaoComparecimentoMantemCobranca:    politica.NaoComparecimentoMantemCobranca,
+		CancelamentoGratuitoHoras:          politica.CancelamentoGratuitoHoras,
+		CancelamentoMultaPercentual:        politica.CancelamentoMultaPercentual,
+	}
+	if politica.AtualizadoEm != nil {
+		response.AtualizadoEm = formatAgendamentoDateTime(*politica.AtualizadoEm)
+	}
+
+	return response
+}
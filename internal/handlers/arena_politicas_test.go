@@ -0,0 +1,66 @@
+package handlers
+
+import "testing"
+
+func TestBuildArenaPoliticaTreatsZeroAsDisabled(t *testing.T) {
+	zero := 0
+	prazo := 120
+	politica, err := buildArenaPolitica(arenaPoliticaRequest{
+		PrazoAceiteMinutos:        &prazo,
+		AntecedenciaAceiteMinutos: &zero,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if politica.PrazoAceiteMinutos == nil || *politica.PrazoAceiteMinutos != 120 {
+		t.Fatalf("expected prazo 120, got %v", politica.PrazoAceiteMinutos)
+	}
+	if politica.AntecedenciaAceiteMinutos != nil {
+		t.Fatalf("expected antecedencia disabled, got %v", *politica.AntecedenciaAceiteMinutos)
+	}
+}
+
+func TestBuildArenaPoliticaRejectsOutOfRangeMinutes(t *testing.T) {
+	for _, value := range []int{-5, arenaPoliticaMaxMinutos + 1} {
+		minutos := value
+		if _, err := buildArenaPolitica(arenaPoliticaRequest{PrazoAceiteMinutos: &minutos}); err == nil {
+			t.Fatalf("expected %d minutes to be rejected", value)
+		}
+	}
+}
+
+func TestBuildArenaPoliticaKeepsNoShowChargeByDefault(t *testing.T) {
+	politica, err := buildArenaPolitica(arenaPoliticaRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !politica.NaoComparecimentoMantemCobranca {
+		t.Fatalf("expected no-shows to keep the charge when the field is omitted")
+	}
+
+	mantem := false
+	politica, err = buildArenaPolitica(arenaPoliticaRequest{NaoComparecimentoMantemCobranca: &mantem})
+	if err != nil || politica.NaoComparecimentoMantemCobranca {
+		t.Fatalf("expected the charge to be waived, got %+v (%v)", politica, err)
+	}
+}
+
+func TestBuildArenaPoliticaValidatesCancellationFee(t *testing.T) {
+	for _, value := range []float64{-1, 100.5} {
+		percentual := value
+		if _, err := buildArenaPolitica(arenaPoliticaRequest{CancelamentoMultaPercentual: &percentual}); err == nil {
+			t.Fatalf("expected %.1f%% to be rejected", value)
+		}
+	}
+
+	horas := 24
+	percentual := 30.0
+	politica, err := buildArenaPolitica(arenaPoliticaRequest{CancelamentoGratuitoHoras: &horas, CancelamentoMultaPercentual: &percentual})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if politica.CancelamentoGratuitoHoras == nil || *politica.CancelamentoGratuitoHoras != 24 || politica.CancelamentoMultaPercentual == nil || *politica.CancelamentoMultaPercentual != 30 {
+		t.Fatalf("expected the cancellation policy to be kept, got %+v", politica)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/danpi/marca_ai_backend/internal/utils"
+)
+
+const (
+	raioTerraKm       = 6371.0
+	kmPorGrauLatitude = 111.045
+)
+
+type arenaProximidade struct {
+	Origem utils.Coordenadas
+	RaioKm *float64
+}
+
+// parseArenaProximidade reads lat, lng and raio_km. It returns nil when the
+// caller did not ask for distance sorting.
+func parseArenaProximidade(query url.Values) (*arenaProximidade, error) {
+	origem, err := utils.ParseCoordenadas(
+		firstNonEmptyArenaValue(query.Get("lat"), query.Get("latitude")),
+		firstNonEmptyArenaValue(query.Get("lng"), query.Get("longitude")),
+	)
+	if err != nil {
+		return nil, errors.New("Informe lat e lng validos")
+	}
+
+	rawRaio := strings.TrimSpace(query.Get("raio_km"))
+	if origem == nil {
+		if rawRaio != "" {
+			return nil, errors.New("raio_km exige lat e lng")
+		}
+		return nil, nil
+	}
+
+	proximidade := &arenaProximidade{Origem: *origem}
+	if rawRaio != "" {
+		raio, err := parseOptionalFloat64(rawRaio)
+		if err != nil || raio <= 0 {
+			return nil, errors.New("raio_km invalido")
+		}
+		proximidade.RaioKm = &raio
+	}
+
+	return proximidade, nil
+}
+
+// arenaDistanciaExpression is the haversine distance in km between the arena
+// and the point bound to the two placeholders. LEAST guards ASIN against
+// rounding slightly above 1 for antipodal points.
+func arenaDistanciaExpression(tableAlias string, latPlaceholder int, lngPlaceholder int) string {
+	return fmt.Sprintf(
+		"(%[4]g * 2 * ASIN(LEAST(1, SQRT("+
+			"POWER(SIN(RADIANS(%[1]s.latitude - $%[2]d::double precision) / 2), 2) + "+
+			"COS(RADIANS($%[2]d::double precision)) * COS(RADIANS(%[1]s.latitude)) * "+
+			"POWER(SIN(RADIANS(%[1]s.longitude - $%[3]d::double precision) / 2), 2)))))",
+		tableAlias, latPlaceholder, lngPlaceholder, raioTerraKm,
+	)
+}
+
+// boundingBox is a cheap prefilter for the radius search so the index on
+// (latitude, longitude) can be used before the haversine is evaluated. The
+// longitude bounds are dropped near the poles or across the antimeridian.
+func (proximidade arenaProximidade) boundingBox() (minLat float64, maxLat float64, minLng *float64, maxLng *float64) {
+	raio := *proximidade.RaioKm
+	deltaLat := raio / kmPorGrauLatitude
+	minLat = math.Max(-90, proximidade.Origem.Latitude-deltaLat)
+	maxLat = math.Min(90, proximidade.Origem.Latitude+deltaLat)
+
+	cosLat := math.Cos(proximidade.Origem.Latitude * math.Pi / 180)
+	if cosLat < 0.01 {
+		return minLat, maxLat, nil, nil
+	}
+
+	deltaLng := raio / (kmPorGrauLatitude * cosLat)
+	menor := proximidade.Origem.Longitude - deltaLng
+	maior := proximidade.Origem.Longitude + deltaLng
+	if menor < -180 || maior > 180 {
+		return minLat, maxLat, nil, nil
+	}
+
+	return minLat, maxLat, &menor, &maior
+}
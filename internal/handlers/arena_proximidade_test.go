@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseArenaProximidade(t *testing.T) {
+	proximidade, err := parseArenaProximidade(url.Values{})
+	if err != nil || proximidade != nil {
+		t.Fatalf("expected no proximity without lat/lng, got %+v %v", proximidade, err)
+	}
+
+	proximidade, err = parseArenaProximidade(url.Values{"lat": {"-23.55"}, "lng": {"-46.63"}, "raio_km": {"5"}})
+	if err != nil {
+		t.Fatalf("expected valid proximity, got %v", err)
+	}
+	if proximidade.RaioKm == nil || *proximidade.RaioKm != 5 {
+		t.Fatalf("expected raio_km 5, got %+v", proximidade.RaioKm)
+	}
+
+	invalid := []url.Values{
+		{"lat": {"-23.55"}},
+		{"raio_km": {"5"}},
+		{"lat": {"-23.55"}, "lng": {"-46.63"}, "raio_km": {"0"}},
+	}
+	for _, query := range invalid {
+		if _, err := parseArenaProximidade(query); err == nil {
+			t.Fatalf("expected error for %v", query)
+		}
+	}
+}
+
+func TestArenaProximidadeBoundingBox(t *testing.T) {
+	raio := 10.0
+	proximidade := arenaProximidade{RaioKm: &raio}
+	proximidade.Origem.Latitude = -23.55
+	proximidade.Origem.Longitude = -46.63
+
+	minLat, maxLat, minLng, maxLng := proximidade.boundingBox()
+	if minLat >= -23.55 || maxLat <= -23.55 || maxLat-minLat > 0.2 {
+		t.Fatalf("unexpected latitude bounds: %f %f", minLat, maxLat)
+	}
+	if minLng == nil || maxLng == nil || *minLng >= -46.63 || *maxLng <= -46.63 {
+		t.Fatalf("unexpected longitude bounds: %v %v", minLng, maxLng)
+	}
+
+	proximidade.Origem.Longitude = 179.99
+	if _, _, minLng, maxLng = proximidade.boundingBox(); minLng != nil || maxLng != nil {
+		t.Fatal("expected longitude bounds to be dropped across the antimeridian")
+	}
+}
+
+func TestArenaDistanciaExpressionUsesPlaceholders(t *testing.T) {
+	expression := arenaDistanciaExpression("a", 1, 2)
+	for _, fragment := range []string{"a.latitude - $1::double precision", "a.longitude - $2::double precision", "ASIN(LEAST(1,"} {
+		if !strings.Contains(expression, fragment) {
+			t.Fatalf("expected %q in distance expression: %s", fragment, expression)
+		}
+	}
+}
@@ -2,16 +2,24 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 
 	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/utils"
 )
 
 type arenaOptionalColumns struct {
 	Observacoes        bool
 	EsportesOferecidos bool
 	InformacoesArena   bool
+	Latitude           bool
+	Longitude          bool
+}
+
+func (columns arenaOptionalColumns) hasCoordenadas() bool {
+	return columns.Latitude && columns.Longitude
 }
 
 func loadArenaOptionalColumns(ctx context.Context) (arenaOptionalColumns, error) {
@@ -20,7 +28,7 @@ func loadArenaOptionalColumns(ctx context.Context) (arenaOptionalColumns, error)
 		FROM information_schema.columns
 		WHERE table_schema = $1
 		  AND table_name = 'arenas'
-		  AND column_name IN ('observacoes', 'esportes_oferecidos', 'informacoes_arena')
+		  AND column_name IN ('observacoes', 'esportes_oferecidos', 'informacoes_arena', 'latitude', 'longitude')
 	`, config.DBSchemaName())
 	if err != nil {
 		return arenaOptionalColumns{}, err
@@ -42,6 +50,10 @@ func loadArenaOptionalColumns(ctx context.Context) (arenaOptionalColumns, error)
 			columns.EsportesOferecidos = true
 		case "informacoes_arena":
 			columns.InformacoesArena = true
+		case "latitude":
+			columns.Latitude = true
+		case "longitude":
+			columns.Longitude = true
 		}
 	}
 
@@ -64,6 +76,17 @@ func optionalArenaSelectExpression(tableAlias, columnName string, exists bool) s
 	return fmt.Sprintf("CAST('' AS TEXT) AS %s", columnName)
 }
 
+func optionalArenaCoordinateExpression(tableAlias, columnName string, exists bool) string {
+	if !exists {
+		return fmt.Sprintf("CAST(NULL AS DOUBLE PRECISION) AS %s", columnName)
+	}
+	if strings.TrimSpace(tableAlias) == "" {
+		return fmt.Sprintf("%s AS %s", columnName, columnName)
+	}
+
+	return fmt.Sprintf("%s.%s AS %s", tableAlias, columnName, columnName)
+}
+
 func buildArenaInsertQuery(columns arenaOptionalColumns) string {
 	columnNames := []string{
 		"nome",
@@ -83,6 +106,9 @@ func buildArenaInsertQuery(columns arenaOptionalColumns) string {
 	if columns.InformacoesArena {
 		columnNames = append(columnNames, "informacoes_arena")
 	}
+	if columns.hasCoordenadas() {
+		columnNames = append(columnNames, "latitude", "longitude")
+	}
 
 	columnNames = append(columnNames, "id_usuario")
 
@@ -99,7 +125,7 @@ func buildArenaInsertQuery(columns arenaOptionalColumns) string {
 	)
 }
 
-func buildArenaInsertArgs(arenaName string, arenaCNPJ string, arenaQtdCampos int, arenaTipo string, arenaImagem string, arenaEndereco string, arenaObservacoes string, arenaEsportesOferecidos string, arenaInformacoes string, userID int, columns arenaOptionalColumns) []any {
+func buildArenaInsertArgs(arenaName string, arenaCNPJ string, arenaQtdCampos int, arenaTipo string, arenaImagem string, arenaEndereco string, arenaObservacoes string, arenaEsportesOferecidos string, arenaInformacoes string, arenaCoordenadas *utils.Coordenadas, userID int, columns arenaOptionalColumns) []any {
 	args := []any{
 		arenaName,
 		arenaCNPJ,
@@ -118,6 +144,9 @@ func buildArenaInsertArgs(arenaName string, arenaCNPJ string, arenaQtdCampos int
 	if columns.InformacoesArena {
 		args = append(args, arenaInformacoes)
 	}
+	if columns.hasCoordenadas() {
+		args = append(args, coordenadaArgs(arenaCoordenadas)...)
+	}
 
 	args = append(args, userID)
 	return args
@@ -147,6 +176,14 @@ func buildArenaUpdateQuery(columns arenaOptionalColumns) string {
 		assignments = append(assignments, fmt.Sprintf("informacoes_arena = COALESCE(NULLIF($%d, ''), informacoes_arena)", nextPlaceholder))
 		nextPlaceholder++
 	}
+	if columns.hasCoordenadas() {
+		assignments = append(
+			assignments,
+			fmt.Sprintf("latitude = COALESCE($%d::double precision, latitude)", nextPlaceholder),
+			fmt.Sprintf("longitude = COALESCE($%d::double precision, longitude)", nextPlaceholder+1),
+		)
+		nextPlaceholder += 2
+	}
 
 	return fmt.Sprintf(
 		"UPDATE %s SET %s WHERE id_usuario = $%d",
@@ -156,7 +193,7 @@ func buildArenaUpdateQuery(columns arenaOptionalColumns) string {
 	)
 }
 
-func buildArenaUpdateArgs(arenaName string, arenaCNPJ string, arenaQtdCampos int, arenaTipo string, arenaEndereco string, arenaImagem string, arenaObservacoes string, arenaEsportesOferecidos string, arenaInformacoes string, userID int, columns arenaOptionalColumns) []any {
+func buildArenaUpdateArgs(arenaName string, arenaCNPJ string, arenaQtdCampos int, arenaTipo string, arenaEndereco string, arenaImagem string, arenaObservacoes string, arenaEsportesOferecidos string, arenaInformacoes string, arenaCoordenadas *utils.Coordenadas, userID int, columns arenaOptionalColumns) []any {
 	args := []any{
 		arenaName,
 		arenaCNPJ,
@@ -175,7 +212,26 @@ func buildArenaUpdateArgs(arenaName string, arenaCNPJ string, arenaQtdCampos int
 	if columns.InformacoesArena {
 		args = append(args, arenaInformacoes)
 	}
+	if columns.hasCoordenadas() {
+		args = append(args, coordenadaArgs(arenaCoordenadas)...)
+	}
 
 	args = append(args, userID)
 	return args
 }
+
+func coordenadaArgs(coordenadas *utils.Coordenadas) []any {
+	if coordenadas == nil {
+		return []any{nil, nil}
+	}
+
+	return []any{coordenadas.Latitude, coordenadas.Longitude}
+}
+
+func nullFloat64Pointer(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.Float64
+}
@@ -51,13 +51,29 @@ func TestBuildArenaInsertAndUpdateQueriesRespectOptionalColumns(t *testing.T) {
 		t.Fatalf("expected update query to include informacoes_arena assignment: %s", updateQuery)
 	}
 
-	insertArgs := buildArenaInsertArgs("Arena X", "123", 2, "Society", "img", "Rua A", "Obs", "Futsal", "Info", 99, columns)
+	insertArgs := buildArenaInsertArgs("Arena X", "123", 2, "Society", "img", "Rua A", "Obs", "Futsal", "Info", nil, 99, columns)
 	if len(insertArgs) != 9 {
 		t.Fatalf("expected 9 insert args with two optional columns enabled, got %d", len(insertArgs))
 	}
 
-	updateArgs := buildArenaUpdateArgs("Arena X", "123", 2, "Society", "Rua A", "img", "Obs", "Futsal", "Info", 99, columns)
+	updateArgs := buildArenaUpdateArgs("Arena X", "123", 2, "Society", "Rua A", "img", "Obs", "Futsal", "Info", nil, 99, columns)
 	if len(updateArgs) != 9 {
 		t.Fatalf("expected 9 update args with two optional columns enabled, got %d", len(updateArgs))
 	}
 }
+
+func TestBuildArenaQueriesIncludeCoordenadasWhenColumnsExist(t *testing.T) {
+	columns := arenaOptionalColumns{Latitude: true, Longitude: true}
+
+	if query := buildArenaInsertQuery(columns); !strings.Contains(query, "latitude, longitude") {
+		t.Fatalf("expected insert query to include coordinates: %s", query)
+	}
+	if query := buildArenaUpdateQuery(columns); !strings.Contains(query, "latitude = COALESCE($7::double precision, latitude)") {
+		t.Fatalf("expected update query to keep coordinates when omitted: %s", query)
+	}
+
+	args := buildArenaUpdateArgs("Arena X", "123", 2, "Society", "Rua A", "img", "", "", "", nil, 99, columns)
+	if len(args) != 9 || args[6] != nil || args[7] != nil {
+		t.Fatalf("expected nil coordinate args when not informed, got %v", args)
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func bloqueioAgendaSelectQuery() string {
+	return fmt.Sprintf(`
+		SELECT
+			b.id,
+			b.id_arena,
+			b.id_campo,
+			ar.nome,
+			COALESCE(c.nome_campo, ''),
+			b.inicio,
+			b.fim,
+			b.motivo,
+			b.criado_em
+		FROM %s b
+		JOIN %s ar ON ar.id = b.id_arena
+		LEFT JOIN %s c ON c.id_campo = b.id_campo
+	`, bloqueiosAgendaTableName(), arenasTableName(), campoTableName())
+}
+
+func scanBloqueioAgenda(scanner agendamentoScanner) (models.BloqueioAgenda, error) {
+	var (
+		bloqueio models.BloqueioAgenda
+		idCampo  sql.NullInt64
+	)
+
+	err := scanner.Scan(
+		&bloqueio.ID,
+		&bloqueio.IDArena,
+		&idCampo,
+		&bloqueio.NomeArena,
+		&bloqueio.NomeCampo,
+		&bloqueio.Inicio,
+		&bloqueio.Fim,
+		&bloqueio.Motivo,
+		&bloqueio.CriadoEm,
+	)
+	if err != nil {
+		return models.BloqueioAgenda{}, err
+	}
+
+	if idCampo.Valid {
+		value := int(idCampo.Int64)
+		bloqueio.IDCampo = &value
+	}
+
+	return bloqueio, nil
+}
+
+// loadBloqueioIntervalos returns the blackout windows touching [inicio, fim)
+// for the campo, including the ones set for its whole arena.
+func loadBloqueioIntervalos(ctx context.Context, db agendamentoDB, campoID int, arenaID int, inicio time.Time, fim time.Time) ([]agendamentoIntervalo, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT inicio, fim
+		FROM %s
+		WHERE (id_campo = $1 OR (id_campo IS NULL AND id_arena = $2))
+		  AND inicio < $4
+		  AND fim > $3
+		ORDER BY inicio ASC
+	`, bloqueiosAgendaTableName()), campoID, arenaID, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intervalos := make([]agendamentoIntervalo, 0)
+	for rows.Next() {
+		var intervalo agendamentoIntervalo
+		if err := rows.Scan(&intervalo.Inicio, &intervalo.Fim); err != nil {
+			return nil, err
+		}
+		intervalos = append(intervalos, intervalo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return intervalos, nil
+}
+
+func (repository agendamentoRepository) hasBloqueioConflict(ctx context.Context, campoID int, arenaID int, inicio time.Time, fim time.Time) (bool, error) {
+	intervalos, err := loadBloqueioIntervalos(ctx, repository.database(), campoID, arenaID, inicio, fim)
+	if err != nil {
+		return false, err
+	}
+
+	return len(intervalos) > 0, nil
+}
+
+// listBloqueioConflicts returns the active agendamentos that overlap the
+// window, so the owner can reschedule or cancel them.
+func (repository agendamentoRepository) listBloqueioConflicts(ctx context.Context, bloqueio models.BloqueioAgenda) ([]models.Agendamento, error) {
+	where := fmt.Sprintf(`
+		WHERE a.status NOT IN ('%s', '%s', '%s')
+		  AND a.horario < $1
+		  AND a.horario + COALESCE(a.duracao_minutos, %d) * INTERVAL '1 minute' > $2
+	`, models.AgendamentoStatusCancelado, models.AgendamentoStatusConcluido, models.AgendamentoStatusNaoCompareceu, models.AgendamentoDuracaoPadraoMinutos)
+	args := []any{bloqueio.Fim, bloqueio.Inicio}
+
+	if bloqueio.IDCampo != nil {
+		where += " AND a.id_campo = $3"
+		args = append(args, *bloqueio.IDCampo)
+	} else {
+		where += " AND c.id_arena = $3"
+		args = append(args, bloqueio.IDArena)
+	}
+
+	rows, err := repository.database().QueryContext(ctx, agendamentoBaseSelectQuery()+where+" ORDER BY a.horario ASC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agendamentos := make([]models.Agendamento, 0)
+	for rows.Next() {
+		agendamento, scanErr := scanAgendamento(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		agendamentos = append(agendamentos, agendamento)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return agendamentos, nil
+}
+
+// loadBloqueioIntervalosByCampos is the multi-campo variant of
+// loadBloqueioIntervalos; arenaPorCampo maps each campo to its arena.
+func loadBloqueioIntervalosByCampos(ctx context.Context, db agendamentoDB, arenaPorCampo map[int]int, inicio time.Time, fim time.Time) (map[int][]agendamentoIntervalo, error) {
+	bloqueios := make(map[int][]agendamentoIntervalo, len(arenaPorCampo))
+	if len(arenaPorCampo) == 0 {
+		return bloqueios, nil
+	}
+
+	args := []any{inicio, fim}
+	campoPlaceholders := make([]string, 0, len(arenaPorCampo))
+	arenaPlaceholders := make([]string, 0, len(arenaPorCampo))
+	arenasVistas := make(map[int]struct{}, len(arenaPorCampo))
+	for campoID, arenaID := range arenaPorCampo {
+		args = append(args, campoID)
+		campoPlaceholders = append(campoPlaceholders, fmt.Sprintf("$%d", len(args)))
+		if _, exists := arenasVistas[arenaID]; exists {
+			continue
+		}
+		arenasVistas[arenaID] = struct{}{}
+		args = append(args, arenaID)
+		arenaPlaceholders = append(arenaPlaceholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id_arena, id_campo, inicio, fim
+		FROM %s
+		WHERE (id_campo IN (%s) OR (id_campo IS NULL AND id_arena IN (%s)))
+		  AND inicio < $2
+		  AND fim > $1
+		ORDER BY inicio ASC
+	`, bloqueiosAgendaTableName(), strings.Join(campoPlaceholders, ", "), strings.Join(arenaPlaceholders, ", ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			arenaID   int
+			campoID   sql.NullInt64
+			intervalo agendamentoIntervalo
+		)
+		if err := rows.Scan(&arenaID, &campoID, &intervalo.Inicio, &intervalo.Fim); err != nil {
+			return nil, err
+		}
+
+		if campoID.Valid {
+			bloqueios[int(campoID.Int64)] = append(bloqueios[int(campoID.Int64)], intervalo)
+			continue
+		}
+		for id, arena := range arenaPorCampo {
+			if arena == arenaID {
+				bloqueios[id] = append(bloqueios[id], intervalo)
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bloqueios, nil
+}
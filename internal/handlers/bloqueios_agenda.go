@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+var errArenaOwnershipForbidden = errors.New("arena nao pertence ao usuario")
+
+type bloqueioAgendaRequest struct {
+	IDArena agendamentoInt `json:"id_arena"`
+	IDCampo agendamentoInt `json:"id_campo"`
+	Inicio  string         `json:"inicio"`
+	Fim     string         `json:"fim"`
+	Motivo  string         `json:"motivo"`
+}
+
+type bloqueioAgendaResponse struct {
+	ID        int    `json:"id"`
+	IDArena   int    `json:"id_arena"`
+	IDCampo   *int   `json:"id_campo,omitempty"`
+	NomeArena string `json:"nome_arena,omitempty"`
+	NomeCampo string `json:"nome_campo,omitempty"`
+	Escopo    string `json:"escopo"`
+	Inicio    string `json:"inicio"`
+	Fim       string `json:"fim"`
+	Motivo    string `json:"motivo"`
+	CriadoEm  string `json:"criado_em,omitempty"`
+}
+
+func GetBloqueiosAgenda(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	where := []string{"ar.id_usuario = $1"}
+	args := []any{userID}
+
+	if idArena := optionalPositiveIntFromQuery(r, "id_arena"); idArena != nil {
+		args = append(args, *idArena)
+		where = append(where, fmt.Sprintf("b.id_arena = $%d", len(args)))
+	}
+	if idCampo := optionalPositiveIntFromQuery(r, "id_campo"); idCampo != nil {
+		args = append(args, *idCampo)
+		where = append(where, fmt.Sprintf("(b.id_campo = $%d OR (b.id_campo IS NULL AND b.id_arena = (SELECT id_arena FROM %s WHERE id_campo = $%d)))", len(args), campoTableName(), len(args)))
+	}
+	if incluir, _ := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("incluir_encerrados"))); !incluir {
+		args = append(args, agendamentoNow())
+		where = append(where, fmt.Sprintf("b.fim > $%d", len(args)))
+	}
+
+	rows, err := config.DB.QueryContext(r.Context(), bloqueioAgendaSelectQuery()+" WHERE "+strings.Join(where, " AND ")+" ORDER BY b.inicio ASC", args...)
+	if err != nil {
+		http.Error(w, "Erro ao buscar bloqueios da agenda", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar bloqueios da agenda do usuario %d: %v", userID, err)
+		return
+	}
+	defer rows.Close()
+
+	response := make([]bloqueioAgendaResponse, 0)
+	for rows.Next() {
+		bloqueio, err := scanBloqueioAgenda(rows)
+		if err != nil {
+			http.Error(w, "Erro ao ler bloqueios da agenda", http.StatusInternalServerError)
+			log.Printf("Erro ao escanear bloqueio da agenda: %v", err)
+			return
+		}
+		response = append(response, newBloqueioAgendaResponse(bloqueio))
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Erro ao ler bloqueios da agenda", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func CriarBloqueioAgenda(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	var request bloqueioAgendaRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Erro ao decodificar JSON", http.StatusBadRequest)
+		return
+	}
+
+	bloqueio, err := buildBloqueioAgenda(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if bloqueio.IDCampo != nil {
+		if err := ensureCampoBelongsToUser(*bloqueio.IDCampo, userID); err != nil {
+			writeBloqueioOwnershipError(w, err)
+			return
+		}
+		if err := config.DB.QueryRowContext(r.Context(), fmt.Sprintf(`SELECT id_arena FROM %s WHERE id_campo = $1`, campoTableName()), *bloqueio.IDCampo).Scan(&bloqueio.IDArena); err != nil {
+			http.Error(w, "Erro ao buscar arena do campo", http.StatusInternalServerError)
+			log.Printf("Erro ao buscar arena do campo %d: %v", *bloqueio.IDCampo, err)
+			return
+		}
+	} else if err := ensureArenaBelongsToUser(bloqueio.IDArena, userID); err != nil {
+		writeBloqueioOwnershipError(w, err)
+		return
+	}
+
+	err = config.DB.QueryRowContext(r.Context(), fmt.Sprintf(`
+		INSERT INTO %s (id_arena, id_campo, inicio, fim, motivo)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, criado_em
+	`, bloqueiosAgendaTableName()),
+		bloqueio.IDArena,
+		nullableIntValue(bloqueio.IDCampo),
+		bloqueio.Inicio,
+		bloqueio.Fim,
+		bloqueio.Motivo,
+	).Scan(&bloqueio.ID, &bloqueio.CriadoEm)
+	if err != nil {
+		http.Error(w, "Erro ao cadastrar bloqueio da agenda", http.StatusInternalServerError)
+		log.Printf("Erro ao cadastrar bloqueio da arena %d: %v", bloqueio.IDArena, err)
+		return
+	}
+
+	conflitos, err := newAgendamentoRepository().listBloqueioConflicts(r.Context(), bloqueio)
+	if err != nil {
+		http.Error(w, "Bloqueio cadastrado, mas houve erro ao buscar agendamentos conflitantes", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar conflitos do bloqueio %d: %v", bloqueio.ID, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"message":                   "Bloqueio da agenda cadastrado com sucesso",
+		"bloqueio":                  newBloqueioAgendaResponse(bloqueio),
+		"agendamentos_conflitantes": newAgendamentoResponses(conflitos),
+	})
+}
+
+func GetConflitosBloqueioAgenda(w http.ResponseWriter, r *http.Request) {
+	bloqueio, ok := loadBloqueioAgendaForOwner(w, r)
+	if !ok {
+		return
+	}
+
+	conflitos, err := newAgendamentoRepository().listBloqueioConflicts(r.Context(), bloqueio)
+	if err != nil {
+		http.Error(w, "Erro ao buscar agendamentos conflitantes", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar conflitos do bloqueio %d: %v", bloqueio.ID, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bloqueio":                  newBloqueioAgendaResponse(bloqueio),
+		"agendamentos_conflitantes": newAgendamentoResponses(conflitos),
+	})
+}
+
+func DeleteBloqueioAgenda(w http.ResponseWriter, r *http.Request) {
+	bloqueio, ok := loadBloqueioAgendaForOwner(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := config.DB.ExecContext(r.Context(), fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, bloqueiosAgendaTableName()), bloqueio.ID); err != nil {
+		http.Error(w, "Erro ao excluir bloqueio da agenda", http.StatusInternalServerError)
+		log.Printf("Erro ao excluir bloqueio %d: %v", bloqueio.ID, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Bloqueio da agenda excluido com sucesso"})
+}
+
+func loadBloqueioAgendaForOwner(w http.ResponseWriter, r *http.Request) (models.BloqueioAgenda, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return models.BloqueioAgenda{}, false
+	}
+
+	idBloqueio, err := strconv.Atoi(strings.TrimSpace(mux.Vars(r)["id"]))
+	if err != nil || idBloqueio <= 0 {
+		http.Error(w, "ID do bloqueio invalido", http.StatusBadRequest)
+		return models.BloqueioAgenda{}, false
+	}
+
+	bloqueio, err := scanBloqueioAgenda(config.DB.QueryRowContext(
+		r.Context(),
+		bloqueioAgendaSelectQuery()+" WHERE b.id = $1 AND ar.id_usuario = $2",
+		idBloqueio,
+		userID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Bloqueio da agenda nao encontrado", http.StatusNotFound)
+			return models.BloqueioAgenda{}, false
+		}
+		http.Error(w, "Erro ao buscar bloqueio da agenda", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar bloqueio %d: %v", idBloqueio, err)
+		return models.BloqueioAgenda{}, false
+	}
+
+	return bloqueio, true
+}
+
+func buildBloqueioAgenda(request bloqueioAgendaRequest) (models.BloqueioAgenda, error) {
+	bloqueio := models.BloqueioAgenda{
+		IDArena: int(request.IDArena),
+		Motivo:  strings.TrimSpace(request.Motivo),
+	}
+	if request.IDCampo > 0 {
+		idCampo := int(request.IDCampo)
+		bloqueio.IDCampo = &idCampo
+	}
+	if bloqueio.IDCampo == nil && bloqueio.IDArena <= 0 {
+		return models.BloqueioAgenda{}, errors.New("Informe id_campo ou id_arena")
+	}
+	if bloqueio.Motivo == "" {
+		return models.BloqueioAgenda{}, errors.New("Informe o motivo do bloqueio")
+	}
+
+	inicio, err := parseAgendamentoHorario(request.Inicio)
+	if err != nil {
+		return models.BloqueioAgenda{}, errors.New("Inicio do bloqueio invalido")
+	}
+	fim, err := parseAgendamentoHorario(request.Fim)
+	if err != nil {
+		return models.BloqueioAgenda{}, errors.New("Fim do bloqueio invalido")
+	}
+	if !fim.After(inicio) {
+		return models.BloqueioAgenda{}, errors.New("O fim do bloqueio deve ser posterior ao inicio")
+	}
+	bloqueio.Inicio = inicio
+	bloqueio.Fim = fim
+
+	return bloqueio, nil
+}
+
+func ensureArenaBelongsToUser(idArena, userID int) error {
+	var pertence bool
+	err := config.DB.QueryRow(fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1 FROM %s WHERE id = $1 AND id_usuario = $2
+		)
+	`, arenasTableName()), idArena, userID).Scan(&pertence)
+	if err != nil {
+		return err
+	}
+	if !pertence {
+		return errArenaOwnershipForbidden
+	}
+
+	return nil
+}
+
+func writeBloqueioOwnershipError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errCampoOwnershipForbidden):
+		http.Error(w, "O campo nao pertence ao usuario", http.StatusForbidden)
+	case errors.Is(err, errArenaOwnershipForbidden):
+		http.Error(w, "Arena nao pertence ao usuario logado", http.StatusForbidden)
+	default:
+		http.Error(w, "Erro ao verificar propriedade do bloqueio", http.StatusInternalServerError)
+		log.Printf("Erro ao verificar propriedade do bloqueio: %v", err)
+	}
+}
+
+func newBloqueioAgendaResponse(bloqueio models.BloqueioAgenda) bloqueioAgendaResponse {
+	response := bloqueioAgendaResponse{
+		ID:        bloqueio.ID,
+		IDArena:   bloqueio.IDArena,
+		IDCampo:   bloqueio.IDCampo,
+		NomeArena: bloqueio.NomeArena,
+		NomeCampo: bloqueio.NomeCampo,
+		Escopo:    "arena",
+		Inicio:    formatAgendamentoDateTime(bloqueio.Inicio),
+		Fim:       formatAgendamentoDateTime(bloqueio.Fim),
+		Motivo:    bloqueio.Motivo,
+	}
+	if bloqueio.IDCampo != nil {
+		response.Escopo = "campo"
+	}
+	if !bloqueio.CriadoEm.IsZero() {
+		response.CriadoEm = formatAgendamentoDateTime(bloqueio.CriadoEm)
+	}
+
+	return response
+}
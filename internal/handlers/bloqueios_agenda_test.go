@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildBloqueioAgendaValidatesScopeAndWindow(t *testing.T) {
+	bloqueio, err := buildBloqueioAgenda(bloqueioAgendaRequest{
+		IDCampo: 4,
+		Inicio:  "2026-05-02T08:00",
+		Fim:     "2026-05-02T12:00",
+		Motivo:  " Troca da grama ",
+	})
+	if err != nil {
+		t.Fatalf("buildBloqueioAgenda returned error: %v", err)
+	}
+	if bloqueio.IDCampo == nil || *bloqueio.IDCampo != 4 {
+		t.Fatalf("expected campo scope, got %+v", bloqueio.IDCampo)
+	}
+	if bloqueio.Motivo != "Troca da grama" {
+		t.Fatalf("expected trimmed motivo, got %q", bloqueio.Motivo)
+	}
+	if got := bloqueio.Fim.Sub(bloqueio.Inicio); got != 4*time.Hour {
+		t.Fatalf("expected 4h window, got %s", got)
+	}
+
+	invalid := []bloqueioAgendaRequest{
+		{Inicio: "2026-05-02T08:00", Fim: "2026-05-02T12:00", Motivo: "sem escopo"},
+		{IDArena: 1, Inicio: "2026-05-02T08:00", Fim: "2026-05-02T12:00"},
+		{IDArena: 1, Inicio: "2026-05-02T12:00", Fim: "2026-05-02T08:00", Motivo: "invertido"},
+	}
+	for _, request := range invalid {
+		if _, err := buildBloqueioAgenda(request); err == nil {
+			t.Fatalf("expected error for %+v", request)
+		}
+	}
+}
+
+func TestAvailableBookingStartsSkipsBloqueios(t *testing.T) {
+	loc := agendamentoLocation()
+	data := time.Date(2026, 5, 2, 0, 0, 0, 0, loc)
+	slots := generateBookingSlots(data, loc, []string{"08:00", "09:00", "10:00", "11:00"})
+	bloqueios := []agendamentoIntervalo{{
+		Inicio: time.Date(2026, 5, 2, 9, 30, 0, 0, loc),
+		Fim:    time.Date(2026, 5, 2, 10, 30, 0, 0, loc),
+	}}
+
+	available := availableBookingStarts(slots, bloqueios, time.Hour)
+	if len(available) != 2 || available[0].Hour() != 8 || available[1].Hour() != 11 {
+		t.Fatalf("expected only 08:00 and 11:00 around the bloqueio, got %v", available)
+	}
+}
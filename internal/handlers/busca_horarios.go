@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+type buscaHorariosFiltros struct {
+	Modalidade     string
+	TipoCampo      string
+	Data           time.Time
+	HoraInicio     *int
+	HoraFim        *int
+	MinJogadores   int
+	PrecoMax       *float64
+	DuracaoMinutos int
+}
+
+type buscaHorarioResponse struct {
+	Horario    string  `json:"horario"`
+	Inicio     string  `json:"inicio"`
+	ValorHora  float64 `json:"valor_hora"`
+	ValorTotal float64 `json:"valor_total"`
+}
+
+type buscaCampoResponse struct {
+	IDArena        int                    `json:"id_arena"`
+	NomeArena      string                 `json:"nome_arena"`
+	Endereco       string                 `json:"endereco"`
+	ImagemArena    string                 `json:"imagem_arena,omitempty"`
+	IDCampo        int                    `json:"id_campo"`
+	NomeCampo      string                 `json:"nome_campo"`
+	Modalidade     string                 `json:"modalidade"`
+	TipoCampo      string                 `json:"tipo_campo"`
+	MaxJogadores   int                    `json:"max_jogadores"`
+	ImagemCampo    string                 `json:"imagem_campo,omitempty"`
+	ValorHora      float64                `json:"valor_hora"`
+	HorariosLivres []buscaHorarioResponse `json:"horarios_livres"`
+}
+
+// BuscarHorariosLivres searches every arena for campos matching the filters
+// and returns only the campos that still have a free slot in the window.
+func BuscarHorariosLivres(w http.ResponseWriter, r *http.Request) {
+	filtros, err := parseBuscaHorariosFiltros(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	arenas, err := fetchArenasJogador(r, nil, nil)
+	if err != nil {
+		http.Error(w, "Erro ao buscar arenas", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar arenas para busca de horarios: %v", err)
+		return
+	}
+
+	type candidato struct {
+		arena models.Arenas
+		campo models.Campo
+	}
+	candidatos := make([]candidato, 0)
+	campoIDs := make([]int, 0)
+	arenaPorCampo := make(map[int]int)
+	for _, arena := range arenas {
+		for _, campo := range arena.Campos {
+			if !campoAtendeBusca(campo, filtros) {
+				continue
+			}
+			candidatos = append(candidatos, candidato{arena: arena, campo: campo})
+			campoIDs = append(campoIDs, campo.IDCampo)
+			arenaPorCampo[campo.IDCampo] = arena.ID
+		}
+	}
+
+	response := make([]buscaCampoResponse, 0)
+	if len(candidatos) == 0 {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	location := agendamentoLocation()
+	inicioPeriodo := filtros.Data
+	fimPeriodo := filtros.Data.AddDate(0, 0, 1).Add(agendamentoHoraVirada * time.Hour)
+
+	regras, err := loadCampoRegrasPreco(r.Context(), config.DB, campoIDs)
+	if err != nil {
+		http.Error(w, "Erro ao buscar regras de preco", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar regras de preco para busca de horarios: %v", err)
+		return
+	}
+	occupied, err := loadOccupiedIntervalsByCampos(r.Context(), campoIDs, inicioPeriodo, fimPeriodo)
+	if err != nil {
+		http.Error(w, "Erro ao buscar horarios ocupados", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar horarios ocupados para busca de horarios: %v", err)
+		return
+	}
+	bloqueios, err := loadBloqueioIntervalosByCampos(r.Context(), config.DB, arenaPorCampo, inicioPeriodo, fimPeriodo)
+	if err != nil {
+		http.Error(w, "Erro ao buscar bloqueios da agenda", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar bloqueios para busca de horarios: %v", err)
+		return
+	}
+
+	for _, item := range candidatos {
+		campo := item.campo
+		campo.RegrasPreco = regras[campo.IDCampo]
+		indisponiveis := append(append([]agendamentoIntervalo(nil), occupied[campo.IDCampo]...), bloqueios[campo.IDCampo]...)
+
+		horarios := buscarHorariosLivresCampo(campo, filtros, indisponiveis, location)
+		if len(horarios) == 0 {
+			continue
+		}
+
+		response = append(response, buscaCampoResponse{
+			IDArena:        item.arena.ID,
+			NomeArena:      item.arena.Nome,
+			Endereco:       item.arena.Endereco,
+			ImagemArena:    item.arena.Imagem,
+			IDCampo:        campo.IDCampo,
+			NomeCampo:      campo.Nome,
+			Modalidade:     campo.Modalidade,
+			TipoCampo:      campo.TipoCampo,
+			MaxJogadores:   campo.MaxJogadores,
+			ImagemCampo:    campo.Imagem,
+			ValorHora:      campo.ValorHora,
+			HorariosLivres: horarios,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func campoAtendeBusca(campo models.Campo, filtros buscaHorariosFiltros) bool {
+	if !campo.Ativo {
+		return false
+	}
+	if filtros.Modalidade != "" && !strings.EqualFold(strings.TrimSpace(campo.Modalidade), filtros.Modalidade) {
+		return false
+	}
+	if filtros.TipoCampo != "" && !strings.EqualFold(strings.TrimSpace(campo.TipoCampo), filtros.TipoCampo) {
+		return false
+	}
+	if filtros.MinJogadores > 0 && campo.MaxJogadores < filtros.MinJogadores {
+		return false
+	}
+
+	return true
+}
+
+func buscarHorariosLivresCampo(campo models.Campo, filtros buscaHorariosFiltros, indisponiveis []agendamentoIntervalo, location *time.Location) []buscaHorarioResponse {
+	horarios, aberto := campoHorariosNaData(campo.HorariosDisponiveis, campo.HorariosSemana, filtros.Data)
+	if !aberto {
+		return nil
+	}
+
+	slots := generateBookingSlots(filtros.Data, location, horarios)
+	livres := make([]buscaHorarioResponse, 0)
+	for _, slot := range availableBookingStarts(slots, indisponiveis, time.Duration(filtros.DuracaoMinutos)*time.Minute) {
+		label := slot.In(location).Format("15:04")
+		inicioMinutos := campoHorarioSortValue(label)
+		if filtros.HoraInicio != nil && inicioMinutos < *filtros.HoraInicio {
+			continue
+		}
+		if filtros.HoraFim != nil && inicioMinutos+filtros.DuracaoMinutos > *filtros.HoraFim {
+			continue
+		}
+
+		valorTotal := calcularValorAgendamento(campo.ValorHora, campo.RegrasPreco, slot, filtros.DuracaoMinutos)
+		if filtros.PrecoMax != nil && valorTotal > *filtros.PrecoMax {
+			continue
+		}
+
+		livres = append(livres, buscaHorarioResponse{
+			Horario:    label,
+			Inicio:     formatAgendamentoDateTime(slot.In(location)),
+			ValorHora:  resolveValorHora(campo.ValorHora, campo.RegrasPreco, slot),
+			ValorTotal: valorTotal,
+		})
+	}
+
+	return livres
+}
+
+func parseBuscaHorariosFiltros(r *http.Request) (buscaHorariosFiltros, error) {
+	query := r.URL.Query()
+	location := agendamentoLocation()
+
+	data, err := parseAvailabilityDate(query.Get("data"), location)
+	if err != nil {
+		return buscaHorariosFiltros{}, errors.New("Data invalida. Use o formato YYYY-MM-DD")
+	}
+
+	duracaoMinutos, err := parseAvailabilityDuracao(query.Get("duracao_minutos"))
+	if err != nil {
+		return buscaHorariosFiltros{}, errors.New("duracao_minutos invalido")
+	}
+
+	filtros := buscaHorariosFiltros{
+		Modalidade:     strings.TrimSpace(query.Get("modalidade")),
+		TipoCampo:      firstNonEmptyCampoValue(query.Get("tipo_campo"), query.Get("tipoCampo")),
+		Data:           data,
+		DuracaoMinutos: duracaoMinutos,
+	}
+
+	if raw := strings.TrimSpace(query.Get("hora_inicio")); raw != "" {
+		horario, err := normalizeCampoHorario(raw)
+		if err != nil {
+			return buscaHorariosFiltros{}, errors.New("hora_inicio invalida")
+		}
+		minutos := campoHorarioSortValue(horario)
+		filtros.HoraInicio = &minutos
+	}
+	if raw := strings.TrimSpace(query.Get("hora_fim")); raw != "" {
+		horario, err := normalizeCampoHorario(raw)
+		if err != nil {
+			return buscaHorariosFiltros{}, errors.New("hora_fim invalida")
+		}
+		minutos := campoHorarioSortValue(horario)
+		filtros.HoraFim = &minutos
+	}
+	if filtros.HoraInicio != nil && filtros.HoraFim != nil && *filtros.HoraFim <= *filtros.HoraInicio {
+		return buscaHorariosFiltros{}, errors.New("hora_fim deve ser posterior a hora_inicio")
+	}
+
+	if raw := firstNonEmptyCampoValue(query.Get("min_jogadores"), query.Get("jogadores")); raw != "" {
+		minJogadores, err := strconv.Atoi(raw)
+		if err != nil || minJogadores < 0 {
+			return buscaHorariosFiltros{}, errors.New("min_jogadores invalido")
+		}
+		filtros.MinJogadores = minJogadores
+	}
+
+	if raw := firstNonEmptyCampoValue(query.Get("preco_max"), query.Get("valor_max")); raw != "" {
+		precoMax, err := parseOptionalFloat64(raw)
+		if err != nil || precoMax < 0 {
+			return buscaHorariosFiltros{}, errors.New("preco_max invalido")
+		}
+		filtros.PrecoMax = &precoMax
+	}
+
+	return filtros, nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestCampoAtendeBuscaFiltersCampo(t *testing.T) {
+	campo := models.Campo{Ativo: true, Modalidade: "Futsal", TipoCampo: "Quadra", MaxJogadores: 10}
+
+	if !campoAtendeBusca(campo, buscaHorariosFiltros{Modalidade: "futsal", TipoCampo: "quadra", MinJogadores: 10}) {
+		t.Fatal("expected campo to match case-insensitive filters")
+	}
+	if campoAtendeBusca(campo, buscaHorariosFiltros{Modalidade: "society"}) {
+		t.Fatal("expected modalidade mismatch to be filtered out")
+	}
+	if campoAtendeBusca(campo, buscaHorariosFiltros{MinJogadores: 12}) {
+		t.Fatal("expected campo smaller than min_jogadores to be filtered out")
+	}
+}
+
+func TestBuscarHorariosLivresCampoAppliesWindowAndPrice(t *testing.T) {
+	request := httptest.NewRequest("GET", "/busca/horarios?data=2026-05-09&hora_inicio=18:00&hora_fim=21:00&preco_max=150", nil)
+	filtros, err := parseBuscaHorariosFiltros(request)
+	if err != nil {
+		t.Fatalf("parseBuscaHorariosFiltros returned error: %v", err)
+	}
+
+	loc := agendamentoLocation()
+	multiplicador := 2.0
+	campo := models.Campo{
+		ValorHora:           100,
+		HorariosDisponiveis: []string{"17:00", "18:00", "19:00", "20:00", "21:00"},
+		RegrasPreco: []models.CampoRegraPreco{
+			{HoraInicio: "20:00", HoraFim: "23:00", Multiplicador: &multiplicador},
+		},
+	}
+	occupied := []agendamentoIntervalo{{
+		Inicio: time.Date(2026, 5, 9, 18, 0, 0, 0, loc),
+		Fim:    time.Date(2026, 5, 9, 19, 0, 0, 0, loc),
+	}}
+
+	horarios := buscarHorariosLivresCampo(campo, filtros, occupied, loc)
+	if len(horarios) != 1 || horarios[0].Horario != "19:00" || horarios[0].ValorTotal != 100 {
+		t.Fatalf("expected only 19:00 inside window and price, got %+v", horarios)
+	}
+}
+
+func TestParseBuscaHorariosFiltrosRejectsInvertedWindow(t *testing.T) {
+	request := httptest.NewRequest("GET", "/busca/horarios?hora_inicio=21:00&hora_fim=18:00", nil)
+	if _, err := parseBuscaHorariosFiltros(request); err == nil {
+		t.Fatal("expected error for inverted time window")
+	}
+
+	// 00:00 closes the operating day, so a window ending at midnight is valid.
+	request = httptest.NewRequest("GET", "/busca/horarios?hora_inicio=22:00&hora_fim=00:00", nil)
+	if _, err := parseBuscaHorariosFiltros(request); err != nil {
+		t.Fatalf("expected window ending at midnight to be valid, got %v", err)
+	}
+}
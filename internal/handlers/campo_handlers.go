@@ -28,6 +28,7 @@ type campoUpdatePayload struct {
 	ValorHora    *float64
 	Ativo        *bool
 	HorariosJSON *string
+	SemanaJSON   *string
 }
 
 type campoUpdateJSONInput struct {
@@ -83,6 +84,7 @@ func CadastrodeCampo(w http.ResponseWriter, r *http.Request) {
 	valorHoraStr := firstNonEmptyCampoValue(r.FormValue("valor_hora"), r.FormValue("valorHora"))
 	ativoStr := r.FormValue("ativo")
 	horariosRaw, horariosProvided := extractCampoHorariosRawFromForm(r.FormValue)
+	semanaRaw, semanaProvided := extractCampoHorariosSemanaRawFromForm(r.FormValue)
 
 	idArena, err := strconv.Atoi(idArenaStr)
 	if err != nil {
@@ -146,15 +148,25 @@ func CadastrodeCampo(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var horariosSemana map[int][]string
+	if semanaProvided {
+		horariosSemana, err = parseCampoHorariosSemanaRaw(semanaRaw)
+		if err != nil {
+			http.Error(w, "Horarios da semana invalidos", http.StatusBadRequest)
+			return
+		}
+	}
+
 	newCampo := models.Campo{
-		Nome:         nome,
-		MaxJogadores: maxJogadoresInt,
-		Modalidade:   modalidade,
-		TipoCampo:    tipoCampo,
-		Imagem:       urlImagem,
-		ValorHora:    valorHora,
-		Ativo:        ativo,
-		IdArena:      idArena,
+		Nome:           nome,
+		MaxJogadores:   maxJogadoresInt,
+		Modalidade:     modalidade,
+		TipoCampo:      tipoCampo,
+		Imagem:         urlImagem,
+		ValorHora:      valorHora,
+		Ativo:          ativo,
+		IdArena:        idArena,
+		HorariosSemana: horariosSemana,
 	}
 	applyCampoHorarioAliases(&newCampo, horarios)
 
@@ -177,6 +189,7 @@ func CadastrodeCampo(w http.ResponseWriter, r *http.Request) {
 			newCampo.ValorHora,
 			newCampo.Ativo,
 			serializeCampoHorarios(newCampo.HorariosDisponiveis),
+			serializeCampoHorariosSemana(newCampo.HorariosSemana),
 			optionalColumns,
 		)...,
 	)
@@ -196,6 +209,7 @@ func CadastrodeCampo(w http.ResponseWriter, r *http.Request) {
 		"horarios_disponiveis": newCampo.HorariosDisponiveis,
 		"horariosDisponiveis":  newCampo.HorariosDisponiveisCamel,
 		"horarios_campo":       newCampo.HorariosCampo,
+		"horarios_semana":      newCampo.HorariosSemana,
 	})
 }
 
@@ -255,6 +269,7 @@ func GetCampos(w http.ResponseWriter, r *http.Request) {
 			`+optionalCampoSelectExpression("c", "valor_hora", optionalColumns.ValorHora)+`,
 			`+optionalCampoSelectExpression("c", "ativo", optionalColumns.Ativo)+`,
 			`+optionalCampoSelectExpression("c", "horarios_disponiveis", optionalColumns.HorariosDisponiveis)+`,
+			`+optionalCampoSelectExpression("c", "horarios_semana", optionalColumns.HorariosSemana)+`,
 			c.id_arena,
 			a.nome AS nome_arena
 		FROM %s c
@@ -274,7 +289,7 @@ func GetCampos(w http.ResponseWriter, r *http.Request) {
 
 	for rowsCampos.Next() {
 		var campo models.Campo
-		var horariosRaw string
+		var horariosRaw, semanaRaw string
 		err := rowsCampos.Scan(
 			&campo.IDCampo,
 			&campo.Nome,
@@ -285,6 +300,7 @@ func GetCampos(w http.ResponseWriter, r *http.Request) {
 			&campo.ValorHora,
 			&campo.Ativo,
 			&horariosRaw,
+			&semanaRaw,
 			&campo.IdArena,
 			&campo.NomeArena,
 		)
@@ -295,9 +311,24 @@ func GetCampos(w http.ResponseWriter, r *http.Request) {
 		}
 		campo.EmManutencao = !campo.Ativo
 		applyCampoHorarioAliases(&campo, decodeCampoHorarios(horariosRaw))
+		campo.HorariosSemana = decodeCampoHorariosSemana(semanaRaw)
 		campos = append(campos, campo)
 	}
 
+	campoIDs := make([]int, 0, len(campos))
+	for _, campo := range campos {
+		campoIDs = append(campoIDs, campo.IDCampo)
+	}
+	regrasPreco, err := loadCampoRegrasPreco(r.Context(), config.DB, campoIDs)
+	if err != nil {
+		http.Error(w, "Erro ao buscar regras de preco dos campos", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar regras de preco: %v", err)
+		return
+	}
+	for index := range campos {
+		campos[index].RegrasPreco = regrasPreco[campos[index].IDCampo]
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(campos)
@@ -414,6 +445,9 @@ func UpdateCampo(w http.ResponseWriter, r *http.Request) {
 		response["horariosDisponiveis"] = horarios
 		response["horarios_campo"] = horarios
 	}
+	if payload.SemanaJSON != nil {
+		response["horarios_semana"] = decodeCampoHorariosSemana(*payload.SemanaJSON)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -559,6 +593,15 @@ func parseCampoUpdateMultipart(r *http.Request) (campoUpdatePayload, error) {
 		payload.HorariosJSON = &serialized
 	}
 
+	if semanaRaw, semanaProvided := extractCampoHorariosSemanaRawFromForm(r.FormValue); semanaProvided {
+		semana, err := parseCampoHorariosSemanaRaw(semanaRaw)
+		if err != nil {
+			return campoUpdatePayload{}, errors.New("Horarios da semana invalidos")
+		}
+		serialized := serializeCampoHorariosSemana(semana)
+		payload.SemanaJSON = &serialized
+	}
+
 	file, fileHeader, err := r.FormFile("imagem")
 	switch {
 	case err == nil:
@@ -635,6 +678,19 @@ func parseCampoUpdateJSON(r *http.Request) (campoUpdatePayload, error) {
 		payload.HorariosJSON = &serialized
 	}
 
+	semanaRaw, semanaProvided, err := extractCampoHorariosSemanaRawFromJSON(rawMessages)
+	if err != nil {
+		return campoUpdatePayload{}, errors.New("Horarios da semana invalidos")
+	}
+	if semanaProvided {
+		semana, err := parseCampoHorariosSemanaRaw(semanaRaw)
+		if err != nil {
+			return campoUpdatePayload{}, errors.New("Horarios da semana invalidos")
+		}
+		serialized := serializeCampoHorariosSemana(semana)
+		payload.SemanaJSON = &serialized
+	}
+
 	return payload, nil
 }
 
@@ -782,7 +838,7 @@ func firstNonZero(values ...int) int {
 }
 
 func updateCampoOptionalFields(idCampo int, payload campoUpdatePayload, columns campoOptionalColumns) error {
-	assignments := make([]string, 0, 3)
+	assignments := make([]string, 0, 4)
 	args := []any{idCampo}
 	placeholder := 2
 
@@ -804,6 +860,12 @@ func updateCampoOptionalFields(idCampo int, payload campoUpdatePayload, columns
 		placeholder++
 	}
 
+	if columns.HorariosSemana && payload.SemanaJSON != nil {
+		assignments = append(assignments, fmt.Sprintf("horarios_semana = $%d::jsonb", placeholder))
+		args = append(args, *payload.SemanaJSON)
+		placeholder++
+	}
+
 	if len(assignments) == 0 {
 		return nil
 	}
@@ -856,7 +918,7 @@ func DeleteCampo(w http.ResponseWriter, r *http.Request) {
 		SELECT COUNT(*)
 		FROM %s
 		WHERE id_campo = $1
-		  AND status NOT IN ('concluido', 'cancelado')
+		  AND status NOT IN ('concluido', 'cancelado', 'nao_compareceu')
 	`, agendamentosTableName()), idCampo).Scan(&countNaoPermitidos)
 	if err != nil {
 		http.Error(w, "Erro ao verificar agendamentos do campo", http.StatusInternalServerError)
@@ -870,7 +932,7 @@ func DeleteCampo(w http.ResponseWriter, r *http.Request) {
 	_, err = config.DB.Exec(fmt.Sprintf(`
 		DELETE FROM %s
 		WHERE id_campo = $1
-		  AND status IN ('cancelado', 'concluido')
+		  AND status IN ('cancelado', 'concluido', 'nao_compareceu')
 	`, agendamentosTableName()), idCampo)
 	if err != nil {
 		http.Error(w, "Erro ao excluir agendamentos cancelados", http.StatusInternalServerError)
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+type campoHoraExtraRequest struct {
+	ToleranciaMinutos *int     `json:"tolerancia_minutos"`
+	BlocoMinutos      *int     `json:"bloco_minutos"`
+	ValorBloco        *float64 `json:"valor_bloco"`
+}
+
+type campoHoraExtraResponse struct {
+	IDCampo           int      `json:"id_campo"`
+	Ativa             bool     `json:"ativa"`
+	ToleranciaMinutos int      `json:"tolerancia_minutos"`
+	BlocoMinutos      *int     `json:"bloco_minutos"`
+	ValorBloco        *float64 `json:"valor_bloco"`
+	AtualizadoEm      string   `json:"atualizado_em,omitempty"`
+}
+
+func GetHoraExtraCampo(w http.ResponseWriter, r *http.Request) {
+	idCampo, ok := resolveCampoRegraPrecoOwner(w, r)
+	if !ok {
+		return
+	}
+
+	regra, err := loadCampoHoraExtra(r.Context(), config.DB, idCampo)
+	if err != nil {
+		http.Error(w, "Erro ao buscar regra de hora extra", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar regra de hora extra do campo %d: %v", idCampo, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCampoHoraExtraResponse(regra))
+}
+
+// AtualizarHoraExtraCampo replaces the campo's overtime rule; leaving the
+// block size or value out stops charging overtime.
+func AtualizarHoraExtraCampo(w http.ResponseWriter, r *http.Request) {
+	idCampo, ok := resolveCampoRegraPrecoOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var request campoHoraExtraRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Erro ao decodificar JSON", http.StatusBadRequest)
+		return
+	}
+
+	regra, err := buildCampoHoraExtra(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	regra.IDCampo = idCampo
+
+	var atualizadoEm time.Time
+	err = config.DB.QueryRowContext(r.Context(), fmt.Sprintf(`
+		INSERT INTO %s (id_campo, tolerancia_minutos, bloco_minutos, valor_bloco, atualizado_em)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (id_campo) DO UPDATE SET
+			tolerancia_minutos = EXCLUDED.tolerancia_minutos,
+			bloco_minutos = EXCLUDED.bloco_minutos,
+			valor_bloco = EXCLUDED.valor_bloco,
+			atualizado_em = EXCLUDED.atualizado_em
+		RETURNING atualizado_em
+	`, campoHoraExtraTableName()),
+		idCampo,
+		regra.ToleranciaMinutos,
+		nullableIntValue(regra.BlocoMinutos),
+		nullableFloat64Value(regra.ValorBloco),
+	).Scan(&atualizadoEm)
+	if err != nil {
+		http.Error(w, "Erro ao salvar regra de hora extra", http.StatusInternalServerError)
+		log.Printf("Erro ao salvar regra de hora extra do campo %d: %v", idCampo, err)
+		return
+	}
+	regra.AtualizadoEm = &atualizadoEm
+
+	writeJSON(w, http.StatusOK, newCampoHoraExtraResponse(regra))
+}
+
+func buildCampoHoraExtra(request campoHoraExtraRequest) (models.CampoHoraExtra, error) {
+	var regra models.CampoHoraExtra
+
+	if request.ToleranciaMinutos != nil {
+		if *request.ToleranciaMinutos < 0 || *request.ToleranciaMinutos > agendamentoDuracaoMaximaMinutos {
+			return models.CampoHoraExtra{}, fmt.Errorf("tolerancia_minutos deve estar entre 0 e %d minutos", agendamentoDuracaoMaximaMinutos)
+		}
+		regra.ToleranciaMinutos = *request.ToleranciaMinutos
+	}
+
+	var err error
+	if regra.BlocoMinutos, err = arenaPoliticaMinutos(request.BlocoMinutos, "bloco_minutos"); err != nil {
+		return models.CampoHoraExtra{}, err
+	}
+	if regra.BlocoMinutos != nil && *regra.BlocoMinutos > agendamentoDuracaoMaximaMinutos {
+		return models.CampoHoraExtra{}, fmt.Errorf("bloco_minutos deve estar entre 1 e %d minutos", agendamentoDuracaoMaximaMinutos)
+	}
+
+	if request.ValorBloco != nil && *request.ValorBloco != 0 {
+		if *request.ValorBloco < 0 {
+			return models.CampoHoraExtra{}, errors.New("valor_bloco deve ser maior que zero")
+		}
+		valor := models.ArredondarCentavos(*request.ValorBloco)
+		regra.ValorBloco = &valor
+	}
+
+	return regra, nil
+}
+
+// loadCampoHoraExtra returns an inactive rule for campos that never saved one.
+func loadCampoHoraExtra(ctx context.Context, db agendamentoDB, idCampo int) (models.CampoHoraExtra, error) {
+	regra := models.CampoHoraExtra{IDCampo: idCampo}
+
+	var (
+		blocoMinutos sql.NullInt64
+		valorBloco   sql.NullFloat64
+		atualizadoEm time.Time
+	)
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT tolerancia_minutos, bloco_minutos, valor_bloco, atualizado_em
+		FROM %s
+		WHERE id_campo = $1
+	`, campoHoraExtraTableName()), idCampo).Scan(
+		&regra.ToleranciaMinutos,
+		&blocoMinutos,
+		&valorBloco,
+		&atualizadoEm,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return regra, nil
+	}
+	if err != nil {
+		return models.CampoHoraExtra{}, err
+	}
+
+	regra.BlocoMinutos = nullIntPointer(blocoMinutos)
+	if valorBloco.Valid {
+		regra.ValorBloco = &valorBloco.Float64
+	}
+	regra.AtualizadoEm = &atualizadoEm
+	return regra, nil
+}
+
+// calcularHoraExtraAgendamento prices the overtime of a closed cronometro and
+// adds it to the agendamento's total. totalPago is what is already paid, so a
+// booking settled before the game owes exactly the overtime. A cronometro
+// restarted after being closed replaces the overtime charged before.
+func calcularHoraExtraAgendamento(agendamento models.Agendamento, regra models.CampoHoraExtra, totalPago float64) models.Agendamento {
+	jogado, ok := agendamento.TempoJogado()
+	if !ok {
+		return agendamento
+	}
+
+	valorTotal := agendamento.ValorTotal
+	if agendamento.HoraExtra != nil {
+		valorTotal -= agendamento.HoraExtra.Valor
+		agendamento.HoraExtra = nil
+	}
+
+	reservado := time.Duration(models.NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos)) * time.Minute
+	horaExtra := regra.Calcular(reservado, jogado)
+	if horaExtra.MinutosExcedentes > 0 {
+		agendamento.HoraExtra = &horaExtra
+		valorTotal += horaExtra.Valor
+	}
+
+	valorTotal = models.ArredondarCentavos(valorTotal)
+	if valorTotal != agendamento.ValorTotal {
+		agendamento.ValorTotal = valorTotal
+		agendamento.ValorRestante, agendamento.Pago, agendamento.StatusDePagamento = resolveFinancialState(agendamento.ValorTotal, totalPago, false, false)
+	}
+
+	return agendamento
+}
+
+func newCampoHoraExtraResponse(regra models.CampoHoraExtra) campoHoraExtraResponse {
+	response := campoHoraExtraResponse{
+		IDCampo:           regra.IDCampo,
+		Ativa:             regra.Ativa(),
+		ToleranciaMinutos: regra.ToleranciaMinutos,
+		BlocoMinutos:      regra.BlocoMinutos,
+		ValorBloco:        regra.ValorBloco,
+	}
+	if regra.AtualizadoEm != nil {
+		response.AtualizadoEm = formatAgendamentoDateTime(*regra.AtualizadoEm)
+	}
+
+	return response
+}
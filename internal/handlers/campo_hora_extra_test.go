@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestCalcularHoraExtraAgendamentoAddsToTheTotal(t *testing.T) {
+	bloco := 30
+	valor := 50.0
+	regra := models.CampoHoraExtra{BlocoMinutos: &bloco, ValorBloco: &valor}
+
+	inicio := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	inicioUnix := inicio.Unix()
+	fim := inicio.Add(80 * time.Minute)
+	agendamento := models.Agendamento{
+		DuracaoMinutos:    60,
+		ValorTotal:        100,
+		Pago:              true,
+		StatusDePagamento: true,
+		InicioCronometro:  &inicioUnix,
+		FimCronometro:     &fim,
+	}
+
+	agendamento = calcularHoraExtraAgendamento(agendamento, regra, 100)
+	if agendamento.HoraExtra == nil || agendamento.HoraExtra.Valor != 50 {
+		t.Fatalf("expected one 50.00 block, got %+v", agendamento.HoraExtra)
+	}
+	if agendamento.ValorTotal != 150 || agendamento.ValorRestante != 50 || agendamento.Pago {
+		t.Fatalf("expected the overtime to be owed, got total %.2f restante %.2f pago %v", agendamento.ValorTotal, agendamento.ValorRestante, agendamento.Pago)
+	}
+}
+
+func TestCalcularHoraExtraAgendamentoReplacesPreviousCharge(t *testing.T) {
+	bloco := 30
+	valor := 50.0
+	regra := models.CampoHoraExtra{BlocoMinutos: &bloco, ValorBloco: &valor}
+
+	inicio := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	inicioUnix := inicio.Unix()
+	fim := inicio.Add(70 * time.Minute)
+	agendamento := models.Agendamento{
+		DuracaoMinutos:           60,
+		ValorTotal:               200,
+		InicioCronometro:         &inicioUnix,
+		FimCronometro:            &fim,
+		CronometroPausasSegundos: 15 * 60,
+		HoraExtra:                &models.AgendamentoHoraExtra{MinutosExcedentes: 40, Blocos: 2, Valor: 100},
+	}
+
+	agendamento = calcularHoraExtraAgendamento(agendamento, regra, 100)
+	if agendamento.HoraExtra != nil {
+		t.Fatalf("expected paused time not to count as overtime, got %+v", agendamento.HoraExtra)
+	}
+	if agendamento.ValorTotal != 100 || agendamento.ValorRestante != 0 || !agendamento.Pago {
+		t.Fatalf("expected the previous overtime to be dropped, got total %.2f restante %.2f pago %v", agendamento.ValorTotal, agendamento.ValorRestante, agendamento.Pago)
+	}
+}
+
+func TestBuildCampoHoraExtraRejectsNegativeValues(t *testing.T) {
+	tolerancia := -1
+	if _, err := buildCampoHoraExtra(campoHoraExtraRequest{ToleranciaMinutos: &tolerancia}); err == nil {
+		t.Fatalf("expected negative tolerance to be refused")
+	}
+
+	valor := -10.0
+	if _, err := buildCampoHoraExtra(campoHoraExtraRequest{ValorBloco: &valor}); err == nil {
+		t.Fatalf("expected negative block value to be refused")
+	}
+}
@@ -275,7 +275,7 @@ func campoHorarioSortValue(horario string) int {
 	hour, _ := strconv.Atoi(parts[0])
 	minute, _ := strconv.Atoi(parts[1])
 
-	if hour < 6 {
+	if hour < agendamentoHoraVirada {
 		hour += 24
 	}
 
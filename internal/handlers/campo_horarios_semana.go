@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+var campoHorariosSemanaInputKeys = []string{
+	"horarios_semana",
+	"horariosSemana",
+	"horario_semanal",
+	"funcionamento",
+}
+
+var campoDiasSemana = map[string]int{
+	"domingo":       0,
+	"dom":           0,
+	"sunday":        0,
+	"segunda":       1,
+	"segunda-feira": 1,
+	"seg":           1,
+	"monday":        1,
+	"terca":         2,
+	"terça":         2,
+	"terca-feira":   2,
+	"terça-feira":   2,
+	"ter":           2,
+	"tuesday":       2,
+	"quarta":        3,
+	"quarta-feira":  3,
+	"qua":           3,
+	"wednesday":     3,
+	"quinta":        4,
+	"quinta-feira":  4,
+	"qui":           4,
+	"thursday":      4,
+	"sexta":         5,
+	"sexta-feira":   5,
+	"sex":           5,
+	"friday":        5,
+	"sabado":        6,
+	"sábado":        6,
+	"sab":           6,
+	"sáb":           6,
+	"saturday":      6,
+}
+
+type campoHorarioFaixa struct {
+	Inicio     string `json:"inicio"`
+	Fim        string `json:"fim"`
+	Abertura   string `json:"abertura"`
+	Fechamento string `json:"fechamento"`
+}
+
+func (faixa campoHorarioFaixa) definida() bool {
+	return firstNonEmptyCampoValue(faixa.Inicio, faixa.Abertura) != "" || firstNonEmptyCampoValue(faixa.Fim, faixa.Fechamento) != ""
+}
+
+func extractCampoHorariosSemanaRawFromForm(getValue func(string) string) (string, bool) {
+	for _, key := range campoHorariosSemanaInputKeys {
+		value := strings.TrimSpace(getValue(key))
+		if value != "" {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func extractCampoHorariosSemanaRawFromJSON(rawMessages map[string]json.RawMessage) (string, bool, error) {
+	for _, key := range campoHorariosSemanaInputKeys {
+		message, exists := rawMessages[key]
+		if !exists {
+			continue
+		}
+
+		raw, ok, err := normalizeCampoHorarioRawJSON(message)
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			return raw, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// parseCampoHorariosSemanaRaw accepts an object keyed by weekday (0-6 or the
+// day name). Each day takes a slot list, an open/close range or a list of
+// ranges; an empty list or false marks the day as closed.
+func parseCampoHorariosSemanaRaw(raw string) (map[int][]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var days map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &days); err != nil {
+		return nil, fmt.Errorf("horarios_semana is not a JSON object")
+	}
+
+	semana := make(map[int][]string, len(days))
+	for key, message := range days {
+		dia, err := parseCampoDiaSemana(key)
+		if err != nil {
+			return nil, err
+		}
+
+		horarios, err := parseCampoHorariosDia(message)
+		if err != nil {
+			return nil, fmt.Errorf("horarios invalidos para %s: %w", key, err)
+		}
+		semana[dia] = horarios
+	}
+
+	return semana, nil
+}
+
+func parseCampoDiaSemana(raw string) (int, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if dia, err := strconv.Atoi(raw); err == nil && dia >= 0 && dia <= 6 {
+		return dia, nil
+	}
+	if dia, ok := campoDiasSemana[raw]; ok {
+		return dia, nil
+	}
+
+	return 0, fmt.Errorf("dia da semana invalido: %s", raw)
+}
+
+func parseCampoHorariosDia(message json.RawMessage) ([]string, error) {
+	trimmed := strings.TrimSpace(string(message))
+	if trimmed == "" || trimmed == "null" || trimmed == "false" {
+		return []string{}, nil
+	}
+
+	var items []json.RawMessage
+	if json.Unmarshal(message, &items) == nil && len(items) == 0 {
+		return []string{}, nil
+	}
+
+	var faixa campoHorarioFaixa
+	if strings.HasPrefix(trimmed, "{") && json.Unmarshal(message, &faixa) == nil && faixa.definida() {
+		return expandCampoHorarioFaixas([]campoHorarioFaixa{faixa})
+	}
+
+	var faixas []campoHorarioFaixa
+	if err := json.Unmarshal(message, &faixas); err == nil && len(faixas) > 0 && faixas[0].definida() {
+		return expandCampoHorarioFaixas(faixas)
+	}
+
+	horarios, err := parseCampoHorariosRaw(trimmed)
+	if err != nil {
+		return nil, err
+	}
+	if horarios == nil {
+		horarios = []string{}
+	}
+
+	return horarios, nil
+}
+
+func expandCampoHorarioFaixas(faixas []campoHorarioFaixa) ([]string, error) {
+	horarios := make([]string, 0)
+	for _, faixa := range faixas {
+		inicio, err := normalizeCampoHorario(firstNonEmptyCampoValue(faixa.Inicio, faixa.Abertura))
+		if err != nil {
+			return nil, err
+		}
+		fim, err := normalizeCampoHorario(firstNonEmptyCampoValue(faixa.Fim, faixa.Fechamento))
+		if err != nil {
+			return nil, err
+		}
+
+		inicioMinutos, _ := horarioEmMinutos(inicio)
+		fimMinutos, _ := horarioEmMinutos(fim)
+		if fimMinutos <= inicioMinutos {
+			fimMinutos += 24 * 60
+		}
+
+		passo := int(agendamentoSlotDuracao / time.Minute)
+		for minuto := inicioMinutos; minuto+passo <= fimMinutos; minuto += passo {
+			horarios = append(horarios, fmt.Sprintf("%02d:%02d", (minuto/60)%24, minuto%60))
+		}
+	}
+
+	if len(horarios) == 0 {
+		return []string{}, nil
+	}
+
+	return normalizeCampoHorarios(horarios)
+}
+
+func serializeCampoHorariosSemana(semana map[int][]string) string {
+	if len(semana) == 0 {
+		return "{}"
+	}
+
+	encoded, err := json.Marshal(semana)
+	if err != nil {
+		return "{}"
+	}
+
+	return string(encoded)
+}
+
+func decodeCampoHorariosSemana(raw string) map[int][]string {
+	semana, err := parseCampoHorariosSemanaRaw(raw)
+	if err != nil || len(semana) == 0 {
+		return nil
+	}
+
+	return semana
+}
+
+// campoHorariosNaData returns the horarios for the operating day of data and
+// whether the campo opens at all. Days missing from the weekly schedule fall
+// back to the legacy list (and generateBookingSlots to the default one).
+func campoHorariosNaData(legacy []string, semana map[int][]string, data time.Time) ([]string, bool) {
+	if horarios, ok := semana[int(data.Weekday())]; ok {
+		return horarios, len(horarios) > 0
+	}
+
+	return legacy, true
+}
+
+func campoFuncionamentoPermite(legacy []string, semana map[int][]string, inicio time.Time, duracaoMinutos int) bool {
+	location := agendamentoLocation()
+	data := agendamentoDataOperacional(inicio)
+	horarios, aberto := campoHorariosNaData(legacy, semana, data)
+	if !aberto {
+		return false
+	}
+
+	slots := generateBookingSlots(data, location, horarios)
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Before(slots[j]) })
+
+	fim := inicio.Add(time.Duration(models.NormalizeAgendamentoDuracao(duracaoMinutos)) * time.Minute)
+	return bookingWindowFits(slots, inicio, fim)
+}
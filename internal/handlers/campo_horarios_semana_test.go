@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCampoHorariosSemanaRawSupportsListsRangesAndClosedDays(t *testing.T) {
+	semana, err := parseCampoHorariosSemanaRaw(`{
+		"segunda": ["18:00", "19:00"],
+		"6": {"abertura": "08:00", "fechamento": "12:00"},
+		"sexta": [{"inicio": "22:00", "fim": "01:00"}],
+		"domingo": []
+	}`)
+	if err != nil {
+		t.Fatalf("parseCampoHorariosSemanaRaw returned error: %v", err)
+	}
+
+	if got := strings.Join(semana[1], ","); got != "18:00,19:00" {
+		t.Fatalf("expected segunda horarios, got %v", semana[1])
+	}
+	if got := strings.Join(semana[6], ","); got != "08:00,09:00,10:00,11:00" {
+		t.Fatalf("expected sabado range expanded hourly, got %v", semana[6])
+	}
+	if got := strings.Join(semana[5], ","); got != "22:00,23:00,00:00" {
+		t.Fatalf("expected sexta range to cross midnight, got %v", semana[5])
+	}
+	if horarios, ok := semana[0]; !ok || len(horarios) != 0 {
+		t.Fatalf("expected domingo to be explicitly closed, got %v (present=%v)", horarios, ok)
+	}
+
+	if _, err := parseCampoHorariosSemanaRaw(`{"feriado": []}`); err == nil {
+		t.Fatal("expected error for unknown weekday")
+	}
+}
+
+func TestCampoHorariosNaDataFallsBackToLegacy(t *testing.T) {
+	loc := agendamentoLocation()
+	legacy := []string{"07:00", "08:00"}
+	semana := map[int][]string{0: {}, 1: {"18:00"}}
+
+	domingo := time.Date(2026, 4, 12, 0, 0, 0, 0, loc)
+	if _, aberto := campoHorariosNaData(legacy, semana, domingo); aberto {
+		t.Fatal("expected campo closed on domingo")
+	}
+
+	horarios, aberto := campoHorariosNaData(legacy, semana, domingo.AddDate(0, 0, 1))
+	if !aberto || strings.Join(horarios, ",") != "18:00" {
+		t.Fatalf("expected segunda schedule, got %v (aberto=%v)", horarios, aberto)
+	}
+
+	horarios, aberto = campoHorariosNaData(legacy, semana, domingo.AddDate(0, 0, 2))
+	if !aberto || strings.Join(horarios, ",") != "07:00,08:00" {
+		t.Fatalf("expected legacy horarios on terca, got %v (aberto=%v)", horarios, aberto)
+	}
+}
+
+func TestCampoFuncionamentoPermiteUsesOperatingDay(t *testing.T) {
+	loc := agendamentoLocation()
+	semana := map[int][]string{5: {"22:00", "23:00", "00:00"}, 6: {}}
+
+	// 00:00 on sabado still belongs to the sexta night.
+	if !campoFuncionamentoPermite(nil, semana, time.Date(2026, 4, 17, 23, 0, 0, 0, loc), 120) {
+		t.Fatal("expected sexta 23:00 for 2h to fit the night schedule")
+	}
+	if campoFuncionamentoPermite(nil, semana, time.Date(2026, 4, 17, 23, 30, 0, 0, loc), 120) {
+		t.Fatal("expected booking past closing time to be rejected")
+	}
+	if campoFuncionamentoPermite(nil, semana, time.Date(2026, 4, 18, 10, 0, 0, 0, loc), 60) {
+		t.Fatal("expected sabado to be closed")
+	}
+}
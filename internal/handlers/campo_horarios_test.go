@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseCampoHorariosRawSupportsJSONAndMap(t *testing.T) {
@@ -49,3 +51,27 @@ func TestExtractCampoHorariosRawFromJSONSupportsString(t *testing.T) {
 		t.Fatalf("unexpected raw horarios value: %q", raw)
 	}
 }
+
+func TestAvailableBookingStartsRequiresFullDuration(t *testing.T) {
+	location := agendamentoLocation()
+	date := time.Date(2026, time.April, 21, 0, 0, 0, 0, location)
+	slots := generateBookingSlots(date, location, []string{"18:00", "19:00", "20:00", "21:00"})
+	occupied := []agendamentoIntervalo{{
+		Inicio: time.Date(2026, time.April, 21, 19, 30, 0, 0, location),
+		Fim:    time.Date(2026, time.April, 21, 20, 30, 0, 0, location),
+	}}
+
+	available := availableBookingStarts(slots, occupied, 90*time.Minute)
+	if len(available) != 1 || available[0].Format("15:04") != "18:00" {
+		t.Fatalf("expected only 18:00 to fit before the 19:30 booking, got %v", available)
+	}
+
+	available = availableBookingStarts(slots, nil, 2*time.Hour)
+	labels := make([]string, 0, len(available))
+	for _, slot := range available {
+		labels = append(labels, slot.Format("15:04"))
+	}
+	if strings.Join(labels, ",") != "18:00,19:00,20:00" {
+		t.Fatalf("expected two-hour starts that fit before closing, got %v", labels)
+	}
+}
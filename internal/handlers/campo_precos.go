@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+// calcularValorAgendamento prices the booking in agendamentoDuracaoPassoMinutos
+// steps, so a game that crosses into a peak window pays each part at its rate.
+func calcularValorAgendamento(valorHora float64, regras []models.CampoRegraPreco, inicio time.Time, duracaoMinutos int) float64 {
+	duracaoMinutos = models.NormalizeAgendamentoDuracao(duracaoMinutos)
+	if len(regras) == 0 {
+		return models.ArredondarCentavos(valorHora * float64(duracaoMinutos) / 60)
+	}
+
+	total := 0.0
+	for minuto := 0; minuto < duracaoMinutos; minuto += agendamentoDuracaoPassoMinutos {
+		passo := agendamentoDuracaoPassoMinutos
+		if restante := duracaoMinutos - minuto; restante < passo {
+			passo = restante
+		}
+
+		instante := inicio.Add(time.Duration(minuto) * time.Minute)
+		total += resolveValorHora(valorHora, regras, instante) * float64(passo) / 60
+	}
+
+	return models.ArredondarCentavos(total)
+}
+
+func resolveValorHora(valorHora float64, regras []models.CampoRegraPreco, instante time.Time) float64 {
+	var aplicada *models.CampoRegraPreco
+	for index := range regras {
+		regra := &regras[index]
+		if !regraPrecoAplica(*regra, instante) {
+			continue
+		}
+		if aplicada == nil || regra.Prioridade() >= aplicada.Prioridade() {
+			aplicada = regra
+		}
+	}
+
+	if aplicada == nil {
+		return valorHora
+	}
+	if aplicada.ValorHora != nil {
+		return *aplicada.ValorHora
+	}
+	if aplicada.Multiplicador != nil {
+		return valorHora * *aplicada.Multiplicador
+	}
+
+	return valorHora
+}
+
+// regraPrecoAplica anchors the window on the rule's day, so a window that
+// crosses midnight (e.g. friday 18:00-02:00) still covers the early hours.
+func regraPrecoAplica(regra models.CampoRegraPreco, instante time.Time) bool {
+	inicioMinutos, ok := horarioEmMinutos(regra.HoraInicio)
+	if !ok {
+		return false
+	}
+	fimMinutos, ok := horarioEmMinutos(regra.HoraFim)
+	if !ok {
+		return false
+	}
+	if fimMinutos <= inicioMinutos {
+		fimMinutos += 24 * 60
+	}
+
+	local := instante.In(agendamentoLocation())
+	hoje := agendamentoDate(local)
+	for _, dia := range []time.Time{hoje, hoje.AddDate(0, 0, -1)} {
+		if !regraPrecoAplicaNoDia(regra, dia) {
+			continue
+		}
+
+		janelaInicio := dia.Add(time.Duration(inicioMinutos) * time.Minute)
+		janelaFim := dia.Add(time.Duration(fimMinutos) * time.Minute)
+		if !local.Before(janelaInicio) && local.Before(janelaFim) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func regraPrecoAplicaNoDia(regra models.CampoRegraPreco, dia time.Time) bool {
+	if regra.Data != nil {
+		return agendamentoDate(*regra.Data).Equal(dia)
+	}
+	if regra.DiaSemana != nil {
+		return int(dia.Weekday()) == *regra.DiaSemana
+	}
+
+	return true
+}
+
+func horarioEmMinutos(horario string) (int, bool) {
+	parts := strings.Split(strings.TrimSpace(horario), ":")
+	if len(parts) != 2 {
+		return 0, false
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, false
+	}
+
+	return hour*60 + minute, true
+}
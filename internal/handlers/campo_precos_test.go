@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestCalcularValorAgendamentoSplitsAcrossPeakWindow(t *testing.T) {
+	location := agendamentoLocation()
+	terca := 2
+	pico := 200.0
+	regras := []models.CampoRegraPreco{{
+		DiaSemana:  &terca,
+		HoraInicio: "18:00",
+		HoraFim:    "23:00",
+		ValorHora:  &pico,
+	}}
+
+	inicio := time.Date(2026, time.April, 21, 17, 0, 0, 0, location)
+	if got := calcularValorAgendamento(100, regras, inicio, 120); got != 300 {
+		t.Fatalf("expected one base hour plus one peak hour (300), got %v", got)
+	}
+}
+
+func TestResolveValorHoraPrefersDateOverride(t *testing.T) {
+	location := agendamentoLocation()
+	terca := 2
+	multiplicador := 1.5
+	feriado := time.Date(2026, time.April, 21, 0, 0, 0, 0, location)
+	valorFeriado := 80.0
+	regras := []models.CampoRegraPreco{
+		{DiaSemana: &terca, HoraInicio: "00:00", HoraFim: "00:00", Multiplicador: &multiplicador},
+		{Data: &feriado, HoraInicio: "00:00", HoraFim: "00:00", ValorHora: &valorFeriado},
+	}
+
+	got := resolveValorHora(100, regras, time.Date(2026, time.April, 21, 20, 0, 0, 0, location))
+	if got != 80 {
+		t.Fatalf("expected holiday override price 80, got %v", got)
+	}
+
+	got = resolveValorHora(100, regras, time.Date(2026, time.April, 28, 20, 0, 0, 0, location))
+	if got != 150 {
+		t.Fatalf("expected weekday multiplier price 150, got %v", got)
+	}
+}
+
+func TestRegraPrecoAplicaCoversWindowAfterMidnight(t *testing.T) {
+	location := agendamentoLocation()
+	sexta := 5
+	regra := models.CampoRegraPreco{DiaSemana: &sexta, HoraInicio: "18:00", HoraFim: "02:00"}
+
+	if !regraPrecoAplica(regra, time.Date(2026, time.April, 25, 1, 0, 0, 0, location)) {
+		t.Fatal("expected saturday 01:00 to belong to the friday night window")
+	}
+	if regraPrecoAplica(regra, time.Date(2026, time.April, 25, 19, 0, 0, 0, location)) {
+		t.Fatal("expected saturday evening to be outside the friday rule")
+	}
+}
+
+func TestBuildCampoRegraPrecoRequiresSinglePriceKind(t *testing.T) {
+	valor := 150.0
+	multiplicador := 1.2
+	if _, err := buildCampoRegraPreco(campoRegraPrecoRequest{ValorHora: &valor, Multiplicador: &multiplicador}); err == nil {
+		t.Fatal("expected error when both valor_hora and multiplicador are informed")
+	}
+
+	if _, err := buildCampoRegraPreco(campoRegraPrecoRequest{HoraInicio: "18:00", HoraFim: "23:00"}); err == nil {
+		t.Fatal("expected error when no price is informed")
+	}
+
+	regra, err := buildCampoRegraPreco(campoRegraPrecoRequest{HoraInicio: "18", HoraFim: "2300", ValorHora: &valor})
+	if err != nil {
+		t.Fatalf("expected valid rule, got %v", err)
+	}
+	if regra.HoraInicio != "18:00" || regra.HoraFim != "23:00" {
+		t.Fatalf("expected normalized window, got %s-%s", regra.HoraInicio, regra.HoraFim)
+	}
+}
@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+type campoRegraPrecoRequest struct {
+	DiaSemana     *int     `json:"dia_semana"`
+	Data          string   `json:"data"`
+	HoraInicio    string   `json:"hora_inicio"`
+	HoraFim       string   `json:"hora_fim"`
+	ValorHora     *float64 `json:"valor_hora"`
+	Multiplicador *float64 `json:"multiplicador"`
+	Descricao     string   `json:"descricao"`
+}
+
+type campoRegraPrecoResponse struct {
+	ID            int      `json:"id"`
+	IDCampo       int      `json:"id_campo"`
+	DiaSemana     *int     `json:"dia_semana,omitempty"`
+	Data          string   `json:"data,omitempty"`
+	HoraInicio    string   `json:"hora_inicio"`
+	HoraFim       string   `json:"hora_fim"`
+	ValorHora     *float64 `json:"valor_hora,omitempty"`
+	Multiplicador *float64 `json:"multiplicador,omitempty"`
+	Descricao     string   `json:"descricao,omitempty"`
+}
+
+func GetRegrasPrecoCampo(w http.ResponseWriter, r *http.Request) {
+	idCampo, ok := resolveCampoRegraPrecoOwner(w, r)
+	if !ok {
+		return
+	}
+
+	regras, err := loadCampoRegrasPreco(r.Context(), config.DB, []int{idCampo})
+	if err != nil {
+		http.Error(w, "Erro ao buscar regras de preco", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar regras de preco do campo %d: %v", idCampo, err)
+		return
+	}
+
+	response := make([]campoRegraPrecoResponse, 0, len(regras[idCampo]))
+	for _, regra := range regras[idCampo] {
+		response = append(response, newCampoRegraPrecoResponse(regra))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func CriarRegraPrecoCampo(w http.ResponseWriter, r *http.Request) {
+	idCampo, ok := resolveCampoRegraPrecoOwner(w, r)
+	if !ok {
+		return
+	}
+
+	regra, err := parseCampoRegraPrecoRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	regra.IDCampo = idCampo
+
+	err = config.DB.QueryRowContext(r.Context(), fmt.Sprintf(`
+		INSERT INTO %s (
+			id_campo,
+			dia_semana,
+			data,
+			hora_inicio,
+			hora_fim,
+			valor_hora,
+			multiplicador,
+			descricao
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+		RETURNING id
+	`, campoRegrasPrecoTableName()),
+		idCampo,
+		nullableIntValue(regra.DiaSemana),
+		nullableTimeValue(regra.Data),
+		regra.HoraInicio,
+		regra.HoraFim,
+		nullableFloat64Value(regra.ValorHora),
+		nullableFloat64Value(regra.Multiplicador),
+		regra.Descricao,
+	).Scan(&regra.ID)
+	if err != nil {
+		http.Error(w, "Erro ao cadastrar regra de preco", http.StatusInternalServerError)
+		log.Printf("Erro ao cadastrar regra de preco do campo %d: %v", idCampo, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"message": "Regra de preco cadastrada com sucesso",
+		"regra":   newCampoRegraPrecoResponse(regra),
+	})
+}
+
+func EditarRegraPrecoCampo(w http.ResponseWriter, r *http.Request) {
+	idCampo, ok := resolveCampoRegraPrecoOwner(w, r)
+	if !ok {
+		return
+	}
+
+	idRegra, err := resolveRegraPrecoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	regra, err := parseCampoRegraPrecoRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	regra.ID = idRegra
+	regra.IDCampo = idCampo
+
+	result, err := config.DB.ExecContext(r.Context(), fmt.Sprintf(`
+		UPDATE %s
+		SET
+			dia_semana = $1,
+			data = $2,
+			hora_inicio = $3,
+			hora_fim = $4,
+			valor_hora = $5,
+			multiplicador = $6,
+			descricao = NULLIF($7, '')
+		WHERE id = $8
+		  AND id_campo = $9
+	`, campoRegrasPrecoTableName()),
+		nullableIntValue(regra.DiaSemana),
+		nullableTimeValue(regra.Data),
+		regra.HoraInicio,
+		regra.HoraFim,
+		nullableFloat64Value(regra.ValorHora),
+		nullableFloat64Value(regra.Multiplicador),
+		regra.Descricao,
+		idRegra,
+		idCampo,
+	)
+	if err != nil {
+		http.Error(w, "Erro ao atualizar regra de preco", http.StatusInternalServerError)
+		log.Printf("Erro ao atualizar regra de preco %d: %v", idRegra, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		http.Error(w, "Regra de preco nao encontrada", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message": "Regra de preco atualizada com sucesso",
+		"regra":   newCampoRegraPrecoResponse(regra),
+	})
+}
+
+func DeleteRegraPrecoCampo(w http.ResponseWriter, r *http.Request) {
+	idCampo, ok := resolveCampoRegraPrecoOwner(w, r)
+	if !ok {
+		return
+	}
+
+	idRegra, err := resolveRegraPrecoID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := config.DB.ExecContext(
+		r.Context(),
+		fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND id_campo = $2`, campoRegrasPrecoTableName()),
+		idRegra,
+		idCampo,
+	)
+	if err != nil {
+		http.Error(w, "Erro ao excluir regra de preco", http.StatusInternalServerError)
+		log.Printf("Erro ao excluir regra de preco %d: %v", idRegra, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		http.Error(w, "Regra de preco nao encontrada", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Regra de preco excluida com sucesso"})
+}
+
+func resolveCampoRegraPrecoOwner(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	idCampo, err := resolveCampoID(r, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, false
+	}
+
+	if err := ensureCampoBelongsToUser(idCampo, userID); err != nil {
+		switch {
+		case errors.Is(err, errCampoOwnershipForbidden):
+			http.Error(w, "O campo nao pertence ao usuario", http.StatusForbidden)
+		default:
+			http.Error(w, "Erro ao verificar propriedade do campo", http.StatusInternalServerError)
+			log.Printf("Erro ao verificar propriedade do campo %d: %v", idCampo, err)
+		}
+		return 0, false
+	}
+
+	return idCampo, true
+}
+
+func resolveRegraPrecoID(r *http.Request) (int, error) {
+	idRegra, err := strconv.Atoi(strings.TrimSpace(mux.Vars(r)["id_regra"]))
+	if err != nil || idRegra <= 0 {
+		return 0, errors.New("ID da regra de preco invalido")
+	}
+
+	return idRegra, nil
+}
+
+func parseCampoRegraPrecoRequest(r *http.Request) (models.CampoRegraPreco, error) {
+	var request campoRegraPrecoRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return models.CampoRegraPreco{}, errors.New("Erro ao decodificar JSON")
+	}
+
+	return buildCampoRegraPreco(request)
+}
+
+func buildCampoRegraPreco(request campoRegraPrecoRequest) (models.CampoRegraPreco, error) {
+	regra := models.CampoRegraPreco{
+		DiaSemana:     request.DiaSemana,
+		ValorHora:     request.ValorHora,
+		Multiplicador: request.Multiplicador,
+		Descricao:     strings.TrimSpace(request.Descricao),
+	}
+
+	if regra.DiaSemana != nil && (*regra.DiaSemana < 0 || *regra.DiaSemana > 6) {
+		return models.CampoRegraPreco{}, errors.New("Dia da semana invalido. Use 0 (domingo) a 6 (sabado)")
+	}
+
+	if data := strings.TrimSpace(request.Data); data != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", data, agendamentoLocation())
+		if err != nil {
+			return models.CampoRegraPreco{}, errors.New("Data invalida. Use o formato YYYY-MM-DD")
+		}
+		regra.Data = &parsed
+	}
+	if regra.Data != nil && regra.DiaSemana != nil {
+		return models.CampoRegraPreco{}, errors.New("Informe dia_semana ou data, nao ambos")
+	}
+
+	horaInicio, err := normalizeCampoHorario(firstNonEmptyCampoValue(request.HoraInicio, "00:00"))
+	if err != nil {
+		return models.CampoRegraPreco{}, errors.New("Hora de inicio invalida")
+	}
+	horaFim, err := normalizeCampoHorario(firstNonEmptyCampoValue(request.HoraFim, "00:00"))
+	if err != nil {
+		return models.CampoRegraPreco{}, errors.New("Hora de fim invalida")
+	}
+	regra.HoraInicio = horaInicio
+	regra.HoraFim = horaFim
+
+	switch {
+	case regra.ValorHora != nil && regra.Multiplicador != nil:
+		return models.CampoRegraPreco{}, errors.New("Informe valor_hora ou multiplicador, nao ambos")
+	case regra.ValorHora != nil:
+		if *regra.ValorHora < 0 {
+			return models.CampoRegraPreco{}, errors.New("Valor da hora invalido")
+		}
+	case regra.Multiplicador != nil:
+		if *regra.Multiplicador <= 0 {
+			return models.CampoRegraPreco{}, errors.New("Multiplicador invalido")
+		}
+	default:
+		return models.CampoRegraPreco{}, errors.New("Informe valor_hora ou multiplicador")
+	}
+
+	return regra, nil
+}
+
+func loadCampoRegrasPreco(ctx context.Context, db agendamentoDB, campoIDs []int) (map[int][]models.CampoRegraPreco, error) {
+	regras := make(map[int][]models.CampoRegraPreco, len(campoIDs))
+	if len(campoIDs) == 0 {
+		return regras, nil
+	}
+
+	placeholders := make([]string, 0, len(campoIDs))
+	args := make([]any, 0, len(campoIDs))
+	for _, campoID := range campoIDs {
+		args = append(args, campoID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			id,
+			id_campo,
+			dia_semana,
+			data,
+			hora_inicio,
+			hora_fim,
+			valor_hora,
+			multiplicador,
+			COALESCE(descricao, '')
+		FROM %s
+		WHERE id_campo IN (%s)
+		ORDER BY id_campo ASC, id ASC
+	`, campoRegrasPrecoTableName(), strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			regra         models.CampoRegraPreco
+			diaSemana     sql.NullInt64
+			data          sql.NullTime
+			valorHora     sql.NullFloat64
+			multiplicador sql.NullFloat64
+		)
+
+		if err := rows.Scan(
+			&regra.ID,
+			&regra.IDCampo,
+			&diaSemana,
+			&data,
+			&regra.HoraInicio,
+			&regra.HoraFim,
+			&valorHora,
+			&multiplicador,
+			&regra.Descricao,
+		); err != nil {
+			return nil, err
+		}
+
+		if diaSemana.Valid {
+			value := int(diaSemana.Int64)
+			regra.DiaSemana = &value
+		}
+		if data.Valid {
+			value := agendamentoDate(data.Time)
+			regra.Data = &value
+		}
+		if valorHora.Valid {
+			value := valorHora.Float64
+			regra.ValorHora = &value
+		}
+		if multiplicador.Valid {
+			value := multiplicador.Float64
+			regra.Multiplicador = &value
+		}
+
+		regras[regra.IDCampo] = append(regras[regra.IDCampo], regra)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return regras, nil
+}
+
+func newCampoRegraPrecoResponse(regra models.CampoRegraPreco) campoRegraPrecoResponse {
+	response := campoRegraPrecoResponse{
+		ID:            regra.ID,
+		IDCampo:       regra.IDCampo,
+		DiaSemana:     regra.DiaSemana,
+		HoraInicio:    regra.HoraInicio,
+		HoraFim:       regra.HoraFim,
+		ValorHora:     regra.ValorHora,
+		Multiplicador: regra.Multiplicador,
+		Descricao:     regra.Descricao,
+	}
+
+	if regra.Data != nil {
+		response.Data = regra.Data.Format("2006-01-02")
+	}
+
+	return response
+}
+
+func nullableFloat64Value(value *float64) any {
+	if value == nil {
+		return nil
+	}
+
+	return *value
+}
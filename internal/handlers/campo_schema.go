@@ -12,6 +12,7 @@ type campoOptionalColumns struct {
 	ValorHora           bool
 	Ativo               bool
 	HorariosDisponiveis bool
+	HorariosSemana      bool
 }
 
 func loadCampoOptionalColumns(ctx context.Context) (campoOptionalColumns, error) {
@@ -20,7 +21,7 @@ func loadCampoOptionalColumns(ctx context.Context) (campoOptionalColumns, error)
 		FROM information_schema.columns
 		WHERE table_schema = $1
 		  AND table_name = 'campo'
-		  AND column_name IN ('valor_hora', 'ativo', 'horarios_disponiveis')
+		  AND column_name IN ('valor_hora', 'ativo', 'horarios_disponiveis', 'horarios_semana')
 	`, config.DBSchemaName())
 	if err != nil {
 		return campoOptionalColumns{}, err
@@ -42,6 +43,8 @@ func loadCampoOptionalColumns(ctx context.Context) (campoOptionalColumns, error)
 			columns.Ativo = true
 		case "horarios_disponiveis":
 			columns.HorariosDisponiveis = true
+		case "horarios_semana":
+			columns.HorariosSemana = true
 		}
 	}
 
@@ -62,6 +65,14 @@ func optionalCampoSelectExpression(tableAlias, columnName string, exists bool) s
 			return fmt.Sprintf("COALESCE(%s.%s, '[]'::jsonb)::text AS %s", tableAlias, columnName, columnName)
 		}
 
+		if columnName == "horarios_semana" {
+			if strings.TrimSpace(tableAlias) == "" {
+				return "COALESCE(horarios_semana, '{}'::jsonb)::text AS horarios_semana"
+			}
+
+			return fmt.Sprintf("COALESCE(%s.%s, '{}'::jsonb)::text AS %s", tableAlias, columnName, columnName)
+		}
+
 		if strings.TrimSpace(tableAlias) == "" {
 			return fmt.Sprintf("%s AS %s", columnName, columnName)
 		}
@@ -76,6 +87,8 @@ func optionalCampoSelectExpression(tableAlias, columnName string, exists bool) s
 		return "CAST(TRUE AS BOOLEAN) AS ativo"
 	case "horarios_disponiveis":
 		return "CAST('[]' AS TEXT) AS horarios_disponiveis"
+	case "horarios_semana":
+		return "CAST('{}' AS TEXT) AS horarios_semana"
 	default:
 		return fmt.Sprintf("NULL AS %s", columnName)
 	}
@@ -100,11 +113,14 @@ func buildCampoInsertQuery(columns campoOptionalColumns) string {
 	if columns.HorariosDisponiveis {
 		columnNames = append(columnNames, "horarios_disponiveis")
 	}
+	if columns.HorariosSemana {
+		columnNames = append(columnNames, "horarios_semana")
+	}
 
 	placeholders := make([]string, 0, len(columnNames))
 	for index, columnName := range columnNames {
 		placeholder := fmt.Sprintf("$%d", index+1)
-		if columnName == "horarios_disponiveis" {
+		if columnName == "horarios_disponiveis" || columnName == "horarios_semana" {
 			placeholder += "::jsonb"
 		}
 		placeholders = append(placeholders, placeholder)
@@ -118,7 +134,7 @@ func buildCampoInsertQuery(columns campoOptionalColumns) string {
 	)
 }
 
-func buildCampoInsertArgs(campoNome string, campoModalidade string, campoTipo string, campoImagem string, campoMaxJogadores int, campoIDArena int, campoValorHora float64, campoAtivo bool, campoHorariosJSON string, columns campoOptionalColumns) []any {
+func buildCampoInsertArgs(campoNome string, campoModalidade string, campoTipo string, campoImagem string, campoMaxJogadores int, campoIDArena int, campoValorHora float64, campoAtivo bool, campoHorariosJSON string, campoHorariosSemanaJSON string, columns campoOptionalColumns) []any {
 	args := []any{
 		campoNome,
 		campoModalidade,
@@ -137,6 +153,9 @@ func buildCampoInsertArgs(campoNome string, campoModalidade string, campoTipo st
 	if columns.HorariosDisponiveis {
 		args = append(args, campoHorariosJSON)
 	}
+	if columns.HorariosSemana {
+		args = append(args, campoHorariosSemanaJSON)
+	}
 
 	return args
 }
@@ -34,9 +34,20 @@ func TestBuildCampoInsertQueryAndArgs(t *testing.T) {
 		t.Fatalf("expected optional campo columns in insert query: %s", query)
 	}
 
-	args := buildCampoInsertArgs("Campo 1", "Society", "Grama", "img", 14, 3, 99.90, false, `["07:00","08:00"]`, columns)
+	args := buildCampoInsertArgs("Campo 1", "Society", "Grama", "img", 14, 3, 99.90, false, `["07:00","08:00"]`, "{}", columns)
 	if len(args) != 9 {
-		t.Fatalf("expected 9 args with all optional campo columns enabled, got %d", len(args))
+		t.Fatalf("expected 9 args without horarios_semana, got %d", len(args))
+	}
+
+	columns.HorariosSemana = true
+	query = buildCampoInsertQuery(columns)
+	if !strings.Contains(query, "horarios_semana") || !strings.Contains(query, "$10::jsonb") {
+		t.Fatalf("expected horarios_semana as jsonb in insert query: %s", query)
+	}
+
+	args = buildCampoInsertArgs("Campo 1", "Society", "Grama", "img", 14, 3, 99.90, false, `["07:00","08:00"]`, `{"0":[]}`, columns)
+	if len(args) != 10 {
+		t.Fatalf("expected 10 args with all optional campo columns enabled, got %d", len(args))
 	}
 }
 
@@ -0,0 +1,949 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	creditoExpiracaoIntervaloPadrao   = 15 * time.Minute
+	creditoExpiracaoLote              = 100
+	creditoValidadeMaximaDias         = 10 * 365
+	creditoPacoteDescricaoPadrao      = "Pacote de creditos"
+	creditoPacoteHorasDescricaoPadrao = "Pacote de horas"
+)
+
+var (
+	errCreditoCarteiraNaoEncontrada = errors.New("carteira de credito nao encontrada")
+	errCreditoCarteiraObrigatoria   = errors.New("carteira de credito obrigatoria")
+	errCreditoSaldoInsuficiente     = errors.New("saldo de credito insuficiente")
+	errCreditoHorasReservaInteira   = errors.New("pacote de horas paga a reserva inteira")
+)
+
+type creditoPacoteRequest struct {
+	IDCarteira     *int     `json:"id_carteira"`
+	NomeCliente    string   `json:"nome_cliente"`
+	IDJogador      *int     `json:"id_jogador"`
+	Descricao      string   `json:"descricao"`
+	Credito        float64  `json:"credito"`
+	Horas          *float64 `json:"horas"`
+	Preco          *float64 `json:"preco"`
+	FormaPagamento string   `json:"forma_pagamento"`
+	ValidadeDias   *int     `json:"validade_dias"`
+}
+
+type creditoCarteiraResponse struct {
+	ID           int     `json:"id"`
+	IDArena      int     `json:"id_arena"`
+	NomeCliente  string  `json:"nome_cliente"`
+	IDJogador    *int    `json:"id_jogador,omitempty"`
+	Saldo        float64 `json:"saldo"`
+	SaldoMinutos int     `json:"saldo_minutos"`
+	CriadoEm     string  `json:"criado_em"`
+}
+
+type creditoPacoteResponse struct {
+	ID             int     `json:"id"`
+	IDCarteira     int     `json:"id_carteira"`
+	Descricao      string  `json:"descricao"`
+	Credito        float64 `json:"credito"`
+	Minutos        int     `json:"minutos,omitempty"`
+	Preco          float64 `json:"preco"`
+	FormaPagamento string  `json:"forma_pagamento"`
+	Saldo          float64 `json:"saldo"`
+	SaldoMinutos   *int    `json:"saldo_minutos,omitempty"`
+	Expirado       bool    `json:"expirado"`
+	CompradoEm     string  `json:"comprado_em"`
+	ExpiraEm       string  `json:"expira_em,omitempty"`
+}
+
+type creditoMovimentoResponse struct {
+	ID            int                         `json:"id"`
+	IDPacote      *int                        `json:"id_pacote,omitempty"`
+	Tipo          models.CreditoMovimentoTipo `json:"tipo"`
+	Valor         float64                     `json:"valor"`
+	Minutos       int                         `json:"minutos,omitempty"`
+	IDPagamento   *int                        `json:"id_pagamento,omitempty"`
+	IDAgendamento *int                        `json:"id_agendamento,omitempty"`
+	CriadoEm      string                      `json:"criado_em"`
+}
+
+type creditoExtratoResponse struct {
+	Carteira   creditoCarteiraResponse    `json:"carteira"`
+	Pacotes    []creditoPacoteResponse    `json:"pacotes"`
+	Movimentos []creditoMovimentoResponse `json:"movimentos"`
+}
+
+const creditoCarteiraColumns = `cc.id, cc.id_arena, cc.nome_cliente, cc.id_jogador, cc.criado_em`
+
+// creditoCarteiraSaldoExpr is the usable balance of carteira cc, in money and
+// in minutes: what is left of the pacotes that have not expired at $1.
+func creditoCarteiraSaldoExpr() string {
+	return creditoCarteiraSomaExpr("valor") + ", " + creditoCarteiraSomaExpr("minutos")
+}
+
+func creditoCarteiraSomaExpr(coluna string) string {
+	return fmt.Sprintf(`COALESCE((
+		SELECT SUM(m.%s)
+		FROM %s m
+		JOIN %s p ON p.id = m.id_pacote
+		WHERE m.id_carteira = cc.id
+		  AND (p.expira_em IS NULL OR p.expira_em > $1)
+	), 0)`, coluna, creditoMovimentosTableName(), creditoPacotesTableName())
+}
+
+func scanCreditoCarteira(scanner agendamentoScanner) (models.CreditoCarteira, error) {
+	var (
+		carteira  models.CreditoCarteira
+		idJogador sql.NullInt64
+	)
+
+	err := scanner.Scan(
+		&carteira.ID,
+		&carteira.IDArena,
+		&carteira.NomeCliente,
+		&idJogador,
+		&carteira.CriadoEm,
+		&carteira.Saldo,
+		&carteira.SaldoMinutos,
+	)
+	if err != nil {
+		return models.CreditoCarteira{}, err
+	}
+
+	carteira.IDJogador = nullIntPointer(idJogador)
+	return carteira, nil
+}
+
+func (repository agendamentoRepository) listCreditoCarteiras(ctx context.Context, idArena int, agora time.Time) ([]models.CreditoCarteira, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s, %s
+		FROM %s cc
+		WHERE cc.id_arena = $2
+		ORDER BY LOWER(cc.nome_cliente) ASC, cc.id ASC
+	`, creditoCarteiraColumns, creditoCarteiraSaldoExpr(), creditoCarteirasTableName()), agora, idArena)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	carteiras := make([]models.CreditoCarteira, 0)
+	for rows.Next() {
+		carteira, scanErr := scanCreditoCarteira(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		carteiras = append(carteiras, carteira)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return carteiras, nil
+}
+
+// getCreditoCarteira loads a carteira of the arena. Locking it serializes
+// every sale and debit of that customer.
+func (repository agendamentoRepository) getCreditoCarteira(ctx context.Context, idArena int, idCarteira int, agora time.Time, lock bool) (models.CreditoCarteira, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM %s cc
+		WHERE cc.id_arena = $2
+		  AND cc.id = $3
+	`, creditoCarteiraColumns, creditoCarteiraSaldoExpr(), creditoCarteirasTableName())
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	return scanCreditoCarteira(repository.database().QueryRowContext(ctx, query, agora, idArena, idCarteira))
+}
+
+func (repository agendamentoRepository) findCreditoCarteiraCliente(ctx context.Context, idArena int, nomeCliente string, idJogador *int, agora time.Time) (models.CreditoCarteira, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM %s cc
+		WHERE cc.id_arena = $2
+	`, creditoCarteiraColumns, creditoCarteiraSaldoExpr(), creditoCarteirasTableName())
+
+	args := []any{agora, idArena}
+	if idJogador != nil {
+		query += " AND cc.id_jogador = $3"
+		args = append(args, *idJogador)
+	} else {
+		query += " AND cc.id_jogador IS NULL AND LOWER(cc.nome_cliente) = LOWER($3)"
+		args = append(args, nomeCliente)
+	}
+
+	return scanCreditoCarteira(repository.database().QueryRowContext(ctx, query+" FOR UPDATE", args...))
+}
+
+// insertCreditoCarteira opens a carteira for the customer unless a concurrent
+// sale already did.
+func (repository agendamentoRepository) insertCreditoCarteira(ctx context.Context, idArena int, nomeCliente string, idJogador *int) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id_arena, nome_cliente, id_jogador)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, creditoCarteirasTableName()), idArena, nomeCliente, nullableIntValue(idJogador))
+	return err
+}
+
+func (repository agendamentoRepository) listCreditoPacotes(ctx context.Context, idCarteira int) ([]models.CreditoPacote, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			p.id,
+			p.id_carteira,
+			p.descricao,
+			p.credito,
+			COALESCE(p.minutos, 0),
+			p.preco,
+			COALESCE(p.forma_pagamento, ''),
+			p.comprado_em,
+			p.expira_em,
+			COALESCE(s.saldo, 0),
+			COALESCE(s.saldo_minutos, 0)
+		FROM %s p
+		LEFT JOIN LATERAL (
+			SELECT SUM(m.valor) AS saldo, SUM(m.minutos) AS saldo_minutos
+			FROM %s m
+			WHERE m.id_pacote = p.id
+		) s ON TRUE
+		WHERE p.id_carteira = $1
+		ORDER BY p.comprado_em ASC, p.id ASC
+	`, creditoPacotesTableName(), creditoMovimentosTableName()), idCarteira)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pacotes := make([]models.CreditoPacote, 0)
+	for rows.Next() {
+		var (
+			pacote   models.CreditoPacote
+			expiraEm sql.NullTime
+		)
+		if err := rows.Scan(
+			&pacote.ID,
+			&pacote.IDCarteira,
+			&pacote.Descricao,
+			&pacote.Credito,
+			&pacote.Minutos,
+			&pacote.Preco,
+			&pacote.FormaPagamento,
+			&pacote.CompradoEm,
+			&expiraEm,
+			&pacote.Saldo,
+			&pacote.SaldoMinutos,
+		); err != nil {
+			return nil, err
+		}
+		if expiraEm.Valid {
+			pacote.ExpiraEm = &expiraEm.Time
+		}
+		pacotes = append(pacotes, pacote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pacotes, nil
+}
+
+func (repository agendamentoRepository) insertCreditoPacote(ctx context.Context, idCarteira int, input models.VenderPacoteCreditoInput, compradoEm time.Time) (models.CreditoPacote, error) {
+	pacote := models.CreditoPacote{
+		IDCarteira:     idCarteira,
+		Descricao:      input.Descricao,
+		Credito:        input.Credito,
+		Minutos:        input.Minutos,
+		Preco:          input.Preco,
+		FormaPagamento: input.FormaPagamento,
+		CompradoEm:     compradoEm,
+		ExpiraEm:       input.ExpiraEm,
+	}
+
+	var expiraEm any
+	if input.ExpiraEm != nil {
+		expiraEm = *input.ExpiraEm
+	}
+	var minutos any
+	if input.Minutos > 0 {
+		minutos = input.Minutos
+	}
+
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id_carteira, descricao, credito, minutos, preco, forma_pagamento, comprado_em, expira_em)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, creditoPacotesTableName()),
+		idCarteira,
+		input.Descricao,
+		input.Credito,
+		minutos,
+		input.Preco,
+		input.FormaPagamento,
+		compradoEm,
+		expiraEm,
+	).Scan(&pacote.ID)
+	if err != nil {
+		return models.CreditoPacote{}, err
+	}
+
+	return pacote, nil
+}
+
+func (repository agendamentoRepository) insertCreditoMovimento(ctx context.Context, movimento models.CreditoMovimento) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id_carteira, id_pacote, tipo, valor, minutos, id_pagamento, id_agendamento, criado_em)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, creditoMovimentosTableName()),
+		movimento.IDCarteira,
+		nullableIntValue(movimento.IDPacote),
+		string(movimento.Tipo),
+		movimento.Valor,
+		movimento.Minutos,
+		nullableIntValue(movimento.IDPagamento),
+		nullableIntValue(movimento.IDAgendamento),
+		movimento.CriadoEm,
+	)
+	return err
+}
+
+func (repository agendamentoRepository) listCreditoMovimentos(ctx context.Context, idCarteira int) ([]models.CreditoMovimento, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, id_carteira, id_pacote, tipo, valor, minutos, id_pagamento, id_agendamento, criado_em
+		FROM %s
+		WHERE id_carteira = $1
+		ORDER BY criado_em DESC, id DESC
+	`, creditoMovimentosTableName()), idCarteira)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movimentos := make([]models.CreditoMovimento, 0)
+	for rows.Next() {
+		var (
+			movimento     models.CreditoMovimento
+			idPacote      sql.NullInt64
+			tipo          string
+			idPagamento   sql.NullInt64
+			idAgendamento sql.NullInt64
+		)
+		if err := rows.Scan(
+			&movimento.ID,
+			&movimento.IDCarteira,
+			&idPacote,
+			&tipo,
+			&movimento.Valor,
+			&movimento.Minutos,
+			&idPagamento,
+			&idAgendamento,
+			&movimento.CriadoEm,
+		); err != nil {
+			return nil, err
+		}
+		movimento.Tipo = models.CreditoMovimentoTipo(tipo)
+		movimento.IDPacote = nullIntPointer(idPacote)
+		movimento.IDPagamento = nullIntPointer(idPagamento)
+		movimento.IDAgendamento = nullIntPointer(idAgendamento)
+		movimentos = append(movimentos, movimento)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movimentos, nil
+}
+
+// creditoConsumo is what a booking still holds of one pacote: its debits less
+// what a cancellation already gave back, in money or in minutes.
+type creditoConsumo struct {
+	IDCarteira int
+	IDPacote   int
+	Valor      float64
+	Minutos    int
+}
+
+func (repository agendamentoRepository) listCreditoConsumosAgendamento(ctx context.Context, agendamentoID int) ([]creditoConsumo, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT id_carteira, id_pacote, -SUM(valor), -SUM(minutos)
+		FROM %s
+		WHERE id_agendamento = $1
+		  AND id_pacote IS NOT NULL
+		  AND tipo IN ('consumo', 'estorno')
+		GROUP BY id_carteira, id_pacote
+		HAVING -SUM(valor) > 0 OR -SUM(minutos) > 0
+		ORDER BY id_carteira ASC, id_pacote ASC
+	`, creditoMovimentosTableName()), agendamentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consumos := make([]creditoConsumo, 0)
+	for rows.Next() {
+		var consumo creditoConsumo
+		if err := rows.Scan(&consumo.IDCarteira, &consumo.IDPacote, &consumo.Valor, &consumo.Minutos); err != nil {
+			return nil, err
+		}
+		consumos = append(consumos, consumo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return consumos, nil
+}
+
+// sumPagamentosHorasAgendamento is what the booking's payments with hour
+// pacotes are worth in money, less what a cancellation already gave back.
+func (repository agendamentoRepository) sumPagamentosHorasAgendamento(ctx context.Context, agendamentoID int) (float64, error) {
+	var total float64
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COALESCE(SUM(p.valor_pago), 0)
+		FROM %s p
+		WHERE p.id_agendamento = $1
+		  AND p.id IN (
+			SELECT m.id_pagamento
+			FROM %s m
+			WHERE m.id_agendamento = $1
+			  AND m.tipo IN ('consumo', 'estorno')
+			  AND m.minutos <> 0
+		  )
+	`, pagamentosPorAgendamentoTableName(), creditoMovimentosTableName()), agendamentoID).Scan(&total)
+	return total, err
+}
+
+// listCreditoPacotesExpirados returns the expired pacotes that still hold a
+// balance, in money or in minutes, with that balance.
+func (repository agendamentoRepository) listCreditoPacotesExpirados(ctx context.Context, agora time.Time, limite int) ([]models.CreditoPacote, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT p.id, p.id_carteira, s.saldo, s.saldo_minutos
+		FROM %s p
+		JOIN LATERAL (
+			SELECT COALESCE(SUM(m.valor), 0) AS saldo, COALESCE(SUM(m.minutos), 0) AS saldo_minutos
+			FROM %s m
+			WHERE m.id_pacote = p.id
+		) s ON TRUE
+		WHERE p.expira_em <= $1
+		  AND (s.saldo > 0 OR s.saldo_minutos > 0)
+		ORDER BY p.expira_em ASC, p.id ASC
+		LIMIT $2
+	`, creditoPacotesTableName(), creditoMovimentosTableName()), agora, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pacotes := make([]models.CreditoPacote, 0)
+	for rows.Next() {
+		var pacote models.CreditoPacote
+		if err := rows.Scan(&pacote.ID, &pacote.IDCarteira, &pacote.Saldo, &pacote.SaldoMinutos); err != nil {
+			return nil, err
+		}
+		pacotes = append(pacotes, pacote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pacotes, nil
+}
+
+func (repository agendamentoRepository) lockCreditoCarteira(ctx context.Context, idCarteira int) error {
+	var id int
+	return repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT id FROM %s WHERE id = $1 FOR UPDATE
+	`, creditoCarteirasTableName()), idCarteira).Scan(&id)
+}
+
+// resolveCreditoCarteira finds the carteira a sale goes to, opening one for a
+// customer buying for the first time. The carteira comes back locked.
+func (service agendamentoService) resolveCreditoCarteira(ctx context.Context, idArena int, input models.VenderPacoteCreditoInput, agora time.Time) (models.CreditoCarteira, error) {
+	if input.IDCarteira != nil {
+		carteira, err := service.repository.getCreditoCarteira(ctx, idArena, *input.IDCarteira, agora, true)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CreditoCarteira{}, errCreditoCarteiraNaoEncontrada
+		}
+		return carteira, err
+	}
+
+	carteira, err := service.repository.findCreditoCarteiraCliente(ctx, idArena, input.NomeCliente, input.IDJogador, agora)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return carteira, err
+	}
+
+	if err := service.repository.insertCreditoCarteira(ctx, idArena, input.NomeCliente, input.IDJogador); err != nil {
+		return models.CreditoCarteira{}, err
+	}
+
+	return service.repository.findCreditoCarteiraCliente(ctx, idArena, input.NomeCliente, input.IDJogador, agora)
+}
+
+// VenderPacoteCredito records a package sale and credits it, in money or in
+// minutes, to the customer's carteira in the arena.
+func (service agendamentoService) VenderPacoteCredito(ctx context.Context, idArena int, input models.VenderPacoteCreditoInput) (models.CreditoCarteira, models.CreditoPacote, error) {
+	var (
+		carteira models.CreditoCarteira
+		pacote   models.CreditoPacote
+	)
+	agora := agendamentoNow()
+	err := service.inTransaction(ctx, func(service agendamentoService) error {
+		var err error
+		carteira, err = service.resolveCreditoCarteira(ctx, idArena, input, agora)
+		if err != nil {
+			return err
+		}
+
+		pacote, err = service.repository.insertCreditoPacote(ctx, carteira.ID, input, agora)
+		if err != nil {
+			return err
+		}
+
+		idPacote := pacote.ID
+		return service.repository.insertCreditoMovimento(ctx, models.CreditoMovimento{
+			IDCarteira: carteira.ID,
+			IDPacote:   &idPacote,
+			Tipo:       models.CreditoMovimentoCompra,
+			Valor:      pacote.Credito,
+			Minutos:    pacote.Minutos,
+			CriadoEm:   agora,
+		})
+	})
+	if err != nil {
+		return models.CreditoCarteira{}, models.CreditoPacote{}, err
+	}
+
+	pacote.Saldo = pacote.Credito
+	pacote.SaldoMinutos = pacote.Minutos
+	carteira.Saldo = models.ArredondarCentavos(carteira.Saldo + pacote.Credito)
+	carteira.SaldoMinutos += pacote.Minutos
+	return carteira, pacote, nil
+}
+
+func (service agendamentoService) ListCarteirasCredito(ctx context.Context, idArena int) ([]models.CreditoCarteira, error) {
+	return service.repository.listCreditoCarteiras(ctx, idArena, agendamentoNow())
+}
+
+func (service agendamentoService) GetExtratoCredito(ctx context.Context, idArena int, idCarteira int) (models.CreditoCarteira, []models.CreditoPacote, []models.CreditoMovimento, error) {
+	carteira, err := service.repository.getCreditoCarteira(ctx, idArena, idCarteira, agendamentoNow(), false)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CreditoCarteira{}, nil, nil, errCreditoCarteiraNaoEncontrada
+		}
+		return models.CreditoCarteira{}, nil, nil, err
+	}
+
+	pacotes, err := service.repository.listCreditoPacotes(ctx, idCarteira)
+	if err != nil {
+		return models.CreditoCarteira{}, nil, nil, err
+	}
+
+	movimentos, err := service.repository.listCreditoMovimentos(ctx, idCarteira)
+	if err != nil {
+		return models.CreditoCarteira{}, nil, nil, err
+	}
+
+	return carteira, pacotes, movimentos, nil
+}
+
+// debitarCredito takes a credito payment out of the carteira. It runs in the
+// payment's transaction, so the payment and the debit stand or fall together.
+func (service agendamentoService) debitarCredito(ctx context.Context, agendamento models.Agendamento, idCarteira int, valor float64, idPagamento int) error {
+	return service.debitarCarteira(ctx, agendamento, idCarteira, idPagamento, func(pacotes []models.CreditoPacote, agora time.Time) ([]models.CreditoDebito, bool) {
+		return models.PlanejarDebitoCredito(pacotes, valor, agora)
+	})
+}
+
+// debitarHoras takes minutos of play out of the carteira's hour pacotes, in
+// the transaction of the payment they settle.
+func (service agendamentoService) debitarHoras(ctx context.Context, agendamento models.Agendamento, idCarteira int, minutos int, idPagamento int) error {
+	return service.debitarCarteira(ctx, agendamento, idCarteira, idPagamento, func(pacotes []models.CreditoPacote, agora time.Time) ([]models.CreditoDebito, bool) {
+		return models.PlanejarDebitoHoras(pacotes, minutos, agora)
+	})
+}
+
+func (service agendamentoService) debitarCarteira(ctx context.Context, agendamento models.Agendamento, idCarteira int, idPagamento int, planejar func([]models.CreditoPacote, time.Time) ([]models.CreditoDebito, bool)) error {
+	agora := agendamentoNow()
+	if _, err := service.repository.getCreditoCarteira(ctx, agendamento.IDArena, idCarteira, agora, true); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errCreditoCarteiraNaoEncontrada
+		}
+		return err
+	}
+
+	pacotes, err := service.repository.listCreditoPacotes(ctx, idCarteira)
+	if err != nil {
+		return err
+	}
+
+	debitos, ok := planejar(pacotes, agora)
+	if !ok {
+		return errCreditoSaldoInsuficiente
+	}
+
+	agendamentoID := agendamento.ID
+	for _, debito := range debitos {
+		idPacote := debito.IDPacote
+		if err := service.repository.insertCreditoMovimento(ctx, models.CreditoMovimento{
+			IDCarteira:    idCarteira,
+			IDPacote:      &idPacote,
+			Tipo:          models.CreditoMovimentoConsumo,
+			Valor:         -debito.Valor,
+			Minutos:       -debito.Minutos,
+			IDPagamento:   &idPagamento,
+			IDAgendamento: &agendamentoID,
+			CriadoEm:      agora,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// estornarCredito returns valor of a cancelled booking to the pacotes it was
+// paid from. It runs in the cancellation's transaction, next to the negative
+// credito payment idPagamento.
+func (service agendamentoService) estornarCredito(ctx context.Context, agendamento models.Agendamento, consumos []creditoConsumo, valor float64, idPagamento int) error {
+	return service.estornarCarteira(ctx, agendamento, consumos, models.CreditoDebito{Valor: valor}, idPagamento, func(consumos []models.CreditoDebito, pacotes []models.CreditoPacote, restante models.CreditoDebito) []models.CreditoDebito {
+		return models.PlanejarEstornoCredito(consumos, pacotes, restante.Valor)
+	})
+}
+
+// estornarHoras is estornarCredito for a booking paid with hour pacotes: it
+// gives minutos back.
+func (service agendamentoService) estornarHoras(ctx context.Context, agendamento models.Agendamento, consumos []creditoConsumo, minutos int, idPagamento int) error {
+	return service.estornarCarteira(ctx, agendamento, consumos, models.CreditoDebito{Minutos: minutos}, idPagamento, func(consumos []models.CreditoDebito, pacotes []models.CreditoPacote, restante models.CreditoDebito) []models.CreditoDebito {
+		return models.PlanejarEstornoHoras(consumos, pacotes, restante.Minutos)
+	})
+}
+
+func (service agendamentoService) estornarCarteira(ctx context.Context, agendamento models.Agendamento, consumos []creditoConsumo, restante models.CreditoDebito, idPagamento int, planejar func([]models.CreditoDebito, []models.CreditoPacote, models.CreditoDebito) []models.CreditoDebito) error {
+	agora := agendamentoNow()
+	agendamentoID := agendamento.ID
+	porCarteira := make(map[int][]models.CreditoDebito)
+	carteiras := make([]int, 0)
+	for _, consumo := range consumos {
+		if _, ok := porCarteira[consumo.IDCarteira]; !ok {
+			carteiras = append(carteiras, consumo.IDCarteira)
+		}
+		porCarteira[consumo.IDCarteira] = append(porCarteira[consumo.IDCarteira], models.CreditoDebito{IDPacote: consumo.IDPacote, Valor: consumo.Valor, Minutos: consumo.Minutos})
+	}
+
+	for _, idCarteira := range carteiras {
+		if restante.Valor <= 0 && restante.Minutos <= 0 {
+			break
+		}
+		if err := service.repository.lockCreditoCarteira(ctx, idCarteira); err != nil {
+			return err
+		}
+		pacotes, err := service.repository.listCreditoPacotes(ctx, idCarteira)
+		if err != nil {
+			return err
+		}
+
+		for _, estorno := range planejar(porCarteira[idCarteira], pacotes, restante) {
+			idPacote := estorno.IDPacote
+			if err := service.repository.insertCreditoMovimento(ctx, models.CreditoMovimento{
+				IDCarteira:    idCarteira,
+				IDPacote:      &idPacote,
+				Tipo:          models.CreditoMovimentoEstorno,
+				Valor:         estorno.Valor,
+				Minutos:       estorno.Minutos,
+				IDPagamento:   &idPagamento,
+				IDAgendamento: &agendamentoID,
+				CriadoEm:      agora,
+			}); err != nil {
+				return err
+			}
+			restante.Valor = models.ArredondarCentavos(restante.Valor - estorno.Valor)
+			restante.Minutos -= estorno.Minutos
+		}
+	}
+
+	return nil
+}
+
+// ExpirarCreditos writes off what is left of expired pacotes, so the ledger
+// explains where the balance went.
+func (service agendamentoService) ExpirarCreditos(ctx context.Context, agora time.Time) (int, error) {
+	pacotes, err := service.repository.listCreditoPacotesExpirados(ctx, agora, creditoExpiracaoLote)
+	if err != nil {
+		return 0, err
+	}
+
+	expirados := 0
+	for _, pacote := range pacotes {
+		err := service.inTransaction(ctx, func(service agendamentoService) error {
+			if err := service.repository.lockCreditoCarteira(ctx, pacote.IDCarteira); err != nil {
+				return err
+			}
+
+			// A debit may have used the pacote since it was listed.
+			atuais, err := service.repository.listCreditoPacotes(ctx, pacote.IDCarteira)
+			if err != nil {
+				return err
+			}
+			for _, atual := range atuais {
+				if atual.ID != pacote.ID || (atual.Saldo <= 0 && atual.SaldoMinutos <= 0) {
+					continue
+				}
+
+				idPacote := atual.ID
+				if err := service.repository.insertCreditoMovimento(ctx, models.CreditoMovimento{
+					IDCarteira: atual.IDCarteira,
+					IDPacote:   &idPacote,
+					Tipo:       models.CreditoMovimentoExpiracao,
+					Valor:      -max(atual.Saldo, 0),
+					Minutos:    -max(atual.SaldoMinutos, 0),
+					CriadoEm:   agora,
+				}); err != nil {
+					return err
+				}
+				expirados++
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Erro ao expirar pacote de credito %d: %v", pacote.ID, err)
+		}
+	}
+
+	return expirados, nil
+}
+
+// StartCreditoExpiracaoWorker expires prepaid credit until ctx is done. It is
+// meant to run in its own goroutine.
+func StartCreditoExpiracaoWorker(ctx context.Context) {
+	service := newAgendamentoService()
+	intervalo := time.Duration(envPositiveInt("CREDITO_EXPIRACAO_INTERVALO_SEGUNDOS", int(creditoExpiracaoIntervaloPadrao/time.Second))) * time.Second
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		expirados, err := service.ExpirarCreditos(ctx, agendamentoNow())
+		if err != nil {
+			log.Printf("Erro ao buscar pacotes de credito expirados: %v", err)
+		} else if expirados > 0 {
+			log.Printf("%d pacote(s) de credito expirado(s)", expirados)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func buildVenderPacoteCreditoInput(request creditoPacoteRequest, agora time.Time) (models.VenderPacoteCreditoInput, error) {
+	input := models.VenderPacoteCreditoInput{
+		IDCarteira:     request.IDCarteira,
+		NomeCliente:    strings.TrimSpace(request.NomeCliente),
+		IDJogador:      request.IDJogador,
+		Descricao:      strings.TrimSpace(request.Descricao),
+		Credito:        models.ArredondarCentavos(request.Credito),
+		FormaPagamento: sanitizePagamento(request.FormaPagamento),
+	}
+
+	if input.IDCarteira != nil && *input.IDCarteira <= 0 {
+		return models.VenderPacoteCreditoInput{}, errors.New("id_carteira invalido")
+	}
+	if input.IDJogador != nil && *input.IDJogador <= 0 {
+		return models.VenderPacoteCreditoInput{}, errors.New("id_jogador invalido")
+	}
+	if input.IDCarteira == nil && input.NomeCliente == "" {
+		return models.VenderPacoteCreditoInput{}, errors.New("Informe o nome do cliente ou a carteira")
+	}
+	if request.Horas != nil {
+		if input.Credito != 0 {
+			return models.VenderPacoteCreditoInput{}, errors.New("Informe credito ou horas, nao ambos")
+		}
+		input.Minutos = int(math.Round(*request.Horas * 60))
+		if input.Minutos <= 0 {
+			return models.VenderPacoteCreditoInput{}, errors.New("horas deve ser maior que zero")
+		}
+		if request.Preco == nil {
+			return models.VenderPacoteCreditoInput{}, errors.New("preco obrigatorio para pacotes de horas")
+		}
+	} else if input.Credito <= 0 {
+		return models.VenderPacoteCreditoInput{}, errors.New("credito deve ser maior que zero")
+	}
+	if strings.EqualFold(input.FormaPagamento, models.AgendamentoFormaPagamentoCredito) {
+		return models.VenderPacoteCreditoInput{}, errors.New("pacotes nao podem ser pagos com credito")
+	}
+	if input.Descricao == "" {
+		input.Descricao = creditoPacoteDescricaoPadrao
+		if input.Minutos > 0 {
+			input.Descricao = creditoPacoteHorasDescricaoPadrao
+		}
+	}
+
+	input.Preco = input.Credito
+	if request.Preco != nil {
+		if *request.Preco < 0 {
+			return models.VenderPacoteCreditoInput{}, errors.New("preco nao pode ser negativo")
+		}
+		input.Preco = models.ArredondarCentavos(*request.Preco)
+	}
+
+	if request.ValidadeDias != nil {
+		if *request.ValidadeDias <= 0 || *request.ValidadeDias > creditoValidadeMaximaDias {
+			return models.VenderPacoteCreditoInput{}, fmt.Errorf("validade_dias deve estar entre 1 e %d dias", creditoValidadeMaximaDias)
+		}
+		expiraEm := agora.AddDate(0, 0, *request.ValidadeDias)
+		input.ExpiraEm = &expiraEm
+	}
+
+	return input, nil
+}
+
+func GetCarteirasCredito(w http.ResponseWriter, r *http.Request) {
+	idArena, ok := resolveArenaPoliticaOwner(w, r)
+	if !ok {
+		return
+	}
+
+	service := newAgendamentoService()
+	carteiras, err := service.ListCarteirasCredito(r.Context(), idArena)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	response := make([]creditoCarteiraResponse, 0, len(carteiras))
+	for _, carteira := range carteiras {
+		response = append(response, newCreditoCarteiraResponse(carteira))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func VenderPacoteCredito(w http.ResponseWriter, r *http.Request) {
+	idArena, ok := resolveArenaPoliticaOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var request creditoPacoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Erro ao decodificar JSON", http.StatusBadRequest)
+		return
+	}
+
+	input, err := buildVenderPacoteCreditoInput(request, agendamentoNow())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	carteira, pacote, err := service.VenderPacoteCredito(r.Context(), idArena, input)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"message":  "Pacote de credito vendido com sucesso",
+		"carteira": newCreditoCarteiraResponse(carteira),
+		"pacote":   newCreditoPacoteResponse(pacote, agendamentoNow()),
+	})
+}
+
+func GetExtratoCredito(w http.ResponseWriter, r *http.Request) {
+	idArena, ok := resolveArenaPoliticaOwner(w, r)
+	if !ok {
+		return
+	}
+
+	idCarteira := parsePositiveIntParam(mux.Vars(r)["id_carteira"])
+	if idCarteira == 0 {
+		http.Error(w, "ID da carteira invalido", http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	carteira, pacotes, movimentos, err := service.GetExtratoCredito(r.Context(), idArena, idCarteira)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	agora := agendamentoNow()
+	response := creditoExtratoResponse{
+		Carteira:   newCreditoCarteiraResponse(carteira),
+		Pacotes:    make([]creditoPacoteResponse, 0, len(pacotes)),
+		Movimentos: make([]creditoMovimentoResponse, 0, len(movimentos)),
+	}
+	for _, pacote := range pacotes {
+		response.Pacotes = append(response.Pacotes, newCreditoPacoteResponse(pacote, agora))
+	}
+	for _, movimento := range movimentos {
+		response.Movimentos = append(response.Movimentos, creditoMovimentoResponse{
+			ID:            movimento.ID,
+			IDPacote:      movimento.IDPacote,
+			Tipo:          movimento.Tipo,
+			Valor:         movimento.Valor,
+			Minutos:       movimento.Minutos,
+			IDPagamento:   movimento.IDPagamento,
+			IDAgendamento: movimento.IDAgendamento,
+			CriadoEm:      formatAgendamentoDateTime(movimento.CriadoEm),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func newCreditoCarteiraResponse(carteira models.CreditoCarteira) creditoCarteiraResponse {
+	return creditoCarteiraResponse{
+		ID:           carteira.ID,
+		IDArena:      carteira.IDArena,
+		NomeCliente:  carteira.NomeCliente,
+		IDJogador:    carteira.IDJogador,
+		Saldo:        carteira.Saldo,
+		SaldoMinutos: carteira.SaldoMinutos,
+		CriadoEm:     formatAgendamentoDateTime(carteira.CriadoEm),
+	}
+}
+
+func newCreditoPacoteResponse(pacote models.CreditoPacote, agora time.Time) creditoPacoteResponse {
+	response := creditoPacoteResponse{
+		ID:             pacote.ID,
+		IDCarteira:     pacote.IDCarteira,
+		Descricao:      pacote.Descricao,
+		Credito:        pacote.Credito,
+		Minutos:        pacote.Minutos,
+		Preco:          pacote.Preco,
+		FormaPagamento: pacote.FormaPagamento,
+		Saldo:          pacote.Saldo,
+		Expirado:       pacote.Expirado(agora),
+		CompradoEm:     formatAgendamentoDateTime(pacote.CompradoEm),
+	}
+	if pacote.ExpiraEm != nil {
+		response.ExpiraEm = formatAgendamentoDateTime(*pacote.ExpiraEm)
+	}
+	if pacote.PorHoras() {
+		saldoMinutos := pacote.SaldoMinutos
+		response.SaldoMinutos = &saldoMinutos
+	}
+
+	return response
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildVenderPacoteCreditoInputDefaults(t *testing.T) {
+	agora := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	validade := 90
+
+	input, err := buildVenderPacoteCreditoInput(creditoPacoteRequest{
+		NomeCliente:  "  Joao  ",
+		Credito:      1200.004,
+		ValidadeDias: &validade,
+	}, agora)
+	if err != nil {
+		t.Fatalf("expected a valid package, got %v", err)
+	}
+	if input.NomeCliente != "Joao" || input.Credito != 1200 || input.Preco != 1200 {
+		t.Fatalf("expected preco to default to the credit, got %+v", input)
+	}
+	if input.Descricao != creditoPacoteDescricaoPadrao {
+		t.Fatalf("expected the default description, got %q", input.Descricao)
+	}
+	if input.ExpiraEm == nil || !input.ExpiraEm.Equal(agora.AddDate(0, 0, 90)) {
+		t.Fatalf("expected expiry in 90 days, got %v", input.ExpiraEm)
+	}
+}
+
+func TestBuildVenderPacoteCreditoInputHourPacote(t *testing.T) {
+	horas := 10.5
+	preco := 900.0
+
+	input, err := buildVenderPacoteCreditoInput(creditoPacoteRequest{
+		NomeCliente: "Joao",
+		Horas:       &horas,
+		Preco:       &preco,
+	}, time.Now())
+	if err != nil {
+		t.Fatalf("expected a valid hour pacote, got %v", err)
+	}
+	if input.Minutos != 630 || input.Credito != 0 || input.Preco != 900 {
+		t.Fatalf("expected 630 minutes for 900 and no money credit, got %+v", input)
+	}
+	if input.Descricao != creditoPacoteHorasDescricaoPadrao {
+		t.Fatalf("expected the hour pacote description, got %q", input.Descricao)
+	}
+}
+
+func TestBuildVenderPacoteCreditoInputRejectsInvalidPackages(t *testing.T) {
+	agora := time.Now()
+	preco := -1.0
+	validade := 0
+	horas := 10.0
+	semHoras := 0.0
+	precoHoras := 900.0
+
+	testCases := []struct {
+		name    string
+		request creditoPacoteRequest
+	}{
+		{name: "no customer", request: creditoPacoteRequest{Credito: 100}},
+		{name: "no credit", request: creditoPacoteRequest{NomeCliente: "Joao"}},
+		{name: "negative price", request: creditoPacoteRequest{NomeCliente: "Joao", Credito: 100, Preco: &preco}},
+		{name: "zero validity", request: creditoPacoteRequest{NomeCliente: "Joao", Credito: 100, ValidadeDias: &validade}},
+		{name: "paid with credit", request: creditoPacoteRequest{NomeCliente: "Joao", Credito: 100, FormaPagamento: "Credito"}},
+		{name: "credit and hours", request: creditoPacoteRequest{NomeCliente: "Joao", Credito: 100, Horas: &horas, Preco: &precoHoras}},
+		{name: "hours without price", request: creditoPacoteRequest{NomeCliente: "Joao", Horas: &horas}},
+		{name: "zero hours", request: creditoPacoteRequest{NomeCliente: "Joao", Horas: &semHoras, Preco: &precoHoras}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if _, err := buildVenderPacoteCreditoInput(testCase.request, agora); err == nil {
+				t.Fatalf("expected %s to be rejected", testCase.name)
+			}
+		})
+	}
+}
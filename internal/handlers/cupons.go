@@ -0,0 +1,770 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const pgUniqueViolation = "23505"
+
+var (
+	errCupomInvalido               = errors.New("cupom invalido")
+	errCupomForaDaValidade         = errors.New("cupom fora da validade")
+	errCupomEsgotado               = errors.New("cupom esgotado")
+	errCupomLimiteCliente          = errors.New("cupom ja usado pelo cliente")
+	errCupomNaoAplicavel           = errors.New("cupom nao se aplica ao horario")
+	errCupomPrimeiraReserva        = errors.New("cupom valido apenas na primeira reserva")
+	errCupomClienteNaoIdentificado = errors.New("cupom exige cliente identificado")
+	errCupomNaoEncontrado          = errors.New("cupom nao encontrado")
+	errCupomCodigoDuplicado        = errors.New("codigo de cupom duplicado")
+	errCupomCampoInvalido          = errors.New("campo do cupom nao pertence a arena")
+)
+
+type cupomRequest struct {
+	Codigo                string  `json:"codigo"`
+	Descricao             string  `json:"descricao"`
+	Tipo                  string  `json:"tipo"`
+	Valor                 float64 `json:"valor"`
+	ValidoDe              string  `json:"valido_de"`
+	ValidoAte             string  `json:"valido_ate"`
+	LimiteUsos            *int    `json:"limite_usos"`
+	LimiteUsosPorCliente  *int    `json:"limite_usos_por_cliente"`
+	IDCampos              []int   `json:"id_campos"`
+	DiasSemana            []int   `json:"dias_semana"`
+	HoraInicio            string  `json:"hora_inicio"`
+	HoraFim               string  `json:"hora_fim"`
+	ApenasPrimeiraReserva bool    `json:"apenas_primeira_reserva"`
+	Ativo                 *bool   `json:"ativo"`
+}
+
+type cupomResponse struct {
+	ID                    int     `json:"id"`
+	IDArena               int     `json:"id_arena"`
+	Codigo                string  `json:"codigo"`
+	Descricao             string  `json:"descricao,omitempty"`
+	Tipo                  string  `json:"tipo"`
+	Valor                 float64 `json:"valor"`
+	ValidoDe              string  `json:"valido_de,omitempty"`
+	ValidoAte             string  `json:"valido_ate,omitempty"`
+	LimiteUsos            *int    `json:"limite_usos,omitempty"`
+	LimiteUsosPorCliente  *int    `json:"limite_usos_por_cliente,omitempty"`
+	IDCampos              []int   `json:"id_campos"`
+	DiasSemana            []int   `json:"dias_semana"`
+	HoraInicio            string  `json:"hora_inicio,omitempty"`
+	HoraFim               string  `json:"hora_fim,omitempty"`
+	ApenasPrimeiraReserva bool    `json:"apenas_primeira_reserva"`
+	Ativo                 bool    `json:"ativo"`
+	Vigente               bool    `json:"vigente"`
+	Usos                  int     `json:"usos"`
+	CriadoEm              string  `json:"criado_em"`
+}
+
+// cupomSelectQuery counts the uses of bookings that are still standing, so a
+// cancelled booking gives its use back.
+func cupomSelectQuery() string {
+	return fmt.Sprintf(`
+		SELECT
+			cu.id,
+			cu.id_arena,
+			cu.codigo,
+			COALESCE(cu.descricao, ''),
+			cu.tipo,
+			cu.valor,
+			cu.valido_de,
+			cu.valido_ate,
+			cu.limite_usos,
+			cu.limite_usos_por_cliente,
+			cu.id_campos,
+			cu.dias_semana,
+			COALESCE(cu.hora_inicio, ''),
+			COALESCE(cu.hora_fim, ''),
+			cu.apenas_primeira_reserva,
+			cu.ativo,
+			cu.criado_em,
+			(
+				SELECT COUNT(*)
+				FROM %s u
+				JOIN %s a ON a.id_agendamento = u.id_agendamento
+				WHERE u.id_cupom = cu.id
+				  AND a.status != '%s'
+			)
+		FROM %s cu
+	`, cupomUsosTableName(), agendamentosTableName(), models.AgendamentoStatusCancelado, cuponsTableName())
+}
+
+func scanCupom(scanner agendamentoScanner) (models.Cupom, error) {
+	var (
+		cupom                models.Cupom
+		tipo                 string
+		validoDe             sql.NullTime
+		validoAte            sql.NullTime
+		limiteUsos           sql.NullInt64
+		limiteUsosPorCliente sql.NullInt64
+		idCamposRaw          []byte
+		diasSemanaRaw        []byte
+	)
+
+	err := scanner.Scan(
+		&cupom.ID,
+		&cupom.IDArena,
+		&cupom.Codigo,
+		&cupom.Descricao,
+		&tipo,
+		&cupom.Valor,
+		&validoDe,
+		&validoAte,
+		&limiteUsos,
+		&limiteUsosPorCliente,
+		&idCamposRaw,
+		&diasSemanaRaw,
+		&cupom.HoraInicio,
+		&cupom.HoraFim,
+		&cupom.ApenasPrimeiraReserva,
+		&cupom.Ativo,
+		&cupom.CriadoEm,
+		&cupom.Usos,
+	)
+	if err != nil {
+		return models.Cupom{}, err
+	}
+
+	cupom.Tipo = models.CupomTipo(tipo)
+	if validoDe.Valid {
+		cupom.ValidoDe = &validoDe.Time
+	}
+	if validoAte.Valid {
+		cupom.ValidoAte = &validoAte.Time
+	}
+	cupom.LimiteUsos = nullIntPointer(limiteUsos)
+	cupom.LimiteUsosPorCliente = nullIntPointer(limiteUsosPorCliente)
+	if len(idCamposRaw) > 0 {
+		if err := json.Unmarshal(idCamposRaw, &cupom.IDCampos); err != nil {
+			return models.Cupom{}, err
+		}
+	}
+	if len(diasSemanaRaw) > 0 {
+		if err := json.Unmarshal(diasSemanaRaw, &cupom.DiasSemana); err != nil {
+			return models.Cupom{}, err
+		}
+	}
+
+	return cupom, nil
+}
+
+func (repository agendamentoRepository) listCupons(ctx context.Context, idArena int) ([]models.Cupom, error) {
+	rows, err := repository.database().QueryContext(ctx, cupomSelectQuery()+`
+		WHERE cu.id_arena = $1
+		ORDER BY cu.ativo DESC, cu.criado_em DESC, cu.id DESC
+	`, idArena)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cupons := make([]models.Cupom, 0)
+	for rows.Next() {
+		cupom, scanErr := scanCupom(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		cupons = append(cupons, cupom)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cupons, nil
+}
+
+func (repository agendamentoRepository) getCupom(ctx context.Context, idCupom int) (models.Cupom, error) {
+	return scanCupom(repository.database().QueryRowContext(ctx, cupomSelectQuery()+`
+		WHERE cu.id = $1
+	`, idCupom))
+}
+
+// lockCupomCodigo takes the coupon's row lock, so two bookings racing for the
+// last use are counted one after the other.
+func (repository agendamentoRepository) lockCupomCodigo(ctx context.Context, idArena int, codigo string) (int, error) {
+	var idCupom int
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT id FROM %s WHERE id_arena = $1 AND UPPER(codigo) = $2 FOR UPDATE
+	`, cuponsTableName()), idArena, models.NormalizeCupomCodigo(codigo)).Scan(&idCupom)
+	return idCupom, err
+}
+
+func (repository agendamentoRepository) insertCupom(ctx context.Context, cupom models.Cupom) (int, error) {
+	idCampos, diasSemana := cupomListasJSON(cupom)
+
+	var idCupom int
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (
+			id_arena,
+			codigo,
+			descricao,
+			tipo,
+			valor,
+			valido_de,
+			valido_ate,
+			limite_usos,
+			limite_usos_por_cliente,
+			id_campos,
+			dias_semana,
+			hora_inicio,
+			hora_fim,
+			apenas_primeira_reserva,
+			ativo
+		)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10::jsonb, $11::jsonb, NULLIF($12, ''), NULLIF($13, ''), $14, $15)
+		RETURNING id
+	`, cuponsTableName()),
+		cupom.IDArena,
+		cupom.Codigo,
+		cupom.Descricao,
+		string(cupom.Tipo),
+		cupom.Valor,
+		nullableTimeValue(cupom.ValidoDe),
+		nullableTimeValue(cupom.ValidoAte),
+		nullableIntValue(cupom.LimiteUsos),
+		nullableIntValue(cupom.LimiteUsosPorCliente),
+		idCampos,
+		diasSemana,
+		cupom.HoraInicio,
+		cupom.HoraFim,
+		cupom.ApenasPrimeiraReserva,
+		cupom.Ativo,
+	).Scan(&idCupom)
+	return idCupom, err
+}
+
+func (repository agendamentoRepository) updateCupom(ctx context.Context, cupom models.Cupom) (bool, error) {
+	idCampos, diasSemana := cupomListasJSON(cupom)
+
+	result, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET
+			codigo = $3,
+			descricao = NULLIF($4, ''),
+			tipo = $5,
+			valor = $6,
+			valido_de = $7,
+			valido_ate = $8,
+			limite_usos = $9,
+			limite_usos_por_cliente = $10,
+			id_campos = $11::jsonb,
+			dias_semana = $12::jsonb,
+			hora_inicio = NULLIF($13, ''),
+			hora_fim = NULLIF($14, ''),
+			apenas_primeira_reserva = $15,
+			ativo = $16
+		WHERE id = $1
+		  AND id_arena = $2
+	`, cuponsTableName()),
+		cupom.ID,
+		cupom.IDArena,
+		cupom.Codigo,
+		cupom.Descricao,
+		string(cupom.Tipo),
+		cupom.Valor,
+		nullableTimeValue(cupom.ValidoDe),
+		nullableTimeValue(cupom.ValidoAte),
+		nullableIntValue(cupom.LimiteUsos),
+		nullableIntValue(cupom.LimiteUsosPorCliente),
+		idCampos,
+		diasSemana,
+		cupom.HoraInicio,
+		cupom.HoraFim,
+		cupom.ApenasPrimeiraReserva,
+		cupom.Ativo,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func cupomListasJSON(cupom models.Cupom) (any, any) {
+	var idCampos, diasSemana any
+	if len(cupom.IDCampos) > 0 {
+		raw, _ := json.Marshal(cupom.IDCampos)
+		idCampos = string(raw)
+	}
+	if len(cupom.DiasSemana) > 0 {
+		raw, _ := json.Marshal(cupom.DiasSemana)
+		diasSemana = string(raw)
+	}
+
+	return idCampos, diasSemana
+}
+
+func (repository agendamentoRepository) camposPertencemArena(ctx context.Context, idArena int, idCampos []int) (bool, error) {
+	raw, err := json.Marshal(idCampos)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM %s
+		WHERE id_arena = $1
+		  AND id_campo IN (SELECT jsonb_array_elements_text($2::jsonb)::INTEGER)
+	`, campoTableName()), idArena, string(raw)).Scan(&count)
+	return count == len(idCampos), err
+}
+
+// countCupomUsosCliente matches the customer by player account when the
+// booking has one and by name otherwise.
+func (repository agendamentoRepository) countCupomUsosCliente(ctx context.Context, idCupom int, idJogador *int, nomeCliente string) (int, error) {
+	var count int
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM %s u
+		JOIN %s a ON a.id_agendamento = u.id_agendamento
+		WHERE u.id_cupom = $1
+		  AND a.status != $2
+		  AND (
+			($3::INTEGER IS NOT NULL AND u.id_usuario_jogador = $3)
+			OR ($3::INTEGER IS NULL AND LOWER(u.nome_cliente) = LOWER($4))
+		  )
+	`, cupomUsosTableName(), agendamentosTableName()),
+		idCupom,
+		string(models.AgendamentoStatusCancelado),
+		nullableIntValue(idJogador),
+		nomeCliente,
+	).Scan(&count)
+	return count, err
+}
+
+// hasReservaCliente tells whether the customer already booked at the arena.
+// Agendamentos only keep the requester's name, so that is what is compared.
+func (repository agendamentoRepository) hasReservaCliente(ctx context.Context, idArena int, nomeCliente string) (bool, error) {
+	var exists bool
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM %s a
+			JOIN %s c ON a.id_campo = c.id_campo
+			WHERE c.id_arena = $1
+			  AND LOWER(TRIM(a.nome_solicitante)) = LOWER($2)
+			  AND a.status != $3
+		)
+	`, agendamentosTableName(), campoTableName()), idArena, nomeCliente, string(models.AgendamentoStatusCancelado)).Scan(&exists)
+	return exists, err
+}
+
+func (repository agendamentoRepository) insertCupomUso(ctx context.Context, idCupom int, agendamento models.Agendamento, idJogador *int) error {
+	_, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id_cupom, id_agendamento, id_usuario_jogador, nome_cliente, valor_bruto, desconto)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+	`, cupomUsosTableName()),
+		idCupom,
+		agendamento.ID,
+		nullableIntValue(idJogador),
+		agendamento.NomeSolicitante,
+		agendamento.ValorBruto,
+		agendamento.ValorDesconto,
+	)
+	return err
+}
+
+// resolveCupom checks input.CodigoCupom against the booking being created and
+// returns the coupon with the discount it gives on valorBruto. It runs inside
+// create's transaction and leaves the coupon locked until it commits.
+func (service agendamentoService) resolveCupom(ctx context.Context, campo campoAgendamentoSnapshot, input models.CreateAgendamentoInput, valorBruto float64) (models.Cupom, float64, error) {
+	idCupom, err := service.repository.lockCupomCodigo(ctx, campo.IDArena, input.CodigoCupom)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Cupom{}, 0, errCupomInvalido
+		}
+		return models.Cupom{}, 0, err
+	}
+
+	cupom, err := service.repository.getCupom(ctx, idCupom)
+	if err != nil {
+		return models.Cupom{}, 0, err
+	}
+	if !cupom.Ativo {
+		return models.Cupom{}, 0, errCupomInvalido
+	}
+	if !cupom.Vigente(agendamentoNow()) {
+		return models.Cupom{}, 0, errCupomForaDaValidade
+	}
+	if !cupomAplica(cupom, input.IDCampo, input.Horario) {
+		return models.Cupom{}, 0, errCupomNaoAplicavel
+	}
+	if cupom.Esgotado() {
+		return models.Cupom{}, 0, errCupomEsgotado
+	}
+
+	nomeCliente := strings.TrimSpace(input.NomeSolicitante)
+	if cupom.LimiteUsosPorCliente != nil {
+		if input.IDUsuarioJogador == nil && nomeCliente == "" {
+			return models.Cupom{}, 0, errCupomClienteNaoIdentificado
+		}
+		usos, err := service.repository.countCupomUsosCliente(ctx, cupom.ID, input.IDUsuarioJogador, nomeCliente)
+		if err != nil {
+			return models.Cupom{}, 0, err
+		}
+		if usos >= *cupom.LimiteUsosPorCliente {
+			return models.Cupom{}, 0, errCupomLimiteCliente
+		}
+	}
+	if cupom.ApenasPrimeiraReserva {
+		if nomeCliente == "" {
+			return models.Cupom{}, 0, errCupomClienteNaoIdentificado
+		}
+		jaReservou, err := service.repository.hasReservaCliente(ctx, campo.IDArena, nomeCliente)
+		if err != nil {
+			return models.Cupom{}, 0, err
+		}
+		if jaReservou {
+			return models.Cupom{}, 0, errCupomPrimeiraReserva
+		}
+	}
+
+	return cupom, cupom.CalcularDesconto(valorBruto), nil
+}
+
+// descontoCupom re-applies the booking's coupon when its price changes. The
+// restrictions were checked when it was booked and are not checked again.
+func (service agendamentoService) descontoCupom(ctx context.Context, agendamento models.Agendamento, valorBruto float64) (float64, error) {
+	if agendamento.IDCupom == nil {
+		return 0, nil
+	}
+
+	cupom, err := service.repository.getCupom(ctx, *agendamento.IDCupom)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return math.Min(agendamento.ValorDesconto, valorBruto), nil
+		}
+		return 0, err
+	}
+
+	return cupom.CalcularDesconto(valorBruto), nil
+}
+
+// cupomAplica checks the campo and weekday of the booking and whether it
+// starts inside the coupon's time window; a window ending before it starts
+// (e.g. 22:00-02:00) runs past midnight.
+func cupomAplica(cupom models.Cupom, idCampo int, horario time.Time) bool {
+	if len(cupom.IDCampos) > 0 && !slices.Contains(cupom.IDCampos, idCampo) {
+		return false
+	}
+
+	local := horario.In(agendamentoLocation())
+	if len(cupom.DiasSemana) > 0 && !slices.Contains(cupom.DiasSemana, int(local.Weekday())) {
+		return false
+	}
+	if cupom.HoraInicio == "" || cupom.HoraFim == "" {
+		return true
+	}
+
+	inicio, ok := horarioEmMinutos(cupom.HoraInicio)
+	if !ok {
+		return false
+	}
+	fim, ok := horarioEmMinutos(cupom.HoraFim)
+	if !ok {
+		return false
+	}
+
+	minuto := local.Hour()*60 + local.Minute()
+	if fim <= inicio {
+		return minuto >= inicio || minuto < fim
+	}
+
+	return minuto >= inicio && minuto < fim
+}
+
+func (service agendamentoService) ListCupons(ctx context.Context, idArena int) ([]models.Cupom, error) {
+	return service.repository.listCupons(ctx, idArena)
+}
+
+func (service agendamentoService) SalvarCupom(ctx context.Context, cupom models.Cupom) (models.Cupom, error) {
+	if len(cupom.IDCampos) > 0 {
+		ok, err := service.repository.camposPertencemArena(ctx, cupom.IDArena, cupom.IDCampos)
+		if err != nil {
+			return models.Cupom{}, err
+		}
+		if !ok {
+			return models.Cupom{}, errCupomCampoInvalido
+		}
+	}
+
+	var err error
+	if cupom.ID == 0 {
+		cupom.ID, err = service.repository.insertCupom(ctx, cupom)
+	} else {
+		var updated bool
+		updated, err = service.repository.updateCupom(ctx, cupom)
+		if err == nil && !updated {
+			return models.Cupom{}, errCupomNaoEncontrado
+		}
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return models.Cupom{}, errCupomCodigoDuplicado
+		}
+		return models.Cupom{}, err
+	}
+
+	return service.repository.getCupom(ctx, cupom.ID)
+}
+
+func buildCupom(request cupomRequest) (models.Cupom, error) {
+	cupom := models.Cupom{
+		Codigo:                models.NormalizeCupomCodigo(request.Codigo),
+		Descricao:             strings.TrimSpace(request.Descricao),
+		Valor:                 models.ArredondarCentavos(request.Valor),
+		LimiteUsos:            request.LimiteUsos,
+		LimiteUsosPorCliente:  request.LimiteUsosPorCliente,
+		ApenasPrimeiraReserva: request.ApenasPrimeiraReserva,
+		Ativo:                 request.Ativo == nil || *request.Ativo,
+	}
+
+	if cupom.Codigo == "" || len(cupom.Codigo) > 50 || strings.ContainsAny(cupom.Codigo, " \t\n") {
+		return models.Cupom{}, errors.New("Codigo do cupom invalido. Use ate 50 caracteres sem espacos")
+	}
+
+	tipo, ok := models.NormalizeCupomTipo(request.Tipo)
+	if !ok {
+		return models.Cupom{}, errors.New("Tipo do cupom invalido. Use percentual ou fixo")
+	}
+	cupom.Tipo = tipo
+	if cupom.Valor <= 0 {
+		return models.Cupom{}, errors.New("Valor do cupom deve ser maior que zero")
+	}
+	if cupom.Tipo == models.CupomTipoPercentual && cupom.Valor > 100 {
+		return models.Cupom{}, errors.New("Desconto percentual deve ser no maximo 100")
+	}
+
+	if raw := strings.TrimSpace(request.ValidoDe); raw != "" {
+		validoDe, err := parseCupomValidade(raw, false)
+		if err != nil {
+			return models.Cupom{}, errors.New("valido_de invalido")
+		}
+		cupom.ValidoDe = &validoDe
+	}
+	if raw := strings.TrimSpace(request.ValidoAte); raw != "" {
+		validoAte, err := parseCupomValidade(raw, true)
+		if err != nil {
+			return models.Cupom{}, errors.New("valido_ate invalido")
+		}
+		cupom.ValidoAte = &validoAte
+	}
+	if cupom.ValidoDe != nil && cupom.ValidoAte != nil && !cupom.ValidoAte.After(*cupom.ValidoDe) {
+		return models.Cupom{}, errors.New("valido_ate deve ser depois de valido_de")
+	}
+
+	if cupom.LimiteUsos != nil && *cupom.LimiteUsos <= 0 {
+		return models.Cupom{}, errors.New("limite_usos deve ser maior que zero")
+	}
+	if cupom.LimiteUsosPorCliente != nil && *cupom.LimiteUsosPorCliente <= 0 {
+		return models.Cupom{}, errors.New("limite_usos_por_cliente deve ser maior que zero")
+	}
+
+	for _, idCampo := range request.IDCampos {
+		if idCampo <= 0 {
+			return models.Cupom{}, errors.New("id_campos invalido")
+		}
+		if !slices.Contains(cupom.IDCampos, idCampo) {
+			cupom.IDCampos = append(cupom.IDCampos, idCampo)
+		}
+	}
+	for _, dia := range request.DiasSemana {
+		if dia < 0 || dia > 6 {
+			return models.Cupom{}, errors.New("Dia da semana invalido. Use 0 (domingo) a 6 (sabado)")
+		}
+		if !slices.Contains(cupom.DiasSemana, dia) {
+			cupom.DiasSemana = append(cupom.DiasSemana, dia)
+		}
+	}
+	slices.Sort(cupom.DiasSemana)
+
+	horaInicio, horaFim := strings.TrimSpace(request.HoraInicio), strings.TrimSpace(request.HoraFim)
+	if (horaInicio == "") != (horaFim == "") {
+		return models.Cupom{}, errors.New("Informe hora_inicio e hora_fim juntos")
+	}
+	if horaInicio != "" {
+		var err error
+		if cupom.HoraInicio, err = normalizeCampoHorario(horaInicio); err != nil {
+			return models.Cupom{}, errors.New("Hora de inicio invalida")
+		}
+		if cupom.HoraFim, err = normalizeCampoHorario(horaFim); err != nil {
+			return models.Cupom{}, errors.New("Hora de fim invalida")
+		}
+		if cupom.HoraInicio == cupom.HoraFim {
+			return models.Cupom{}, errors.New("hora_inicio e hora_fim devem ser diferentes")
+		}
+	}
+
+	return cupom, nil
+}
+
+// parseCupomValidade accepts a date or a date and time. A bare valido_ate date
+// keeps the coupon valid through the end of that day.
+func parseCupomValidade(raw string, fimDoDia bool) (time.Time, error) {
+	if data, err := time.ParseInLocation("2006-01-02", raw, agendamentoLocation()); err == nil {
+		if fimDoDia {
+			return data.AddDate(0, 0, 1), nil
+		}
+		return data, nil
+	}
+
+	return parseAgendamentoHorario(raw)
+}
+
+func GetCuponsArena(w http.ResponseWriter, r *http.Request) {
+	idArena, ok := resolveArenaPoliticaOwner(w, r)
+	if !ok {
+		return
+	}
+
+	cupons, err := newAgendamentoService().ListCupons(r.Context(), idArena)
+	if err != nil {
+		http.Error(w, "Erro ao buscar cupons", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar cupons da arena %d: %v", idArena, err)
+		return
+	}
+
+	agora := agendamentoNow()
+	response := make([]cupomResponse, 0, len(cupons))
+	for _, cupom := range cupons {
+		response = append(response, newCupomResponse(cupom, agora))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func CriarCupomArena(w http.ResponseWriter, r *http.Request) {
+	idArena, ok := resolveArenaPoliticaOwner(w, r)
+	if !ok {
+		return
+	}
+
+	cupom, err := parseCupomRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cupom.IDArena = idArena
+
+	cupom, err = newAgendamentoService().SalvarCupom(r.Context(), cupom)
+	if err != nil {
+		writeCupomError(w, err, idArena)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"message": "Cupom cadastrado com sucesso",
+		"cupom":   newCupomResponse(cupom, agendamentoNow()),
+	})
+}
+
+func EditarCupomArena(w http.ResponseWriter, r *http.Request) {
+	idArena, ok := resolveArenaPoliticaOwner(w, r)
+	if !ok {
+		return
+	}
+
+	idCupom := parsePositiveIntParam(mux.Vars(r)["id_cupom"])
+	if idCupom == 0 {
+		http.Error(w, "ID do cupom invalido", http.StatusBadRequest)
+		return
+	}
+
+	cupom, err := parseCupomRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cupom.ID = idCupom
+	cupom.IDArena = idArena
+
+	cupom, err = newAgendamentoService().SalvarCupom(r.Context(), cupom)
+	if err != nil {
+		writeCupomError(w, err, idArena)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message": "Cupom atualizado com sucesso",
+		"cupom":   newCupomResponse(cupom, agendamentoNow()),
+	})
+}
+
+func parseCupomRequest(r *http.Request) (models.Cupom, error) {
+	var request cupomRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return models.Cupom{}, errors.New("Erro ao decodificar JSON")
+	}
+
+	return buildCupom(request)
+}
+
+func writeCupomError(w http.ResponseWriter, err error, idArena int) {
+	switch {
+	case errors.Is(err, errCupomNaoEncontrado):
+		http.Error(w, "Cupom nao encontrado", http.StatusNotFound)
+	case errors.Is(err, errCupomCodigoDuplicado):
+		http.Error(w, "Ja existe um cupom com este codigo na arena", http.StatusConflict)
+	case errors.Is(err, errCupomCampoInvalido):
+		http.Error(w, "Os campos do cupom devem pertencer a arena", http.StatusBadRequest)
+	default:
+		http.Error(w, "Erro ao salvar cupom", http.StatusInternalServerError)
+		log.Printf("Erro ao salvar cupom da arena %d: %v", idArena, err)
+	}
+}
+
+func newCupomResponse(cupom models.Cupom, agora time.Time) cupomResponse {
+	response := cupomResponse{
+		ID:                    cupom.ID,
+		IDArena:               cupom.IDArena,
+		Codigo:                cupom.Codigo,
+		Descricao:             cupom.Descricao,
+		Tipo:                  string(cupom.Tipo),
+		Valor:                 cupom.Valor,
+		LimiteUsos:            cupom.LimiteUsos,
+		LimiteUsosPorCliente:  cupom.LimiteUsosPorCliente,
+		IDCampos:              cupom.IDCampos,
+		DiasSemana:            cupom.DiasSemana,
+		HoraInicio:            cupom.HoraInicio,
+		HoraFim:               cupom.HoraFim,
+		ApenasPrimeiraReserva: cupom.ApenasPrimeiraReserva,
+		Ativo:                 cupom.Ativo,
+		Vigente:               cupom.Ativo && cupom.Vigente(agora) && !cupom.Esgotado(),
+		Usos:                  cupom.Usos,
+		CriadoEm:              formatAgendamentoDateTime(cupom.CriadoEm),
+	}
+	if response.IDCampos == nil {
+		response.IDCampos = []int{}
+	}
+	if response.DiasSemana == nil {
+		response.DiasSemana = []int{}
+	}
+	if cupom.ValidoDe != nil {
+		response.ValidoDe = formatAgendamentoDateTime(*cupom.ValidoDe)
+	}
+	if cupom.ValidoAte != nil {
+		response.ValidoAte = formatAgendamentoDateTime(*cupom.ValidoAte)
+	}
+
+	return response
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestCupomAplicaRestrictions(t *testing.T) {
+	location := agendamentoLocation()
+	// 2026-05-05 is a tuesday.
+	tercaManha := time.Date(2026, time.May, 5, 9, 0, 0, 0, location)
+	cupom := models.Cupom{IDCampos: []int{7}, DiasSemana: []int{2}, HoraInicio: "06:00", HoraFim: "12:00"}
+
+	testCases := []struct {
+		name    string
+		idCampo int
+		horario time.Time
+		want    bool
+	}{
+		{name: "tuesday morning", idCampo: 7, horario: tercaManha, want: true},
+		{name: "other campo", idCampo: 8, horario: tercaManha, want: false},
+		{name: "wednesday", idCampo: 7, horario: tercaManha.AddDate(0, 0, 1), want: false},
+		{name: "window end is exclusive", idCampo: 7, horario: tercaManha.Add(3 * time.Hour), want: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := cupomAplica(cupom, testCase.idCampo, testCase.horario); got != testCase.want {
+				t.Fatalf("expected %v, got %v", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestCupomAplicaWindowPastMidnight(t *testing.T) {
+	location := agendamentoLocation()
+	cupom := models.Cupom{HoraInicio: "22:00", HoraFim: "02:00"}
+
+	if !cupomAplica(cupom, 1, time.Date(2026, time.May, 5, 23, 0, 0, 0, location)) {
+		t.Fatalf("expected 23:00 to be inside 22:00-02:00")
+	}
+	if !cupomAplica(cupom, 1, time.Date(2026, time.May, 6, 1, 0, 0, 0, location)) {
+		t.Fatalf("expected 01:00 to be inside 22:00-02:00")
+	}
+	if cupomAplica(cupom, 1, time.Date(2026, time.May, 5, 12, 0, 0, 0, location)) {
+		t.Fatalf("expected 12:00 to be outside 22:00-02:00")
+	}
+}
+
+func TestBuildCupomNormalizesRequest(t *testing.T) {
+	cupom, err := buildCupom(cupomRequest{
+		Codigo:     " terca10 ",
+		Tipo:       "Percentual",
+		Valor:      10,
+		ValidoAte:  "2026-05-31",
+		DiasSemana: []int{4, 2, 2},
+		HoraInicio: "6:00",
+		HoraFim:    "12:00",
+	})
+	if err != nil {
+		t.Fatalf("expected a valid coupon, got %v", err)
+	}
+	if cupom.Codigo != "TERCA10" || cupom.Tipo != models.CupomTipoPercentual || !cupom.Ativo {
+		t.Fatalf("expected normalized active coupon, got %+v", cupom)
+	}
+	if len(cupom.DiasSemana) != 2 || cupom.DiasSemana[0] != 2 || cupom.DiasSemana[1] != 4 {
+		t.Fatalf("expected sorted unique weekdays, got %v", cupom.DiasSemana)
+	}
+	fimDoDia := time.Date(2026, time.June, 1, 0, 0, 0, 0, agendamentoLocation())
+	if cupom.ValidoAte == nil || !cupom.ValidoAte.Equal(fimDoDia) {
+		t.Fatalf("expected valido_ate to cover the whole day, got %v", cupom.ValidoAte)
+	}
+}
+
+func TestBuildCupomRejectsInvalidRequests(t *testing.T) {
+	zero := 0
+
+	testCases := []struct {
+		name    string
+		request cupomRequest
+	}{
+		{name: "no code", request: cupomRequest{Tipo: "fixo", Valor: 10}},
+		{name: "code with spaces", request: cupomRequest{Codigo: "TERCA 10", Tipo: "fixo", Valor: 10}},
+		{name: "unknown type", request: cupomRequest{Codigo: "X", Tipo: "brinde", Valor: 10}},
+		{name: "percent above 100", request: cupomRequest{Codigo: "X", Tipo: "percentual", Valor: 120}},
+		{name: "no value", request: cupomRequest{Codigo: "X", Tipo: "fixo"}},
+		{name: "zero limit", request: cupomRequest{Codigo: "X", Tipo: "fixo", Valor: 10, LimiteUsos: &zero}},
+		{name: "invalid weekday", request: cupomRequest{Codigo: "X", Tipo: "fixo", Valor: 10, DiasSemana: []int{7}}},
+		{name: "half a window", request: cupomRequest{Codigo: "X", Tipo: "fixo", Valor: 10, HoraInicio: "06:00"}},
+		{name: "period ends before it starts", request: cupomRequest{Codigo: "X", Tipo: "fixo", Valor: 10, ValidoDe: "2026-06-01", ValidoAte: "2026-05-01"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if _, err := buildCupom(testCase.request); err == nil {
+				t.Fatalf("expected %s to be rejected", testCase.name)
+			}
+		})
+	}
+}
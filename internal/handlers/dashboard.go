@@ -14,7 +14,13 @@ type DashboardDados struct {
 	CamposCadastrados int `json:"camposCadastrados"`
 	Cancelados        int `json:"cancelados"`
 	Concluidos        int `json:"concluidos"`
+	NaoCompareceu     int `json:"naoCompareceu"`
 	OcupacaoHoje      int `json:"ocupacaoHoje"`
+	// Receita is net of refunds; RetidoCancelamentos is what cancelled
+	// bookings left behind as fees.
+	Receita             float64 `json:"receita"`
+	Reembolsos          float64 `json:"reembolsos"`
+	RetidoCancelamentos float64 `json:"retidoCancelamentos"`
 }
 
 type ProximoJogo struct {
@@ -51,7 +57,11 @@ func GetDashboard(w http.ResponseWriter, r *http.Request) {
 		camposAgendados    int
 		cancelados         int
 		concluidos         int
+		naoCompareceu      int
 		camposOcupadosHoje int
+		receita            float64
+		reembolsos         float64
+		retidoCancelamento float64
 	)
 
 	metricasQuery := `
@@ -75,12 +85,30 @@ func GetDashboard(w http.ResponseWriter, r *http.Request) {
 			 FROM ` + agendamentosTableName() + ` ag
 			 JOIN campos_usuario cu ON cu.id_campo = ag.id_campo
 			 WHERE ag.status = 'concluido') AS concluidos,
+			(SELECT COUNT(*)
+			 FROM ` + agendamentosTableName() + ` ag
+			 JOIN campos_usuario cu ON cu.id_campo = ag.id_campo
+			 WHERE ag.status = 'nao_compareceu') AS nao_compareceu,
 			(SELECT COUNT(DISTINCT ag.id_campo)
 			 FROM ` + agendamentosTableName() + ` ag
 			 JOIN campos_usuario cu ON cu.id_campo = ag.id_campo
 			 WHERE ag.status <> 'cancelado'
 			   AND DATE(ag.horario AT TIME ZONE 'America/Sao_Paulo') = DATE(NOW() AT TIME ZONE 'America/Sao_Paulo')
-			) AS campos_ocupados_hoje;
+			) AS campos_ocupados_hoje,
+			(SELECT COALESCE(SUM(p.valor_pago), 0)
+			 FROM ` + pagamentosPorAgendamentoTableName() + ` p
+			 JOIN ` + agendamentosTableName() + ` ag ON ag.id_agendamento = p.id_agendamento
+			 JOIN campos_usuario cu ON cu.id_campo = ag.id_campo) AS receita,
+			(SELECT COALESCE(-SUM(p.valor_pago), 0)
+			 FROM ` + pagamentosPorAgendamentoTableName() + ` p
+			 JOIN ` + agendamentosTableName() + ` ag ON ag.id_agendamento = p.id_agendamento
+			 JOIN campos_usuario cu ON cu.id_campo = ag.id_campo
+			 WHERE p.valor_pago < 0) AS reembolsos,
+			(SELECT COALESCE(SUM(p.valor_pago), 0)
+			 FROM ` + pagamentosPorAgendamentoTableName() + ` p
+			 JOIN ` + agendamentosTableName() + ` ag ON ag.id_agendamento = p.id_agendamento
+			 JOIN campos_usuario cu ON cu.id_campo = ag.id_campo
+			 WHERE ag.status = 'cancelado') AS retido_cancelamentos;
 	`
 
 	err := config.DB.QueryRow(metricasQuery, userID).Scan(
@@ -88,7 +116,11 @@ func GetDashboard(w http.ResponseWriter, r *http.Request) {
 		&camposAgendados,
 		&cancelados,
 		&concluidos,
+		&naoCompareceu,
 		&camposOcupadosHoje,
+		&receita,
+		&reembolsos,
+		&retidoCancelamento,
 	)
 	if err != nil {
 		log.Printf("Erro ao buscar m\u00e9tricas do dashboard: %v", err)
@@ -178,11 +210,15 @@ func GetDashboard(w http.ResponseWriter, r *http.Request) {
 
 	response := DashboardResponse{
 		Dados: DashboardDados{
-			CamposAgendados:   camposAgendados,
-			CamposCadastrados: camposCadastrados,
-			Cancelados:        cancelados,
-			Concluidos:        concluidos,
-			OcupacaoHoje:      ocupacaoHoje,
+			CamposAgendados:     camposAgendados,
+			CamposCadastrados:   camposCadastrados,
+			Cancelados:          cancelados,
+			Concluidos:          concluidos,
+			NaoCompareceu:       naoCompareceu,
+			OcupacaoHoje:        ocupacaoHoje,
+			Receita:             receita,
+			Reembolsos:          reembolsos,
+			RetidoCancelamentos: retidoCancelamento,
 		},
 		ProximosJogos: proximosJogos,
 		RankingCampos: rankingCampos,
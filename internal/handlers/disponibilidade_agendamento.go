@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/models"
 	"github.com/gorilla/mux"
 )
 
@@ -26,23 +27,44 @@ type campoDisponibilidadeInfo struct {
 	CampoEmManutencao   bool
 	ArenaEmManutencao   bool
 	HorariosDisponiveis []string
+	HorariosSemana      map[int][]string
+	RegrasPreco         []models.CampoRegraPreco
+}
+
+type agendamentoIntervalo struct {
+	Inicio time.Time
+	Fim    time.Time
+}
+
+// agendamentoSlotDuracao is the length covered by each configured campo horario.
+const agendamentoSlotDuracao = time.Hour
+
+type horarioPrecoResponse struct {
+	Horario    string  `json:"horario"`
+	ValorHora  float64 `json:"valor_hora"`
+	ValorTotal float64 `json:"valor_total"`
 }
 
 type horarioDisponivelResponse struct {
-	IDCampo                  int      `json:"id_campo"`
-	NomeCampo                string   `json:"nome_campo"`
-	IDArena                  int      `json:"id_arena"`
-	NomeArena                string   `json:"nome_arena"`
-	Data                     string   `json:"data"`
-	ValorHora                float64  `json:"valor_hora"`
-	Ativo                    bool     `json:"ativo"`
-	EmManutencao             bool     `json:"em_manutencao"`
-	ArenaEmManutencao        bool     `json:"arena_em_manutencao"`
-	Horarios                 []string `json:"horarios,omitempty"`
-	HorariosDisponiveis      []string `json:"horarios_disponiveis"`
-	HorariosDisponiveisCamel []string `json:"horariosDisponiveis,omitempty"`
-	HorariosCampo            []string `json:"horarios_campo,omitempty"`
-	HorariosOcupados         []string `json:"horarios_ocupados"`
+	IDCampo                  int                    `json:"id_campo"`
+	NomeCampo                string                 `json:"nome_campo"`
+	IDArena                  int                    `json:"id_arena"`
+	NomeArena                string                 `json:"nome_arena"`
+	Data                     string                 `json:"data"`
+	DuracaoMinutos           int                    `json:"duracao_minutos"`
+	ValorHora                float64                `json:"valor_hora"`
+	Ativo                    bool                   `json:"ativo"`
+	EmManutencao             bool                   `json:"em_manutencao"`
+	ArenaEmManutencao        bool                   `json:"arena_em_manutencao"`
+	Fechado                  bool                   `json:"fechado"`
+	Horarios                 []string               `json:"horarios,omitempty"`
+	HorariosDisponiveis      []string               `json:"horarios_disponiveis"`
+	HorariosDisponiveisCamel []string               `json:"horariosDisponiveis,omitempty"`
+	HorariosCampo            []string               `json:"horarios_campo,omitempty"`
+	HorariosSemana           map[int][]string       `json:"horarios_semana,omitempty"`
+	HorariosOcupados         []string               `json:"horarios_ocupados"`
+	HorariosBloqueados       []string               `json:"horarios_bloqueados"`
+	HorariosPrecos           []horarioPrecoResponse `json:"horarios_precos"`
 }
 
 func GetHorariosDisponiveisCampo(w http.ResponseWriter, r *http.Request) {
@@ -80,6 +102,12 @@ func GetHorariosDisponiveisCampo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	duracaoMinutos, err := parseAvailabilityDuracao(r.URL.Query().Get("duracao_minutos"))
+	if err != nil {
+		http.Error(w, "duracao_minutos invalido", http.StatusBadRequest)
+		return
+	}
+
 	info, err := loadCampoDisponibilidadeInfo(campoID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -91,51 +119,72 @@ func GetHorariosDisponiveisCampo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	horariosDia, aberto := campoHorariosNaData(info.HorariosDisponiveis, info.HorariosSemana, requestedDate)
 	response := horarioDisponivelResponse{
 		IDCampo:                  info.IDCampo,
 		NomeCampo:                info.NomeCampo,
 		IDArena:                  info.IDArena,
 		NomeArena:                info.NomeArena,
 		Data:                     requestedDate.Format("2006-01-02"),
+		DuracaoMinutos:           duracaoMinutos,
 		ValorHora:                info.ValorHora,
 		Ativo:                    info.Ativo,
 		EmManutencao:             info.CampoEmManutencao,
 		ArenaEmManutencao:        info.ArenaEmManutencao,
-		Horarios:                 append([]string(nil), info.HorariosDisponiveis...),
+		Fechado:                  !aberto,
+		Horarios:                 append([]string(nil), horariosDia...),
 		HorariosDisponiveis:      []string{},
-		HorariosDisponiveisCamel: append([]string(nil), info.HorariosDisponiveis...),
-		HorariosCampo:            append([]string(nil), info.HorariosDisponiveis...),
+		HorariosDisponiveisCamel: append([]string(nil), horariosDia...),
+		HorariosCampo:            append([]string(nil), horariosDia...),
+		HorariosSemana:           info.HorariosSemana,
 		HorariosOcupados:         []string{},
+		HorariosBloqueados:       []string{},
+		HorariosPrecos:           []horarioPrecoResponse{},
 	}
 
-	if info.CampoEmManutencao || info.ArenaEmManutencao {
+	if info.CampoEmManutencao || info.ArenaEmManutencao || !aberto {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 		return
 	}
 
-	allSlots := generateBookingSlots(requestedDate, location, info.HorariosDisponiveis)
-	occupied, err := loadOccupiedSlotsByCampo(campoID, requestedDate, location)
+	allSlots := generateBookingSlots(requestedDate, location, horariosDia)
+	occupied, err := loadOccupiedIntervalsByCampo(campoID, requestedDate, location)
 	if err != nil {
 		http.Error(w, "Erro ao buscar horarios ocupados", http.StatusInternalServerError)
 		log.Printf("Erro ao buscar horarios ocupados do campo %d: %v", campoID, err)
 		return
 	}
 
-	occupiedSet := make(map[string]struct{}, len(occupied))
-	for _, slot := range occupied {
-		label := slot.In(location).Format("15:04")
-		occupiedSet[label] = struct{}{}
-		response.HorariosOcupados = append(response.HorariosOcupados, label)
+	startOfDay := time.Date(requestedDate.Year(), requestedDate.Month(), requestedDate.Day(), 0, 0, 0, 0, location)
+	bloqueios, err := loadBloqueioIntervalos(r.Context(), config.DB, campoID, info.IDArena, startOfDay, startOfDay.Add(30*time.Hour))
+	if err != nil {
+		http.Error(w, "Erro ao buscar bloqueios da agenda", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar bloqueios do campo %d: %v", campoID, err)
+		return
 	}
-	sort.Strings(response.HorariosOcupados)
 
 	for _, slot := range allSlots {
-		label := slot.In(location).Format("15:04")
-		if _, exists := occupiedSet[label]; exists {
-			continue
+		switch {
+		case overlapsAnyIntervalo(bloqueios, slot, slot.Add(agendamentoSlotDuracao)):
+			response.HorariosBloqueados = append(response.HorariosBloqueados, slot.In(location).Format("15:04"))
+		case overlapsAnyIntervalo(occupied, slot, slot.Add(agendamentoSlotDuracao)):
+			response.HorariosOcupados = append(response.HorariosOcupados, slot.In(location).Format("15:04"))
 		}
+	}
+	sort.Strings(response.HorariosOcupados)
+	sort.Strings(response.HorariosBloqueados)
+
+	indisponiveis := append(append([]agendamentoIntervalo(nil), occupied...), bloqueios...)
+	duracao := time.Duration(duracaoMinutos) * time.Minute
+	for _, slot := range availableBookingStarts(allSlots, indisponiveis, duracao) {
+		label := slot.In(location).Format("15:04")
 		response.HorariosDisponiveis = append(response.HorariosDisponiveis, label)
+		response.HorariosPrecos = append(response.HorariosPrecos, horarioPrecoResponse{
+			Horario:    label,
+			ValorHora:  resolveValorHora(info.ValorHora, info.RegrasPreco, slot),
+			ValorTotal: calcularValorAgendamento(info.ValorHora, info.RegrasPreco, slot, duracaoMinutos),
+		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -143,10 +192,22 @@ func GetHorariosDisponiveisCampo(w http.ResponseWriter, r *http.Request) {
 }
 
 func loadCampoDisponibilidadeInfo(campoID int) (campoDisponibilidadeInfo, error) {
-	optionalColumns, err := loadCampoOptionalColumns(context.Background())
+	infos, err := loadCamposDisponibilidadeInfo(context.Background(), "c.id_campo = $1", campoID)
 	if err != nil {
 		return campoDisponibilidadeInfo{}, err
 	}
+	if len(infos) == 0 {
+		return campoDisponibilidadeInfo{}, sql.ErrNoRows
+	}
+
+	return infos[0], nil
+}
+
+func loadCamposDisponibilidadeInfo(ctx context.Context, where string, args ...any) ([]campoDisponibilidadeInfo, error) {
+	optionalColumns, err := loadCampoOptionalColumns(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	campoTable := campoTableName()
 	arenasTable := arenasTableName()
@@ -171,65 +232,125 @@ func loadCampoDisponibilidadeInfo(campoID int) (campoDisponibilidadeInfo, error)
 			%s,
 			%s,
 			%s,
+			%s,
 			%s
 		FROM %s c
 		JOIN %s a ON a.id = c.id_arena
-		WHERE c.id_campo = $1
+		WHERE %s
+		ORDER BY c.id_campo ASC
 	`,
 		optionalCampoSelectExpression("c", "valor_hora", optionalColumns.ValorHora),
 		optionalCampoSelectExpression("c", "ativo", optionalColumns.Ativo),
 		optionalCampoSelectExpression("c", "horarios_disponiveis", optionalColumns.HorariosDisponiveis),
+		optionalCampoSelectExpression("c", "horarios_semana", optionalColumns.HorariosSemana),
 		arenaMaintenanceExpr,
 		campoTable,
 		arenasTable,
+		where,
 	)
 
-	var info campoDisponibilidadeInfo
-	var horariosRaw string
-	err = config.DB.QueryRow(query, campoID).Scan(
-		&info.IDCampo,
-		&info.NomeCampo,
-		&info.IDArena,
-		&info.NomeArena,
-		&info.ValorHora,
-		&info.Ativo,
-		&horariosRaw,
-		&info.ArenaEmManutencao,
-	)
+	rows, err := config.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return campoDisponibilidadeInfo{}, err
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := make([]campoDisponibilidadeInfo, 0)
+	for rows.Next() {
+		var info campoDisponibilidadeInfo
+		var horariosRaw, semanaRaw string
+		if err := rows.Scan(
+			&info.IDCampo,
+			&info.NomeCampo,
+			&info.IDArena,
+			&info.NomeArena,
+			&info.ValorHora,
+			&info.Ativo,
+			&horariosRaw,
+			&semanaRaw,
+			&info.ArenaEmManutencao,
+		); err != nil {
+			return nil, err
+		}
+
+		info.CampoEmManutencao = !info.Ativo
+		info.HorariosDisponiveis = decodeCampoHorarios(horariosRaw)
+		info.HorariosSemana = decodeCampoHorariosSemana(semanaRaw)
+		infos = append(infos, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	campoIDs := make([]int, 0, len(infos))
+	for _, info := range infos {
+		campoIDs = append(campoIDs, info.IDCampo)
+	}
+	regras, err := loadCampoRegrasPreco(ctx, config.DB, campoIDs)
+	if err != nil {
+		return nil, err
+	}
+	for index := range infos {
+		infos[index].RegrasPreco = regras[infos[index].IDCampo]
 	}
 
-	info.CampoEmManutencao = !info.Ativo
-	info.HorariosDisponiveis = decodeCampoHorarios(horariosRaw)
-	return info, nil
+	return infos, nil
 }
 
-func loadOccupiedSlotsByCampo(campoID int, requestedDate time.Time, location *time.Location) ([]time.Time, error) {
+func loadOccupiedIntervalsByCampo(campoID int, requestedDate time.Time, location *time.Location) ([]agendamentoIntervalo, error) {
 	startOfDay := time.Date(requestedDate.Year(), requestedDate.Month(), requestedDate.Day(), 0, 0, 0, 0, location)
-	endOfDay := startOfDay.Add(30 * time.Hour)
+	occupied, err := loadOccupiedIntervalsByCampos(context.Background(), []int{campoID}, startOfDay, startOfDay.Add(30*time.Hour))
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := config.DB.Query(fmt.Sprintf(`
-		SELECT horario
+	return occupied[campoID], nil
+}
+
+// loadOccupiedIntervalsByCampos loads every active agendamento touching
+// [inicio, fim) for the campos in a single query, plus the slots held by
+// pending reschedule proposals.
+func loadOccupiedIntervalsByCampos(ctx context.Context, campoIDs []int, inicio time.Time, fim time.Time) (map[int][]agendamentoIntervalo, error) {
+	occupied := make(map[int][]agendamentoIntervalo, len(campoIDs))
+	if len(campoIDs) == 0 {
+		return occupied, nil
+	}
+
+	args := []any{inicio, fim}
+	placeholders := make([]string, 0, len(campoIDs))
+	for _, campoID := range campoIDs {
+		args = append(args, campoID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	campos := strings.Join(placeholders, ", ")
+	rows, err := config.DB.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id_campo, horario, COALESCE(duracao_minutos, %d) AS duracao_minutos
 		FROM %s
-		WHERE id_campo = $1
-		  AND horario >= $2
-		  AND horario < $3
+		WHERE id_campo IN (%s)
+		  AND horario < $2
+		  AND horario + COALESCE(duracao_minutos, %d) * INTERVAL '1 minute' > $1
 		  AND status != 'cancelado'
-		ORDER BY horario ASC
-	`, agendamentosTableName()), campoID, startOfDay, endOfDay)
+		UNION ALL
+		%s
+		ORDER BY id_campo ASC, horario ASC
+	`, models.AgendamentoDuracaoPadraoMinutos, agendamentosTableName(), campos, models.AgendamentoDuracaoPadraoMinutos, remarcacoesPendentesQuery(campos)), args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var occupied []time.Time
 	for rows.Next() {
+		var campoID int
 		var horario time.Time
-		if err := rows.Scan(&horario); err != nil {
+		var duracaoMinutos int
+		if err := rows.Scan(&campoID, &horario, &duracaoMinutos); err != nil {
 			return nil, err
 		}
-		occupied = append(occupied, horario)
+		occupied[campoID] = append(occupied[campoID], agendamentoIntervalo{
+			Inicio: horario,
+			Fim:    horario.Add(time.Duration(models.NormalizeAgendamentoDuracao(duracaoMinutos)) * time.Minute),
+		})
 	}
 
 	if err := rows.Err(); err != nil {
@@ -239,6 +360,70 @@ func loadOccupiedSlotsByCampo(campoID int, requestedDate time.Time, location *ti
 	return occupied, nil
 }
 
+// availableBookingStarts returns the configured slots where a booking of the
+// given duration stays inside the campo horarios and overlaps no occupied interval.
+func availableBookingStarts(slots []time.Time, occupied []agendamentoIntervalo, duracao time.Duration) []time.Time {
+	ordered := append([]time.Time(nil), slots...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Before(ordered[j]) })
+
+	available := make([]time.Time, 0, len(ordered))
+	for _, inicio := range ordered {
+		fim := inicio.Add(duracao)
+		if !bookingWindowFits(ordered, inicio, fim) {
+			continue
+		}
+		if overlapsAnyIntervalo(occupied, inicio, fim) {
+			continue
+		}
+		available = append(available, inicio)
+	}
+
+	return available
+}
+
+func bookingWindowFits(orderedSlots []time.Time, inicio time.Time, fim time.Time) bool {
+	coberto := inicio
+	for _, slot := range orderedSlots {
+		if !coberto.Before(fim) {
+			break
+		}
+		if slot.After(coberto) {
+			break
+		}
+
+		slotFim := slot.Add(agendamentoSlotDuracao)
+		if slotFim.After(coberto) {
+			coberto = slotFim
+		}
+	}
+
+	return !coberto.Before(fim)
+}
+
+func overlapsAnyIntervalo(intervalos []agendamentoIntervalo, inicio time.Time, fim time.Time) bool {
+	for _, intervalo := range intervalos {
+		if inicio.Before(intervalo.Fim) && fim.After(intervalo.Inicio) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseAvailabilityDuracao(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return models.AgendamentoDuracaoPadraoMinutos, nil
+	}
+
+	duracaoMinutos, err := strconv.Atoi(raw)
+	if err != nil || !isAgendamentoDuracaoValida(duracaoMinutos) {
+		return 0, errAgendamentoDuracaoInvalida
+	}
+
+	return duracaoMinutos, nil
+}
+
 func parseAvailabilityDate(rawDate string, location *time.Location) (time.Time, error) {
 	rawDate = strings.TrimSpace(rawDate)
 	if rawDate == "" {
@@ -277,7 +462,7 @@ func generateBookingSlots(requestedDate time.Time, location *time.Location, conf
 		}
 
 		slotDate := requestedDate
-		if hour < 6 {
+		if hour < agendamentoHoraVirada {
 			slotDate = requestedDate.Add(24 * time.Hour)
 		}
 
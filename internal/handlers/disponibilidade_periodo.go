@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+)
+
+const (
+	disponibilidadeDiasPadrao = 7
+	disponibilidadeMaxDias    = 14
+)
+
+const (
+	slotEstadoLivre     = "livre"
+	slotEstadoOcupado   = "ocupado"
+	slotEstadoBloqueado = "bloqueado"
+)
+
+type disponibilidadeSlotResponse struct {
+	Horario    string  `json:"horario"`
+	Inicio     string  `json:"inicio"`
+	Estado     string  `json:"estado"`
+	Disponivel bool    `json:"disponivel"`
+	ValorTotal float64 `json:"valor_total,omitempty"`
+}
+
+type disponibilidadeDiaResponse struct {
+	Data    string                        `json:"data"`
+	Fechado bool                          `json:"fechado"`
+	Slots   []disponibilidadeSlotResponse `json:"slots"`
+}
+
+type disponibilidadeCampoResponse struct {
+	IDCampo           int                          `json:"id_campo"`
+	NomeCampo         string                       `json:"nome_campo"`
+	IDArena           int                          `json:"id_arena"`
+	NomeArena         string                       `json:"nome_arena"`
+	ValorHora         float64                      `json:"valor_hora"`
+	EmManutencao      bool                         `json:"em_manutencao"`
+	ArenaEmManutencao bool                         `json:"arena_em_manutencao"`
+	Dias              []disponibilidadeDiaResponse `json:"dias"`
+}
+
+type disponibilidadePeriodoResponse struct {
+	DataInicio     string                         `json:"data_inicio"`
+	DataFim        string                         `json:"data_fim"`
+	DuracaoMinutos int                            `json:"duracao_minutos"`
+	Campos         []disponibilidadeCampoResponse `json:"campos"`
+}
+
+// GetDisponibilidadePeriodo answers a calendar view: every slot of one campo
+// (or of all campos of an arena) for up to disponibilidadeMaxDias days.
+func GetDisponibilidadePeriodo(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	location := agendamentoLocation()
+
+	campoID := firstNonZero(parsePositiveIntParam(query.Get("campo_id")), parsePositiveIntParam(query.Get("id_campo")))
+	arenaID := parsePositiveIntParam(query.Get("id_arena"))
+	if campoID == 0 && arenaID == 0 {
+		http.Error(w, "Informe campo_id ou id_arena", http.StatusBadRequest)
+		return
+	}
+
+	dataInicio, err := parseAvailabilityDate(query.Get("data_inicio"), location)
+	if err != nil {
+		http.Error(w, "Data invalida. Use o formato YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	dias, err := parseDisponibilidadeDias(query.Get("dias"), query.Get("data_fim"), dataInicio, location)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	duracaoMinutos, err := parseAvailabilityDuracao(query.Get("duracao_minutos"))
+	if err != nil {
+		http.Error(w, "duracao_minutos invalido", http.StatusBadRequest)
+		return
+	}
+
+	where, arg := "c.id_campo = $1", campoID
+	if campoID == 0 {
+		where, arg = "c.id_arena = $1", arenaID
+	}
+	infos, err := loadCamposDisponibilidadeInfo(r.Context(), where, arg)
+	if err != nil {
+		http.Error(w, "Erro ao buscar campos para disponibilidade", http.StatusInternalServerError)
+		log.Printf("Erro ao carregar campos para disponibilidade: %v", err)
+		return
+	}
+	if len(infos) == 0 {
+		http.Error(w, "Campo nao encontrado", http.StatusNotFound)
+		return
+	}
+
+	campoIDs := make([]int, 0, len(infos))
+	arenaPorCampo := make(map[int]int, len(infos))
+	for _, info := range infos {
+		campoIDs = append(campoIDs, info.IDCampo)
+		arenaPorCampo[info.IDCampo] = info.IDArena
+	}
+
+	inicioPeriodo := dataInicio
+	fimPeriodo := dataInicio.AddDate(0, 0, dias).Add(agendamentoHoraVirada * time.Hour)
+	occupied, err := loadOccupiedIntervalsByCampos(r.Context(), campoIDs, inicioPeriodo, fimPeriodo)
+	if err != nil {
+		http.Error(w, "Erro ao buscar horarios ocupados", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar horarios ocupados para disponibilidade: %v", err)
+		return
+	}
+	bloqueios, err := loadBloqueioIntervalosByCampos(r.Context(), config.DB, arenaPorCampo, inicioPeriodo, fimPeriodo)
+	if err != nil {
+		http.Error(w, "Erro ao buscar bloqueios da agenda", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar bloqueios para disponibilidade: %v", err)
+		return
+	}
+
+	response := disponibilidadePeriodoResponse{
+		DataInicio:     dataInicio.Format("2006-01-02"),
+		DataFim:        dataInicio.AddDate(0, 0, dias-1).Format("2006-01-02"),
+		DuracaoMinutos: duracaoMinutos,
+		Campos:         make([]disponibilidadeCampoResponse, 0, len(infos)),
+	}
+	for _, info := range infos {
+		campo := disponibilidadeCampoResponse{
+			IDCampo:           info.IDCampo,
+			NomeCampo:         info.NomeCampo,
+			IDArena:           info.IDArena,
+			NomeArena:         info.NomeArena,
+			ValorHora:         info.ValorHora,
+			EmManutencao:      info.CampoEmManutencao,
+			ArenaEmManutencao: info.ArenaEmManutencao,
+			Dias:              make([]disponibilidadeDiaResponse, 0, dias),
+		}
+		for dia := 0; dia < dias; dia++ {
+			data := dataInicio.AddDate(0, 0, dia)
+			campo.Dias = append(campo.Dias, buildDisponibilidadeDia(info, data, location, occupied[info.IDCampo], bloqueios[info.IDCampo], duracaoMinutos))
+		}
+		response.Campos = append(response.Campos, campo)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func buildDisponibilidadeDia(info campoDisponibilidadeInfo, data time.Time, location *time.Location, occupied []agendamentoIntervalo, bloqueios []agendamentoIntervalo, duracaoMinutos int) disponibilidadeDiaResponse {
+	dia := disponibilidadeDiaResponse{
+		Data:  data.Format("2006-01-02"),
+		Slots: []disponibilidadeSlotResponse{},
+	}
+
+	horarios, aberto := campoHorariosNaData(info.HorariosDisponiveis, info.HorariosSemana, data)
+	if !aberto {
+		dia.Fechado = true
+		return dia
+	}
+
+	slots := generateBookingSlots(data, location, horarios)
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Before(slots[j]) })
+
+	indisponiveis := append(append([]agendamentoIntervalo(nil), occupied...), bloqueios...)
+	disponiveis := make(map[time.Time]struct{})
+	if !info.CampoEmManutencao && !info.ArenaEmManutencao {
+		for _, slot := range availableBookingStarts(slots, indisponiveis, time.Duration(duracaoMinutos)*time.Minute) {
+			disponiveis[slot] = struct{}{}
+		}
+	}
+
+	for _, slot := range slots {
+		fim := slot.Add(agendamentoSlotDuracao)
+		item := disponibilidadeSlotResponse{
+			Horario: slot.In(location).Format("15:04"),
+			Inicio:  formatAgendamentoDateTime(slot.In(location)),
+			Estado:  slotEstadoLivre,
+		}
+
+		switch {
+		case info.CampoEmManutencao || info.ArenaEmManutencao || overlapsAnyIntervalo(bloqueios, slot, fim):
+			item.Estado = slotEstadoBloqueado
+		case overlapsAnyIntervalo(occupied, slot, fim):
+			item.Estado = slotEstadoOcupado
+		}
+
+		if _, ok := disponiveis[slot]; ok {
+			item.Disponivel = true
+			item.ValorTotal = calcularValorAgendamento(info.ValorHora, info.RegrasPreco, slot, duracaoMinutos)
+		}
+		dia.Slots = append(dia.Slots, item)
+	}
+
+	return dia
+}
+
+func parseDisponibilidadeDias(rawDias string, rawDataFim string, dataInicio time.Time, location *time.Location) (int, error) {
+	rawDias = strings.TrimSpace(rawDias)
+	rawDataFim = strings.TrimSpace(rawDataFim)
+
+	dias := disponibilidadeDiasPadrao
+	switch {
+	case rawDias != "":
+		parsed, err := strconv.Atoi(rawDias)
+		if err != nil || parsed <= 0 {
+			return 0, errors.New("dias invalido")
+		}
+		dias = parsed
+	case rawDataFim != "":
+		dataFim, err := time.ParseInLocation("2006-01-02", rawDataFim, location)
+		if err != nil {
+			return 0, errors.New("data_fim invalida. Use o formato YYYY-MM-DD")
+		}
+		if dataFim.Before(dataInicio) {
+			return 0, errors.New("data_fim deve ser igual ou posterior a data_inicio")
+		}
+		dias = int(dataFim.Sub(dataInicio).Hours()/24+0.5) + 1
+	}
+
+	if dias > disponibilidadeMaxDias {
+		return 0, fmt.Errorf("O periodo maximo de disponibilidade e de %d dias", disponibilidadeMaxDias)
+	}
+
+	return dias, nil
+}
+
+func parsePositiveIntParam(raw string) int {
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || value <= 0 {
+		return 0
+	}
+
+	return value
+}
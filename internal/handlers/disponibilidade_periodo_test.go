@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildDisponibilidadeDiaMarksSlotStates(t *testing.T) {
+	loc := agendamentoLocation()
+	data := time.Date(2026, 5, 4, 0, 0, 0, 0, loc)
+	info := campoDisponibilidadeInfo{
+		IDCampo:             1,
+		ValorHora:           100,
+		Ativo:               true,
+		HorariosDisponiveis: []string{"18:00", "19:00", "20:00", "21:00"},
+	}
+	occupied := []agendamentoIntervalo{{
+		Inicio: time.Date(2026, 5, 4, 19, 0, 0, 0, loc),
+		Fim:    time.Date(2026, 5, 4, 20, 0, 0, 0, loc),
+	}}
+	bloqueios := []agendamentoIntervalo{{
+		Inicio: time.Date(2026, 5, 4, 21, 0, 0, 0, loc),
+		Fim:    time.Date(2026, 5, 4, 23, 0, 0, 0, loc),
+	}}
+
+	dia := buildDisponibilidadeDia(info, data, loc, occupied, bloqueios, 60)
+	expected := []struct {
+		estado     string
+		disponivel bool
+	}{
+		{slotEstadoLivre, true},
+		{slotEstadoOcupado, false},
+		{slotEstadoLivre, true},
+		{slotEstadoBloqueado, false},
+	}
+	if len(dia.Slots) != len(expected) {
+		t.Fatalf("expected %d slots, got %+v", len(expected), dia.Slots)
+	}
+	for index, want := range expected {
+		got := dia.Slots[index]
+		if got.Estado != want.estado || got.Disponivel != want.disponivel {
+			t.Fatalf("slot %s: expected %s/%v, got %s/%v", got.Horario, want.estado, want.disponivel, got.Estado, got.Disponivel)
+		}
+	}
+	if dia.Slots[0].ValorTotal != 100 {
+		t.Fatalf("expected valor_total on free slot, got %v", dia.Slots[0].ValorTotal)
+	}
+
+	// 20:00 is free but a 2h game would run into the bloqueio.
+	dia = buildDisponibilidadeDia(info, data, loc, occupied, bloqueios, 120)
+	if dia.Slots[2].Estado != slotEstadoLivre || dia.Slots[2].Disponivel {
+		t.Fatalf("expected 20:00 free but not bookable for 2h, got %+v", dia.Slots[2])
+	}
+
+	info.HorariosSemana = map[int][]string{1: {}}
+	if dia := buildDisponibilidadeDia(info, data, loc, nil, nil, 60); !dia.Fechado || len(dia.Slots) != 0 {
+		t.Fatalf("expected closed day, got %+v", dia)
+	}
+}
+
+func TestParseDisponibilidadeDiasLimitsRange(t *testing.T) {
+	loc := agendamentoLocation()
+	inicio := time.Date(2026, 5, 4, 0, 0, 0, 0, loc)
+
+	if dias, err := parseDisponibilidadeDias("", "", inicio, loc); err != nil || dias != disponibilidadeDiasPadrao {
+		t.Fatalf("expected default of %d days, got %d (%v)", disponibilidadeDiasPadrao, dias, err)
+	}
+	if dias, err := parseDisponibilidadeDias("", "2026-05-10", inicio, loc); err != nil || dias != 7 {
+		t.Fatalf("expected inclusive range of 7 days, got %d (%v)", dias, err)
+	}
+	if _, err := parseDisponibilidadeDias("15", "", inicio, loc); err == nil {
+		t.Fatal("expected error above the maximum range")
+	}
+	if _, err := parseDisponibilidadeDias("", "2026-05-01", inicio, loc); err == nil {
+		t.Fatal("expected error when data_fim is before data_inicio")
+	}
+}
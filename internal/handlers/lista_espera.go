@@ -0,0 +1,588 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	listaEsperaIntervaloPadrao = time.Minute
+	listaEsperaLote            = 50
+	listaEsperaMotivo          = "lista_espera"
+)
+
+var (
+	errListaEsperaHorarioLivre  = errors.New("horario disponivel")
+	errListaEsperaDuplicada     = errors.New("jogador ja esta na lista de espera")
+	errListaEsperaNaoEncontrada = errors.New("entrada da lista de espera nao encontrada")
+)
+
+type listaEsperaResponse struct {
+	ID                int    `json:"id"`
+	IDCampo           int    `json:"id_campo"`
+	IDArena           int    `json:"id_arena,omitempty"`
+	NomeCampo         string `json:"nome_campo,omitempty"`
+	Horario           string `json:"horario"`
+	DuracaoMinutos    int    `json:"duracao_minutos"`
+	Jogadores         int    `json:"jogadores"`
+	NomeSolicitante   string `json:"nome_solicitante,omitempty"`
+	OrigemAgendamento string `json:"origem_agendamento"`
+	IDUsuarioJogador  *int   `json:"id_usuario_jogador,omitempty"`
+	Status            string `json:"status"`
+	IDAgendamento     *int   `json:"id_agendamento,omitempty"`
+	CriadoEm          string `json:"criado_em"`
+	AtendidaEm        string `json:"atendida_em,omitempty"`
+}
+
+const listaEsperaColumns = `
+	l.id, l.id_campo, c.id_arena, c.nome_campo, l.horario, l.duracao_minutos, l.jogadores,
+	COALESCE(l.pagamento, ''), COALESCE(l.nome_solicitante, ''), l.origem_agendamento, l.id_usuario_jogador,
+	COALESCE(l.time1, ''), COALESCE(l.time2, ''), COALESCE(l.modo_de_jogo, ''), l.status, l.id_agendamento,
+	l.criado_em, l.atendida_em`
+
+func listaEsperaSelectQuery() string {
+	return fmt.Sprintf(`
+		SELECT %s
+		FROM %s l
+		JOIN %s c ON c.id_campo = l.id_campo
+	`, listaEsperaColumns, listaEsperaTableName(), campoTableName())
+}
+
+func scanListaEsperaEntrada(scanner agendamentoScanner) (models.ListaEsperaEntrada, error) {
+	var (
+		entrada          models.ListaEsperaEntrada
+		origem           string
+		idUsuarioJogador sql.NullInt64
+		status           string
+		idAgendamento    sql.NullInt64
+		atendidaEm       sql.NullTime
+	)
+
+	err := scanner.Scan(
+		&entrada.ID,
+		&entrada.IDCampo,
+		&entrada.IDArena,
+		&entrada.NomeCampo,
+		&entrada.Horario,
+		&entrada.DuracaoMinutos,
+		&entrada.Jogadores,
+		&entrada.Pagamento,
+		&entrada.NomeSolicitante,
+		&origem,
+		&idUsuarioJogador,
+		&entrada.Time1,
+		&entrada.Time2,
+		&entrada.ModoDeJogo,
+		&status,
+		&idAgendamento,
+		&entrada.CriadoEm,
+		&atendidaEm,
+	)
+	if err != nil {
+		return models.ListaEsperaEntrada{}, err
+	}
+
+	entrada.OrigemAgendamento = models.AgendamentoOrigem(origem)
+	entrada.Status = models.ListaEsperaStatus(status)
+	entrada.IDUsuarioJogador = nullIntPointer(idUsuarioJogador)
+	entrada.IDAgendamento = nullIntPointer(idAgendamento)
+	if atendidaEm.Valid {
+		entrada.AtendidaEm = &atendidaEm.Time
+	}
+
+	return entrada, nil
+}
+
+func scanListaEsperaEntradas(rows *sql.Rows) ([]models.ListaEsperaEntrada, error) {
+	defer rows.Close()
+
+	entradas := make([]models.ListaEsperaEntrada, 0)
+	for rows.Next() {
+		entrada, err := scanListaEsperaEntrada(rows)
+		if err != nil {
+			return nil, err
+		}
+		entradas = append(entradas, entrada)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entradas, nil
+}
+
+func (repository agendamentoRepository) insertListaEspera(ctx context.Context, input models.CreateAgendamentoInput) (int, error) {
+	var id int
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (
+			id_campo,
+			horario,
+			duracao_minutos,
+			jogadores,
+			pagamento,
+			nome_solicitante,
+			origem_agendamento,
+			id_usuario_jogador,
+			time1,
+			time2,
+			modo_de_jogo
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''))
+		RETURNING id
+	`, listaEsperaTableName()),
+		input.IDCampo,
+		input.Horario,
+		input.DuracaoMinutos,
+		input.Jogadores,
+		sanitizePagamento(input.Pagamento),
+		input.NomeSolicitante,
+		string(input.OrigemAgendamento),
+		nullableIntValue(input.IDUsuarioJogador),
+		input.Time1,
+		input.Time2,
+		input.ModoDeJogo,
+	).Scan(&id)
+	return id, err
+}
+
+func (repository agendamentoRepository) getListaEspera(ctx context.Context, id int) (models.ListaEsperaEntrada, error) {
+	return scanListaEsperaEntrada(repository.database().QueryRowContext(ctx, listaEsperaSelectQuery()+`
+		WHERE l.id = $1
+	`, id))
+}
+
+func (repository agendamentoRepository) hasListaEsperaJogador(ctx context.Context, input models.CreateAgendamentoInput) (bool, error) {
+	if input.IDUsuarioJogador == nil {
+		return false, nil
+	}
+
+	var exists bool
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1 FROM %s
+			WHERE id_campo = $1 AND horario = $2 AND id_usuario_jogador = $3 AND status = $4
+		)
+	`, listaEsperaTableName()), input.IDCampo, input.Horario, *input.IDUsuarioJogador, string(models.ListaEsperaAguardando)).Scan(&exists)
+	return exists, err
+}
+
+// listaEsperaPosicao counts the waiting entries for the same slot created up
+// to this one, so the first in line is 1.
+func (repository agendamentoRepository) listaEsperaPosicao(ctx context.Context, entrada models.ListaEsperaEntrada) (int, error) {
+	var posicao int
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM %s
+		WHERE id_campo = $1 AND horario = $2 AND status = $3
+		  AND (criado_em, id) <= ($4, $5)
+	`, listaEsperaTableName()), entrada.IDCampo, entrada.Horario, string(models.ListaEsperaAguardando), entrada.CriadoEm, entrada.ID).Scan(&posicao)
+	return posicao, err
+}
+
+func (repository agendamentoRepository) listListaEsperaByOwner(ctx context.Context, ownerUserID int, campoID *int) ([]models.ListaEsperaEntrada, error) {
+	query := listaEsperaSelectQuery() + fmt.Sprintf(`
+		JOIN %s ar ON ar.id = c.id_arena
+		WHERE ar.id_usuario = $1
+	`, arenasTableName())
+	args := []any{ownerUserID}
+	if campoID != nil {
+		args = append(args, *campoID)
+		query += fmt.Sprintf(" AND l.id_campo = $%d", len(args))
+	}
+	query += " ORDER BY l.horario ASC, l.criado_em ASC, l.id ASC"
+
+	rows, err := repository.database().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanListaEsperaEntradas(rows)
+}
+
+// listListaEsperaLiberadas returns, for each future slot with a queue, the
+// first entry in line when nothing else occupies the slot anymore. Like
+// validateCampoAndSchedule, a slot held by a pending remarcacao or covered by
+// a bloqueio is not free, so its queue keeps waiting without taking the batch.
+func (repository agendamentoRepository) listListaEsperaLiberadas(ctx context.Context, agora time.Time, limite int) ([]models.ListaEsperaEntrada, error) {
+	rows, err := repository.database().QueryContext(ctx, fmt.Sprintf(`
+		SELECT DISTINCT ON (l.id_campo, l.horario) %s
+		FROM %s l
+		JOIN %s c ON c.id_campo = l.id_campo
+		WHERE l.status = $1
+		  AND l.horario > $2
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM %s a
+			WHERE a.id_campo = l.id_campo
+			  AND a.status != $3
+			  AND a.horario < l.horario + l.duracao_minutos * INTERVAL '1 minute'
+			  AND a.horario + COALESCE(a.duracao_minutos, %d) * INTERVAL '1 minute' > l.horario
+		  )
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM %s r
+			JOIN %s ag ON ag.id_agendamento = r.id_agendamento
+			WHERE r.id_campo = l.id_campo
+			  AND r.status = '%s'
+			  AND ag.status IN ('%s', '%s')
+			  AND r.horario < l.horario + l.duracao_minutos * INTERVAL '1 minute'
+			  AND r.horario + r.duracao_minutos * INTERVAL '1 minute' > l.horario
+		  )
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM %s b
+			WHERE (b.id_campo = l.id_campo OR (b.id_campo IS NULL AND b.id_arena = c.id_arena))
+			  AND b.inicio < l.horario + l.duracao_minutos * INTERVAL '1 minute'
+			  AND b.fim > l.horario
+		  )
+		ORDER BY l.id_campo, l.horario, l.criado_em, l.id
+		LIMIT $4
+	`, listaEsperaColumns, listaEsperaTableName(), campoTableName(), agendamentosTableName(), models.AgendamentoDuracaoPadraoMinutos,
+		agendamentoRemarcacoesTableName(), agendamentosTableName(),
+		models.AgendamentoRemarcacaoPendente, models.AgendamentoStatusPedido, models.AgendamentoStatusAgendado,
+		bloqueiosAgendaTableName()),
+		string(models.ListaEsperaAguardando),
+		agora,
+		string(models.AgendamentoStatusCancelado),
+		limite,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanListaEsperaEntradas(rows)
+}
+
+func (repository agendamentoRepository) lockListaEsperaStatus(ctx context.Context, id int) (models.ListaEsperaStatus, error) {
+	var status string
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT status FROM %s WHERE id = $1 FOR UPDATE
+	`, listaEsperaTableName()), id).Scan(&status)
+	return models.ListaEsperaStatus(status), err
+}
+
+func (repository agendamentoRepository) updateListaEsperaStatus(ctx context.Context, id int, status models.ListaEsperaStatus, agendamentoID *int) (bool, error) {
+	result, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2,
+			id_agendamento = COALESCE($3, id_agendamento),
+			atendida_em = CASE WHEN $2 = '%s' THEN NOW() ELSE atendida_em END
+		WHERE id = $1 AND status = $4
+	`, listaEsperaTableName(), models.ListaEsperaAtendida), id, string(status), nullableIntValue(agendamentoID), string(models.ListaEsperaAguardando))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (repository agendamentoRepository) expirarListaEspera(ctx context.Context, agora time.Time) (int64, error) {
+	result, err := repository.database().ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s SET status = $1 WHERE status = $2 AND horario <= $3
+	`, listaEsperaTableName()), string(models.ListaEsperaExpirada), string(models.ListaEsperaAguardando), agora)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// EntrarListaEspera only queues the jogador when the slot is really taken; a
+// free slot should be requested directly.
+func (service agendamentoService) EntrarListaEspera(ctx context.Context, input models.CreateAgendamentoInput) (models.ListaEsperaEntrada, int, error) {
+	switch input.OrigemAgendamento {
+	case models.AgendamentoOrigemJogador, models.AgendamentoOrigemTimeXTime:
+	default:
+		return models.ListaEsperaEntrada{}, 0, errAgendamentoOrigemInvalida
+	}
+
+	input.IDUsuario = nil
+	input.DuracaoMinutos = models.NormalizeAgendamentoDuracao(input.DuracaoMinutos)
+	input, err := resolveNomeSolicitanteFromJogador(ctx, input, service.repository.loadJogadorNomeByID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ListaEsperaEntrada{}, 0, errAgendamentoJogadorNaoEncontrado
+		}
+		return models.ListaEsperaEntrada{}, 0, err
+	}
+
+	var (
+		entrada models.ListaEsperaEntrada
+		posicao int
+	)
+	err = service.inTransaction(ctx, func(service agendamentoService) error {
+		_, err := service.validateCampoAndSchedule(ctx, input, 0, nil)
+		switch {
+		case err == nil:
+			return errListaEsperaHorarioLivre
+		case !errors.Is(err, errAgendamentoHorarioIndisponivel):
+			return err
+		}
+
+		duplicada, err := service.repository.hasListaEsperaJogador(ctx, input)
+		if err != nil {
+			return err
+		}
+		if duplicada {
+			return errListaEsperaDuplicada
+		}
+
+		id, err := service.repository.insertListaEspera(ctx, input)
+		if err != nil {
+			return err
+		}
+		if entrada, err = service.repository.getListaEspera(ctx, id); err != nil {
+			return err
+		}
+
+		posicao, err = service.repository.listaEsperaPosicao(ctx, entrada)
+		return err
+	})
+	if err != nil {
+		return models.ListaEsperaEntrada{}, 0, err
+	}
+
+	return entrada, posicao, nil
+}
+
+func (service agendamentoService) SairListaEspera(ctx context.Context, id int) error {
+	removida, err := service.repository.updateListaEsperaStatus(ctx, id, models.ListaEsperaCancelada, nil)
+	if err != nil {
+		return err
+	}
+	if !removida {
+		return errListaEsperaNaoEncontrada
+	}
+
+	return nil
+}
+
+func (service agendamentoService) ListListaEspera(ctx context.Context, ownerUserID int, campoID *int) ([]models.ListaEsperaEntrada, error) {
+	return service.repository.listListaEsperaByOwner(ctx, ownerUserID, campoID)
+}
+
+// AtenderListaEspera turns the first entry of every freed slot into a pedido,
+// whatever freed it: a cancellation, an expired pedido or a rescheduled game.
+// Entries that can never become a pedido are dropped so the next in line gets
+// its turn; past slots expire.
+func (service agendamentoService) AtenderListaEspera(ctx context.Context, agora time.Time) (int, error) {
+	if _, err := service.repository.expirarListaEspera(ctx, agora); err != nil {
+		return 0, err
+	}
+
+	entradas, err := service.repository.listListaEsperaLiberadas(ctx, agora, listaEsperaLote)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = withAgendamentoAtor(ctx, agendamentoAtor{
+		Tipo:   models.AgendamentoAtorSistema,
+		Origem: agendamentoOrigemEventoListaEspera,
+		Motivo: listaEsperaMotivo,
+	})
+
+	atendidas := 0
+	for _, entrada := range entradas {
+		atendida := false
+		err := service.inTransaction(ctx, func(service agendamentoService) error {
+			status, err := service.repository.lockListaEsperaStatus(ctx, entrada.ID)
+			if err != nil || status != models.ListaEsperaAguardando {
+				return err
+			}
+
+			pedido, err := service.CreatePedidoExterno(ctx, entrada.AgendamentoInput())
+			if err != nil {
+				return err
+			}
+			if _, err := service.repository.updateListaEsperaStatus(ctx, entrada.ID, models.ListaEsperaAtendida, &pedido.ID); err != nil {
+				return err
+			}
+
+			atendida = true
+			return service.enqueueJogadorListaEspera(ctx, pedido, entrada.ID)
+		})
+		if err == nil {
+			if atendida {
+				atendidas++
+			}
+			continue
+		}
+
+		if !listaEsperaErroDefinitivo(err) {
+			log.Printf("Erro ao atender lista de espera %d: %v", entrada.ID, err)
+			continue
+		}
+		log.Printf("Entrada %d da lista de espera descartada: %v", entrada.ID, err)
+		if _, err := service.repository.updateListaEsperaStatus(ctx, entrada.ID, models.ListaEsperaCancelada, nil); err != nil {
+			log.Printf("Erro ao descartar entrada %d da lista de espera: %v", entrada.ID, err)
+		}
+	}
+
+	return atendidas, nil
+}
+
+// enqueueJogadorListaEspera tells the jogador backend about the pedido it
+// did not create, with the waitlist entry it came from.
+func (service agendamentoService) enqueueJogadorListaEspera(ctx context.Context, pedido models.Agendamento, entradaID int) error {
+	if !service.notifier.enabled() {
+		return nil
+	}
+
+	payload := newJogadorStatusCallbackPayload(pedido)
+	payload.IDListaEspera = &entradaID
+	_, err := service.repository.enqueueNotificacao(ctx, pedido, notificacaoOutboxInput{
+		Tipo:    notificacaoTipoJogadorStatus,
+		Destino: service.notifier.callbackURL,
+		Payload: payload,
+	})
+	return err
+}
+
+// listaEsperaErroDefinitivo tells apart the entries that will never fit the
+// slot from the ones that only have to wait, e.g. for a bloqueio to end.
+func listaEsperaErroDefinitivo(err error) bool {
+	switch {
+	case errors.Is(err, errAgendamentoCampoNaoEncontrado),
+		errors.Is(err, errAgendamentoJogadorNaoEncontrado),
+		errors.Is(err, errAgendamentoJogadoresInvalidos),
+		errors.Is(err, errAgendamentoDuracaoInvalida),
+		errors.Is(err, errAgendamentoForaDoHorario),
+		errors.Is(err, errAgendamentoOrigemInvalida):
+		return true
+	default:
+		return false
+	}
+}
+
+// StartListaEsperaWorker serves the waitlists until ctx is done. It is meant
+// to run in its own goroutine.
+func StartListaEsperaWorker(ctx context.Context) {
+	service := newAgendamentoService()
+	intervalo := time.Duration(envPositiveInt("LISTA_ESPERA_INTERVALO_SEGUNDOS", int(listaEsperaIntervaloPadrao/time.Second))) * time.Second
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		atendidas, err := service.AtenderListaEspera(ctx, agendamentoNow())
+		if err != nil {
+			log.Printf("Erro ao processar lista de espera: %v", err)
+		} else if atendidas > 0 {
+			log.Printf("%d pedido(s) criado(s) a partir da lista de espera", atendidas)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func EntrarListaEsperaJogador(w http.ResponseWriter, r *http.Request) {
+	if err := validateJogadorIntegrationRequest(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	input, err := parseAgendamentoCreateRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.OrigemAgendamento == "" {
+		input.OrigemAgendamento = models.AgendamentoOrigemJogador
+	}
+
+	service := newAgendamentoService()
+	entrada, posicao, err := service.EntrarListaEspera(r.Context(), input)
+	if err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"message":      "Jogador adicionado a lista de espera",
+		"lista_espera": newListaEsperaResponse(entrada),
+		"posicao":      posicao,
+	})
+}
+
+func SairListaEsperaJogador(w http.ResponseWriter, r *http.Request) {
+	if err := validateJogadorIntegrationRequest(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	entradaID := parsePositiveIntParam(mux.Vars(r)["id"])
+	if entradaID == 0 {
+		http.Error(w, "ID da lista de espera invalido", http.StatusBadRequest)
+		return
+	}
+
+	service := newAgendamentoService()
+	if err := service.SairListaEspera(r.Context(), entradaID); err != nil {
+		writeAgendamentoServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message": "Jogador removido da lista de espera",
+	})
+}
+
+func GetListaEspera(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	service := newAgendamentoService()
+	entradas, err := service.ListListaEspera(r.Context(), userID, optionalPositiveIntFromQuery(r, "id_campo"))
+	if err != nil {
+		http.Error(w, "Erro ao buscar lista de espera", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar lista de espera do usuario %d: %v", userID, err)
+		return
+	}
+
+	response := make([]listaEsperaResponse, 0, len(entradas))
+	for _, entrada := range entradas {
+		response = append(response, newListaEsperaResponse(entrada))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func newListaEsperaResponse(entrada models.ListaEsperaEntrada) listaEsperaResponse {
+	response := listaEsperaResponse{
+		ID:                entrada.ID,
+		IDCampo:           entrada.IDCampo,
+		IDArena:           entrada.IDArena,
+		NomeCampo:         entrada.NomeCampo,
+		Horario:           formatAgendamentoDateTime(entrada.Horario),
+		DuracaoMinutos:    entrada.DuracaoMinutos,
+		Jogadores:         entrada.Jogadores,
+		NomeSolicitante:   entrada.NomeSolicitante,
+		OrigemAgendamento: string(entrada.OrigemAgendamento),
+		IDUsuarioJogador:  entrada.IDUsuarioJogador,
+		Status:            string(entrada.Status),
+		IDAgendamento:     entrada.IDAgendamento,
+		CriadoEm:          formatAgendamentoDateTime(entrada.CriadoEm),
+	}
+	if entrada.AtendidaEm != nil {
+		response.AtendidaEm = formatAgendamentoDateTime(*entrada.AtendidaEm)
+	}
+
+	return response
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestListaEsperaErroDefinitivo(t *testing.T) {
+	if !listaEsperaErroDefinitivo(fmt.Errorf("criar pedido: %w", errAgendamentoJogadorNaoEncontrado)) {
+		t.Fatalf("expected a missing jogador to drop the entry")
+	}
+	for _, err := range []error{errAgendamentoHorarioIndisponivel, errAgendamentoHorarioBloqueado, errAgendamentoCampoIndisponivel} {
+		if listaEsperaErroDefinitivo(err) {
+			t.Fatalf("expected %v to keep the entry waiting", err)
+		}
+	}
+}
+
+func TestListaEsperaEntradaAgendamentoInputKeepsRequest(t *testing.T) {
+	jogador := 7
+	entrada := models.ListaEsperaEntrada{
+		IDCampo:           3,
+		Horario:           time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC),
+		DuracaoMinutos:    90,
+		Jogadores:         10,
+		OrigemAgendamento: models.AgendamentoOrigemJogador,
+		IDUsuarioJogador:  &jogador,
+	}
+
+	input := entrada.AgendamentoInput()
+	if input.IDCampo != 3 || input.DuracaoMinutos != 90 || input.IDUsuarioJogador == nil || *input.IDUsuarioJogador != 7 {
+		t.Fatalf("expected the entry request to be kept, got %+v", input)
+	}
+	if input.IDUsuario != nil {
+		t.Fatalf("expected no owner on a waitlist pedido")
+	}
+}
+
+// TestListListaEsperaLiberadasSkipsHeldAndBlockedSlots needs a disposable
+// Postgres database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestListListaEsperaLiberadasSkipsHeldAndBlockedSlots(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	// Slot 1 is covered by a bloqueio, slot 2 is held by a pending
+	// remarcacao and slot 3 is free.
+	statements := []string{
+		fmt.Sprintf(`INSERT INTO %s.agendamentos (id_campo, horario, duracao_minutos, jogadores, status)
+			VALUES (1, NOW() + INTERVAL '1 day', 60, 10, 'agendado')`, schema),
+		fmt.Sprintf(`INSERT INTO %s.agendamento_remarcacoes (id_agendamento, id_campo_anterior, horario_anterior, duracao_minutos_anterior, id_campo, horario, duracao_minutos)
+			VALUES (1, 1, NOW() + INTERVAL '1 day', 60, 1, NOW() + INTERVAL '2 days', 60)`, schema),
+		fmt.Sprintf(`INSERT INTO %s.bloqueios_agenda (id_arena, inicio, fim, motivo)
+			VALUES (1, NOW() + INTERVAL '3 days' - INTERVAL '30 minutes', NOW() + INTERVAL '3 days' + INTERVAL '30 minutes', 'manutencao')`, schema),
+		fmt.Sprintf(`INSERT INTO %s.lista_espera (id_campo, horario, duracao_minutos, jogadores, origem_agendamento)
+			VALUES
+				(1, NOW() + INTERVAL '3 days', 60, 10, 'jogador'),
+				(1, NOW() + INTERVAL '2 days', 60, 10, 'jogador'),
+				(1, NOW() + INTERVAL '4 days', 60, 10, 'jogador')`, schema),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("failed to prepare lista de espera: %v", err)
+		}
+	}
+
+	entradas, err := newAgendamentoRepository().listListaEsperaLiberadas(ctx, time.Now(), listaEsperaLote)
+	if err != nil {
+		t.Fatalf("failed to list freed slots: %v", err)
+	}
+	if len(entradas) != 1 || entradas[0].ID != 3 {
+		t.Fatalf("expected only the free slot to be served, got %+v", entradas)
+	}
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+const (
+	notificacaoTipoJogadorStatus = "jogador_status"
+	notificacaoTipoWebhook       = "webhook"
+
+	notificacaoOutboxIntervaloPadrao     = 10 * time.Second
+	notificacaoOutboxLote                = 20
+	notificacaoOutboxMaxTentativasPadrao = 8
+	notificacaoOutboxBackoffBase         = 30 * time.Second
+	notificacaoOutboxBackoffMax          = time.Hour
+	// A claimed row is hidden from other workers for this long; if the
+	// process dies mid-delivery it becomes due again afterwards.
+	notificacaoOutboxLease = 2 * time.Minute
+)
+
+const notificacaoEntregaColumns = `
+	n.id, n.id_agendamento, n.id_arena, n.tipo, COALESCE(n.evento, ''), n.id_webhook, n.destino, n.payload, n.status,
+	n.tentativas, n.proxima_tentativa, COALESCE(n.ultimo_erro, ''), n.criado_em, n.entregue_em`
+
+type notificacaoOutboxInput struct {
+	Tipo      string
+	Evento    string
+	IDWebhook *int
+	Destino   string
+	Payload   any
+}
+
+func (repository agendamentoRepository) enqueueNotificacao(ctx context.Context, agendamento models.Agendamento, input notificacaoOutboxInput) (int64, error) {
+	body, err := json.Marshal(input.Payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id_agendamento, id_arena, tipo, evento, id_webhook, destino, payload)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7::jsonb)
+		RETURNING id
+	`, notificacoesOutboxTableName()),
+		agendamento.ID,
+		agendamento.IDArena,
+		input.Tipo,
+		input.Evento,
+		nullableIntValue(input.IDWebhook),
+		input.Destino,
+		string(body),
+	).Scan(&id)
+	return id, err
+}
+
+// enqueueJogadorStatusChange records the callback for the jogador backend. It
+// must run in the same transaction as the status update so neither can be
+// lost without the other.
+func (service agendamentoService) enqueueJogadorStatusChange(ctx context.Context, agendamento models.Agendamento) (jogadorNotificationResult, error) {
+	result := jogadorNotificationResult{
+		CallbackURL: service.notifier.callbackURL,
+		Payload:     newJogadorStatusCallbackPayload(agendamento),
+	}
+	if !service.notifier.enabled() {
+		return result, nil
+	}
+
+	id, err := service.repository.enqueueNotificacao(ctx, agendamento, notificacaoOutboxInput{
+		Tipo:    notificacaoTipoJogadorStatus,
+		Destino: service.notifier.callbackURL,
+		Payload: result.Payload,
+	})
+	if err != nil {
+		return jogadorNotificationResult{}, err
+	}
+
+	result.Enfileirada = true
+	result.IDEntrega = id
+	return result, nil
+}
+
+func notificacaoEntregaSelectQuery() string {
+	return fmt.Sprintf(`
+		SELECT %s
+		FROM %s n
+		JOIN %s ar ON ar.id = n.id_arena
+		LEFT JOIN %s w ON w.id = n.id_webhook
+	`, notificacaoEntregaColumns, notificacoesOutboxTableName(), arenasTableName(), webhookAssinaturasTableName())
+}
+
+// notificacaoEntregaDoUsuario scopes notificacaoEntregaSelectQuery to the
+// owner in $1: deliveries of their arenas, except webhooks subscribed by an
+// integrator, which the owner does not manage.
+const notificacaoEntregaDoUsuario = `ar.id_usuario = $1 AND (n.id_webhook IS NULL OR w.id_usuario = $1)`
+
+func scanNotificacaoEntrega(scanner agendamentoScanner) (models.NotificacaoEntrega, error) {
+	var (
+		entrega    models.NotificacaoEntrega
+		idWebhook  sql.NullInt64
+		payload    []byte
+		statusRaw  string
+		entregueEm sql.NullTime
+	)
+
+	err := scanner.Scan(
+		&entrega.ID,
+		&entrega.IDAgendamento,
+		&entrega.IDArena,
+		&entrega.Tipo,
+		&entrega.Evento,
+		&idWebhook,
+		&entrega.Destino,
+		&payload,
+		&statusRaw,
+		&entrega.Tentativas,
+		&entrega.ProximaTentativa,
+		&entrega.UltimoErro,
+		&entrega.CriadoEm,
+		&entregueEm,
+	)
+	if err != nil {
+		return models.NotificacaoEntrega{}, err
+	}
+
+	entrega.Payload = json.RawMessage(payload)
+	if idWebhook.Valid {
+		value := int(idWebhook.Int64)
+		entrega.IDWebhook = &value
+	}
+	if status, ok := models.NormalizeNotificacaoEntregaStatus(statusRaw); ok {
+		entrega.Status = status
+	}
+	if entregueEm.Valid {
+		value := entregueEm.Time
+		entrega.EntregueEm = &value
+	}
+
+	return entrega, nil
+}
+
+// claimNotificacoesPendentes pushes the due rows forward by the lease and
+// returns them, so concurrent workers never pick the same delivery.
+func claimNotificacoesPendentes(ctx context.Context, db agendamentoDB, limite int) ([]models.NotificacaoEntrega, error) {
+	table := notificacoesOutboxTableName()
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		WITH devidas AS (
+			SELECT id
+			FROM %s
+			WHERE status = '%s'
+			  AND proxima_tentativa <= NOW()
+			ORDER BY proxima_tentativa ASC, id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE %s n
+		SET proxima_tentativa = NOW() + make_interval(secs => $2)
+		FROM devidas
+		WHERE n.id = devidas.id
+		RETURNING %s
+	`, table, models.NotificacaoEntregaPendente, table, notificacaoEntregaColumns), limite, notificacaoOutboxLease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entregas := make([]models.NotificacaoEntrega, 0)
+	for rows.Next() {
+		entrega, scanErr := scanNotificacaoEntrega(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		entregas = append(entregas, entrega)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entregas, nil
+}
+
+func markNotificacaoEntregue(ctx context.Context, db agendamentoDB, id int64) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, tentativas = tentativas + 1, entregue_em = NOW(), ultimo_erro = NULL
+		WHERE id = $1
+	`, notificacoesOutboxTableName()), id, string(models.NotificacaoEntregaEntregue))
+	return err
+}
+
+func markNotificacaoFalha(ctx context.Context, db agendamentoDB, entrega models.NotificacaoEntrega, maxTentativas int, causa error) error {
+	tentativas := entrega.Tentativas + 1
+	status, espera := resolveNotificacaoRetry(tentativas, maxTentativas)
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, tentativas = $3, ultimo_erro = $4, proxima_tentativa = NOW() + make_interval(secs => $5)
+		WHERE id = $1
+	`, notificacoesOutboxTableName()), entrega.ID, string(status), tentativas, causa.Error(), espera.Seconds())
+	return err
+}
+
+// resolveNotificacaoRetry decides what happens after a failed attempt: the
+// row is dead-lettered once it reaches maxTentativas, otherwise it waits an
+// exponentially growing backoff.
+func resolveNotificacaoRetry(tentativas int, maxTentativas int) (models.NotificacaoEntregaStatus, time.Duration) {
+	if tentativas >= maxTentativas {
+		return models.NotificacaoEntregaFalhou, 0
+	}
+
+	return models.NotificacaoEntregaPendente, notificacaoBackoff(tentativas)
+}
+
+func notificacaoBackoff(tentativas int) time.Duration {
+	if tentativas <= 1 {
+		return notificacaoOutboxBackoffBase
+	}
+
+	espera := notificacaoOutboxBackoffBase
+	for i := 1; i < tentativas; i++ {
+		espera *= 2
+		if espera >= notificacaoOutboxBackoffMax {
+			return notificacaoOutboxBackoffMax
+		}
+	}
+
+	return espera
+}
+
+type notificacaoOutboxWorker struct {
+	notifier      jogadorStatusNotifier
+	intervalo     time.Duration
+	maxTentativas int
+}
+
+func newNotificacaoOutboxWorker() notificacaoOutboxWorker {
+	return notificacaoOutboxWorker{
+		notifier:      newJogadorStatusNotifier(),
+		intervalo:     time.Duration(envPositiveInt("NOTIFICACAO_OUTBOX_INTERVALO_SEGUNDOS", int(notificacaoOutboxIntervaloPadrao/time.Second))) * time.Second,
+		maxTentativas: envPositiveInt("NOTIFICACAO_OUTBOX_MAX_TENTATIVAS", notificacaoOutboxMaxTentativasPadrao),
+	}
+}
+
+// StartNotificacaoOutboxWorker delivers pending callbacks until ctx is done.
+// It is meant to run in its own goroutine.
+func StartNotificacaoOutboxWorker(ctx context.Context) {
+	worker := newNotificacaoOutboxWorker()
+	ticker := time.NewTicker(worker.intervalo)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means there is probably more waiting; drain it before
+		// sleeping.
+		if worker.processarLote(ctx) == notificacaoOutboxLote && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (worker notificacaoOutboxWorker) processarLote(ctx context.Context) int {
+	entregas, err := claimNotificacoesPendentes(ctx, config.DB, notificacaoOutboxLote)
+	if err != nil {
+		log.Printf("Erro ao buscar notificacoes pendentes: %v", err)
+		return 0
+	}
+
+	for _, entrega := range entregas {
+		if err := worker.entregar(ctx, entrega); err != nil {
+			log.Printf("Falha ao entregar notificacao %d (tentativa %d): %v", entrega.ID, entrega.Tentativas+1, err)
+			if markErr := markNotificacaoFalha(ctx, config.DB, entrega, worker.maxTentativas, err); markErr != nil {
+				log.Printf("Erro ao registrar falha da notificacao %d: %v", entrega.ID, markErr)
+			}
+			continue
+		}
+
+		if err := markNotificacaoEntregue(ctx, config.DB, entrega.ID); err != nil {
+			log.Printf("Erro ao marcar notificacao %d como entregue: %v", entrega.ID, err)
+		}
+	}
+
+	return len(entregas)
+}
+
+func (worker notificacaoOutboxWorker) entregar(ctx context.Context, entrega models.NotificacaoEntrega) error {
+	if entrega.IDWebhook == nil {
+		return worker.notifier.deliver(ctx, entrega.Destino, entrega.Payload)
+	}
+
+	// The secret is read at send time so a rotated or disabled subscription
+	// takes effect for deliveries already queued.
+	assinatura, err := loadWebhookAssinatura(ctx, config.DB, *entrega.IDWebhook)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar assinatura do webhook: %w", err)
+	}
+	if !assinatura.Ativo {
+		return errors.New("assinatura do webhook desativada")
+	}
+
+	return worker.notifier.deliverWebhook(ctx, entrega, assinatura.Segredo)
+}
+
+func envPositiveInt(key string, fallback int) int {
+	value, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestNotificacaoBackoffGrowsExponentiallyUpToCap(t *testing.T) {
+	expected := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, want := range expected {
+		if got := notificacaoBackoff(i + 1); got != want {
+			t.Fatalf("expected backoff %v after %d attempts, got %v", want, i+1, got)
+		}
+	}
+
+	if got := notificacaoBackoff(20); got != notificacaoOutboxBackoffMax {
+		t.Fatalf("expected backoff to be capped at %v, got %v", notificacaoOutboxBackoffMax, got)
+	}
+}
+
+func TestResolveNotificacaoRetryDeadLettersAfterMaxTentativas(t *testing.T) {
+	status, espera := resolveNotificacaoRetry(2, 3)
+	if status != models.NotificacaoEntregaPendente || espera != time.Minute {
+		t.Fatalf("expected retry in 1m, got %s in %v", status, espera)
+	}
+
+	status, _ = resolveNotificacaoRetry(3, 3)
+	if status != models.NotificacaoEntregaFalhou {
+		t.Fatalf("expected delivery to be dead-lettered, got %s", status)
+	}
+}
+
+func TestJogadorStatusNotifierDeliverReportsNon2xx(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	notifier := jogadorStatusNotifier{httpClient: server.Client(), callbackToken: "segredo"}
+	if err := notifier.deliver(context.Background(), server.URL, []byte(`{}`)); err == nil {
+		t.Fatal("expected 503 from callback to be reported as an error")
+	}
+	if authorization != "Bearer segredo" {
+		t.Fatalf("expected bearer token to be forwarded, got %q", authorization)
+	}
+}
+
+func TestEnqueueJogadorStatusChangeSkipsWhenCallbackDisabled(t *testing.T) {
+	service := agendamentoService{notifier: jogadorStatusNotifier{}}
+
+	result, err := service.enqueueJogadorStatusChange(context.Background(), models.Agendamento{
+		ID:     10,
+		Status: models.AgendamentoStatusAgendado,
+	})
+	if err != nil {
+		t.Fatalf("expected no error without callback url, got %v", err)
+	}
+	if result.Enfileirada || result.Payload.IDAgendamento != 10 {
+		t.Fatalf("expected payload without enqueue, got %+v", result)
+	}
+}
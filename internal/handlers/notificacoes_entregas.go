@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+const notificacaoEntregasLimite = 200
+
+type notificacaoEntregaResponse struct {
+	ID               int64                           `json:"id"`
+	IDAgendamento    int                             `json:"id_agendamento"`
+	IDArena          int                             `json:"id_arena"`
+	Tipo             string                          `json:"tipo"`
+	Evento           string                          `json:"evento,omitempty"`
+	IDWebhook        *int                            `json:"id_webhook,omitempty"`
+	Destino          string                          `json:"destino"`
+	Payload          json.RawMessage                 `json:"payload"`
+	Status           models.NotificacaoEntregaStatus `json:"status"`
+	Tentativas       int                             `json:"tentativas"`
+	ProximaTentativa string                          `json:"proxima_tentativa,omitempty"`
+	UltimoErro       string                          `json:"ultimo_erro,omitempty"`
+	CriadoEm         string                          `json:"criado_em"`
+	EntregueEm       string                          `json:"entregue_em,omitempty"`
+}
+
+// GetEntregasNotificacao lists the outbox rows of the owner's arenas, newest
+// first, leaving out integrator webhooks. ?status=falhou shows the
+// dead-lettered deliveries.
+func GetEntregasNotificacao(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	where := []string{notificacaoEntregaDoUsuario}
+	args := []any{userID}
+
+	if raw := strings.TrimSpace(r.URL.Query().Get("status")); raw != "" {
+		status, ok := models.NormalizeNotificacaoEntregaStatus(raw)
+		if !ok {
+			http.Error(w, "Status de entrega invalido", http.StatusBadRequest)
+			return
+		}
+		args = append(args, string(status))
+		where = append(where, fmt.Sprintf("n.status = $%d", len(args)))
+	}
+	if idAgendamento := optionalPositiveIntFromQuery(r, "id_agendamento"); idAgendamento != nil {
+		args = append(args, *idAgendamento)
+		where = append(where, fmt.Sprintf("n.id_agendamento = $%d", len(args)))
+	}
+	if idArena := optionalPositiveIntFromQuery(r, "id_arena"); idArena != nil {
+		args = append(args, *idArena)
+		where = append(where, fmt.Sprintf("n.id_arena = $%d", len(args)))
+	}
+
+	query := notificacaoEntregaSelectQuery() +
+		" WHERE " + strings.Join(where, " AND ") +
+		fmt.Sprintf(" ORDER BY n.criado_em DESC, n.id DESC LIMIT %d", notificacaoEntregasLimite)
+	rows, err := config.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		http.Error(w, "Erro ao buscar entregas de notificacao", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar entregas de notificacao do usuario %d: %v", userID, err)
+		return
+	}
+	defer rows.Close()
+
+	response := make([]notificacaoEntregaResponse, 0)
+	for rows.Next() {
+		entrega, err := scanNotificacaoEntrega(rows)
+		if err != nil {
+			http.Error(w, "Erro ao ler entregas de notificacao", http.StatusInternalServerError)
+			log.Printf("Erro ao escanear entrega de notificacao: %v", err)
+			return
+		}
+		response = append(response, newNotificacaoEntregaResponse(entrega))
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Erro ao ler entregas de notificacao", http.StatusInternalServerError)
+		log.Printf("Erro ao iterar entregas de notificacao: %v", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// ReenviarEntregaNotificacao puts a dead-lettered delivery back in the queue
+// with a fresh attempt budget; the worker picks it up on its next pass.
+func ReenviarEntregaNotificacao(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	idEntrega, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || idEntrega <= 0 {
+		http.Error(w, "ID da entrega invalido", http.StatusBadRequest)
+		return
+	}
+
+	entrega, err := scanNotificacaoEntrega(config.DB.QueryRowContext(
+		r.Context(),
+		notificacaoEntregaSelectQuery()+" WHERE "+notificacaoEntregaDoUsuario+" AND n.id = $2",
+		userID,
+		idEntrega,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Entrega de notificacao nao encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Erro ao buscar entrega de notificacao", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar entrega de notificacao %d: %v", idEntrega, err)
+		return
+	}
+
+	if entrega.Status != models.NotificacaoEntregaFalhou {
+		http.Error(w, "Apenas entregas com falha podem ser reenviadas", http.StatusConflict)
+		return
+	}
+
+	entrega, err = scanNotificacaoEntrega(config.DB.QueryRowContext(r.Context(), fmt.Sprintf(`
+		UPDATE %s n
+		SET status = $2, tentativas = 0, proxima_tentativa = NOW()
+		WHERE n.id = $1
+		RETURNING %s
+	`, notificacoesOutboxTableName(), notificacaoEntregaColumns), entrega.ID, string(models.NotificacaoEntregaPendente)))
+	if err != nil {
+		http.Error(w, "Erro ao reenfileirar entrega de notificacao", http.StatusInternalServerError)
+		log.Printf("Erro ao reenfileirar entrega de notificacao %d: %v", idEntrega, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message": "Entrega reenfileirada com sucesso",
+		"entrega": newNotificacaoEntregaResponse(entrega),
+	})
+}
+
+func newNotificacaoEntregaResponse(entrega models.NotificacaoEntrega) notificacaoEntregaResponse {
+	response := notificacaoEntregaResponse{
+		ID:            entrega.ID,
+		IDAgendamento: entrega.IDAgendamento,
+		IDArena:       entrega.IDArena,
+		Tipo:          entrega.Tipo,
+		Evento:        entrega.Evento,
+		IDWebhook:     entrega.IDWebhook,
+		Destino:       entrega.Destino,
+		Payload:       entrega.Payload,
+		Status:        entrega.Status,
+		Tentativas:    entrega.Tentativas,
+		UltimoErro:    entrega.UltimoErro,
+		CriadoEm:      formatAgendamentoDateTime(entrega.CriadoEm),
+	}
+	if entrega.Status == models.NotificacaoEntregaPendente {
+		response.ProximaTentativa = formatAgendamentoDateTime(entrega.ProximaTentativa)
+	}
+	if entrega.EntregueEm != nil {
+		response.EntregueEm = formatAgendamentoDateTime(*entrega.EntregueEm)
+	}
+
+	return response
+}
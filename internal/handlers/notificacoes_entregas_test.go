@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/gorilla/mux"
+)
+
+// TestReenviarEntregaNotificacaoSkipsIntegratorWebhooks needs a disposable
+// Postgres database, e.g. TEST_DATABASE_URL=postgres://postgres@localhost/marcaai_test.
+func TestReenviarEntregaNotificacaoSkipsIntegratorWebhooks(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL nao definido")
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("marcaai_test_%d", time.Now().UnixNano())
+	t.Setenv("DB_SCHEMA", schema)
+
+	previousDB := config.DB
+	config.DB = db
+	defer func() { config.DB = previousDB }()
+
+	ctx := context.Background()
+	setupAgendamentoTestSchema(t, db, schema)
+	defer db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+
+	statements := []string{
+		fmt.Sprintf(`INSERT INTO %s.agendamentos (id_campo, horario, jogadores, status) VALUES (1, NOW() + INTERVAL '1 day', 10, 'agendado')`, schema),
+		fmt.Sprintf(`INSERT INTO %s.webhook_assinaturas (id_usuario, url, segredo) VALUES (NULL, 'https://integrador.exemplo.com', 'segredo-integrador-1')`, schema),
+		fmt.Sprintf(`INSERT INTO %s.webhook_assinaturas (id_usuario, url, segredo) VALUES (1, 'https://dono.exemplo.com', 'segredo-do-dono-123')`, schema),
+		fmt.Sprintf(`INSERT INTO %s.notificacoes_outbox (id_agendamento, id_arena, tipo, id_webhook, destino, payload, status)
+			VALUES (1, 1, 'webhook', 1, 'https://integrador.exemplo.com', '{}', 'falhou')`, schema),
+		fmt.Sprintf(`INSERT INTO %s.notificacoes_outbox (id_agendamento, id_arena, tipo, id_webhook, destino, payload, status)
+			VALUES (1, 1, 'webhook', 2, 'https://dono.exemplo.com', '{}', 'falhou')`, schema),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("failed to prepare entregas: %v", err)
+		}
+	}
+
+	reenviar := func(idEntrega int) int {
+		request := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/notificacoes/entregas/%d/reenviar", idEntrega), nil)
+		request = request.WithContext(context.WithValue(request.Context(), middleware.UserIDKey, 1))
+		request = mux.SetURLVars(request, map[string]string{"id": fmt.Sprint(idEntrega)})
+		recorder := httptest.NewRecorder()
+		ReenviarEntregaNotificacao(recorder, request)
+		return recorder.Code
+	}
+
+	if code := reenviar(1); code != http.StatusNotFound {
+		t.Fatalf("expected the integrator delivery to be hidden from the owner, got %d", code)
+	}
+	if code := reenviar(2); code != http.StatusOK {
+		t.Fatalf("expected the owner's own webhook delivery to be replayed, got %d", code)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/notificacoes/entregas", nil)
+	request = request.WithContext(context.WithValue(request.Context(), middleware.UserIDKey, 1))
+	recorder := httptest.NewRecorder()
+	GetEntregasNotificacao(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected the list to load, got %d", recorder.Code)
+	}
+	var entregas []notificacaoEntregaResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &entregas); err != nil {
+		t.Fatalf("failed to decode entregas: %v", err)
+	}
+	if len(entregas) != 1 || entregas[0].ID != 2 {
+		t.Fatalf("expected only the owner's delivery, got %+v", entregas)
+	}
+}
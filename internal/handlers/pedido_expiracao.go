@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+const (
+	pedidoExpiracaoIntervaloPadrao = time.Minute
+	pedidoExpiracaoLote            = 50
+	pedidoExpiracaoMotivo          = "prazo_aceite_expirado"
+)
+
+// listPedidosExpirados returns pedidos whose arena deadline has passed: either
+// prazo_aceite_minutos since creation or antecedencia_aceite_minutos before
+// the game. Arenas without a policy row never expire pedidos.
+func (repository agendamentoRepository) listPedidosExpirados(ctx context.Context, agora time.Time, limite int) ([]models.Agendamento, error) {
+	rows, err := repository.database().QueryContext(ctx, agendamentoBaseSelectQuery()+fmt.Sprintf(`
+		JOIN %s p ON p.id_arena = ar.id
+		WHERE a.status = $1
+		  AND (
+			(p.prazo_aceite_minutos IS NOT NULL AND a.criado_em + p.prazo_aceite_minutos * INTERVAL '1 minute' <= $2)
+			OR (p.antecedencia_aceite_minutos IS NOT NULL AND a.horario - p.antecedencia_aceite_minutos * INTERVAL '1 minute' <= $2)
+		  )
+		ORDER BY a.criado_em ASC, a.id_agendamento ASC
+		LIMIT $3
+	`, arenaPoliticasTableName()), string(models.AgendamentoStatusPedido), agora, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pedidos := make([]models.Agendamento, 0)
+	for rows.Next() {
+		pedido, scanErr := scanAgendamento(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		pedidos = append(pedidos, pedido)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pedidos, nil
+}
+
+// lockAgendamentoStatus re-reads the status under a row lock so a job does not
+// act on an agendamento the owner changed after the job listed it.
+func (repository agendamentoRepository) lockAgendamentoStatus(ctx context.Context, agendamentoID int) (models.AgendamentoStatus, error) {
+	var status string
+	err := repository.database().QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT status FROM %s WHERE id_agendamento = $1 FOR UPDATE
+	`, agendamentosTableName()), agendamentoID).Scan(&status)
+	return models.AgendamentoStatus(status), err
+}
+
+func (service agendamentoService) PrazoAceitePedido(ctx context.Context, pedido models.Agendamento) (time.Time, bool, error) {
+	politica, err := loadArenaPolitica(ctx, service.repository.database(), pedido.IDArena)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	prazo, ok := politica.PrazoAceitePedido(pedido.CriadoEm, pedido.Horario)
+	return prazo, ok, nil
+}
+
+// ExpirarPedidos cancels the pedidos past their acceptance deadline as the
+// system. The cancellation frees the slot and, through transitionStatus,
+// queues the jogador callback and the webhooks.
+func (service agendamentoService) ExpirarPedidos(ctx context.Context, agora time.Time) (int, error) {
+	pedidos, err := service.repository.listPedidosExpirados(ctx, agora, pedidoExpiracaoLote)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = withAgendamentoAtor(ctx, newAgendamentoAtorSistema(pedidoExpiracaoMotivo))
+
+	expirados := 0
+	for _, pedido := range pedidos {
+		cancelado := false
+		err := service.inTransaction(ctx, func(service agendamentoService) error {
+			status, err := service.repository.lockAgendamentoStatus(ctx, pedido.ID)
+			if err != nil || status != models.AgendamentoStatusPedido {
+				return err
+			}
+
+			_, err = service.transitionStatus(ctx, pedido, models.AgendamentoAcaoCancelar, models.AgendamentoStatusCancelado)
+			cancelado = err == nil
+			return err
+		})
+		if err != nil {
+			log.Printf("Erro ao expirar pedido %d: %v", pedido.ID, err)
+			continue
+		}
+		if cancelado {
+			expirados++
+		}
+	}
+
+	return expirados, nil
+}
+
+// StartPedidoExpiracaoWorker cancels expired pedidos until ctx is done. It is
+// meant to run in its own goroutine.
+func StartPedidoExpiracaoWorker(ctx context.Context) {
+	service := newAgendamentoService()
+	intervalo := time.Duration(envPositiveInt("PEDIDO_EXPIRACAO_INTERVALO_SEGUNDOS", int(pedidoExpiracaoIntervaloPadrao/time.Second))) * time.Second
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		expirados, err := service.ExpirarPedidos(ctx, agendamentoNow())
+		if err != nil {
+			log.Printf("Erro ao buscar pedidos expirados: %v", err)
+		} else if expirados > 0 {
+			log.Printf("%d pedido(s) expirado(s) cancelado(s) automaticamente", expirados)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -18,10 +18,22 @@ func campoTableName() string {
 	return arenaTableName("campo")
 }
 
+func campoRegrasPrecoTableName() string {
+	return arenaTableName("campo_regras_preco")
+}
+
+func bloqueiosAgendaTableName() string {
+	return arenaTableName("bloqueios_agenda")
+}
+
 func agendamentosTableName() string {
 	return arenaTableName("agendamentos")
 }
 
+func agendamentoRecorrenciasTableName() string {
+	return arenaTableName("agendamento_recorrencias")
+}
+
 func pagamentosPorAgendamentoTableName() string {
 	return arenaTableName("pagamentos_por_agendamento")
 }
@@ -37,3 +49,59 @@ func jogadorTableName(table string) string {
 func usuarioJogadorTableName() string {
 	return jogadorTableName("usuario_jogador")
 }
+
+func notificacoesOutboxTableName() string {
+	return arenaTableName("notificacoes_outbox")
+}
+
+func webhookAssinaturasTableName() string {
+	return arenaTableName("webhook_assinaturas")
+}
+
+func agendamentoStatusEventosTableName() string {
+	return arenaTableName("agendamento_status_eventos")
+}
+
+func arenaPoliticasTableName() string {
+	return arenaTableName("arena_politicas")
+}
+
+func listaEsperaTableName() string {
+	return arenaTableName("lista_espera")
+}
+
+func agendamentoRemarcacoesTableName() string {
+	return arenaTableName("agendamento_remarcacoes")
+}
+
+func agendamentoParticipantesTableName() string {
+	return arenaTableName("agendamento_participantes")
+}
+
+func campoHoraExtraTableName() string {
+	return arenaTableName("campo_hora_extra")
+}
+
+func agendamentoCronometroPausasTableName() string {
+	return arenaTableName("agendamento_cronometro_pausas")
+}
+
+func creditoCarteirasTableName() string {
+	return arenaTableName("credito_carteiras")
+}
+
+func creditoPacotesTableName() string {
+	return arenaTableName("credito_pacotes")
+}
+
+func creditoMovimentosTableName() string {
+	return arenaTableName("credito_movimentos")
+}
+
+func cuponsTableName() string {
+	return arenaTableName("cupons")
+}
+
+func cupomUsosTableName() string {
+	return arenaTableName("cupom_usos")
+}
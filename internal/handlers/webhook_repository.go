@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+const (
+	webhookHeaderTimestamp  = "X-MarcaAi-Timestamp"
+	webhookHeaderAssinatura = "X-MarcaAi-Signature"
+	webhookHeaderEvento     = "X-MarcaAi-Event"
+	webhookHeaderEntrega    = "X-MarcaAi-Delivery"
+
+	webhookPayloadVersaoAtual = 1
+)
+
+// webhookPayloadVersoes lists the envelope versions a subscription may pin.
+// A new version gets its own builder here; old ones stay frozen.
+var webhookPayloadVersoes = map[int]func(models.WebhookEvento, models.Agendamento, time.Time) any{
+	1: newWebhookPayloadV1,
+}
+
+type webhookAgendamentoV1 struct {
+	IDAgendamento     int     `json:"id_agendamento"`
+	IDCampo           int     `json:"id_campo"`
+	IDArena           int     `json:"id_arena"`
+	Status            string  `json:"status"`
+	OrigemAgendamento string  `json:"origem_agendamento"`
+	Horario           string  `json:"horario"`
+	DuracaoMinutos    int     `json:"duracao_minutos"`
+	NomeCampo         string  `json:"nome_campo,omitempty"`
+	NomeArena         string  `json:"nome_arena,omitempty"`
+	NomeSolicitante   string  `json:"nome_solicitante,omitempty"`
+	ValorTotal        float64 `json:"valor_total"`
+	ValorRestante     float64 `json:"valor_restante"`
+	// Remarcacao is only present on the remarcacao.* events.
+	Remarcacao *agendamentoRemarcacaoResponse `json:"remarcacao,omitempty"`
+}
+
+type webhookPayloadV1 struct {
+	Versao     int                  `json:"versao"`
+	Evento     string               `json:"evento"`
+	OcorridoEm string               `json:"ocorrido_em"`
+	Dados      webhookAgendamentoV1 `json:"dados"`
+}
+
+func newWebhookPayloadV1(evento models.WebhookEvento, agendamento models.Agendamento, ocorridoEm time.Time) any {
+	payload := webhookPayloadV1{
+		Versao:     1,
+		Evento:     string(evento),
+		OcorridoEm: ocorridoEm.Format(time.RFC3339),
+		Dados: webhookAgendamentoV1{
+			IDAgendamento:     agendamento.ID,
+			IDCampo:           agendamento.IDCampo,
+			IDArena:           agendamento.IDArena,
+			Status:            string(agendamento.Status),
+			OrigemAgendamento: string(agendamento.OrigemAgendamento),
+			Horario:           agendamento.Horario.Format(time.RFC3339),
+			DuracaoMinutos:    models.NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos),
+			NomeCampo:         agendamento.NomeCampo,
+			NomeArena:         agendamento.NomeArena,
+			NomeSolicitante:   agendamento.NomeSolicitante,
+			ValorTotal:        agendamento.ValorTotal,
+			ValorRestante:     agendamento.ValorRestante,
+		},
+	}
+	if agendamento.Remarcacao != nil {
+		remarcacao := newAgendamentoRemarcacaoResponse(*agendamento.Remarcacao)
+		payload.Dados.Remarcacao = &remarcacao
+	}
+
+	return payload
+}
+
+// signWebhookPayload returns hex(HMAC-SHA256(segredo, "<timestamp>.<body>")).
+// Receivers recompute it and reject old timestamps to stop replays.
+func signWebhookPayload(segredo string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateWebhookSegredo() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(buffer), nil
+}
+
+func webhookAssinaturaSelectQuery() string {
+	return fmt.Sprintf(`
+		SELECT id, id_usuario, id_arena, url, segredo, eventos, versao_payload, ativo, criado_em
+		FROM %s
+	`, webhookAssinaturasTableName())
+}
+
+func scanWebhookAssinatura(scanner agendamentoScanner) (models.WebhookAssinatura, error) {
+	var (
+		assinatura models.WebhookAssinatura
+		idUsuario  sql.NullInt64
+		idArena    sql.NullInt64
+		eventosRaw []byte
+	)
+
+	err := scanner.Scan(
+		&assinatura.ID,
+		&idUsuario,
+		&idArena,
+		&assinatura.URL,
+		&assinatura.Segredo,
+		&eventosRaw,
+		&assinatura.VersaoPayload,
+		&assinatura.Ativo,
+		&assinatura.CriadoEm,
+	)
+	if err != nil {
+		return models.WebhookAssinatura{}, err
+	}
+
+	if idUsuario.Valid {
+		value := int(idUsuario.Int64)
+		assinatura.IDUsuario = &value
+	}
+	if idArena.Valid {
+		value := int(idArena.Int64)
+		assinatura.IDArena = &value
+	}
+	if err := json.Unmarshal(eventosRaw, &assinatura.Eventos); err != nil {
+		return models.WebhookAssinatura{}, err
+	}
+
+	return assinatura, nil
+}
+
+// listWebhooksParaEvento returns the active subscriptions that want the event
+// for this arena: the arena owner's (all arenas or this one) and integrators'.
+func (repository agendamentoRepository) listWebhooksParaEvento(ctx context.Context, evento models.WebhookEvento, arenaID int) ([]models.WebhookAssinatura, error) {
+	rows, err := repository.database().QueryContext(ctx, webhookAssinaturaSelectQuery()+fmt.Sprintf(`
+		WHERE ativo
+		  AND eventos ? $1
+		  AND (id_arena IS NULL OR id_arena = $2)
+		  AND (id_usuario IS NULL OR id_usuario = (SELECT id_usuario FROM %s WHERE id = $2))
+		ORDER BY id ASC
+	`, arenasTableName()), string(evento), arenaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assinaturas := make([]models.WebhookAssinatura, 0)
+	for rows.Next() {
+		assinatura, scanErr := scanWebhookAssinatura(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		assinaturas = append(assinaturas, assinatura)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return assinaturas, nil
+}
+
+func loadWebhookAssinatura(ctx context.Context, db agendamentoDB, id int) (models.WebhookAssinatura, error) {
+	return scanWebhookAssinatura(db.QueryRowContext(ctx, webhookAssinaturaSelectQuery()+" WHERE id = $1", id))
+}
+
+// dispatchWebhookEvento queues one signed delivery per interested
+// subscription. Like the jogador callback it runs inside the caller's
+// transaction.
+func (service agendamentoService) dispatchWebhookEvento(ctx context.Context, evento models.WebhookEvento, agendamento models.Agendamento) error {
+	assinaturas, err := service.repository.listWebhooksParaEvento(ctx, evento, agendamento.IDArena)
+	if err != nil {
+		return err
+	}
+
+	ocorridoEm := agendamentoNow()
+	for _, assinatura := range assinaturas {
+		buildPayload, ok := webhookPayloadVersoes[assinatura.VersaoPayload]
+		if !ok {
+			buildPayload = webhookPayloadVersoes[webhookPayloadVersaoAtual]
+		}
+
+		idWebhook := assinatura.ID
+		if _, err := service.repository.enqueueNotificacao(ctx, agendamento, notificacaoOutboxInput{
+			Tipo:      notificacaoTipoWebhook,
+			Evento:    string(evento),
+			IDWebhook: &idWebhook,
+			Destino:   assinatura.URL,
+			Payload:   buildPayload(evento, agendamento, ocorridoEm),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// webhookEventoParaTransicao maps a status change to the webhook event it
+// publishes, if any.
+func webhookEventoParaTransicao(anterior models.AgendamentoStatus, novo models.AgendamentoStatus) (models.WebhookEvento, bool) {
+	switch novo {
+	case models.AgendamentoStatusAgendado:
+		if anterior == models.AgendamentoStatusPedido {
+			return models.WebhookEventoPedidoAceito, true
+		}
+	case models.AgendamentoStatusCancelado:
+		return models.WebhookEventoAgendamentoCancelado, true
+	case models.AgendamentoStatusConcluido:
+		return models.WebhookEventoAgendamentoConcluido, true
+	case models.AgendamentoStatusNaoCompareceu:
+		return models.WebhookEventoNaoCompareceu, true
+	}
+
+	return "", false
+}
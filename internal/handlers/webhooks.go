@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danpi/marca_ai_backend/internal/config"
+	"github.com/danpi/marca_ai_backend/internal/middleware"
+	"github.com/danpi/marca_ai_backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+type webhookAssinaturaRequest struct {
+	URL           string         `json:"url"`
+	Eventos       []string       `json:"eventos"`
+	IDArena       agendamentoInt `json:"id_arena"`
+	VersaoPayload agendamentoInt `json:"versao_payload"`
+	Segredo       string         `json:"segredo"`
+}
+
+type webhookAssinaturaResponse struct {
+	ID            int                    `json:"id"`
+	IDArena       *int                   `json:"id_arena,omitempty"`
+	Escopo        string                 `json:"escopo"`
+	URL           string                 `json:"url"`
+	Eventos       []models.WebhookEvento `json:"eventos"`
+	VersaoPayload int                    `json:"versao_payload"`
+	Ativo         bool                   `json:"ativo"`
+	CriadoEm      string                 `json:"criado_em,omitempty"`
+	Segredo       string                 `json:"segredo,omitempty"`
+}
+
+// webhookEscopo is who owns a subscription: an arena owner, or the jogador
+// integration (IDUsuario nil) whose subscriptions cover every arena.
+type webhookEscopo struct {
+	IDUsuario *int
+}
+
+func (escopo webhookEscopo) where(args []any) (string, []any) {
+	if escopo.IDUsuario == nil {
+		return "id_usuario IS NULL", args
+	}
+
+	args = append(args, *escopo.IDUsuario)
+	return fmt.Sprintf("id_usuario = $%d", len(args)), args
+}
+
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	escopo, ok := ownerWebhookEscopo(w, r)
+	if !ok {
+		return
+	}
+
+	listWebhooks(w, r, escopo)
+}
+
+func CriarWebhook(w http.ResponseWriter, r *http.Request) {
+	escopo, ok := ownerWebhookEscopo(w, r)
+	if !ok {
+		return
+	}
+
+	criarWebhook(w, r, escopo)
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	escopo, ok := ownerWebhookEscopo(w, r)
+	if !ok {
+		return
+	}
+
+	deleteWebhook(w, r, escopo)
+}
+
+func GetWebhooksIntegracao(w http.ResponseWriter, r *http.Request) {
+	if !authorizeWebhookIntegracao(w, r) {
+		return
+	}
+
+	listWebhooks(w, r, webhookEscopo{})
+}
+
+func CriarWebhookIntegracao(w http.ResponseWriter, r *http.Request) {
+	if !authorizeWebhookIntegracao(w, r) {
+		return
+	}
+
+	criarWebhook(w, r, webhookEscopo{})
+}
+
+func DeleteWebhookIntegracao(w http.ResponseWriter, r *http.Request) {
+	if !authorizeWebhookIntegracao(w, r) {
+		return
+	}
+
+	deleteWebhook(w, r, webhookEscopo{})
+}
+
+func ownerWebhookEscopo(w http.ResponseWriter, r *http.Request) (webhookEscopo, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuario nao autenticado", http.StatusUnauthorized)
+		return webhookEscopo{}, false
+	}
+
+	return webhookEscopo{IDUsuario: &userID}, true
+}
+
+// authorizeWebhookIntegracao is stricter than the pedido endpoint: an
+// integrator subscription sees every arena, so an unset token is a refusal.
+func authorizeWebhookIntegracao(w http.ResponseWriter, r *http.Request) bool {
+	if strings.TrimSpace(os.Getenv("JOGADOR_INTEGRATION_TOKEN")) == "" {
+		http.Error(w, "Integracao nao configurada", http.StatusForbidden)
+		return false
+	}
+	if err := validateJogadorIntegrationRequest(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
+
+func listWebhooks(w http.ResponseWriter, r *http.Request, escopo webhookEscopo) {
+	where, args := escopo.where(nil)
+	rows, err := config.DB.QueryContext(r.Context(), webhookAssinaturaSelectQuery()+" WHERE "+where+" ORDER BY id ASC", args...)
+	if err != nil {
+		http.Error(w, "Erro ao buscar webhooks", http.StatusInternalServerError)
+		log.Printf("Erro ao buscar webhooks: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	response := make([]webhookAssinaturaResponse, 0)
+	for rows.Next() {
+		assinatura, err := scanWebhookAssinatura(rows)
+		if err != nil {
+			http.Error(w, "Erro ao ler webhooks", http.StatusInternalServerError)
+			log.Printf("Erro ao escanear webhook: %v", err)
+			return
+		}
+		response = append(response, newWebhookAssinaturaResponse(assinatura))
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Erro ao ler webhooks", http.StatusInternalServerError)
+		log.Printf("Erro ao iterar webhooks: %v", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func criarWebhook(w http.ResponseWriter, r *http.Request, escopo webhookEscopo) {
+	var request webhookAssinaturaRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "JSON invalido", http.StatusBadRequest)
+		return
+	}
+
+	assinatura, err := buildWebhookAssinatura(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	assinatura.IDUsuario = escopo.IDUsuario
+
+	if assinatura.IDArena != nil && escopo.IDUsuario != nil {
+		if err := ensureArenaBelongsToUser(*assinatura.IDArena, *escopo.IDUsuario); err != nil {
+			writeBloqueioOwnershipError(w, err)
+			return
+		}
+	}
+
+	if assinatura.Segredo == "" {
+		assinatura.Segredo, err = generateWebhookSegredo()
+		if err != nil {
+			http.Error(w, "Erro ao gerar segredo do webhook", http.StatusInternalServerError)
+			log.Printf("Erro ao gerar segredo de webhook: %v", err)
+			return
+		}
+	}
+
+	eventos, _ := json.Marshal(assinatura.Eventos)
+	err = config.DB.QueryRowContext(r.Context(), fmt.Sprintf(`
+		INSERT INTO %s (id_usuario, id_arena, url, segredo, eventos, versao_payload)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6)
+		RETURNING id, ativo, criado_em
+	`, webhookAssinaturasTableName()),
+		nullableIntValue(assinatura.IDUsuario),
+		nullableIntValue(assinatura.IDArena),
+		assinatura.URL,
+		assinatura.Segredo,
+		string(eventos),
+		assinatura.VersaoPayload,
+	).Scan(&assinatura.ID, &assinatura.Ativo, &assinatura.CriadoEm)
+	if err != nil {
+		http.Error(w, "Erro ao registrar webhook", http.StatusInternalServerError)
+		log.Printf("Erro ao registrar webhook: %v", err)
+		return
+	}
+
+	// The secret is shown only once, at creation.
+	response := newWebhookAssinaturaResponse(assinatura)
+	response.Segredo = assinatura.Segredo
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func deleteWebhook(w http.ResponseWriter, r *http.Request, escopo webhookEscopo) {
+	idWebhook, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || idWebhook <= 0 {
+		http.Error(w, "ID do webhook invalido", http.StatusBadRequest)
+		return
+	}
+
+	where, args := escopo.where([]any{idWebhook})
+	var id int
+	err = config.DB.QueryRowContext(r.Context(), fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE id = $1 AND %s
+		RETURNING id
+	`, webhookAssinaturasTableName(), where), args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Webhook nao encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Erro ao excluir webhook", http.StatusInternalServerError)
+		log.Printf("Erro ao excluir webhook %d: %v", idWebhook, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Webhook excluido com sucesso"})
+}
+
+func buildWebhookAssinatura(request webhookAssinaturaRequest) (models.WebhookAssinatura, error) {
+	assinatura := models.WebhookAssinatura{
+		URL:           strings.TrimSpace(request.URL),
+		Segredo:       strings.TrimSpace(request.Segredo),
+		VersaoPayload: int(request.VersaoPayload),
+	}
+
+	parsed, err := url.Parse(assinatura.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return models.WebhookAssinatura{}, errors.New("URL do webhook invalida")
+	}
+
+	if len(request.Eventos) == 0 {
+		return models.WebhookAssinatura{}, errors.New("Informe ao menos um evento")
+	}
+	vistos := make(map[models.WebhookEvento]struct{}, len(request.Eventos))
+	for _, raw := range request.Eventos {
+		evento, ok := models.NormalizeWebhookEvento(raw)
+		if !ok {
+			return models.WebhookAssinatura{}, fmt.Errorf("Evento de webhook invalido: %s", raw)
+		}
+		if _, repetido := vistos[evento]; repetido {
+			continue
+		}
+		vistos[evento] = struct{}{}
+		assinatura.Eventos = append(assinatura.Eventos, evento)
+	}
+
+	if assinatura.VersaoPayload == 0 {
+		assinatura.VersaoPayload = webhookPayloadVersaoAtual
+	}
+	if _, ok := webhookPayloadVersoes[assinatura.VersaoPayload]; !ok {
+		return models.WebhookAssinatura{}, errors.New("versao_payload nao suportada")
+	}
+
+	if assinatura.Segredo != "" && len(assinatura.Segredo) < 16 {
+		return models.WebhookAssinatura{}, errors.New("O segredo do webhook deve ter ao menos 16 caracteres")
+	}
+	// segredo is a VARCHAR(128).
+	if utf8.RuneCountInString(assinatura.Segredo) > 128 {
+		return models.WebhookAssinatura{}, errors.New("O segredo do webhook deve ter no maximo 128 caracteres")
+	}
+
+	if request.IDArena > 0 {
+		idArena := int(request.IDArena)
+		assinatura.IDArena = &idArena
+	}
+
+	return assinatura, nil
+}
+
+func newWebhookAssinaturaResponse(assinatura models.WebhookAssinatura) webhookAssinaturaResponse {
+	response := webhookAssinaturaResponse{
+		ID:            assinatura.ID,
+		IDArena:       assinatura.IDArena,
+		Escopo:        "arena",
+		URL:           assinatura.URL,
+		Eventos:       assinatura.Eventos,
+		VersaoPayload: assinatura.VersaoPayload,
+		Ativo:         assinatura.Ativo,
+	}
+	if assinatura.IDUsuario == nil {
+		response.Escopo = "integracao"
+	}
+	if !assinatura.CriadoEm.IsZero() {
+		response.CriadoEm = formatAgendamentoDateTime(assinatura.CriadoEm)
+	}
+
+	return response
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danpi/marca_ai_backend/internal/models"
+)
+
+func TestSignWebhookPayloadMatchesHMACOfTimestampAndBody(t *testing.T) {
+	body := []byte(`{"evento":"pedido.criado"}`)
+	mac := hmac.New(sha256.New, []byte("segredo-de-teste"))
+	mac.Write([]byte("1700000000." + string(body)))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if got := signWebhookPayload("segredo-de-teste", 1700000000, body); got != expected {
+		t.Fatalf("expected signature %s, got %s", expected, got)
+	}
+	if signWebhookPayload("segredo-de-teste", 1700000001, body) == expected {
+		t.Fatal("expected a different timestamp to change the signature")
+	}
+}
+
+func TestDeliverWebhookSendsVerifiableSignature(t *testing.T) {
+	var (
+		received  []byte
+		timestamp string
+		signature string
+		evento    string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		timestamp = r.Header.Get(webhookHeaderTimestamp)
+		signature = r.Header.Get(webhookHeaderAssinatura)
+		evento = r.Header.Get(webhookHeaderEvento)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := jogadorStatusNotifier{httpClient: server.Client(), callbackToken: "nao-deve-ir"}
+	entrega := models.NotificacaoEntrega{ID: 5, Destino: server.URL, Evento: "pedido.aceito", Payload: json.RawMessage(`{"versao":1}`)}
+	if err := notifier.deliverWebhook(context.Background(), entrega, "segredo-de-teste"); err != nil {
+		t.Fatalf("expected delivery to succeed, got %v", err)
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		t.Fatalf("expected unix timestamp header, got %q", timestamp)
+	}
+	if signature != "sha256="+signWebhookPayload("segredo-de-teste", ts, received) {
+		t.Fatalf("signature header does not match body: %q", signature)
+	}
+	if evento != "pedido.aceito" {
+		t.Fatalf("expected event header, got %q", evento)
+	}
+}
+
+func TestWebhookEventoParaTransicao(t *testing.T) {
+	cases := []struct {
+		anterior models.AgendamentoStatus
+		novo     models.AgendamentoStatus
+		evento   models.WebhookEvento
+		ok       bool
+	}{
+		{models.AgendamentoStatusPedido, models.AgendamentoStatusAgendado, models.WebhookEventoPedidoAceito, true},
+		{models.AgendamentoStatusAgendado, models.AgendamentoStatusCancelado, models.WebhookEventoAgendamentoCancelado, true},
+		{models.AgendamentoStatusAguardandoPagamento, models.AgendamentoStatusConcluido, models.WebhookEventoAgendamentoConcluido, true},
+		{models.AgendamentoStatusCancelado, models.AgendamentoStatusAgendado, "", false},
+	}
+
+	for _, tc := range cases {
+		evento, ok := webhookEventoParaTransicao(tc.anterior, tc.novo)
+		if evento != tc.evento || ok != tc.ok {
+			t.Fatalf("%s -> %s: expected (%q, %v), got (%q, %v)", tc.anterior, tc.novo, tc.evento, tc.ok, evento, ok)
+		}
+	}
+}
+
+func TestWebhookPayloadV1IsVersionedEnvelope(t *testing.T) {
+	payload := newWebhookPayloadV1(models.WebhookEventoPedidoCriado, models.Agendamento{ID: 9, IDArena: 2}, time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if decoded["versao"] != float64(1) || decoded["evento"] != "pedido.criado" {
+		t.Fatalf("unexpected envelope: %s", body)
+	}
+	if dados, ok := decoded["dados"].(map[string]any); !ok || dados["id_agendamento"] != float64(9) {
+		t.Fatalf("expected agendamento under dados: %s", body)
+	}
+}
+
+func TestBuildWebhookAssinaturaAcceptsNaoCompareceu(t *testing.T) {
+	for _, raw := range []string{"agendamento.nao_compareceu", "agendamento_nao_compareceu"} {
+		assinatura, err := buildWebhookAssinatura(webhookAssinaturaRequest{
+			URL:     "https://exemplo.com/hooks",
+			Eventos: []string{raw},
+		})
+		if err != nil {
+			t.Fatalf("expected %s to be accepted, got %v", raw, err)
+		}
+		if len(assinatura.Eventos) != 1 || assinatura.Eventos[0] != models.WebhookEventoNaoCompareceu {
+			t.Fatalf("expected the no-show event for %s, got %+v", raw, assinatura.Eventos)
+		}
+	}
+}
+
+func TestBuildWebhookAssinaturaValidatesRequest(t *testing.T) {
+	assinatura, err := buildWebhookAssinatura(webhookAssinaturaRequest{
+		URL:     "https://exemplo.com/hooks",
+		Eventos: []string{"pedido_criado", "pedido.criado", "pagamento.registrado"},
+	})
+	if err != nil {
+		t.Fatalf("expected valid subscription, got %v", err)
+	}
+	if len(assinatura.Eventos) != 2 || assinatura.VersaoPayload != webhookPayloadVersaoAtual {
+		t.Fatalf("unexpected subscription: %+v", assinatura)
+	}
+
+	invalid := []webhookAssinaturaRequest{
+		{URL: "ftp://exemplo.com", Eventos: []string{"pedido.criado"}},
+		{URL: "https://exemplo.com"},
+		{URL: "https://exemplo.com", Eventos: []string{"desconhecido"}},
+		{URL: "https://exemplo.com", Eventos: []string{"pedido.criado"}, VersaoPayload: 99},
+		{URL: "https://exemplo.com", Eventos: []string{"pedido.criado"}, Segredo: "curto"},
+		{URL: "https://exemplo.com", Eventos: []string{"pedido.criado"}, Segredo: strings.Repeat("s", 129)},
+	}
+	for _, request := range invalid {
+		if _, err := buildWebhookAssinatura(request); err == nil {
+			t.Fatalf("expected error for %+v", request)
+		}
+	}
+}
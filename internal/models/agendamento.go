@@ -22,32 +22,53 @@ const (
 	AgendamentoStatusAguardandoPagamento AgendamentoStatus = "aguardando_pagamento"
 	AgendamentoStatusCancelado           AgendamentoStatus = "cancelado"
 	AgendamentoStatusConcluido           AgendamentoStatus = "concluido"
+	AgendamentoStatusNaoCompareceu       AgendamentoStatus = "nao_compareceu"
 )
 
+const AgendamentoDuracaoPadraoMinutos = 60
+
 type Agendamento struct {
-	ID                 int               `json:"id"`
-	IDUsuario          int               `json:"id_usuario,omitempty"`
-	IDCampo            int               `json:"id_campo"`
-	IDArena            int               `json:"id_arena,omitempty"`
-	NomeSolicitante    string            `json:"nome_solicitante,omitempty"`
-	Horario            time.Time         `json:"horario"`
-	Jogadores          int               `json:"jogadores"`
-	Pagamento          string            `json:"pagamento"`
-	Pago               bool              `json:"pago"`
-	Status             AgendamentoStatus `json:"status"`
-	CriadoEm           time.Time         `json:"criado_em,omitempty"`
-	NomeCampo          string            `json:"nome_campo,omitempty"`
-	NomeArena          string            `json:"nome_arena,omitempty"`
-	OrigemAgendamento  AgendamentoOrigem `json:"origem_agendamento"`
-	ValorTotal         float64           `json:"valor_total"`
-	ValorRestante      float64           `json:"valor_restante"`
-	StatusDePagamento  bool              `json:"status_de_pagamento"`
-	InicioCronometro   *int64            `json:"inicio_cronometro,omitempty"`
-	FimCronometro      *time.Time        `json:"fim_cronometro,omitempty"`
-	Time1              string            `json:"time1,omitempty"`
-	Time2              string            `json:"time2,omitempty"`
-	ModoDeJogo         string            `json:"modo_de_jogo,omitempty"`
-	OrigemStatusEvento string            `json:"origem_status_evento,omitempty"`
+	ID                int               `json:"id"`
+	IDUsuario         int               `json:"id_usuario,omitempty"`
+	IDCampo           int               `json:"id_campo"`
+	IDArena           int               `json:"id_arena,omitempty"`
+	NomeSolicitante   string            `json:"nome_solicitante,omitempty"`
+	Horario           time.Time         `json:"horario"`
+	Jogadores         int               `json:"jogadores"`
+	Pagamento         string            `json:"pagamento"`
+	Pago              bool              `json:"pago"`
+	Status            AgendamentoStatus `json:"status"`
+	CriadoEm          time.Time         `json:"criado_em,omitempty"`
+	NomeCampo         string            `json:"nome_campo,omitempty"`
+	NomeArena         string            `json:"nome_arena,omitempty"`
+	OrigemAgendamento AgendamentoOrigem `json:"origem_agendamento"`
+	ValorTotal        float64           `json:"valor_total"`
+	ValorRestante     float64           `json:"valor_restante"`
+	StatusDePagamento bool              `json:"status_de_pagamento"`
+	InicioCronometro  *int64            `json:"inicio_cronometro,omitempty"`
+	FimCronometro     *time.Time        `json:"fim_cronometro,omitempty"`
+	// CronometroPausadoEm is set while the game is paused; the closed pauses
+	// add up to CronometroPausasSegundos.
+	CronometroPausadoEm      *time.Time `json:"cronometro_pausado_em,omitempty"`
+	CronometroPausasSegundos int64      `json:"cronometro_pausas_segundos,omitempty"`
+	Time1                    string     `json:"time1,omitempty"`
+	Time2                    string     `json:"time2,omitempty"`
+	ModoDeJogo               string     `json:"modo_de_jogo,omitempty"`
+	OrigemStatusEvento       string     `json:"origem_status_evento,omitempty"`
+	IDRecorrencia            *int       `json:"id_recorrencia,omitempty"`
+	DuracaoMinutos           int        `json:"duracao_minutos"`
+	// HoraExtra is set once the cronometro closed past the booked time.
+	HoraExtra *AgendamentoHoraExtra `json:"hora_extra,omitempty"`
+	// Remarcacao is only loaded when an event is about a reschedule proposal.
+	Remarcacao *AgendamentoRemarcacao `json:"remarcacao,omitempty"`
+	// ValorBruto is the price before the coupon; ValorTotal is what is owed.
+	ValorBruto    float64 `json:"valor_bruto"`
+	ValorDesconto float64 `json:"valor_desconto"`
+	IDCupom       *int    `json:"id_cupom,omitempty"`
+	CodigoCupom   string  `json:"codigo_cupom,omitempty"`
+	// ValorRetido is what the arena kept when the agendamento was cancelled or
+	// closed as a no-show without its charge.
+	ValorRetido *float64 `json:"valor_retido,omitempty"`
 }
 
 type CreateAgendamentoInput struct {
@@ -63,6 +84,21 @@ type CreateAgendamentoInput struct {
 	Time1             string
 	Time2             string
 	ModoDeJogo        string
+	IDRecorrencia     *int
+	DuracaoMinutos    int
+	CodigoCupom       string
+}
+
+func (agendamento Agendamento) HorarioFim() time.Time {
+	return agendamento.Horario.Add(time.Duration(NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos)) * time.Minute)
+}
+
+func NormalizeAgendamentoDuracao(minutos int) int {
+	if minutos <= 0 {
+		return AgendamentoDuracaoPadraoMinutos
+	}
+
+	return minutos
 }
 
 func NormalizeAgendamentoOrigem(raw string) (AgendamentoOrigem, bool) {
@@ -92,6 +128,8 @@ func NormalizeAgendamentoStatus(raw string) (AgendamentoStatus, bool) {
 		return AgendamentoStatusCancelado, true
 	case "concluido", "concluído":
 		return AgendamentoStatusConcluido, true
+	case string(AgendamentoStatusNaoCompareceu), "no_show":
+		return AgendamentoStatusNaoCompareceu, true
 	default:
 		return "", false
 	}
@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+type AgendamentoCronometroEstado string
+
+const (
+	AgendamentoCronometroNaoIniciado AgendamentoCronometroEstado = "nao_iniciado"
+	AgendamentoCronometroRodando     AgendamentoCronometroEstado = "rodando"
+	AgendamentoCronometroPausado     AgendamentoCronometroEstado = "pausado"
+	AgendamentoCronometroEncerrado   AgendamentoCronometroEstado = "encerrado"
+)
+
+// AgendamentoCronometroPausa is one stop of the clock; Fim is nil while the
+// game is still paused.
+type AgendamentoCronometroPausa struct {
+	ID            int        `json:"id"`
+	IDAgendamento int        `json:"id_agendamento"`
+	Inicio        time.Time  `json:"inicio"`
+	Fim           *time.Time `json:"fim,omitempty"`
+}
+
+// AgendamentoCronometro is the clock as seen at Agora. Clients render it from
+// these values instead of keeping their own timer.
+type AgendamentoCronometro struct {
+	IDAgendamento     int                          `json:"id_agendamento"`
+	Estado            AgendamentoCronometroEstado  `json:"estado"`
+	Inicio            *time.Time                   `json:"inicio,omitempty"`
+	Fim               *time.Time                   `json:"fim,omitempty"`
+	PausadoEm         *time.Time                   `json:"pausado_em,omitempty"`
+	DecorridoSegundos int64                        `json:"decorrido_segundos"`
+	PausadoSegundos   int64                        `json:"pausado_segundos"`
+	ReservadoSegundos int64                        `json:"reservado_segundos"`
+	Pausas            []AgendamentoCronometroPausa `json:"pausas"`
+	Agora             time.Time                    `json:"agora"`
+}
+
+// TempoDecorrido is the time played up to agora, or up to the end of the
+// cronometro once it is closed. Pauses never count.
+func (agendamento Agendamento) TempoDecorrido(agora time.Time) time.Duration {
+	if agendamento.InicioCronometro == nil {
+		return 0
+	}
+
+	fim := agora
+	if agendamento.FimCronometro != nil {
+		fim = *agendamento.FimCronometro
+	}
+	if agendamento.CronometroPausadoEm != nil && agendamento.CronometroPausadoEm.Before(fim) {
+		fim = *agendamento.CronometroPausadoEm
+	}
+
+	decorrido := fim.Sub(time.Unix(*agendamento.InicioCronometro, 0)) - time.Duration(agendamento.CronometroPausasSegundos)*time.Second
+	if decorrido < 0 {
+		return 0
+	}
+
+	return decorrido
+}
+
+// TempoJogado is how long the game ran; ok is false until the cronometro is
+// closed.
+func (agendamento Agendamento) TempoJogado() (time.Duration, bool) {
+	if agendamento.InicioCronometro == nil || agendamento.FimCronometro == nil {
+		return 0, false
+	}
+
+	return agendamento.TempoDecorrido(*agendamento.FimCronometro), true
+}
+
+func (agendamento Agendamento) Cronometro(agora time.Time) AgendamentoCronometro {
+	cronometro := AgendamentoCronometro{
+		IDAgendamento:     agendamento.ID,
+		Estado:            AgendamentoCronometroNaoIniciado,
+		ReservadoSegundos: int64(NormalizeAgendamentoDuracao(agendamento.DuracaoMinutos)) * 60,
+		Pausas:            []AgendamentoCronometroPausa{},
+		Agora:             agora,
+	}
+	if agendamento.InicioCronometro == nil {
+		return cronometro
+	}
+
+	inicio := time.Unix(*agendamento.InicioCronometro, 0).In(agora.Location())
+	cronometro.Inicio = &inicio
+	cronometro.Fim = agendamento.FimCronometro
+	cronometro.PausadoEm = agendamento.CronometroPausadoEm
+
+	fim := agora
+	switch {
+	case agendamento.FimCronometro != nil:
+		cronometro.Estado = AgendamentoCronometroEncerrado
+		fim = *agendamento.FimCronometro
+	case agendamento.CronometroPausadoEm != nil:
+		cronometro.Estado = AgendamentoCronometroPausado
+	default:
+		cronometro.Estado = AgendamentoCronometroRodando
+	}
+
+	decorrido := agendamento.TempoDecorrido(fim)
+	cronometro.DecorridoSegundos = int64(decorrido / time.Second)
+	if total := fim.Sub(inicio); total > decorrido {
+		cronometro.PausadoSegundos = int64((total - decorrido) / time.Second)
+	}
+
+	return cronometro
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgendamentoCronometroExcludesPauses(t *testing.T) {
+	inicio := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	inicioUnix := inicio.Unix()
+	pausadoEm := inicio.Add(40 * time.Minute)
+
+	testCases := []struct {
+		name        string
+		agendamento Agendamento
+		agora       time.Time
+		estado      AgendamentoCronometroEstado
+		decorrido   int64
+		pausado     int64
+	}{
+		{
+			name:        "not started",
+			agendamento: Agendamento{DuracaoMinutos: 60},
+			agora:       inicio,
+			estado:      AgendamentoCronometroNaoIniciado,
+		},
+		{
+			name:        "running after a closed pause",
+			agendamento: Agendamento{DuracaoMinutos: 60, InicioCronometro: &inicioUnix, CronometroPausasSegundos: 300},
+			agora:       inicio.Add(30 * time.Minute),
+			estado:      AgendamentoCronometroRodando,
+			decorrido:   25 * 60,
+			pausado:     5 * 60,
+		},
+		{
+			name:        "paused stops the clock",
+			agendamento: Agendamento{DuracaoMinutos: 60, InicioCronometro: &inicioUnix, CronometroPausadoEm: &pausadoEm},
+			agora:       inicio.Add(50 * time.Minute),
+			estado:      AgendamentoCronometroPausado,
+			decorrido:   40 * 60,
+			pausado:     10 * 60,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cronometro := testCase.agendamento.Cronometro(testCase.agora)
+			if cronometro.Estado != testCase.estado {
+				t.Fatalf("expected estado %s, got %s", testCase.estado, cronometro.Estado)
+			}
+			if cronometro.DecorridoSegundos != testCase.decorrido || cronometro.PausadoSegundos != testCase.pausado {
+				t.Fatalf("expected %ds elapsed and %ds paused, got %ds and %ds", testCase.decorrido, testCase.pausado, cronometro.DecorridoSegundos, cronometro.PausadoSegundos)
+			}
+			if cronometro.ReservadoSegundos != 3600 {
+				t.Fatalf("expected 3600s booked, got %d", cronometro.ReservadoSegundos)
+			}
+		})
+	}
+}
+
+func TestAgendamentoTempoJogadoCapsAtOpenPause(t *testing.T) {
+	inicio := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	inicioUnix := inicio.Unix()
+	pausadoEm := inicio.Add(55 * time.Minute)
+	fim := inicio.Add(70 * time.Minute)
+
+	jogado, ok := Agendamento{
+		InicioCronometro:         &inicioUnix,
+		FimCronometro:            &fim,
+		CronometroPausadoEm:      &pausadoEm,
+		CronometroPausasSegundos: 120,
+	}.TempoJogado()
+	if !ok || jogado != 53*time.Minute {
+		t.Fatalf("expected 53 minutes played, got %s (ok=%v)", jogado, ok)
+	}
+}
@@ -12,16 +12,33 @@ type AgendamentoPagamento struct {
 	NomeUsuario      string    `json:"nome_usuario,omitempty"`
 	SobrenomeUsuario string    `json:"sobrenome_usuario,omitempty"`
 	EmailUsuario     string    `json:"email_usuario,omitempty"`
+	IDParticipante   *int      `json:"id_participante,omitempty"`
+	NomeParticipante string    `json:"nome_participante,omitempty"`
 }
 
 type AgendamentoPagamentosResumo struct {
 	Agendamento Agendamento            `json:"agendamento"`
 	Pagamentos  []AgendamentoPagamento `json:"pagamentos"`
 	TotalPago   float64                `json:"total_pago"`
+	// Participantes is empty when the agendamento has no roster.
+	Participantes []AgendamentoParticipanteSaldo `json:"participantes"`
 }
 
 type RegistrarPagamentoInput struct {
 	IDUsuario      *int
+	IDParticipante *int
+	// IDCarteira is the prepaid balance debited by a credito payment.
+	IDCarteira *int
+	// UsarHoras pays the whole booking from the carteira's hour pacotes,
+	// debiting its duration instead of its price.
+	UsarHoras      bool
 	ValorPago      float64
 	FormaPagamento string
 }
+
+// AgendamentoFormaPagamentoReembolso marks the negative entry written when a
+// cancellation gives money back.
+const AgendamentoFormaPagamentoReembolso = "reembolso"
+
+// AgendamentoFormaPagamentoCredito pays from the customer's prepaid carteira.
+const AgendamentoFormaPagamentoCredito = "credito"
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// AgendamentoParticipante is one player on an agendamento's roster. Token
+// identifies the participant's own payment link.
+type AgendamentoParticipante struct {
+	ID            int       `json:"id"`
+	IDAgendamento int       `json:"id_agendamento"`
+	Nome          string    `json:"nome"`
+	IDJogador     *int      `json:"id_jogador,omitempty"`
+	Token         string    `json:"token"`
+	CriadoEm      time.Time `json:"criado_em"`
+}
+
+// AgendamentoParticipanteSaldo is a participant's share of valor_total and
+// what they already paid towards it.
+type AgendamentoParticipanteSaldo struct {
+	Participante AgendamentoParticipante `json:"participante"`
+	Cota         float64                 `json:"cota"`
+	TotalPago    float64                 `json:"total_pago"`
+	Restante     float64                 `json:"restante"`
+}
+
+type AdicionarParticipanteInput struct {
+	Nome      string
+	IDJogador *int
+}
+
+// DividirValorPorParticipante splits valorTotal into equal shares in cents.
+// The leftover cents go to the first participants so the shares always add up
+// to the total.
+func DividirValorPorParticipante(valorTotal float64, participantes int) []float64 {
+	if participantes <= 0 {
+		return nil
+	}
+
+	totalCentavos := int64(math.Round(valorTotal * 100))
+	if totalCentavos < 0 {
+		totalCentavos = 0
+	}
+	base := totalCentavos / int64(participantes)
+	sobra := totalCentavos % int64(participantes)
+
+	cotas := make([]float64, participantes)
+	for index := range cotas {
+		centavos := base
+		if int64(index) < sobra {
+			centavos++
+		}
+		cotas[index] = float64(centavos) / 100
+	}
+
+	return cotas
+}
+
+// CalcularSaldosParticipantes pairs each participant, in roster order, with
+// its share and the payments tied to it.
+func CalcularSaldosParticipantes(valorTotal float64, participantes []AgendamentoParticipante, pagoPorParticipante map[int]float64) []AgendamentoParticipanteSaldo {
+	cotas := DividirValorPorParticipante(valorTotal, len(participantes))
+	saldos := make([]AgendamentoParticipanteSaldo, 0, len(participantes))
+	for index, participante := range participantes {
+		totalPago := pagoPorParticipante[participante.ID]
+		restante := ArredondarCentavos(cotas[index] - totalPago)
+		if restante < 0 {
+			restante = 0
+		}
+
+		saldos = append(saldos, AgendamentoParticipanteSaldo{
+			Participante: participante,
+			Cota:         cotas[index],
+			TotalPago:    totalPago,
+			Restante:     restante,
+		})
+	}
+
+	return saldos
+}
@@ -0,0 +1,32 @@
+package models
+
+import "testing"
+
+func TestDividirValorPorParticipanteKeepsTheTotal(t *testing.T) {
+	cotas := DividirValorPorParticipante(100, 3)
+	if len(cotas) != 3 {
+		t.Fatalf("expected 3 shares, got %d", len(cotas))
+	}
+	if cotas[0] != 33.34 || cotas[1] != 33.33 || cotas[2] != 33.33 {
+		t.Fatalf("expected leftover cent on the first share, got %v", cotas)
+	}
+
+	if cotas := DividirValorPorParticipante(100, 0); cotas != nil {
+		t.Fatalf("expected no shares without participants, got %v", cotas)
+	}
+}
+
+func TestCalcularSaldosParticipantes(t *testing.T) {
+	participantes := []AgendamentoParticipante{{ID: 7, Nome: "Ana"}, {ID: 9, Nome: "Bia"}}
+
+	saldos := CalcularSaldosParticipantes(150, participantes, map[int]float64{7: 75, 9: 20})
+	if len(saldos) != 2 {
+		t.Fatalf("expected 2 balances, got %d", len(saldos))
+	}
+	if saldos[0].Cota != 75 || saldos[0].Restante != 0 {
+		t.Fatalf("expected Ana to be settled, got %+v", saldos[0])
+	}
+	if saldos[1].TotalPago != 20 || saldos[1].Restante != 55 {
+		t.Fatalf("expected Bia to owe 55, got %+v", saldos[1])
+	}
+}
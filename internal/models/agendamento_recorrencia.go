@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type AgendamentoRecorrenciaFrequencia string
+
+const (
+	AgendamentoRecorrenciaSemanal   AgendamentoRecorrenciaFrequencia = "semanal"
+	AgendamentoRecorrenciaQuinzenal AgendamentoRecorrenciaFrequencia = "quinzenal"
+)
+
+type AgendamentoRecorrenciaStatus string
+
+const (
+	AgendamentoRecorrenciaAtiva     AgendamentoRecorrenciaStatus = "ativa"
+	AgendamentoRecorrenciaCancelada AgendamentoRecorrenciaStatus = "cancelada"
+)
+
+type AgendamentoRecorrencia struct {
+	ID              int                              `json:"id"`
+	IDUsuario       int                              `json:"id_usuario,omitempty"`
+	IDCampo         int                              `json:"id_campo"`
+	IDArena         int                              `json:"id_arena,omitempty"`
+	NomeCampo       string                           `json:"nome_campo,omitempty"`
+	NomeArena       string                           `json:"nome_arena,omitempty"`
+	Frequencia      AgendamentoRecorrenciaFrequencia `json:"frequencia"`
+	DataInicio      time.Time                        `json:"data_inicio"`
+	DataFim         *time.Time                       `json:"data_fim,omitempty"`
+	Horario         string                           `json:"horario"`
+	DuracaoMinutos  int                              `json:"duracao_minutos"`
+	Jogadores       int                              `json:"jogadores"`
+	Pagamento       string                           `json:"pagamento"`
+	NomeSolicitante string                           `json:"nome_solicitante,omitempty"`
+	Time1           string                           `json:"time1,omitempty"`
+	Time2           string                           `json:"time2,omitempty"`
+	ModoDeJogo      string                           `json:"modo_de_jogo,omitempty"`
+	Status          AgendamentoRecorrenciaStatus     `json:"status"`
+	CriadoEm        time.Time                        `json:"criado_em,omitempty"`
+}
+
+type CreateAgendamentoRecorrenciaInput struct {
+	IDCampo         int
+	Frequencia      AgendamentoRecorrenciaFrequencia
+	DataInicio      time.Time
+	DataFim         *time.Time
+	Horario         string
+	DuracaoMinutos  int
+	Jogadores       int
+	Pagamento       string
+	NomeSolicitante string
+	Time1           string
+	Time2           string
+	ModoDeJogo      string
+}
+
+func NormalizeAgendamentoRecorrenciaFrequencia(raw string) (AgendamentoRecorrenciaFrequencia, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(AgendamentoRecorrenciaSemanal), "weekly":
+		return AgendamentoRecorrenciaSemanal, true
+	case string(AgendamentoRecorrenciaQuinzenal), "biweekly":
+		return AgendamentoRecorrenciaQuinzenal, true
+	default:
+		return "", false
+	}
+}
+
+func (frequencia AgendamentoRecorrenciaFrequencia) IntervaloDias() int {
+	if frequencia == AgendamentoRecorrenciaQuinzenal {
+		return 14
+	}
+
+	return 7
+}
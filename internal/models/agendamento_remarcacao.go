@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type AgendamentoRemarcacaoStatus string
+
+const (
+	AgendamentoRemarcacaoPendente  AgendamentoRemarcacaoStatus = "pendente"
+	AgendamentoRemarcacaoAceita    AgendamentoRemarcacaoStatus = "aceita"
+	AgendamentoRemarcacaoRejeitada AgendamentoRemarcacaoStatus = "rejeitada"
+	AgendamentoRemarcacaoCancelada AgendamentoRemarcacaoStatus = "cancelada"
+)
+
+// AgendamentoRemarcacao is a new slot the owner proposed for an agendamento
+// requested by a jogador. While pendente the proposed slot is held and the
+// original one is kept; only the jogador's acceptance moves the booking.
+type AgendamentoRemarcacao struct {
+	ID                     int                         `json:"id"`
+	IDAgendamento          int                         `json:"id_agendamento"`
+	IDCampoAnterior        int                         `json:"id_campo_anterior"`
+	HorarioAnterior        time.Time                   `json:"horario_anterior"`
+	DuracaoMinutosAnterior int                         `json:"duracao_minutos_anterior"`
+	IDCampo                int                         `json:"id_campo"`
+	Horario                time.Time                   `json:"horario"`
+	DuracaoMinutos         int                         `json:"duracao_minutos"`
+	ValorTotal             float64                     `json:"valor_total"`
+	Status                 AgendamentoRemarcacaoStatus `json:"status"`
+	CriadoEm               time.Time                   `json:"criado_em"`
+	RespondidaEm           *time.Time                  `json:"respondida_em,omitempty"`
+}
+
+func (remarcacao AgendamentoRemarcacao) HorarioFim() time.Time {
+	return remarcacao.Horario.Add(time.Duration(NormalizeAgendamentoDuracao(remarcacao.DuracaoMinutos)) * time.Minute)
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AgendamentoStatusEventoTipo string
+
+const (
+	AgendamentoStatusEventoCriacao             AgendamentoStatusEventoTipo = "criacao"
+	AgendamentoStatusEventoStatus              AgendamentoStatusEventoTipo = "status"
+	AgendamentoStatusEventoCronometroIniciado  AgendamentoStatusEventoTipo = "cronometro_iniciado"
+	AgendamentoStatusEventoCronometroEncerrado AgendamentoStatusEventoTipo = "cronometro_encerrado"
+	AgendamentoStatusEventoCronometroPausado   AgendamentoStatusEventoTipo = "cronometro_pausado"
+	AgendamentoStatusEventoCronometroRetomado  AgendamentoStatusEventoTipo = "cronometro_retomado"
+	AgendamentoStatusEventoPagamento           AgendamentoStatusEventoTipo = "pagamento"
+	AgendamentoStatusEventoRemarcacao          AgendamentoStatusEventoTipo = "remarcacao"
+)
+
+type AgendamentoAtorTipo string
+
+const (
+	AgendamentoAtorUsuario    AgendamentoAtorTipo = "usuario"
+	AgendamentoAtorIntegracao AgendamentoAtorTipo = "integracao"
+	AgendamentoAtorSistema    AgendamentoAtorTipo = "sistema"
+)
+
+// AgendamentoStatusEvento is one append-only row of an agendamento's history.
+// StatusAnterior is nil only for the creation event.
+type AgendamentoStatusEvento struct {
+	ID             int64                       `json:"id"`
+	IDAgendamento  int                         `json:"id_agendamento"`
+	Tipo           AgendamentoStatusEventoTipo `json:"tipo"`
+	StatusAnterior *AgendamentoStatus          `json:"status_anterior,omitempty"`
+	StatusNovo     AgendamentoStatus           `json:"status_novo"`
+	AtorTipo       AgendamentoAtorTipo         `json:"ator_tipo"`
+	IDAtor         *int                        `json:"id_ator,omitempty"`
+	Origem         string                      `json:"origem"`
+	Detalhes       json.RawMessage             `json:"detalhes,omitempty"`
+	CriadoEm       time.Time                   `json:"criado_em"`
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type AgendamentoAcao string
+
+const (
+	AgendamentoAcaoAceitar             AgendamentoAcao = "aceitar"
+	AgendamentoAcaoCancelar            AgendamentoAcao = "cancelar"
+	AgendamentoAcaoIniciarCronometro   AgendamentoAcao = "iniciar_cronometro"
+	AgendamentoAcaoEncerrarCronometro  AgendamentoAcao = "encerrar_cronometro"
+	AgendamentoAcaoPausarCronometro    AgendamentoAcao = "pausar_cronometro"
+	AgendamentoAcaoRetomarCronometro   AgendamentoAcao = "retomar_cronometro"
+	AgendamentoAcaoRegistrarPagamento  AgendamentoAcao = "registrar_pagamento"
+	AgendamentoAcaoConcluir            AgendamentoAcao = "concluir"
+	AgendamentoAcaoMarcarNaoCompareceu AgendamentoAcao = "marcar_nao_compareceu"
+	AgendamentoAcaoRemarcar            AgendamentoAcao = "remarcar"
+)
+
+var ErrAgendamentoTransicaoInvalida = errors.New("transicao de status do agendamento invalida")
+
+// AgendamentoTransicao is one allowed move: Acao takes an agendamento from De
+// to Para. De == Para means the action is allowed but keeps the status.
+type AgendamentoTransicao struct {
+	De   AgendamentoStatus
+	Para AgendamentoStatus
+	Acao AgendamentoAcao
+}
+
+// agendamentoTransicoes is the whole lifecycle. Anything not listed here is
+// refused; cancelado and concluido are terminal, and nao_compareceu only
+// accepts payments of a charge the arena kept.
+var agendamentoTransicoes = []AgendamentoTransicao{
+	{De: AgendamentoStatusPedido, Para: AgendamentoStatusAgendado, Acao: AgendamentoAcaoAceitar},
+	{De: AgendamentoStatusPedido, Para: AgendamentoStatusCancelado, Acao: AgendamentoAcaoCancelar},
+	{De: AgendamentoStatusPedido, Para: AgendamentoStatusPedido, Acao: AgendamentoAcaoRegistrarPagamento},
+	{De: AgendamentoStatusPedido, Para: AgendamentoStatusPedido, Acao: AgendamentoAcaoRemarcar},
+
+	{De: AgendamentoStatusAgendado, Para: AgendamentoStatusEmAndamento, Acao: AgendamentoAcaoIniciarCronometro},
+	{De: AgendamentoStatusAgendado, Para: AgendamentoStatusAgendado, Acao: AgendamentoAcaoRegistrarPagamento},
+	{De: AgendamentoStatusAgendado, Para: AgendamentoStatusAguardandoPagamento, Acao: AgendamentoAcaoRegistrarPagamento},
+	{De: AgendamentoStatusAgendado, Para: AgendamentoStatusCancelado, Acao: AgendamentoAcaoCancelar},
+	{De: AgendamentoStatusAgendado, Para: AgendamentoStatusConcluido, Acao: AgendamentoAcaoConcluir},
+	{De: AgendamentoStatusAgendado, Para: AgendamentoStatusNaoCompareceu, Acao: AgendamentoAcaoMarcarNaoCompareceu},
+	{De: AgendamentoStatusAgendado, Para: AgendamentoStatusAgendado, Acao: AgendamentoAcaoRemarcar},
+
+	{De: AgendamentoStatusEmAndamento, Para: AgendamentoStatusEmAndamento, Acao: AgendamentoAcaoIniciarCronometro},
+	{De: AgendamentoStatusEmAndamento, Para: AgendamentoStatusEmAndamento, Acao: AgendamentoAcaoPausarCronometro},
+	{De: AgendamentoStatusEmAndamento, Para: AgendamentoStatusEmAndamento, Acao: AgendamentoAcaoRetomarCronometro},
+	{De: AgendamentoStatusEmAndamento, Para: AgendamentoStatusAguardandoPagamento, Acao: AgendamentoAcaoEncerrarCronometro},
+	{De: AgendamentoStatusEmAndamento, Para: AgendamentoStatusAgendado, Acao: AgendamentoAcaoEncerrarCronometro},
+	{De: AgendamentoStatusEmAndamento, Para: AgendamentoStatusEmAndamento, Acao: AgendamentoAcaoRegistrarPagamento},
+	{De: AgendamentoStatusEmAndamento, Para: AgendamentoStatusCancelado, Acao: AgendamentoAcaoCancelar},
+
+	{De: AgendamentoStatusAguardandoPagamento, Para: AgendamentoStatusEmAndamento, Acao: AgendamentoAcaoIniciarCronometro},
+	{De: AgendamentoStatusAguardandoPagamento, Para: AgendamentoStatusAguardandoPagamento, Acao: AgendamentoAcaoRegistrarPagamento},
+	{De: AgendamentoStatusAguardandoPagamento, Para: AgendamentoStatusAgendado, Acao: AgendamentoAcaoRegistrarPagamento},
+	{De: AgendamentoStatusAguardandoPagamento, Para: AgendamentoStatusCancelado, Acao: AgendamentoAcaoCancelar},
+	{De: AgendamentoStatusAguardandoPagamento, Para: AgendamentoStatusConcluido, Acao: AgendamentoAcaoConcluir},
+
+	{De: AgendamentoStatusNaoCompareceu, Para: AgendamentoStatusNaoCompareceu, Acao: AgendamentoAcaoRegistrarPagamento},
+}
+
+// agendamentoAcaoPorStatus is the action behind a plain "set status to X"
+// request from the owner.
+var agendamentoAcaoPorStatus = map[AgendamentoStatus]AgendamentoAcao{
+	AgendamentoStatusAgendado:      AgendamentoAcaoAceitar,
+	AgendamentoStatusCancelado:     AgendamentoAcaoCancelar,
+	AgendamentoStatusConcluido:     AgendamentoAcaoConcluir,
+	AgendamentoStatusNaoCompareceu: AgendamentoAcaoMarcarNaoCompareceu,
+}
+
+// AgendamentoTransicaoError is returned when the table refuses a move. It
+// carries what the agendamento can do instead.
+type AgendamentoTransicaoError struct {
+	StatusAtual     AgendamentoStatus
+	Acao            AgendamentoAcao
+	StatusDestino   AgendamentoStatus
+	ProximosStatus  []AgendamentoStatus
+	AcoesPermitidas []AgendamentoAcao
+}
+
+func (err *AgendamentoTransicaoError) Error() string {
+	permitidos := make([]string, 0, len(err.ProximosStatus))
+	for _, status := range err.ProximosStatus {
+		permitidos = append(permitidos, string(status))
+	}
+	if len(permitidos) == 0 {
+		permitidos = append(permitidos, "nenhum")
+	}
+
+	destino := string(err.StatusDestino)
+	if destino == "" {
+		destino = string(err.Acao)
+	}
+
+	return fmt.Sprintf("agendamento %s nao pode ir para %s; permitidos: %s", err.StatusAtual, destino, strings.Join(permitidos, ", "))
+}
+
+func (err *AgendamentoTransicaoError) Unwrap() error {
+	return ErrAgendamentoTransicaoInvalida
+}
+
+func newAgendamentoTransicaoError(atual AgendamentoStatus, acao AgendamentoAcao, destino AgendamentoStatus) *AgendamentoTransicaoError {
+	return &AgendamentoTransicaoError{
+		StatusAtual:     atual,
+		Acao:            acao,
+		StatusDestino:   destino,
+		ProximosStatus:  AgendamentoProximosStatus(atual),
+		AcoesPermitidas: AgendamentoAcoesPermitidas(atual),
+	}
+}
+
+// ValidarTransicaoAgendamento accepts the move only when the table lists
+// exactly this action from atual to destino.
+func ValidarTransicaoAgendamento(atual AgendamentoStatus, acao AgendamentoAcao, destino AgendamentoStatus) error {
+	for _, transicao := range agendamentoTransicoes {
+		if transicao.De == atual && transicao.Para == destino && transicao.Acao == acao {
+			return nil
+		}
+	}
+
+	return newAgendamentoTransicaoError(atual, acao, destino)
+}
+
+// ValidarAcaoAgendamento checks an action before its destination is known,
+// e.g. a payment whose resulting status depends on the remaining balance.
+func ValidarAcaoAgendamento(atual AgendamentoStatus, acao AgendamentoAcao) error {
+	for _, transicao := range agendamentoTransicoes {
+		if transicao.De == atual && transicao.Acao == acao {
+			return nil
+		}
+	}
+
+	return newAgendamentoTransicaoError(atual, acao, "")
+}
+
+func AgendamentoAcaoParaStatus(destino AgendamentoStatus) (AgendamentoAcao, bool) {
+	acao, ok := agendamentoAcaoPorStatus[destino]
+	return acao, ok
+}
+
+// AgendamentoProximosStatus lists the statuses reachable from atual, without
+// atual itself, in table order.
+func AgendamentoProximosStatus(atual AgendamentoStatus) []AgendamentoStatus {
+	proximos := make([]AgendamentoStatus, 0)
+	vistos := make(map[AgendamentoStatus]struct{})
+	for _, transicao := range agendamentoTransicoes {
+		if transicao.De != atual || transicao.Para == atual {
+			continue
+		}
+		if _, ok := vistos[transicao.Para]; ok {
+			continue
+		}
+		vistos[transicao.Para] = struct{}{}
+		proximos = append(proximos, transicao.Para)
+	}
+
+	return proximos
+}
+
+func AgendamentoAcoesPermitidas(atual AgendamentoStatus) []AgendamentoAcao {
+	acoes := make([]AgendamentoAcao, 0)
+	vistas := make(map[AgendamentoAcao]struct{})
+	for _, transicao := range agendamentoTransicoes {
+		if transicao.De != atual {
+			continue
+		}
+		if _, ok := vistas[transicao.Acao]; ok {
+			continue
+		}
+		vistas[transicao.Acao] = struct{}{}
+		acoes = append(acoes, transicao.Acao)
+	}
+
+	return acoes
+}
+
+// AgendamentoDestinosDaAcao lists where acao can take an agendamento in atual.
+func AgendamentoDestinosDaAcao(atual AgendamentoStatus, acao AgendamentoAcao) []AgendamentoStatus {
+	destinos := make([]AgendamentoStatus, 0)
+	for _, transicao := range agendamentoTransicoes {
+		if transicao.De == atual && transicao.Acao == acao {
+			destinos = append(destinos, transicao.Para)
+		}
+	}
+
+	return destinos
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidarTransicaoAgendamentoRejectsLeavingTerminalStatus(t *testing.T) {
+	err := ValidarTransicaoAgendamento(AgendamentoStatusConcluido, AgendamentoAcaoAceitar, AgendamentoStatusPedido)
+	if !errors.Is(err, ErrAgendamentoTransicaoInvalida) {
+		t.Fatalf("expected invalid transition, got %v", err)
+	}
+
+	var transicaoErr *AgendamentoTransicaoError
+	if !errors.As(err, &transicaoErr) {
+		t.Fatalf("expected typed transition error, got %T", err)
+	}
+	if len(transicaoErr.ProximosStatus) != 0 || len(transicaoErr.AcoesPermitidas) != 0 {
+		t.Fatalf("expected no way out of concluido, got %+v", transicaoErr)
+	}
+}
+
+func TestValidarTransicaoAgendamentoRequiresMatchingAction(t *testing.T) {
+	if err := ValidarTransicaoAgendamento(AgendamentoStatusPedido, AgendamentoAcaoAceitar, AgendamentoStatusAgendado); err != nil {
+		t.Fatalf("expected pedido to be accepted, got %v", err)
+	}
+
+	// aguardando_pagamento -> agendado exists only as the result of a payment.
+	err := ValidarTransicaoAgendamento(AgendamentoStatusAguardandoPagamento, AgendamentoAcaoAceitar, AgendamentoStatusAgendado)
+	if !errors.Is(err, ErrAgendamentoTransicaoInvalida) {
+		t.Fatalf("expected aceitar to be refused outside pedido, got %v", err)
+	}
+	if err := ValidarTransicaoAgendamento(AgendamentoStatusAguardandoPagamento, AgendamentoAcaoRegistrarPagamento, AgendamentoStatusAgendado); err != nil {
+		t.Fatalf("expected payment to settle the agendamento, got %v", err)
+	}
+}
+
+func TestAgendamentoTransicaoErrorListsAllowedStatuses(t *testing.T) {
+	err := ValidarTransicaoAgendamento(AgendamentoStatusPedido, "", AgendamentoStatusEmAndamento)
+
+	var transicaoErr *AgendamentoTransicaoError
+	if !errors.As(err, &transicaoErr) {
+		t.Fatalf("expected typed transition error, got %v", err)
+	}
+
+	expected := []AgendamentoStatus{AgendamentoStatusAgendado, AgendamentoStatusCancelado}
+	if len(transicaoErr.ProximosStatus) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, transicaoErr.ProximosStatus)
+	}
+	for index, status := range expected {
+		if transicaoErr.ProximosStatus[index] != status {
+			t.Fatalf("expected %v, got %v", expected, transicaoErr.ProximosStatus)
+		}
+	}
+	if got := err.Error(); got != "agendamento pedido nao pode ir para em_andamento; permitidos: agendado, cancelado" {
+		t.Fatalf("unexpected error message %q", got)
+	}
+}
+
+func TestValidarAcaoAgendamentoChecksOrigin(t *testing.T) {
+	if err := ValidarAcaoAgendamento(AgendamentoStatusEmAndamento, AgendamentoAcaoEncerrarCronometro); err != nil {
+		t.Fatalf("expected encerrar to be allowed while running, got %v", err)
+	}
+	if err := ValidarAcaoAgendamento(AgendamentoStatusCancelado, AgendamentoAcaoRegistrarPagamento); !errors.Is(err, ErrAgendamentoTransicaoInvalida) {
+		t.Fatalf("expected payment on cancelado to be refused, got %v", err)
+	}
+}
+
+func TestAgendamentoAcaoParaStatusHasNoActionForPedido(t *testing.T) {
+	if _, ok := AgendamentoAcaoParaStatus(AgendamentoStatusPedido); ok {
+		t.Fatalf("expected no owner action leading back to pedido")
+	}
+	if acao, ok := AgendamentoAcaoParaStatus(AgendamentoStatusCancelado); !ok || acao != AgendamentoAcaoCancelar {
+		t.Fatalf("expected cancelar, got %q", acao)
+	}
+}
+
+func TestNaoCompareceuOnlyAcceptsPayments(t *testing.T) {
+	if err := ValidarTransicaoAgendamento(AgendamentoStatusAgendado, AgendamentoAcaoMarcarNaoCompareceu, AgendamentoStatusNaoCompareceu); err != nil {
+		t.Fatalf("expected agendado to accept a no-show, got %v", err)
+	}
+	if err := ValidarTransicaoAgendamento(AgendamentoStatusEmAndamento, AgendamentoAcaoMarcarNaoCompareceu, AgendamentoStatusNaoCompareceu); err == nil {
+		t.Fatalf("expected a started game to refuse a no-show")
+	}
+
+	acoes := AgendamentoAcoesPermitidas(AgendamentoStatusNaoCompareceu)
+	if len(acoes) != 1 || acoes[0] != AgendamentoAcaoRegistrarPagamento {
+		t.Fatalf("expected only payments after a no-show, got %v", acoes)
+	}
+}
+
+func TestRemarcarKeepsStatusOfOpenBookings(t *testing.T) {
+	for _, status := range []AgendamentoStatus{AgendamentoStatusPedido, AgendamentoStatusAgendado} {
+		if err := ValidarTransicaoAgendamento(status, AgendamentoAcaoRemarcar, status); err != nil {
+			t.Fatalf("expected %s to accept a remarcacao, got %v", status, err)
+		}
+	}
+	if err := ValidarAcaoAgendamento(AgendamentoStatusEmAndamento, AgendamentoAcaoRemarcar); !errors.Is(err, ErrAgendamentoTransicaoInvalida) {
+		t.Fatalf("expected a started game to refuse a remarcacao, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// ArenaPolitica holds the per-arena rules enforced by the background jobs. A
+// nil field turns that rule off.
+type ArenaPolitica struct {
+	IDArena                   int  `json:"id_arena"`
+	PrazoAceiteMinutos        *int `json:"prazo_aceite_minutos"`
+	AntecedenciaAceiteMinutos *int `json:"antecedencia_aceite_minutos"`
+	// ToleranciaNaoComparecimentoMinutos is how long after the start an
+	// unstarted game waits before it counts as a no-show.
+	ToleranciaNaoComparecimentoMinutos *int `json:"tolerancia_nao_comparecimento_minutos"`
+	NaoComparecimentoMantemCobranca    bool `json:"nao_comparecimento_mantem_cobranca"`
+	// Cancelling at least CancelamentoGratuitoHoras before the game is free;
+	// later, CancelamentoMultaPercentual of the total is kept as a fee.
+	CancelamentoGratuitoHoras   *int       `json:"cancelamento_gratuito_horas"`
+	CancelamentoMultaPercentual *float64   `json:"cancelamento_multa_percentual"`
+	AtualizadoEm                *time.Time `json:"atualizado_em,omitempty"`
+}
+
+// PrazoAceitePedido is when an unanswered pedido expires: PrazoAceiteMinutos
+// after it was created or AntecedenciaAceiteMinutos before the game,
+// whichever comes first.
+func (politica ArenaPolitica) PrazoAceitePedido(criadoEm time.Time, horario time.Time) (time.Time, bool) {
+	var (
+		prazo time.Time
+		ok    bool
+	)
+
+	if politica.PrazoAceiteMinutos != nil {
+		prazo = criadoEm.Add(time.Duration(*politica.PrazoAceiteMinutos) * time.Minute)
+		ok = true
+	}
+	if politica.AntecedenciaAceiteMinutos != nil {
+		limite := horario.Add(-time.Duration(*politica.AntecedenciaAceiteMinutos) * time.Minute)
+		if !ok || limite.Before(prazo) {
+			prazo = limite
+			ok = true
+		}
+	}
+
+	return prazo, ok
+}
+
+// AgendamentoCancelamento is the money side of a cancellation: the fee owed,
+// how much of what was paid the arena keeps and how much goes back.
+type AgendamentoCancelamento struct {
+	TotalPago float64 `json:"total_pago"`
+	Multa     float64 `json:"multa"`
+	Retido    float64 `json:"retido"`
+	Reembolso float64 `json:"reembolso"`
+	// ReembolsoCredito is the part of Reembolso returned to the carteira
+	// instead of paid back.
+	ReembolsoCredito float64 `json:"reembolso_credito,omitempty"`
+	// ReembolsoMinutos is what a booking paid with an hour pacote gets back
+	// on it; its money value is part of ReembolsoCredito.
+	ReembolsoMinutos int  `json:"reembolso_minutos,omitempty"`
+	Gratuito         bool `json:"gratuito"`
+}
+
+// CalcularCancelamento applies the cancellation policy. The fee never exceeds
+// what was paid: a cancelled booking has nothing left to charge.
+func (politica ArenaPolitica) CalcularCancelamento(valorTotal float64, totalPago float64, horario time.Time, agora time.Time) AgendamentoCancelamento {
+	cancelamento := AgendamentoCancelamento{TotalPago: totalPago, Reembolso: totalPago, Gratuito: true}
+	if politica.CancelamentoMultaPercentual == nil || *politica.CancelamentoMultaPercentual <= 0 {
+		return cancelamento
+	}
+	if politica.CancelamentoGratuitoHoras != nil {
+		limite := horario.Add(-time.Duration(*politica.CancelamentoGratuitoHoras) * time.Hour)
+		if !agora.After(limite) {
+			return cancelamento
+		}
+	}
+
+	cancelamento.Gratuito = false
+	cancelamento.Multa = ArredondarCentavos(valorTotal * *politica.CancelamentoMultaPercentual / 100)
+	cancelamento.Retido = math.Min(cancelamento.Multa, math.Max(totalPago, 0))
+	cancelamento.Reembolso = ArredondarCentavos(math.Max(totalPago, 0) - cancelamento.Retido)
+	return cancelamento
+}
+
+// ArredondarCentavos rounds a money value to whole centavos.
+func ArredondarCentavos(valor float64) float64 {
+	return math.Round(valor*100) / 100
+}
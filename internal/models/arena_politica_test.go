@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArenaPoliticaPrazoAceitePedidoDisabledWithoutRules(t *testing.T) {
+	if _, ok := (ArenaPolitica{}).PrazoAceitePedido(time.Now(), time.Now().Add(time.Hour)); ok {
+		t.Fatalf("expected no deadline without rules")
+	}
+}
+
+func TestArenaPoliticaPrazoAceitePedidoUsesEarliestRule(t *testing.T) {
+	criadoEm := time.Date(2026, time.May, 4, 10, 0, 0, 0, time.UTC)
+	horario := time.Date(2026, time.May, 4, 13, 0, 0, 0, time.UTC)
+	prazoAceite := 120
+	antecedencia := 90
+
+	politica := ArenaPolitica{PrazoAceiteMinutos: &prazoAceite}
+	prazo, ok := politica.PrazoAceitePedido(criadoEm, horario)
+	if !ok || !prazo.Equal(criadoEm.Add(2*time.Hour)) {
+		t.Fatalf("expected deadline 2h after creation, got %v", prazo)
+	}
+
+	politica.AntecedenciaAceiteMinutos = &antecedencia
+	prazo, ok = politica.PrazoAceitePedido(criadoEm, horario)
+	if !ok || !prazo.Equal(horario.Add(-90*time.Minute)) {
+		t.Fatalf("expected deadline 90min before the game, got %v", prazo)
+	}
+}
+
+func TestArenaPoliticaCalcularCancelamento(t *testing.T) {
+	horario := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	horas := 24
+	percentual := 50.0
+	politica := ArenaPolitica{CancelamentoGratuitoHoras: &horas, CancelamentoMultaPercentual: &percentual}
+
+	cancelamento := politica.CalcularCancelamento(200, 80, horario, horario.Add(-48*time.Hour))
+	if !cancelamento.Gratuito || cancelamento.Reembolso != 80 || cancelamento.Retido != 0 {
+		t.Fatalf("expected a free cancellation with full refund, got %+v", cancelamento)
+	}
+
+	cancelamento = politica.CalcularCancelamento(200, 150, horario, horario.Add(-2*time.Hour))
+	if cancelamento.Gratuito || cancelamento.Multa != 100 || cancelamento.Retido != 100 || cancelamento.Reembolso != 50 {
+		t.Fatalf("expected a 100 fee with 50 refunded, got %+v", cancelamento)
+	}
+
+	cancelamento = politica.CalcularCancelamento(200, 30, horario, horario.Add(-2*time.Hour))
+	if cancelamento.Retido != 30 || cancelamento.Reembolso != 0 {
+		t.Fatalf("expected the fee to be capped at the amount paid, got %+v", cancelamento)
+	}
+}
+
+func TestArenaPoliticaCalcularCancelamentoFreeWithoutFee(t *testing.T) {
+	horario := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	cancelamento := (ArenaPolitica{}).CalcularCancelamento(200, 200, horario, horario)
+	if !cancelamento.Gratuito || cancelamento.Reembolso != 200 {
+		t.Fatalf("expected a full refund without a fee, got %+v", cancelamento)
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// BloqueioAgenda blocks a campo (or every campo of an arena when IDCampo is
+// nil) between Inicio and Fim, e.g. for maintenance or a private event.
+type BloqueioAgenda struct {
+	ID        int       `json:"id"`
+	IDArena   int       `json:"id_arena"`
+	IDCampo   *int      `json:"id_campo,omitempty"`
+	NomeArena string    `json:"nome_arena,omitempty"`
+	NomeCampo string    `json:"nome_campo,omitempty"`
+	Inicio    time.Time `json:"inicio"`
+	Fim       time.Time `json:"fim"`
+	Motivo    string    `json:"motivo"`
+	CriadoEm  time.Time `json:"criado_em"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// CampoHoraExtra is how a campo charges games that run past the booked time.
+// Up to ToleranciaMinutos of overtime is free; past it, every started block of
+// BlocoMinutos after the booked end costs ValorBloco. Without a block size or
+// value the campo does not charge overtime.
+type CampoHoraExtra struct {
+	IDCampo           int        `json:"id_campo"`
+	ToleranciaMinutos int        `json:"tolerancia_minutos"`
+	BlocoMinutos      *int       `json:"bloco_minutos"`
+	ValorBloco        *float64   `json:"valor_bloco"`
+	AtualizadoEm      *time.Time `json:"atualizado_em,omitempty"`
+}
+
+// AgendamentoHoraExtra is the itemized overtime charge added to valor_total
+// when the cronometro is closed.
+type AgendamentoHoraExtra struct {
+	MinutosExcedentes int     `json:"minutos_excedentes"`
+	Blocos            int     `json:"blocos"`
+	Valor             float64 `json:"valor"`
+}
+
+func (regra CampoHoraExtra) Ativa() bool {
+	return regra.BlocoMinutos != nil && *regra.BlocoMinutos > 0 && regra.ValorBloco != nil && *regra.ValorBloco > 0
+}
+
+// Calcular prices the time played beyond the booked duration. Partial minutes
+// are not counted.
+func (regra CampoHoraExtra) Calcular(reservado time.Duration, jogado time.Duration) AgendamentoHoraExtra {
+	excedente := int((jogado - reservado) / time.Minute)
+	if excedente <= 0 {
+		return AgendamentoHoraExtra{}
+	}
+
+	horaExtra := AgendamentoHoraExtra{MinutosExcedentes: excedente}
+	if !regra.Ativa() || excedente <= regra.ToleranciaMinutos {
+		return horaExtra
+	}
+
+	horaExtra.Blocos = (excedente + *regra.BlocoMinutos - 1) / *regra.BlocoMinutos
+	horaExtra.Valor = ArredondarCentavos(float64(horaExtra.Blocos) * *regra.ValorBloco)
+	return horaExtra
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCampoHoraExtraCalcular(t *testing.T) {
+	bloco := 15
+	valor := 20.0
+	regra := CampoHoraExtra{ToleranciaMinutos: 5, BlocoMinutos: &bloco, ValorBloco: &valor}
+	reservado := time.Hour
+
+	testCases := []struct {
+		name   string
+		regra  CampoHoraExtra
+		jogado time.Duration
+		want   AgendamentoHoraExtra
+	}{
+		{
+			name:   "within booked time",
+			regra:  regra,
+			jogado: 55 * time.Minute,
+		},
+		{
+			name:   "within tolerance is free",
+			regra:  regra,
+			jogado: reservado + 5*time.Minute,
+			want:   AgendamentoHoraExtra{MinutosExcedentes: 5},
+		},
+		{
+			name:   "started block is charged whole",
+			regra:  regra,
+			jogado: reservado + 16*time.Minute,
+			want:   AgendamentoHoraExtra{MinutosExcedentes: 16, Blocos: 2, Valor: 40},
+		},
+		{
+			name:   "inactive rule only reports the minutes",
+			regra:  CampoHoraExtra{},
+			jogado: reservado + 30*time.Minute,
+			want:   AgendamentoHoraExtra{MinutosExcedentes: 30},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.regra.Calcular(reservado, testCase.jogado); got != testCase.want {
+				t.Fatalf("expected %+v, got %+v", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestAgendamentoTempoJogadoNeedsClosedCronometro(t *testing.T) {
+	inicio := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	inicioUnix := inicio.Unix()
+	agendamento := Agendamento{InicioCronometro: &inicioUnix}
+
+	if _, ok := agendamento.TempoJogado(); ok {
+		t.Fatalf("expected no played time while the cronometro runs")
+	}
+
+	fim := inicio.Add(75 * time.Minute)
+	agendamento.FimCronometro = &fim
+	if jogado, ok := agendamento.TempoJogado(); !ok || jogado != 75*time.Minute {
+		t.Fatalf("expected 75 minutes, got %v/%v", jogado, ok)
+	}
+}
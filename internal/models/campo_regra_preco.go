@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type CampoRegraPreco struct {
+	ID            int        `json:"id"`
+	IDCampo       int        `json:"id_campo"`
+	DiaSemana     *int       `json:"dia_semana,omitempty"`
+	Data          *time.Time `json:"data,omitempty"`
+	HoraInicio    string     `json:"hora_inicio"`
+	HoraFim       string     `json:"hora_fim"`
+	ValorHora     *float64   `json:"valor_hora,omitempty"`
+	Multiplicador *float64   `json:"multiplicador,omitempty"`
+	Descricao     string     `json:"descricao,omitempty"`
+}
+
+// Prioridade orders overlapping rules: date overrides beat weekday rules,
+// which beat rules that apply to every day.
+func (regra CampoRegraPreco) Prioridade() int {
+	switch {
+	case regra.Data != nil:
+		return 2
+	case regra.DiaSemana != nil:
+		return 1
+	default:
+		return 0
+	}
+}
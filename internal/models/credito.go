@@ -0,0 +1,268 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+type CreditoMovimentoTipo string
+
+const (
+	CreditoMovimentoCompra    CreditoMovimentoTipo = "compra"
+	CreditoMovimentoConsumo   CreditoMovimentoTipo = "consumo"
+	CreditoMovimentoExpiracao CreditoMovimentoTipo = "expiracao"
+	CreditoMovimentoEstorno   CreditoMovimentoTipo = "estorno"
+)
+
+// CreditoCarteira is a customer's prepaid balance at one arena. Customers
+// with a player account are matched by IDJogador, the others by name.
+type CreditoCarteira struct {
+	ID          int     `json:"id"`
+	IDArena     int     `json:"id_arena"`
+	NomeCliente string  `json:"nome_cliente"`
+	IDJogador   *int    `json:"id_jogador,omitempty"`
+	Saldo       float64 `json:"saldo"`
+	// SaldoMinutos is what is left of its hour pacotes.
+	SaldoMinutos int       `json:"saldo_minutos"`
+	CriadoEm     time.Time `json:"criado_em"`
+}
+
+// CreditoPacote is one package sold to a carteira. Credito is what it is worth
+// in bookings and Preco what the customer paid, so bonus credit is possible.
+// Saldo is what is left of it. An hour pacote sells Minutos of play instead:
+// its Credito is zero and SaldoMinutos is what is left.
+type CreditoPacote struct {
+	ID             int        `json:"id"`
+	IDCarteira     int        `json:"id_carteira"`
+	Descricao      string     `json:"descricao"`
+	Credito        float64    `json:"credito"`
+	Minutos        int        `json:"minutos,omitempty"`
+	Preco          float64    `json:"preco"`
+	FormaPagamento string     `json:"forma_pagamento"`
+	Saldo          float64    `json:"saldo"`
+	SaldoMinutos   int        `json:"saldo_minutos,omitempty"`
+	CompradoEm     time.Time  `json:"comprado_em"`
+	ExpiraEm       *time.Time `json:"expira_em,omitempty"`
+}
+
+// CreditoMovimento is one append-only ledger entry. Valor is positive for a
+// purchase and for credit given back by a cancellation (estorno), negative
+// for consumption and expiry. Entries of hour pacotes move Minutos the same
+// way and leave Valor at zero.
+type CreditoMovimento struct {
+	ID            int                  `json:"id"`
+	IDCarteira    int                  `json:"id_carteira"`
+	IDPacote      *int                 `json:"id_pacote,omitempty"`
+	Tipo          CreditoMovimentoTipo `json:"tipo"`
+	Valor         float64              `json:"valor"`
+	Minutos       int                  `json:"minutos,omitempty"`
+	IDPagamento   *int                 `json:"id_pagamento,omitempty"`
+	IDAgendamento *int                 `json:"id_agendamento,omitempty"`
+	CriadoEm      time.Time            `json:"criado_em"`
+}
+
+type VenderPacoteCreditoInput struct {
+	IDCarteira  *int
+	NomeCliente string
+	IDJogador   *int
+	Descricao   string
+	Credito     float64
+	// Minutos sells an hour pacote; Credito is zero then.
+	Minutos        int
+	Preco          float64
+	FormaPagamento string
+	ExpiraEm       *time.Time
+}
+
+// CreditoDebito is the part of a payment taken from one pacote, in money or,
+// for an hour pacote, in Minutos.
+type CreditoDebito struct {
+	IDPacote int
+	Valor    float64
+	Minutos  int
+}
+
+func (pacote CreditoPacote) Expirado(agora time.Time) bool {
+	return pacote.ExpiraEm != nil && !pacote.ExpiraEm.After(agora)
+}
+
+// PorHoras reports whether the pacote sells minutes of play.
+func (pacote CreditoPacote) PorHoras() bool {
+	return pacote.Minutos > 0
+}
+
+// creditoParcela is an amount of one pacote in its smallest unit: cents for
+// money pacotes, minutes for hour pacotes.
+type creditoParcela struct {
+	IDPacote int
+	Unidades int64
+}
+
+func centavos(valor float64) int64 {
+	return int64(math.Round(valor * 100))
+}
+
+// PlanejarDebitoCredito spends valor from the pacotes that expire first, so
+// credit that is about to be lost is used before credit that never expires.
+// ok is false when the usable balance does not cover valor.
+func PlanejarDebitoCredito(pacotes []CreditoPacote, valor float64, agora time.Time) ([]CreditoDebito, bool) {
+	parcelas, ok := planejarDebito(pacotes, centavos(valor), agora, func(pacote CreditoPacote) int64 {
+		return centavos(pacote.Saldo)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	debitos := make([]CreditoDebito, 0, len(parcelas))
+	for _, parcela := range parcelas {
+		debitos = append(debitos, CreditoDebito{IDPacote: parcela.IDPacote, Valor: float64(parcela.Unidades) / 100})
+	}
+	return debitos, true
+}
+
+// PlanejarDebitoHoras is PlanejarDebitoCredito for hour pacotes: it spends
+// minutos of play.
+func PlanejarDebitoHoras(pacotes []CreditoPacote, minutos int, agora time.Time) ([]CreditoDebito, bool) {
+	parcelas, ok := planejarDebito(pacotes, int64(minutos), agora, func(pacote CreditoPacote) int64 {
+		return int64(pacote.SaldoMinutos)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	debitos := make([]CreditoDebito, 0, len(parcelas))
+	for _, parcela := range parcelas {
+		debitos = append(debitos, CreditoDebito{IDPacote: parcela.IDPacote, Minutos: int(parcela.Unidades)})
+	}
+	return debitos, true
+}
+
+func planejarDebito(pacotes []CreditoPacote, restante int64, agora time.Time, saldo func(CreditoPacote) int64) ([]creditoParcela, bool) {
+	if restante <= 0 {
+		return nil, false
+	}
+
+	disponiveis := make([]CreditoPacote, 0, len(pacotes))
+	for _, pacote := range pacotes {
+		if saldo(pacote) > 0 && !pacote.Expirado(agora) {
+			disponiveis = append(disponiveis, pacote)
+		}
+	}
+	ordenarPacotesPorExpiracao(disponiveis)
+
+	parcelas := make([]creditoParcela, 0, len(disponiveis))
+	for _, pacote := range disponiveis {
+		if restante == 0 {
+			break
+		}
+
+		unidades := min(saldo(pacote), restante)
+		parcelas = append(parcelas, creditoParcela{IDPacote: pacote.ID, Unidades: unidades})
+		restante -= unidades
+	}
+
+	if restante > 0 {
+		return nil, false
+	}
+
+	return parcelas, true
+}
+
+// DividirReembolsoCredito is the part of a cancellation refund that goes back
+// as credit: the refund is split in the same proportion as what was paid.
+func DividirReembolsoCredito(reembolso float64, totalPago float64, pagoCredito float64) float64 {
+	if reembolso <= 0 || totalPago <= 0 || pagoCredito <= 0 {
+		return 0
+	}
+
+	credito := ArredondarCentavos(reembolso * math.Min(pagoCredito, totalPago) / totalPago)
+	return math.Min(credito, math.Min(reembolso, pagoCredito))
+}
+
+// DividirReembolsoHoras converts the money part of a refund that was paid
+// with an hour pacote back into minutes: pagoHoras bought minutosConsumidos,
+// so reembolsoHoras returns the same share of them.
+func DividirReembolsoHoras(reembolsoHoras float64, pagoHoras float64, minutosConsumidos int) int {
+	if reembolsoHoras <= 0 || pagoHoras <= 0 || minutosConsumidos <= 0 {
+		return 0
+	}
+
+	minutos := int(math.Round(float64(minutosConsumidos) * math.Min(reembolsoHoras, pagoHoras) / pagoHoras))
+	return min(minutos, minutosConsumidos)
+}
+
+// PlanejarEstornoCredito gives valor back to the pacotes a booking consumed,
+// never more than was taken from each. Pacotes that are still valid and last
+// longest are refilled first; credit returned to an expired pacote keeps its
+// original validity and is written off by the expiry job.
+func PlanejarEstornoCredito(consumos []CreditoDebito, pacotes []CreditoPacote, valor float64) []CreditoDebito {
+	parcelas := planejarEstorno(consumos, pacotes, centavos(valor), func(consumo CreditoDebito) int64 {
+		return centavos(consumo.Valor)
+	})
+
+	estornos := make([]CreditoDebito, 0, len(parcelas))
+	for _, parcela := range parcelas {
+		estornos = append(estornos, CreditoDebito{IDPacote: parcela.IDPacote, Valor: float64(parcela.Unidades) / 100})
+	}
+	return estornos
+}
+
+// PlanejarEstornoHoras is PlanejarEstornoCredito for hour pacotes: it gives
+// minutos back.
+func PlanejarEstornoHoras(consumos []CreditoDebito, pacotes []CreditoPacote, minutos int) []CreditoDebito {
+	parcelas := planejarEstorno(consumos, pacotes, int64(minutos), func(consumo CreditoDebito) int64 {
+		return int64(consumo.Minutos)
+	})
+
+	estornos := make([]CreditoDebito, 0, len(parcelas))
+	for _, parcela := range parcelas {
+		estornos = append(estornos, CreditoDebito{IDPacote: parcela.IDPacote, Minutos: int(parcela.Unidades)})
+	}
+	return estornos
+}
+
+func planejarEstorno(consumos []CreditoDebito, pacotes []CreditoPacote, restante int64, consumido func(CreditoDebito) int64) []creditoParcela {
+	if restante <= 0 {
+		return nil
+	}
+
+	consumidoPorPacote := make(map[int]int64, len(consumos))
+	for _, consumo := range consumos {
+		consumidoPorPacote[consumo.IDPacote] += consumido(consumo)
+	}
+
+	origem := make([]CreditoPacote, 0, len(consumidoPorPacote))
+	for _, pacote := range pacotes {
+		if consumidoPorPacote[pacote.ID] > 0 {
+			origem = append(origem, pacote)
+		}
+	}
+	ordenarPacotesPorExpiracao(origem)
+
+	parcelas := make([]creditoParcela, 0, len(origem))
+	for index := len(origem) - 1; index >= 0 && restante > 0; index-- {
+		unidades := min(consumidoPorPacote[origem[index].ID], restante)
+		parcelas = append(parcelas, creditoParcela{IDPacote: origem[index].ID, Unidades: unidades})
+		restante -= unidades
+	}
+
+	return parcelas
+}
+
+func ordenarPacotesPorExpiracao(pacotes []CreditoPacote) {
+	sort.SliceStable(pacotes, func(i, j int) bool {
+		a, b := pacotes[i], pacotes[j]
+		switch {
+		case a.ExpiraEm != nil && b.ExpiraEm == nil:
+			return true
+		case a.ExpiraEm == nil && b.ExpiraEm != nil:
+			return false
+		case a.ExpiraEm != nil && !a.ExpiraEm.Equal(*b.ExpiraEm):
+			return a.ExpiraEm.Before(*b.ExpiraEm)
+		case !a.CompradoEm.Equal(b.CompradoEm):
+			return a.CompradoEm.Before(b.CompradoEm)
+		}
+		return a.ID < b.ID
+	})
+}
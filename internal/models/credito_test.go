@@ -0,0 +1,168 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanejarDebitoCreditoUsesCreditExpiringFirst(t *testing.T) {
+	agora := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	amanha := agora.AddDate(0, 0, 1)
+	semana := agora.AddDate(0, 0, 7)
+	ontem := agora.AddDate(0, 0, -1)
+
+	pacotes := []CreditoPacote{
+		{ID: 1, Saldo: 100, CompradoEm: agora.AddDate(0, -2, 0)},
+		{ID: 2, Saldo: 30, CompradoEm: agora.AddDate(0, -1, 0), ExpiraEm: &semana},
+		{ID: 3, Saldo: 20.5, CompradoEm: agora.AddDate(0, -1, 0), ExpiraEm: &amanha},
+		{ID: 4, Saldo: 500, CompradoEm: agora.AddDate(0, -3, 0), ExpiraEm: &ontem},
+	}
+
+	debitos, ok := PlanejarDebitoCredito(pacotes, 60, agora)
+	if !ok {
+		t.Fatalf("expected the balance to cover the payment")
+	}
+
+	want := []CreditoDebito{{IDPacote: 3, Valor: 20.5}, {IDPacote: 2, Valor: 30}, {IDPacote: 1, Valor: 9.5}}
+	if len(debitos) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, debitos)
+	}
+	for index := range want {
+		if debitos[index] != want[index] {
+			t.Fatalf("expected %+v, got %+v", want, debitos)
+		}
+	}
+}
+
+func TestPlanejarDebitoCreditoIgnoresExpiredBalance(t *testing.T) {
+	agora := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	ontem := agora.AddDate(0, 0, -1)
+
+	pacotes := []CreditoPacote{
+		{ID: 1, Saldo: 40},
+		{ID: 2, Saldo: 500, ExpiraEm: &ontem},
+	}
+
+	if debitos, ok := PlanejarDebitoCredito(pacotes, 50, agora); ok {
+		t.Fatalf("expected expired credit not to count, got %+v", debitos)
+	}
+}
+
+func TestDividirReembolsoCreditoFollowsWhatWasPaid(t *testing.T) {
+	testCases := []struct {
+		name        string
+		reembolso   float64
+		totalPago   float64
+		pagoCredito float64
+		want        float64
+	}{
+		{name: "paid only with credit", reembolso: 60, totalPago: 120, pagoCredito: 120, want: 60},
+		{name: "paid only with money", reembolso: 60, totalPago: 120, want: 0},
+		{name: "mixed payment", reembolso: 100, totalPago: 120, pagoCredito: 40, want: 33.33},
+		{name: "nothing refunded", totalPago: 120, pagoCredito: 120, want: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := DividirReembolsoCredito(testCase.reembolso, testCase.totalPago, testCase.pagoCredito); got != testCase.want {
+				t.Fatalf("expected %.2f, got %.2f", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestPlanejarEstornoCreditoRefillsConsumedPacotes(t *testing.T) {
+	agora := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	semana := agora.AddDate(0, 0, 7)
+	ontem := agora.AddDate(0, 0, -1)
+
+	pacotes := []CreditoPacote{
+		{ID: 1, ExpiraEm: &ontem},
+		{ID: 2, ExpiraEm: &semana},
+		{ID: 3},
+		{ID: 4},
+	}
+	consumos := []CreditoDebito{{IDPacote: 1, Valor: 50}, {IDPacote: 2, Valor: 30}, {IDPacote: 3, Valor: 20}}
+
+	estornos := PlanejarEstornoCredito(consumos, pacotes, 70)
+	want := []CreditoDebito{{IDPacote: 3, Valor: 20}, {IDPacote: 2, Valor: 30}, {IDPacote: 1, Valor: 20}}
+	if len(estornos) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, estornos)
+	}
+	for index := range want {
+		if estornos[index] != want[index] {
+			t.Fatalf("expected %+v, got %+v", want, estornos)
+		}
+	}
+}
+
+func TestPlanejarDebitoHorasSpendsOnlyHourPacotes(t *testing.T) {
+	agora := time.Date(2026, time.May, 4, 20, 0, 0, 0, time.UTC)
+	semana := agora.AddDate(0, 0, 7)
+
+	pacotes := []CreditoPacote{
+		{ID: 1, Saldo: 500},
+		{ID: 2, Minutos: 600, SaldoMinutos: 600, CompradoEm: agora.AddDate(0, -1, 0)},
+		{ID: 3, Minutos: 120, SaldoMinutos: 60, ExpiraEm: &semana},
+	}
+
+	debitos, ok := PlanejarDebitoHoras(pacotes, 90, agora)
+	if !ok {
+		t.Fatalf("expected the hours to cover the booking")
+	}
+
+	want := []CreditoDebito{{IDPacote: 3, Minutos: 60}, {IDPacote: 2, Minutos: 30}}
+	if len(debitos) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, debitos)
+	}
+	for index := range want {
+		if debitos[index] != want[index] {
+			t.Fatalf("expected %+v, got %+v", want, debitos)
+		}
+	}
+
+	if debitos, ok := PlanejarDebitoHoras(pacotes, 700, agora); ok {
+		t.Fatalf("expected money credit not to pay for hours, got %+v", debitos)
+	}
+}
+
+func TestDividirReembolsoHorasFollowsTheRefund(t *testing.T) {
+	testCases := []struct {
+		name           string
+		reembolsoHoras float64
+		pagoHoras      float64
+		minutos        int
+		want           int
+	}{
+		{name: "full refund", reembolsoHoras: 120, pagoHoras: 120, minutos: 90, want: 90},
+		{name: "half refund", reembolsoHoras: 60, pagoHoras: 120, minutos: 90, want: 45},
+		{name: "never more than consumed", reembolsoHoras: 200, pagoHoras: 120, minutos: 90, want: 90},
+		{name: "not paid with hours", reembolsoHoras: 60, minutos: 90, want: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := DividirReembolsoHoras(testCase.reembolsoHoras, testCase.pagoHoras, testCase.minutos); got != testCase.want {
+				t.Fatalf("expected %d, got %d", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestPlanejarEstornoHorasRefillsConsumedMinutes(t *testing.T) {
+	semana := time.Date(2026, time.May, 11, 20, 0, 0, 0, time.UTC)
+
+	pacotes := []CreditoPacote{{ID: 1, ExpiraEm: &semana}, {ID: 2}}
+	consumos := []CreditoDebito{{IDPacote: 1, Minutos: 30}, {IDPacote: 2, Minutos: 60}}
+
+	estornos := PlanejarEstornoHoras(consumos, pacotes, 75)
+	want := []CreditoDebito{{IDPacote: 2, Minutos: 60}, {IDPacote: 1, Minutos: 15}}
+	if len(estornos) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, estornos)
+	}
+	for index := range want {
+		if estornos[index] != want[index] {
+			t.Fatalf("expected %+v, got %+v", want, estornos)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+type CupomTipo string
+
+const (
+	CupomTipoPercentual CupomTipo = "percentual"
+	CupomTipoFixo       CupomTipo = "fixo"
+)
+
+// Cupom is a discount code owned by an arena. Empty IDCampos, DiasSemana or
+// HoraInicio/HoraFim leave that restriction off; a nil limit is unlimited.
+type Cupom struct {
+	ID                    int        `json:"id"`
+	IDArena               int        `json:"id_arena"`
+	Codigo                string     `json:"codigo"`
+	Descricao             string     `json:"descricao,omitempty"`
+	Tipo                  CupomTipo  `json:"tipo"`
+	Valor                 float64    `json:"valor"`
+	ValidoDe              *time.Time `json:"valido_de,omitempty"`
+	ValidoAte             *time.Time `json:"valido_ate,omitempty"`
+	LimiteUsos            *int       `json:"limite_usos,omitempty"`
+	LimiteUsosPorCliente  *int       `json:"limite_usos_por_cliente,omitempty"`
+	IDCampos              []int      `json:"id_campos,omitempty"`
+	DiasSemana            []int      `json:"dias_semana,omitempty"`
+	HoraInicio            string     `json:"hora_inicio,omitempty"`
+	HoraFim               string     `json:"hora_fim,omitempty"`
+	ApenasPrimeiraReserva bool       `json:"apenas_primeira_reserva"`
+	Ativo                 bool       `json:"ativo"`
+	// Usos counts the bookings that used the code and were not cancelled.
+	Usos     int       `json:"usos"`
+	CriadoEm time.Time `json:"criado_em"`
+}
+
+func NormalizeCupomTipo(raw string) (CupomTipo, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(CupomTipoPercentual), "percent":
+		return CupomTipoPercentual, true
+	case string(CupomTipoFixo), "valor_fixo":
+		return CupomTipoFixo, true
+	default:
+		return "", false
+	}
+}
+
+// NormalizeCupomCodigo makes codes case-insensitive: "terca10" and "TERCA10"
+// are the same coupon.
+func NormalizeCupomCodigo(raw string) string {
+	return strings.ToUpper(strings.TrimSpace(raw))
+}
+
+func (cupom Cupom) Vigente(agora time.Time) bool {
+	if cupom.ValidoDe != nil && agora.Before(*cupom.ValidoDe) {
+		return false
+	}
+	if cupom.ValidoAte != nil && !agora.Before(*cupom.ValidoAte) {
+		return false
+	}
+
+	return true
+}
+
+func (cupom Cupom) Esgotado() bool {
+	return cupom.LimiteUsos != nil && cupom.Usos >= *cupom.LimiteUsos
+}
+
+// CalcularDesconto never discounts more than valorBruto, so a fixed coupon on
+// a cheap slot makes it free instead of negative.
+func (cupom Cupom) CalcularDesconto(valorBruto float64) float64 {
+	if valorBruto <= 0 || cupom.Valor <= 0 {
+		return 0
+	}
+
+	var desconto float64
+	switch cupom.Tipo {
+	case CupomTipoPercentual:
+		desconto = ArredondarCentavos(valorBruto * math.Min(cupom.Valor, 100) / 100)
+	case CupomTipoFixo:
+		desconto = ArredondarCentavos(cupom.Valor)
+	}
+
+	return math.Min(desconto, valorBruto)
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCupomCalcularDesconto(t *testing.T) {
+	testCases := []struct {
+		name       string
+		cupom      Cupom
+		valorBruto float64
+		want       float64
+	}{
+		{name: "percent", cupom: Cupom{Tipo: CupomTipoPercentual, Valor: 10}, valorBruto: 145.5, want: 14.55},
+		{name: "percent rounds to cents", cupom: Cupom{Tipo: CupomTipoPercentual, Valor: 15}, valorBruto: 33.33, want: 5},
+		{name: "fixed", cupom: Cupom{Tipo: CupomTipoFixo, Valor: 20}, valorBruto: 120, want: 20},
+		{name: "fixed above the price", cupom: Cupom{Tipo: CupomTipoFixo, Valor: 50}, valorBruto: 30, want: 30},
+		{name: "free slot", cupom: Cupom{Tipo: CupomTipoPercentual, Valor: 10}, valorBruto: 0, want: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.cupom.CalcularDesconto(testCase.valorBruto); got != testCase.want {
+				t.Fatalf("expected discount %.2f, got %.2f", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestCupomVigenteAndEsgotado(t *testing.T) {
+	agora := time.Date(2026, time.May, 5, 9, 0, 0, 0, time.UTC)
+	inicio := agora.AddDate(0, 0, -1)
+	fim := agora
+	limite := 3
+
+	cupom := Cupom{ValidoDe: &inicio, ValidoAte: &fim, LimiteUsos: &limite, Usos: 2}
+	if cupom.Vigente(agora) {
+		t.Fatalf("expected valido_ate to be exclusive")
+	}
+	if !cupom.Vigente(agora.Add(-time.Minute)) {
+		t.Fatalf("expected coupon to be valid inside its period")
+	}
+	if cupom.Vigente(inicio.Add(-time.Minute)) {
+		t.Fatalf("expected coupon not to be valid before valido_de")
+	}
+	if cupom.Esgotado() {
+		t.Fatalf("expected coupon with uses left not to be exhausted")
+	}
+
+	cupom.Usos = 3
+	if !cupom.Esgotado() {
+		t.Fatalf("expected coupon at its limit to be exhausted")
+	}
+}
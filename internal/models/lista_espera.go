@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type ListaEsperaStatus string
+
+const (
+	ListaEsperaAguardando ListaEsperaStatus = "aguardando"
+	ListaEsperaAtendida   ListaEsperaStatus = "atendida"
+	ListaEsperaCancelada  ListaEsperaStatus = "cancelada"
+	ListaEsperaExpirada   ListaEsperaStatus = "expirada"
+)
+
+// ListaEsperaEntrada is a jogador waiting for a campo+horario that was taken.
+// When the slot frees up the entry becomes a pedido and IDAgendamento points
+// to it.
+type ListaEsperaEntrada struct {
+	ID                int               `json:"id"`
+	IDCampo           int               `json:"id_campo"`
+	IDArena           int               `json:"id_arena,omitempty"`
+	NomeCampo         string            `json:"nome_campo,omitempty"`
+	Horario           time.Time         `json:"horario"`
+	DuracaoMinutos    int               `json:"duracao_minutos"`
+	Jogadores         int               `json:"jogadores"`
+	Pagamento         string            `json:"pagamento,omitempty"`
+	NomeSolicitante   string            `json:"nome_solicitante,omitempty"`
+	OrigemAgendamento AgendamentoOrigem `json:"origem_agendamento"`
+	IDUsuarioJogador  *int              `json:"id_usuario_jogador,omitempty"`
+	Time1             string            `json:"time1,omitempty"`
+	Time2             string            `json:"time2,omitempty"`
+	ModoDeJogo        string            `json:"modo_de_jogo,omitempty"`
+	Status            ListaEsperaStatus `json:"status"`
+	IDAgendamento     *int              `json:"id_agendamento,omitempty"`
+	CriadoEm          time.Time         `json:"criado_em"`
+	AtendidaEm        *time.Time        `json:"atendida_em,omitempty"`
+}
+
+func (entrada ListaEsperaEntrada) AgendamentoInput() CreateAgendamentoInput {
+	return CreateAgendamentoInput{
+		IDCampo:           entrada.IDCampo,
+		Horario:           entrada.Horario,
+		Jogadores:         entrada.Jogadores,
+		Pagamento:         entrada.Pagamento,
+		NomeSolicitante:   entrada.NomeSolicitante,
+		OrigemAgendamento: entrada.OrigemAgendamento,
+		IDUsuarioJogador:  entrada.IDUsuarioJogador,
+		Time1:             entrada.Time1,
+		Time2:             entrada.Time2,
+		ModoDeJogo:        entrada.ModoDeJogo,
+		DuracaoMinutos:    entrada.DuracaoMinutos,
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type NotificacaoEntregaStatus string
+
+const (
+	NotificacaoEntregaPendente NotificacaoEntregaStatus = "pendente"
+	NotificacaoEntregaEntregue NotificacaoEntregaStatus = "entregue"
+	NotificacaoEntregaFalhou   NotificacaoEntregaStatus = "falhou"
+)
+
+// NotificacaoEntrega is one outbox row: a callback waiting to be delivered,
+// already delivered, or dead-lettered after too many attempts.
+type NotificacaoEntrega struct {
+	ID               int64                    `json:"id"`
+	IDAgendamento    int                      `json:"id_agendamento"`
+	IDArena          int                      `json:"id_arena"`
+	Tipo             string                   `json:"tipo"`
+	Evento           string                   `json:"evento,omitempty"`
+	IDWebhook        *int                     `json:"id_webhook,omitempty"`
+	Destino          string                   `json:"destino"`
+	Payload          json.RawMessage          `json:"payload"`
+	Status           NotificacaoEntregaStatus `json:"status"`
+	Tentativas       int                      `json:"tentativas"`
+	ProximaTentativa time.Time                `json:"proxima_tentativa"`
+	UltimoErro       string                   `json:"ultimo_erro,omitempty"`
+	CriadoEm         time.Time                `json:"criado_em"`
+	EntregueEm       *time.Time               `json:"entregue_em,omitempty"`
+}
+
+func NormalizeNotificacaoEntregaStatus(raw string) (NotificacaoEntregaStatus, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(NotificacaoEntregaPendente):
+		return NotificacaoEntregaPendente, true
+	case string(NotificacaoEntregaEntregue):
+		return NotificacaoEntregaEntregue, true
+	case string(NotificacaoEntregaFalhou), "dead_letter":
+		return NotificacaoEntregaFalhou, true
+	default:
+		return "", false
+	}
+}
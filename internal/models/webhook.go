@@ -0,0 +1,61 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type WebhookEvento string
+
+const (
+	WebhookEventoPedidoCriado         WebhookEvento = "pedido.criado"
+	WebhookEventoPedidoAceito         WebhookEvento = "pedido.aceito"
+	WebhookEventoAgendamentoCancelado WebhookEvento = "agendamento.cancelado"
+	WebhookEventoPagamentoRegistrado  WebhookEvento = "pagamento.registrado"
+	WebhookEventoAgendamentoConcluido WebhookEvento = "agendamento.concluido"
+	WebhookEventoNaoCompareceu        WebhookEvento = "agendamento.nao_compareceu"
+	WebhookEventoRemarcacaoProposta   WebhookEvento = "remarcacao.proposta"
+	WebhookEventoRemarcacaoAceita     WebhookEvento = "remarcacao.aceita"
+	WebhookEventoRemarcacaoRejeitada  WebhookEvento = "remarcacao.rejeitada"
+)
+
+// WebhookAssinatura is an endpoint that receives signed event deliveries.
+// IDUsuario nil means an integrator subscription that covers every arena.
+type WebhookAssinatura struct {
+	ID            int             `json:"id"`
+	IDUsuario     *int            `json:"id_usuario,omitempty"`
+	IDArena       *int            `json:"id_arena,omitempty"`
+	URL           string          `json:"url"`
+	Segredo       string          `json:"-"`
+	Eventos       []WebhookEvento `json:"eventos"`
+	VersaoPayload int             `json:"versao_payload"`
+	Ativo         bool            `json:"ativo"`
+	CriadoEm      time.Time       `json:"criado_em"`
+}
+
+func WebhookEventos() []WebhookEvento {
+	return []WebhookEvento{
+		WebhookEventoPedidoCriado,
+		WebhookEventoPedidoAceito,
+		WebhookEventoAgendamentoCancelado,
+		WebhookEventoPagamentoRegistrado,
+		WebhookEventoAgendamentoConcluido,
+		WebhookEventoNaoCompareceu,
+		WebhookEventoRemarcacaoProposta,
+		WebhookEventoRemarcacaoAceita,
+		WebhookEventoRemarcacaoRejeitada,
+	}
+}
+
+// NormalizeWebhookEvento also accepts "_" in place of the dots of a name, so
+// agendamento_nao_compareceu still means agendamento.nao_compareceu.
+func NormalizeWebhookEvento(raw string) (WebhookEvento, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(raw))
+	for _, evento := range WebhookEventos() {
+		if string(evento) == normalized || strings.ReplaceAll(string(evento), ".", "_") == normalized {
+			return evento, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrGeocodingManual               = errors.New("geocodificacao automatica desativada")
+	ErrCoordenadasInvalidas          = errors.New("coordenadas invalidas")
+	ErrGeocodingUnavailable          = errors.New("nao foi possivel geocodificar o endereco no momento")
+	activeGeocoder          Geocoder = ManualGeocoder{}
+	activeGeocoderMu        sync.RWMutex
+)
+
+type Coordenadas struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Geocoder turns a free-text address into coordinates. Implementations should
+// wrap ErrGeocodingUnavailable on transient failures.
+type Geocoder interface {
+	Geocode(ctx context.Context, endereco string) (Coordenadas, error)
+}
+
+// ManualGeocoder is the default: owners type latitude/longitude themselves.
+type ManualGeocoder struct{}
+
+func (ManualGeocoder) Geocode(context.Context, string) (Coordenadas, error) {
+	return Coordenadas{}, ErrGeocodingManual
+}
+
+func SetGeocoder(geocoder Geocoder) {
+	if geocoder == nil {
+		geocoder = ManualGeocoder{}
+	}
+
+	activeGeocoderMu.Lock()
+	defer activeGeocoderMu.Unlock()
+	activeGeocoder = geocoder
+}
+
+func GeocodeEndereco(ctx context.Context, endereco string) (Coordenadas, error) {
+	activeGeocoderMu.RLock()
+	geocoder := activeGeocoder
+	activeGeocoderMu.RUnlock()
+
+	if strings.TrimSpace(endereco) == "" {
+		return Coordenadas{}, ErrGeocodingManual
+	}
+
+	coordenadas, err := geocoder.Geocode(ctx, endereco)
+	if err != nil {
+		return Coordenadas{}, err
+	}
+	if !coordenadas.Valid() {
+		return Coordenadas{}, ErrCoordenadasInvalidas
+	}
+
+	return coordenadas, nil
+}
+
+func (coordenadas Coordenadas) Valid() bool {
+	return coordenadas.Latitude >= -90 && coordenadas.Latitude <= 90 &&
+		coordenadas.Longitude >= -180 && coordenadas.Longitude <= 180 &&
+		!math.IsNaN(coordenadas.Latitude) && !math.IsNaN(coordenadas.Longitude)
+}
+
+// ParseCoordenadas reads a latitude/longitude pair typed by the user. Both
+// empty means "not informed"; only one of them is an error.
+func ParseCoordenadas(rawLatitude string, rawLongitude string) (*Coordenadas, error) {
+	rawLatitude = strings.TrimSpace(strings.ReplaceAll(rawLatitude, ",", "."))
+	rawLongitude = strings.TrimSpace(strings.ReplaceAll(rawLongitude, ",", "."))
+	if rawLatitude == "" && rawLongitude == "" {
+		return nil, nil
+	}
+	if rawLatitude == "" || rawLongitude == "" {
+		return nil, ErrCoordenadasInvalidas
+	}
+
+	latitude, err := strconv.ParseFloat(rawLatitude, 64)
+	if err != nil {
+		return nil, ErrCoordenadasInvalidas
+	}
+	longitude, err := strconv.ParseFloat(rawLongitude, 64)
+	if err != nil {
+		return nil, ErrCoordenadasInvalidas
+	}
+
+	coordenadas := Coordenadas{Latitude: latitude, Longitude: longitude}
+	if !coordenadas.Valid() {
+		return nil, ErrCoordenadasInvalidas
+	}
+
+	return &coordenadas, nil
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fixedGeocoder struct {
+	coordenadas Coordenadas
+}
+
+func (geocoder fixedGeocoder) Geocode(context.Context, string) (Coordenadas, error) {
+	return geocoder.coordenadas, nil
+}
+
+func TestParseCoordenadas(t *testing.T) {
+	coordenadas, err := ParseCoordenadas("", " ")
+	if err != nil || coordenadas != nil {
+		t.Fatalf("expected empty input to mean not informed, got %v %v", coordenadas, err)
+	}
+
+	coordenadas, err = ParseCoordenadas("-23,5505", "-46.6333")
+	if err != nil {
+		t.Fatalf("expected valid coordinates, got %v", err)
+	}
+	if coordenadas.Latitude != -23.5505 || coordenadas.Longitude != -46.6333 {
+		t.Fatalf("unexpected coordinates: %+v", coordenadas)
+	}
+
+	if _, err := ParseCoordenadas("-23.5", ""); !errors.Is(err, ErrCoordenadasInvalidas) {
+		t.Fatalf("expected error when only latitude is informed, got %v", err)
+	}
+	if _, err := ParseCoordenadas("91", "10"); !errors.Is(err, ErrCoordenadasInvalidas) {
+		t.Fatalf("expected error for out of range latitude, got %v", err)
+	}
+}
+
+func TestGeocodeEnderecoUsesConfiguredGeocoder(t *testing.T) {
+	t.Cleanup(func() { SetGeocoder(nil) })
+
+	if _, err := GeocodeEndereco(context.Background(), "Rua A, 10"); !errors.Is(err, ErrGeocodingManual) {
+		t.Fatalf("expected manual geocoder by default, got %v", err)
+	}
+
+	SetGeocoder(fixedGeocoder{coordenadas: Coordenadas{Latitude: -22.9, Longitude: -43.2}})
+	coordenadas, err := GeocodeEndereco(context.Background(), "Rua A, 10")
+	if err != nil {
+		t.Fatalf("expected configured geocoder to answer, got %v", err)
+	}
+	if coordenadas.Latitude != -22.9 || coordenadas.Longitude != -43.2 {
+		t.Fatalf("unexpected coordinates: %+v", coordenadas)
+	}
+
+	SetGeocoder(fixedGeocoder{coordenadas: Coordenadas{Latitude: 200}})
+	if _, err := GeocodeEndereco(context.Background(), "Rua A, 10"); !errors.Is(err, ErrCoordenadasInvalidas) {
+		t.Fatalf("expected invalid geocoder output to be rejected, got %v", err)
+	}
+}
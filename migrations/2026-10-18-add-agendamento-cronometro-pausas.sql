@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS arena.agendamento_cronometro_pausas (
+	id SERIAL PRIMARY KEY,
+	id_agendamento INTEGER NOT NULL REFERENCES arena.agendamentos (id_agendamento) ON DELETE CASCADE,
+	inicio TIMESTAMP NOT NULL,
+	fim TIMESTAMP,
+	CHECK (fim IS NULL OR fim >= inicio)
+);
+
+CREATE INDEX IF NOT EXISTS agendamento_cronometro_pausas_agendamento_idx
+	ON arena.agendamento_cronometro_pausas (id_agendamento, inicio);
+
+-- A game can only be paused once at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS agendamento_cronometro_pausas_aberta_uidx
+	ON arena.agendamento_cronometro_pausas (id_agendamento)
+	WHERE fim IS NULL;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE arena.agendamentos
+	ADD COLUMN IF NOT EXISTS duracao_minutos INTEGER NOT NULL DEFAULT 60;
+
+CREATE INDEX IF NOT EXISTS agendamentos_id_campo_horario_idx
+	ON arena.agendamentos (id_campo, horario);
+
+COMMIT;
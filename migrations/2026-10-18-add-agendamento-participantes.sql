@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS arena.agendamento_participantes (
+	id SERIAL PRIMARY KEY,
+	id_agendamento INTEGER NOT NULL REFERENCES arena.agendamentos (id_agendamento) ON DELETE CASCADE,
+	nome VARCHAR(255) NOT NULL,
+	id_jogador INTEGER,
+	token VARCHAR(64) NOT NULL UNIQUE,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS agendamento_participantes_agendamento_idx
+	ON arena.agendamento_participantes (id_agendamento, id);
+
+ALTER TABLE arena.pagamentos_por_agendamento
+	ADD COLUMN IF NOT EXISTS id_participante INTEGER
+		REFERENCES arena.agendamento_participantes (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS pagamentos_por_agendamento_participante_idx
+	ON arena.pagamentos_por_agendamento (id_participante)
+	WHERE id_participante IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS arena.agendamento_recorrencias (
+	id SERIAL PRIMARY KEY,
+	id_usuario INTEGER,
+	id_campo INTEGER NOT NULL REFERENCES arena.campo (id_campo) ON DELETE CASCADE,
+	frequencia VARCHAR(20) NOT NULL DEFAULT 'semanal',
+	data_inicio DATE NOT NULL,
+	data_fim DATE,
+	horario VARCHAR(5) NOT NULL,
+	jogadores INTEGER NOT NULL,
+	pagamento VARCHAR(100) NOT NULL,
+	nome_solicitante VARCHAR(255),
+	time1 VARCHAR(255),
+	time2 VARCHAR(255),
+	modo_de_jogo VARCHAR(100),
+	status VARCHAR(20) NOT NULL DEFAULT 'ativa',
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS agendamento_recorrencias_id_campo_idx
+	ON arena.agendamento_recorrencias (id_campo);
+
+ALTER TABLE arena.agendamentos
+	ADD COLUMN IF NOT EXISTS id_recorrencia INTEGER REFERENCES arena.agendamento_recorrencias (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS agendamentos_id_recorrencia_idx
+	ON arena.agendamentos (id_recorrencia);
+
+COMMIT;
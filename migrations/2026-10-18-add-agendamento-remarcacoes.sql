@@ -0,0 +1,28 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS arena.agendamento_remarcacoes (
+	id SERIAL PRIMARY KEY,
+	id_agendamento INTEGER NOT NULL REFERENCES arena.agendamentos (id_agendamento) ON DELETE CASCADE,
+	id_campo_anterior INTEGER NOT NULL,
+	horario_anterior TIMESTAMP NOT NULL,
+	duracao_minutos_anterior INTEGER NOT NULL,
+	id_campo INTEGER NOT NULL REFERENCES arena.campo (id_campo) ON DELETE CASCADE,
+	horario TIMESTAMP NOT NULL,
+	duracao_minutos INTEGER NOT NULL,
+	valor_total NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+		CHECK (status IN ('pendente', 'aceita', 'rejeitada', 'cancelada')),
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	respondida_em TIMESTAMP
+);
+
+-- One open proposal per agendamento; its slot is held until answered.
+CREATE UNIQUE INDEX IF NOT EXISTS agendamento_remarcacoes_pendente_uidx
+	ON arena.agendamento_remarcacoes (id_agendamento)
+	WHERE status = 'pendente';
+
+CREATE INDEX IF NOT EXISTS agendamento_remarcacoes_campo_horario_idx
+	ON arena.agendamento_remarcacoes (id_campo, horario)
+	WHERE status = 'pendente';
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Append-only history of every agendamento state change. Rows are never
+-- updated or deleted by the application.
+CREATE TABLE IF NOT EXISTS arena.agendamento_status_eventos (
+	id BIGSERIAL PRIMARY KEY,
+	id_agendamento INTEGER NOT NULL REFERENCES arena.agendamentos (id_agendamento) ON DELETE CASCADE,
+	tipo VARCHAR(50) NOT NULL,
+	status_anterior VARCHAR(50),
+	status_novo VARCHAR(50) NOT NULL,
+	ator_tipo VARCHAR(20) NOT NULL,
+	id_ator INTEGER,
+	origem VARCHAR(100) NOT NULL,
+	detalhes JSONB,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (ator_tipo IN ('usuario', 'integracao', 'sistema'))
+);
+
+CREATE INDEX IF NOT EXISTS agendamento_status_eventos_id_agendamento_idx
+	ON arena.agendamento_status_eventos (id_agendamento, criado_em, id);
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+-- What the arena kept when the agendamento was cancelled or closed as a
+-- no-show without its charge. valor_total keeps the price, so the coupon
+-- discount still adds up afterwards.
+ALTER TABLE arena.agendamentos
+	ADD COLUMN IF NOT EXISTS valor_retido NUMERIC(10, 2);
+
+-- Cancellations and waived no-shows made before this column stored the
+-- retained amount in valor_total; the original price is not recoverable for
+-- them.
+UPDATE arena.agendamentos
+SET valor_retido = COALESCE(valor_total, 0)
+WHERE status = 'cancelado'
+  AND valor_retido IS NULL;
+
+UPDATE arena.agendamentos
+SET valor_retido = COALESCE(valor_total, 0)
+WHERE status = 'nao_compareceu'
+  AND valor_restante = 0
+  AND valor_retido IS NULL;
+
+COMMIT;
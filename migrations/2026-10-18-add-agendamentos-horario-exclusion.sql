@@ -0,0 +1,58 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+DO $$
+DECLARE
+	horario_type TEXT;
+	overlapping INTEGER;
+BEGIN
+	SELECT data_type
+	INTO horario_type
+	FROM information_schema.columns
+	WHERE table_schema = 'arena'
+	  AND table_name = 'agendamentos'
+	  AND column_name = 'horario';
+
+	SELECT COUNT(*)
+	INTO overlapping
+	FROM arena.agendamentos a
+	JOIN arena.agendamentos b
+	  ON a.id_campo = b.id_campo
+	 AND a.id_agendamento < b.id_agendamento
+	 AND a.horario < b.horario + b.duracao_minutos * INTERVAL '1 minute'
+	 AND b.horario < a.horario + a.duracao_minutos * INTERVAL '1 minute'
+	WHERE a.status <> 'cancelado'
+	  AND b.status <> 'cancelado';
+
+	IF overlapping > 0 THEN
+		RAISE EXCEPTION 'arena.agendamentos possui % pares de agendamentos sobrepostos; resolva antes de aplicar a constraint', overlapping;
+	END IF;
+
+	ALTER TABLE arena.agendamentos
+		DROP CONSTRAINT IF EXISTS agendamentos_campo_horario_excl;
+
+	-- timestamptz + interval is not immutable, so the range is built in UTC.
+	IF horario_type = 'timestamp with time zone' THEN
+		ALTER TABLE arena.agendamentos
+			ADD CONSTRAINT agendamentos_campo_horario_excl
+			EXCLUDE USING gist (
+				id_campo WITH =,
+				tsrange(
+					horario AT TIME ZONE 'UTC',
+					(horario AT TIME ZONE 'UTC') + duracao_minutos * INTERVAL '1 minute'
+				) WITH &&
+			)
+			WHERE (status <> 'cancelado');
+	ELSE
+		ALTER TABLE arena.agendamentos
+			ADD CONSTRAINT agendamentos_campo_horario_excl
+			EXCLUDE USING gist (
+				id_campo WITH =,
+				tsrange(horario, horario + duracao_minutos * INTERVAL '1 minute') WITH &&
+			)
+			WHERE (status <> 'cancelado');
+	END IF;
+END $$;
+
+COMMIT;
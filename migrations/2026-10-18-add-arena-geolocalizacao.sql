@@ -0,0 +1,20 @@
+BEGIN;
+
+ALTER TABLE arena.arenas
+ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+ALTER TABLE arena.arenas
+DROP CONSTRAINT IF EXISTS arenas_coordenadas_chk;
+
+ALTER TABLE arena.arenas
+ADD CONSTRAINT arenas_coordenadas_chk
+CHECK (
+    (latitude IS NULL AND longitude IS NULL)
+    OR (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+CREATE INDEX IF NOT EXISTS arenas_latitude_longitude_idx
+ON arena.arenas (latitude, longitude);
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- Per-arena rules for the background jobs. A NULL column disables the rule.
+CREATE TABLE IF NOT EXISTS arena.arena_politicas (
+	id_arena INTEGER PRIMARY KEY REFERENCES arena.arenas (id) ON DELETE CASCADE,
+	prazo_aceite_minutos INTEGER CHECK (prazo_aceite_minutos > 0),
+	antecedencia_aceite_minutos INTEGER CHECK (antecedencia_aceite_minutos > 0),
+	atualizado_em TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS agendamentos_pedidos_criado_em_idx
+	ON arena.agendamentos (criado_em)
+	WHERE status = 'pedido';
+
+COMMIT;
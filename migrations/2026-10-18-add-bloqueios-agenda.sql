@@ -0,0 +1,22 @@
+BEGIN;
+
+-- id_campo NULL blocks every campo of the arena.
+CREATE TABLE IF NOT EXISTS arena.bloqueios_agenda (
+	id SERIAL PRIMARY KEY,
+	id_arena INTEGER NOT NULL REFERENCES arena.arenas (id) ON DELETE CASCADE,
+	id_campo INTEGER REFERENCES arena.campo (id_campo) ON DELETE CASCADE,
+	inicio TIMESTAMP NOT NULL,
+	fim TIMESTAMP NOT NULL,
+	motivo VARCHAR(255) NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (fim > inicio)
+);
+
+CREATE INDEX IF NOT EXISTS bloqueios_agenda_id_arena_periodo_idx
+	ON arena.bloqueios_agenda (id_arena, inicio, fim);
+
+CREATE INDEX IF NOT EXISTS bloqueios_agenda_id_campo_periodo_idx
+	ON arena.bloqueios_agenda (id_campo, inicio, fim)
+	WHERE id_campo IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS arena.campo_hora_extra (
+	id_campo INTEGER PRIMARY KEY REFERENCES arena.campo (id_campo) ON DELETE CASCADE,
+	tolerancia_minutos INTEGER NOT NULL DEFAULT 0 CHECK (tolerancia_minutos >= 0),
+	bloco_minutos INTEGER CHECK (bloco_minutos > 0),
+	valor_bloco NUMERIC(10, 2) CHECK (valor_bloco > 0),
+	atualizado_em TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Overtime charged when the cronometro was closed; already part of valor_total.
+ALTER TABLE arena.agendamentos
+	ADD COLUMN IF NOT EXISTS hora_extra_minutos INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS hora_extra_blocos INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS hora_extra_valor NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+-- Keys are weekdays (0 = domingo); an empty list closes the campo that day.
+ALTER TABLE arena.campo
+ADD COLUMN IF NOT EXISTS horarios_semana JSONB;
+
+ALTER TABLE arena.campo
+DROP CONSTRAINT IF EXISTS campo_horarios_semana_object_chk;
+
+ALTER TABLE arena.campo
+ADD CONSTRAINT campo_horarios_semana_object_chk
+CHECK (horarios_semana IS NULL OR jsonb_typeof(horarios_semana) = 'object');
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS arena.campo_regras_preco (
+	id SERIAL PRIMARY KEY,
+	id_campo INTEGER NOT NULL REFERENCES arena.campo (id_campo) ON DELETE CASCADE,
+	dia_semana SMALLINT CHECK (dia_semana BETWEEN 0 AND 6),
+	data DATE,
+	hora_inicio VARCHAR(5) NOT NULL DEFAULT '00:00',
+	hora_fim VARCHAR(5) NOT NULL DEFAULT '00:00',
+	valor_hora NUMERIC(10, 2),
+	multiplicador NUMERIC(6, 3),
+	descricao VARCHAR(255),
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (dia_semana IS NULL OR data IS NULL),
+	CHECK ((valor_hora IS NULL) <> (multiplicador IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS campo_regras_preco_id_campo_idx
+	ON arena.campo_regras_preco (id_campo);
+
+COMMIT;
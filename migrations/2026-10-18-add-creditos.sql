@@ -0,0 +1,50 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS arena.credito_carteiras (
+	id SERIAL PRIMARY KEY,
+	id_arena INTEGER NOT NULL REFERENCES arena.arenas (id) ON DELETE CASCADE,
+	nome_cliente VARCHAR(255) NOT NULL,
+	id_jogador INTEGER,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One carteira per customer and arena: by player account when there is one,
+-- otherwise by name.
+CREATE UNIQUE INDEX IF NOT EXISTS credito_carteiras_jogador_idx
+	ON arena.credito_carteiras (id_arena, id_jogador)
+	WHERE id_jogador IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS credito_carteiras_nome_idx
+	ON arena.credito_carteiras (id_arena, LOWER(nome_cliente))
+	WHERE id_jogador IS NULL;
+
+CREATE TABLE IF NOT EXISTS arena.credito_pacotes (
+	id SERIAL PRIMARY KEY,
+	id_carteira INTEGER NOT NULL REFERENCES arena.credito_carteiras (id) ON DELETE CASCADE,
+	descricao VARCHAR(255) NOT NULL,
+	credito NUMERIC(10, 2) NOT NULL CHECK (credito > 0),
+	preco NUMERIC(10, 2) NOT NULL CHECK (preco >= 0),
+	forma_pagamento VARCHAR(100),
+	comprado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	expira_em TIMESTAMP
+);
+
+-- Append-only ledger; a pacote's balance is the sum of its rows.
+CREATE TABLE IF NOT EXISTS arena.credito_movimentos (
+	id SERIAL PRIMARY KEY,
+	id_carteira INTEGER NOT NULL REFERENCES arena.credito_carteiras (id) ON DELETE CASCADE,
+	id_pacote INTEGER REFERENCES arena.credito_pacotes (id) ON DELETE CASCADE,
+	tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('compra', 'consumo', 'expiracao')),
+	valor NUMERIC(10, 2) NOT NULL,
+	id_pagamento INTEGER REFERENCES arena.pagamentos_por_agendamento (id) ON DELETE SET NULL,
+	id_agendamento INTEGER REFERENCES arena.agendamentos (id_agendamento) ON DELETE SET NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS credito_movimentos_carteira_idx
+	ON arena.credito_movimentos (id_carteira, criado_em, id);
+
+CREATE INDEX IF NOT EXISTS credito_movimentos_pacote_idx
+	ON arena.credito_movimentos (id_pacote);
+
+COMMIT;
@@ -0,0 +1,58 @@
+BEGIN;
+
+-- id_campos and dias_semana are JSON arrays (dias_semana: 0 = domingo); NULL
+-- leaves the restriction off.
+CREATE TABLE IF NOT EXISTS arena.cupons (
+	id SERIAL PRIMARY KEY,
+	id_arena INTEGER NOT NULL REFERENCES arena.arenas (id) ON DELETE CASCADE,
+	codigo VARCHAR(50) NOT NULL,
+	descricao VARCHAR(255),
+	tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('percentual', 'fixo')),
+	valor NUMERIC(10, 2) NOT NULL CHECK (valor > 0),
+	valido_de TIMESTAMP,
+	valido_ate TIMESTAMP,
+	limite_usos INTEGER CHECK (limite_usos > 0),
+	limite_usos_por_cliente INTEGER CHECK (limite_usos_por_cliente > 0),
+	id_campos JSONB CHECK (id_campos IS NULL OR jsonb_typeof(id_campos) = 'array'),
+	dias_semana JSONB CHECK (dias_semana IS NULL OR jsonb_typeof(dias_semana) = 'array'),
+	hora_inicio VARCHAR(5),
+	hora_fim VARCHAR(5),
+	apenas_primeira_reserva BOOLEAN NOT NULL DEFAULT FALSE,
+	ativo BOOLEAN NOT NULL DEFAULT TRUE,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (tipo <> 'percentual' OR valor <= 100),
+	CHECK (valido_ate IS NULL OR valido_de IS NULL OR valido_ate > valido_de),
+	CHECK ((hora_inicio IS NULL) = (hora_fim IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS cupons_codigo_idx
+	ON arena.cupons (id_arena, UPPER(codigo));
+
+-- One row per booking that used a coupon; uses of cancelled bookings do not
+-- count against the limits.
+CREATE TABLE IF NOT EXISTS arena.cupom_usos (
+	id SERIAL PRIMARY KEY,
+	id_cupom INTEGER NOT NULL REFERENCES arena.cupons (id) ON DELETE CASCADE,
+	id_agendamento INTEGER NOT NULL UNIQUE REFERENCES arena.agendamentos (id_agendamento) ON DELETE CASCADE,
+	id_usuario_jogador INTEGER,
+	nome_cliente VARCHAR(255),
+	valor_bruto NUMERIC(10, 2) NOT NULL,
+	desconto NUMERIC(10, 2) NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS cupom_usos_id_cupom_idx
+	ON arena.cupom_usos (id_cupom);
+
+-- valor_total stays the net amount owed: valor_bruto - valor_desconto plus any
+-- hora extra.
+ALTER TABLE arena.agendamentos
+	ADD COLUMN IF NOT EXISTS valor_bruto NUMERIC(10, 2),
+	ADD COLUMN IF NOT EXISTS valor_desconto NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS id_cupom INTEGER REFERENCES arena.cupons (id) ON DELETE SET NULL;
+
+UPDATE arena.agendamentos
+SET valor_bruto = COALESCE(valor_total, 0) - COALESCE(hora_extra_valor, 0)
+WHERE valor_bruto IS NULL;
+
+COMMIT;
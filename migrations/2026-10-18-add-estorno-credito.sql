@@ -0,0 +1,16 @@
+BEGIN;
+
+-- A cancelled booking paid with credit gives it back to the pacotes it came
+-- from as an 'estorno' entry, linked to the booking.
+ALTER TABLE arena.credito_movimentos
+	DROP CONSTRAINT IF EXISTS credito_movimentos_tipo_check;
+
+ALTER TABLE arena.credito_movimentos
+	ADD CONSTRAINT credito_movimentos_tipo_check
+	CHECK (tipo IN ('compra', 'consumo', 'expiracao', 'estorno'));
+
+CREATE INDEX IF NOT EXISTS credito_movimentos_agendamento_idx
+	ON arena.credito_movimentos (id_agendamento)
+	WHERE id_agendamento IS NOT NULL;
+
+COMMIT;
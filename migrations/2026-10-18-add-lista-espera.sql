@@ -0,0 +1,27 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS arena.lista_espera (
+	id SERIAL PRIMARY KEY,
+	id_campo INTEGER NOT NULL REFERENCES arena.campo (id_campo) ON DELETE CASCADE,
+	horario TIMESTAMP NOT NULL,
+	duracao_minutos INTEGER NOT NULL,
+	jogadores INTEGER NOT NULL,
+	pagamento VARCHAR(100),
+	nome_solicitante VARCHAR(255),
+	origem_agendamento VARCHAR(50) NOT NULL,
+	id_usuario_jogador INTEGER,
+	time1 VARCHAR(255),
+	time2 VARCHAR(255),
+	modo_de_jogo VARCHAR(100),
+	status VARCHAR(20) NOT NULL DEFAULT 'aguardando'
+		CHECK (status IN ('aguardando', 'atendida', 'cancelada', 'expirada')),
+	id_agendamento INTEGER REFERENCES arena.agendamentos (id_agendamento) ON DELETE SET NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	atendida_em TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS lista_espera_aguardando_idx
+	ON arena.lista_espera (id_campo, horario, criado_em)
+	WHERE status = 'aguardando';
+
+COMMIT;
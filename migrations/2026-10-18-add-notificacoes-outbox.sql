@@ -0,0 +1,28 @@
+BEGIN;
+
+-- Status callbacks are written here in the same transaction as the status
+-- change and delivered by the background worker.
+CREATE TABLE IF NOT EXISTS arena.notificacoes_outbox (
+	id BIGSERIAL PRIMARY KEY,
+	id_agendamento INTEGER NOT NULL REFERENCES arena.agendamentos (id_agendamento) ON DELETE CASCADE,
+	id_arena INTEGER NOT NULL REFERENCES arena.arenas (id) ON DELETE CASCADE,
+	tipo VARCHAR(50) NOT NULL,
+	destino TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pendente',
+	tentativas INTEGER NOT NULL DEFAULT 0,
+	proxima_tentativa TIMESTAMP NOT NULL DEFAULT NOW(),
+	ultimo_erro TEXT,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	entregue_em TIMESTAMP,
+	CHECK (status IN ('pendente', 'entregue', 'falhou'))
+);
+
+CREATE INDEX IF NOT EXISTS notificacoes_outbox_pendentes_idx
+	ON arena.notificacoes_outbox (proxima_tentativa, id)
+	WHERE status = 'pendente';
+
+CREATE INDEX IF NOT EXISTS notificacoes_outbox_id_arena_idx
+	ON arena.notificacoes_outbox (id_arena, criado_em DESC);
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Hour bundles: a pacote sells minutos of play instead of an amount of money.
+-- Their ledger rows move minutos and leave valor at zero, so money balances
+-- are unchanged.
+ALTER TABLE arena.credito_pacotes
+	ADD COLUMN IF NOT EXISTS minutos INTEGER;
+
+ALTER TABLE arena.credito_pacotes
+	DROP CONSTRAINT IF EXISTS credito_pacotes_credito_check;
+
+ALTER TABLE arena.credito_pacotes
+	DROP CONSTRAINT IF EXISTS credito_pacotes_credito_ou_minutos_check;
+
+ALTER TABLE arena.credito_pacotes
+	ADD CONSTRAINT credito_pacotes_credito_ou_minutos_check
+	CHECK ((minutos IS NULL AND credito > 0) OR (minutos > 0 AND credito = 0));
+
+ALTER TABLE arena.credito_movimentos
+	ADD COLUMN IF NOT EXISTS minutos INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE arena.arena_politicas
+	ADD COLUMN IF NOT EXISTS cancelamento_gratuito_horas INTEGER CHECK (cancelamento_gratuito_horas > 0),
+	ADD COLUMN IF NOT EXISTS cancelamento_multa_percentual NUMERIC(5, 2) CHECK (cancelamento_multa_percentual > 0 AND cancelamento_multa_percentual <= 100);
+
+COMMIT;
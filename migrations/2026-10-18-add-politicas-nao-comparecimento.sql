@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE arena.arena_politicas
+	ADD COLUMN IF NOT EXISTS tolerancia_nao_comparecimento_minutos INTEGER CHECK (tolerancia_nao_comparecimento_minutos > 0),
+	ADD COLUMN IF NOT EXISTS nao_comparecimento_mantem_cobranca BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE INDEX IF NOT EXISTS agendamentos_agendados_horario_idx
+	ON arena.agendamentos (horario)
+	WHERE status = 'agendado';
+
+COMMIT;
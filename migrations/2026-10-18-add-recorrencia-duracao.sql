@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Kept apart from add-agendamento-duracao: it has to sort after
+-- add-agendamento-recorrencias, which creates the table.
+ALTER TABLE arena.agendamento_recorrencias
+	ADD COLUMN IF NOT EXISTS duracao_minutos INTEGER NOT NULL DEFAULT 60;
+
+COMMIT;
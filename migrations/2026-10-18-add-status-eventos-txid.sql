@@ -0,0 +1,13 @@
+BEGIN;
+
+-- BIGSERIAL ids are handed out at insert time, not at commit, so a feed that
+-- only remembers the last id skips rows whose transaction commits late. The
+-- writing transaction id lets readers hold back rows until every older
+-- transaction has finished.
+ALTER TABLE arena.agendamento_status_eventos
+	ADD COLUMN IF NOT EXISTS txid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS agendamento_status_eventos_txid_idx
+	ON arena.agendamento_status_eventos (txid, id);
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+-- id_usuario NULL marks an integrator subscription (all arenas); id_arena
+-- narrows an owner subscription to one arena.
+CREATE TABLE IF NOT EXISTS arena.webhook_assinaturas (
+	id SERIAL PRIMARY KEY,
+	id_usuario INTEGER,
+	id_arena INTEGER REFERENCES arena.arenas (id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	segredo VARCHAR(128) NOT NULL,
+	eventos JSONB NOT NULL DEFAULT '[]'::jsonb,
+	versao_payload INTEGER NOT NULL DEFAULT 1,
+	ativo BOOLEAN NOT NULL DEFAULT TRUE,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (jsonb_typeof(eventos) = 'array')
+);
+
+CREATE INDEX IF NOT EXISTS webhook_assinaturas_id_usuario_idx
+	ON arena.webhook_assinaturas (id_usuario)
+	WHERE ativo;
+
+ALTER TABLE arena.notificacoes_outbox
+ADD COLUMN IF NOT EXISTS id_webhook INTEGER REFERENCES arena.webhook_assinaturas (id) ON DELETE CASCADE,
+ADD COLUMN IF NOT EXISTS evento VARCHAR(50);
+
+COMMIT;